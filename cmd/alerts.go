@@ -183,6 +183,18 @@ This command remains for backward compatibility.`,
 			if notifyCfg.Webhook != nil {
 				providers = append(providers, "webhook")
 			}
+			if notifyCfg.Email != nil {
+				providers = append(providers, "email")
+			}
+			if notifyCfg.Ntfy != nil {
+				providers = append(providers, "ntfy")
+			}
+			if notifyCfg.Gotify != nil {
+				providers = append(providers, "gotify")
+			}
+			if notifyCfg.Pushover != nil {
+				providers = append(providers, "pushover")
+			}
 
 			errMap := make(map[string]bool)
 			for _, e := range errs {
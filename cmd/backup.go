@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/Higangssh/homebutler/internal/backup"
 	"github.com/spf13/cobra"
@@ -10,6 +11,7 @@ import (
 func newBackupCmd() *cobra.Command {
 	var service string
 	var backupTo string
+	var notifyFailure bool
 
 	cmd := &cobra.Command{
 		Use:   "backup",
@@ -17,7 +19,9 @@ func newBackupCmd() *cobra.Command {
 		Long: `Backup Docker service volumes to a tar archive.
 
 Use --service to backup a specific service only.
-Use --to to specify a custom backup destination.`,
+Use --to to specify a custom backup destination.
+Use --notify to send a notification to the configured providers if the
+backup fails.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if err := loadConfig(); err != nil {
 				return err
@@ -33,6 +37,9 @@ Use --to to specify a custom backup destination.`,
 
 			result, err := backup.Run(backupDir, service)
 			if err != nil {
+				if notifyFailure {
+					sendNotification(backup.FailureEvent(service, err, time.Now()))
+				}
 				return err
 			}
 			return output(result, jsonOutput)
@@ -41,6 +48,7 @@ Use --to to specify a custom backup destination.`,
 
 	cmd.Flags().StringVar(&service, "service", "", "Backup a specific service only")
 	cmd.Flags().StringVar(&backupTo, "to", "", "Custom backup destination")
+	cmd.Flags().BoolVar(&notifyFailure, "notify", false, "Send a notification when the backup fails")
 
 	cmd.AddCommand(newBackupListCmd())
 	cmd.AddCommand(newDrillCmd())
@@ -73,6 +81,7 @@ func newBackupListCmd() *cobra.Command {
 func newDrillCmd() *cobra.Command {
 	var archive string
 	var all bool
+	var notifyFailure bool
 
 	cmd := &cobra.Command{
 		Use:   "drill [service]",
@@ -80,7 +89,8 @@ func newDrillCmd() *cobra.Command {
 		Long: `Run a backup drill in an isolated Docker environment.
 
 By default, homebutler picks the latest backup archive from the configured backup directory.
-Use --archive to drill a specific archive, or --all to verify every supported app in the archive.`,
+Use --archive to drill a specific archive, or --all to verify every supported app in the archive.
+Use --notify to send a notification to the configured providers for each drill that fails.`,
 		Args: func(cmd *cobra.Command, args []string) error {
 			if all {
 				if len(args) != 0 {
@@ -106,24 +116,41 @@ Use --archive to drill a specific archive, or --all to verify every supported ap
 				Archive:   archive,
 			}
 
+			// notifyFailed sends the failed drills when --notify is set.
+			notifyFailed := func(results ...backup.DrillResult) {
+				if !notifyFailure {
+					return
+				}
+				for _, r := range results {
+					if !r.Passed {
+						sendNotification(r.Event(time.Now()))
+					}
+				}
+			}
+
 			if all {
 				report, err := backup.RunDrillAll(opts)
 				if err != nil {
+					notifyFailed(backup.DrillResult{App: "all apps", Error: err.Error()})
 					return err
 				}
+				notifyFailed(report.Results...)
 				return output(report, jsonOutput)
 			}
 
 			result, err := backup.RunDrill(args[0], opts)
 			if err != nil {
+				notifyFailed(backup.DrillResult{App: args[0], Error: err.Error()})
 				return err
 			}
+			notifyFailed(*result)
 			return output(result, jsonOutput)
 		},
 	}
 
 	cmd.Flags().StringVar(&archive, "archive", "", "Specific backup archive to verify")
 	cmd.Flags().BoolVar(&all, "all", false, "Verify all supported apps in the backup archive")
+	cmd.Flags().BoolVar(&notifyFailure, "notify", false, "Send a notification for each drill that fails")
 
 	return cmd
 }
@@ -2,23 +2,49 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/Higangssh/homebutler/internal/doctor"
+	"github.com/Higangssh/homebutler/internal/style"
+	isatty "github.com/mattn/go-isatty"
 	"github.com/spf13/cobra"
 )
 
 func newDoctorCmd() *cobra.Command {
 	var strict bool
 	var backupMaxAge time.Duration
+	var fix, yes bool
+	var notifyFindings bool
 
 	cmd := &cobra.Command{
 		Use:   "doctor",
 		Short: "Diagnose homelab health, exposure, backups, and readiness",
 		Long: `Run a read-only diagnosis for the things that usually hurt self-hosted servers:
-resource pressure, stopped containers, public bind ports, backup hygiene,
-notification readiness, and report baseline status.`,
+resource pressure, drive and filesystem health, stopped containers, Docker
+hygiene, public bind ports, TLS certificates, backup hygiene, notification
+readiness, and report baseline status.
+
+With --fix, doctor offers the safe remediations it knows for what it found
+(start a stopped container, create the report baseline, run a backup, prune
+dangling images) and asks before each one. --fix --yes applies only the
+unattended-safe ones. Every offered fix is recorded in the alerts history,
+including the ones declined, skipped or refused. With --fix --strict,
+doctor diagnoses again after the fixes and exits non-zero if anything is
+still left.
+
+--notify sends the warnings and failures to the providers under notify in
+the config, so a cron job can report them without parsing the output.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if yes && !fix {
+				return fmt.Errorf("--yes only applies to --fix")
+			}
+			if fix && jsonOutput {
+				return fmt.Errorf("--fix cannot be combined with --json")
+			}
+			if fix && !yes && !isatty.IsTerminal(os.Stdin.Fd()) && !isatty.IsCygwinTerminal(os.Stdin.Fd()) {
+				return fmt.Errorf("--fix asks before each change and needs a terminal; use --fix --yes to apply only unattended-safe fixes")
+			}
 			if err := loadConfig(); err != nil {
 				return err
 			}
@@ -26,7 +52,8 @@ notification readiness, and report baseline status.`,
 				return err
 			}
 
-			result, err := doctor.Run(cfg, doctor.DefaultCollectFuncs(), doctor.Options{
+			fns := doctor.DefaultCollectFuncs()
+			result, err := doctor.Run(cfg, fns, doctor.Options{
 				BackupMaxAge: backupMaxAge,
 				Strict:       strict,
 			})
@@ -42,14 +69,52 @@ notification readiness, and report baseline status.`,
 				fmt.Print(doctor.FormatHuman(result))
 			}
 
+			found := "found"
+			if fix {
+				if doctor.FixableCount(result) == 0 {
+					fmt.Println(style.Dim.Render("Nothing doctor can fix automatically."))
+				} else {
+					fmt.Println(style.Section("Fixes"))
+					steps := doctor.ApplyFixes(result, doctor.FixOptions{
+						In:     os.Stdin,
+						Out:    os.Stdout,
+						Yes:    yes,
+						Fixers: doctor.DefaultFixers(cfg, fns),
+					})
+					fmt.Println()
+					fmt.Println(doctor.FormatFixSummary(steps))
+					if !strict && !notifyFindings {
+						fmt.Println(style.Dim.Render("Run homebutler doctor again to confirm."))
+						return nil
+					}
+					// --strict and --notify judge what is left after the
+					// fixes, so diagnose again rather than reuse the first
+					// result.
+					result, err = doctor.Run(cfg, fns, doctor.Options{
+						BackupMaxAge: backupMaxAge,
+						Strict:       strict,
+					})
+					if err != nil {
+						return fmt.Errorf("doctor failed: %w", err)
+					}
+					found = "still finds"
+				}
+			}
+
+			if notifyFindings && result.Status != doctor.SeverityPass {
+				sendNotification(result.Event())
+			}
 			if strict && result.Status != doctor.SeverityPass {
-				return fmt.Errorf("doctor found %d warning(s) and %d failure(s)", result.Summary.Warn, result.Summary.Fail)
+				return fmt.Errorf("doctor %s %d warning(s) and %d failure(s)", found, result.Summary.Warn, result.Summary.Fail)
 			}
 			return nil
 		},
 	}
 
 	cmd.Flags().BoolVar(&strict, "strict", false, "Exit non-zero when warnings or failures are found")
+	cmd.Flags().BoolVar(&fix, "fix", false, "Offer safe remediations for findings, asking before each")
+	cmd.Flags().BoolVar(&notifyFindings, "notify", false, "Send a notification to the configured providers when warnings or failures are found")
+	cmd.Flags().BoolVar(&yes, "yes", false, "With --fix, apply unattended-safe fixes without asking")
 	cmd.Flags().DurationVar(&backupMaxAge, "backup-max-age", 7*24*time.Hour, "Warn when the latest backup is older than this duration")
 
 	return cmd
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Higangssh/homebutler/internal/history"
+	"github.com/Higangssh/homebutler/internal/system"
+	"github.com/spf13/cobra"
+)
+
+func newHistoryCmd() *cobra.Command {
+	var since string
+	var step string
+
+	cmd := &cobra.Command{
+		Use:   "history [metric]",
+		Short: "Show recorded metrics history (CPU, memory, disk, containers)",
+		Long: `Show how a metric has changed over time.
+
+Every 'status' and 'report' run appends a sample to ~/.homebutler/history/.
+Samples are kept at full resolution for 48h, then averaged into hourly
+points and kept for 90 days (see history: in the config file).
+
+Metric names:
+  cpu                         Host CPU usage
+  memory                      Host memory usage
+  disk:<mount>                Disk usage, e.g. disk:/mnt/data
+  container:<name>:cpu        Container CPU (recorded by report)
+  container:<name>:memory     Container memory (recorded by report)
+
+Run without a metric to list everything that has been recorded.`,
+		Example: `  homebutler history
+  homebutler history disk:/mnt/data --since 7d
+  homebutler history memory --since 24h --step 1h --json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := loadConfig(); err != nil {
+				return err
+			}
+			if handled, err := maybeRouteRemote(); handled {
+				return err
+			}
+
+			window, err := history.ParseDuration(since)
+			if err != nil {
+				return fmt.Errorf("--since: %w", err)
+			}
+			var bucket time.Duration
+			if step != "" {
+				if bucket, err = history.ParseDuration(step); err != nil {
+					return fmt.Errorf("--step: %w", err)
+				}
+			}
+
+			store, err := openHistory()
+			if err != nil {
+				return err
+			}
+			from := time.Now().Add(-window)
+
+			if len(args) == 0 {
+				metrics, err := store.Metrics(from)
+				if err != nil {
+					return fmt.Errorf("reading history: %w", err)
+				}
+				if jsonOutput {
+					return output(metrics, true)
+				}
+				fmt.Print(history.FormatMetrics(metrics))
+				return nil
+			}
+
+			points, err := store.Query(args[0], from)
+			if err != nil {
+				return fmt.Errorf("reading history: %w", err)
+			}
+			if jsonOutput {
+				if bucket > 0 {
+					points = history.Downsample(points, bucket)
+				}
+				if points == nil {
+					points = []history.Point{}
+				}
+				return output(map[string]any{"metric": args[0], "since": from.UTC().Format(time.RFC3339), "points": points}, true)
+			}
+			if bucket == 0 {
+				bucket = history.AutoStep(window)
+			}
+			fmt.Print(history.FormatSeries(args[0], since, points, bucket))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "24h", "How far back to look (e.g. 6h, 7d, 2w)")
+	cmd.Flags().StringVar(&step, "step", "", "Average points into buckets of this size (default: fit the window)")
+
+	return cmd
+}
+
+// openHistory opens the metrics store with the retention settings from the
+// loaded config.
+func openHistory() (*history.Store, error) {
+	dir, err := history.Dir()
+	if err != nil {
+		return nil, err
+	}
+	var hc history.Config
+	if cfg != nil {
+		hc = cfg.History
+	}
+	return history.Open(dir, hc)
+}
+
+// recordStatus appends a status reading to the metrics history. History is a
+// side effect of status, so a failure is reported but never fails the command.
+func recordStatus(info *system.StatusInfo) {
+	store, err := openHistory()
+	if err == nil {
+		err = store.Append(history.NewSample(time.Now(), info, nil))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: metrics history: %v\n", err)
+	}
+}
@@ -1,6 +1,12 @@
 package cmd
 
-import "github.com/spf13/cobra"
+import (
+	"fmt"
+	"os"
+
+	"github.com/Higangssh/homebutler/internal/notify"
+	"github.com/spf13/cobra"
+)
 
 func newNotifyCmd() *cobra.Command {
 	cmd := &cobra.Command{
@@ -8,12 +14,14 @@ func newNotifyCmd() *cobra.Command {
 		Short: "Notification helpers",
 		Long: `Notification helpers for testing configured delivery providers.
 
-Use this command to verify that your Telegram, Slack, Discord, or webhook
-configuration can actually send messages before relying on alerts or watch
-notifications.`,
+Use this command to verify that your Telegram, Slack, Discord, email, ntfy,
+Gotify, Pushover, or webhook configuration can actually send messages before
+relying on alerts or watch notifications, and to preview the message
+templates of each provider.`,
 	}
 
 	cmd.AddCommand(newNotifyTestCmd())
+	cmd.AddCommand(newNotifyPreviewCmd())
 	return cmd
 }
 
@@ -28,3 +36,16 @@ The legacy command 'homebutler alerts test-notify' still works for backward
 compatibility.`
 	return cmd
 }
+
+// sendNotification sends event to the providers under notify in the
+// config. Delivery problems are reported on stderr rather than failing the
+// command whose result is being sent.
+func sendNotification(event notify.Event) {
+	if len(cfg.Notify.EnabledChannels()) == 0 {
+		fmt.Fprintln(os.Stderr, "--notify: no notification provider is configured")
+		return
+	}
+	for _, err := range notify.SendAll(&cfg.Notify, event) {
+		fmt.Fprintf(os.Stderr, "notify: %v\n", err)
+	}
+}
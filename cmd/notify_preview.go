@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Higangssh/homebutler/internal/alerts"
+	"github.com/Higangssh/homebutler/internal/backup"
+	"github.com/Higangssh/homebutler/internal/doctor"
+	"github.com/Higangssh/homebutler/internal/notify"
+	"github.com/Higangssh/homebutler/internal/watch"
+	"github.com/spf13/cobra"
+)
+
+func newNotifyPreviewCmd() *cobra.Command {
+	var kind, provider string
+
+	cmd := &cobra.Command{
+		Use:   "preview",
+		Short: "Render a sample event with the configured message templates",
+		Long: `Render a sample event of the given kind as each configured provider would
+send it, using the templates under notify.templates and each provider's own
+templates, or the built-in layout where there is none. Nothing is sent.
+
+Kinds: incident, flapping, alert, doctor, backup.
+
+Without any provider configured, every provider is shown; --provider shows
+just one.`,
+		Example: `  homebutler notify preview --kind incident
+  homebutler notify preview --kind flapping --provider slack`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := loadConfig(); err != nil {
+				return err
+			}
+			event, err := sampleEvent(kind, time.Now())
+			if err != nil {
+				return err
+			}
+
+			providers := &cfg.Notify
+			channels := providers.EnabledChannels()
+			if provider != "" {
+				channels = []notify.Channel{notify.Channel(provider)}
+			} else if len(channels) == 0 {
+				channels = notify.Channels
+			}
+
+			type preview struct {
+				Provider  notify.Channel `json:"provider"`
+				Templated bool           `json:"templated"`
+				Skipped   bool           `json:"skipped,omitempty"`
+				Message   string         `json:"message,omitempty"`
+				Error     string         `json:"error,omitempty"`
+			}
+			var previews []preview
+			for _, ch := range channels {
+				msg, templated, err := providers.Preview(ch, event)
+				if err != nil && !templated {
+					return err
+				}
+				p := preview{Provider: ch, Templated: templated, Message: msg}
+				if err != nil {
+					p.Error = err.Error()
+				}
+				p.Skipped = templated && err == nil && msg == ""
+				previews = append(previews, p)
+			}
+
+			if jsonOutput {
+				return output(map[string]any{"kind": kind, "event": event, "previews": previews}, true)
+			}
+			for i, p := range previews {
+				if i > 0 {
+					fmt.Println()
+				}
+				source := "built-in layout"
+				if p.Templated {
+					source = "template"
+				}
+				fmt.Printf("=== %s (%s) ===\n", p.Provider, source)
+				switch {
+				case p.Error != "":
+					fmt.Printf("template error: %s\n", p.Error)
+				case p.Skipped:
+					fmt.Println("(the template renders nothing, so this event is not sent)")
+				default:
+					fmt.Println(p.Message)
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&kind, "kind", notify.KindIncident, "Event kind: "+strings.Join(notify.TemplateKinds, ", "))
+	cmd.Flags().StringVar(&provider, "provider", "", "Only this provider (telegram, slack, discord, webhook, email, ntfy, gotify, pushover)")
+	return cmd
+}
+
+// sampleEvent builds a representative event of kind. Each goes through the
+// same code as real ones, so the preview shows every field a template can
+// use.
+func sampleEvent(kind string, now time.Time) (notify.Event, error) {
+	switch kind {
+	case notify.KindIncident, notify.KindFlapping:
+		inc := watch.Incident{
+			ID:           watch.GenerateIncidentID("postgres", now),
+			Container:    "postgres",
+			DetectedAt:   now,
+			RestartCount: 4,
+			PreLogs: strings.Join([]string{
+				"LOG:  checkpoint starting: time",
+				"LOG:  checkpoint complete: wrote 1893 buffers (11.6%)",
+				"ERROR:  could not resize shared memory segment: No space left on device",
+				"FATAL:  out of memory",
+				"DETAIL:  Failed on request of size 16777216.",
+			}, "\n"),
+		}
+		crash := &watch.CrashSummary{
+			Category:   "oom",
+			Reason:     "out of memory in the container's logs",
+			ExitCode:   137,
+			Signal:     "SIGKILL",
+			Confidence: "high",
+		}
+		var flap watch.FlappingResult
+		if kind == notify.KindFlapping {
+			flap = watch.FlappingResult{IsFlapping: true, Level: "acute", Count: 4, Window: "10m", Since: now.Add(-8 * time.Minute)}
+			inc.Flapping = &flap
+		}
+		inc.CrashAnalysis = crash
+		inc.Recovery = &watch.Recovery{Verdict: "healthy", Check: "docker", CheckedAt: now.Add(14 * time.Second), TimeToRecover: 14}
+		return watch.IncidentEvent(inc, flap, crash), nil
+
+	case notify.KindAlert:
+		return alerts.NotifyEvent{
+			RuleName: "disk-full",
+			Status:   "triggered",
+			Details:  "disk / at 93.4% (threshold: 90%)",
+			Action:   "notify",
+			Result:   "success",
+			Time:     now.Format("2006-01-02 15:04:05"),
+		}.Event(), nil
+
+	case notify.KindDoctor:
+		return (&doctor.Result{
+			Timestamp:  now.UTC().Format(time.RFC3339),
+			ServerName: "homelab",
+			Summary:    doctor.Summary{Pass: 9, Warn: 2, Fail: 1},
+			Findings: []doctor.Finding{
+				{Severity: doctor.SeverityFail, Category: "disk", Title: "/ is 93% full"},
+				{Severity: doctor.SeverityWarn, Category: "containers", Title: "vaultwarden is exited"},
+				{Severity: doctor.SeverityWarn, Category: "backups", Title: "last backup is 9 days old"},
+			},
+		}).Event(), nil
+
+	case notify.KindBackup:
+		return (&backup.DrillResult{
+			App:          "uptime-kuma",
+			Archive:      "/var/backups/homebutler/backup_2026-10-17_0300.tar.gz",
+			Integrity:    true,
+			Booted:       true,
+			HealthStatus: 503,
+			Error:        "health check returned HTTP 503",
+			Logs:         "Error: SQLITE_CORRUPT: database disk image is malformed",
+			TotalSeconds: 41,
+		}).Event(now), nil
+	}
+	return notify.Event{}, fmt.Errorf("unknown kind %q (use %s)", kind, strings.Join(notify.TemplateKinds, ", "))
+}
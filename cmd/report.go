@@ -2,7 +2,10 @@ package cmd
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/Higangssh/homebutler/internal/docker"
+	"github.com/Higangssh/homebutler/internal/history"
 	"github.com/Higangssh/homebutler/internal/report"
 	"github.com/spf13/cobra"
 )
@@ -10,6 +13,7 @@ import (
 func newReportCmd() *cobra.Command {
 	var keep int
 	var noSave bool
+	var horizon string
 
 	cmd := &cobra.Command{
 		Use:   "report",
@@ -18,19 +22,69 @@ func newReportCmd() *cobra.Command {
 compare against the previous snapshot, and print a human-readable report.
 
 Snapshots are stored in ~/.homebutler/reports/snapshots/ and pruned to
-the most recent --keep entries (default 30).`,
+the most recent --keep entries (default 30). Retained snapshots feed the
+weekly disk growth lines and the disk-full forecast, so keeping fewer than
+a few days of them disables both.
+
+Each saved report also records a metrics sample, including per-container
+usage, for 'homebutler history'.
+
+With --all, every configured server is inventoried (remote ones over SSH)
+and compared against its own snapshot history, kept under
+~/.homebutler/reports/snapshots/servers/<name>/, and the results are
+combined into one fleet report.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if err := loadConfig(); err != nil {
 				return err
 			}
-			if handled, err := maybeRouteRemote(); handled {
+			// --all is handled here rather than by maybeRouteRemote: the
+			// fleet report compares each server against its own history,
+			// which needs the raw inventory rather than a rendered report.
+			if !allServers {
+				if handled, err := maybeRouteRemote(); handled {
+					return err
+				}
+			}
+
+			if horizon == "" {
+				horizon = cfg.Report.ForecastHorizon
+			}
+			var forecastHorizon time.Duration
+			if horizon != "" {
+				d, err := history.ParseDuration(horizon)
+				if err != nil {
+					return fmt.Errorf("forecast horizon: %w", err)
+				}
+				forecastHorizon = d
+			}
+
+			store, err := openHistory()
+			if err != nil {
 				return err
 			}
 
-			r, err := report.Run(cfg, report.DefaultCollectFuncs(), report.Options{
-				Keep:   keep,
-				NoSave: noSave,
-			})
+			opts := report.Options{
+				Keep:    keep,
+				NoSave:  noSave,
+				History: store,
+				StatsFn: docker.Stats,
+
+				ForecastHorizon: forecastHorizon,
+			}
+
+			if allServers {
+				fr, err := report.RunFleet(cfg, report.DefaultFleetCollect(cfg, report.DefaultCollectFuncs()), opts)
+				if err != nil {
+					return fmt.Errorf("report failed: %w", err)
+				}
+				if jsonOutput {
+					return output(fr, true)
+				}
+				fmt.Print(report.FormatFleetHuman(fr))
+				return nil
+			}
+
+			r, err := report.Run(cfg, report.DefaultCollectFuncs(), opts)
 			if err != nil {
 				return fmt.Errorf("report failed: %w", err)
 			}
@@ -45,6 +99,89 @@ the most recent --keep entries (default 30).`,
 
 	cmd.Flags().IntVar(&keep, "keep", 30, "Number of daily snapshots to retain (minimum 1)")
 	cmd.Flags().BoolVar(&noSave, "no-save", false, "Print report without writing a snapshot")
+	cmd.Flags().StringVar(&horizon, "forecast-horizon", "", "Flag disks projected to fill within this window (default: report.forecast_horizon or 14d)")
+
+	cmd.AddCommand(newReportSnapshotsCmd(), newReportDiffCmd())
 
 	return cmd
 }
+
+func newReportSnapshotsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "snapshots",
+		Short: "List stored report snapshots",
+		Long: `List the report snapshots stored on this machine, oldest first.
+
+With --server, list the snapshots 'report --all' keeps for that server
+instead of this machine's own.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := loadConfig(); err != nil {
+				return err
+			}
+			dir, err := reportSnapshotDir()
+			if err != nil {
+				return err
+			}
+			infos, err := report.ListSnapshots(dir)
+			if err != nil {
+				infos = []report.SnapshotInfo{}
+			}
+			if jsonOutput {
+				return output(infos, true)
+			}
+			fmt.Print(report.FormatSnapshotsHuman(dir, infos))
+			return nil
+		},
+	}
+}
+
+func newReportDiffCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "diff <snapshot-a> <snapshot-b>",
+		Short: "Compare two stored report snapshots",
+		Long: `Compare any two stored snapshots using the same checks report runs
+against the previous snapshot.
+
+A snapshot can be named by its ID from 'report snapshots', a unique prefix
+of one (a date such as 2026-10-12 works), its file name, or the keywords
+"latest" and "previous". The older snapshot is always the baseline.`,
+		Example: `  homebutler report diff previous latest
+  homebutler report diff 2026-10-12 latest --json
+  homebutler report diff 20261012T0800 20261018T0800 --server nas`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := loadConfig(); err != nil {
+				return err
+			}
+			dir, err := reportSnapshotDir()
+			if err != nil {
+				return err
+			}
+			d, err := report.Diff(dir, args[0], args[1])
+			if err != nil {
+				return err
+			}
+			if jsonOutput {
+				return output(d, true)
+			}
+			fmt.Print(report.FormatDiffHuman(d))
+			return nil
+		},
+	}
+}
+
+// reportSnapshotDir picks the snapshot directory for the snapshot browsing
+// commands. These read history kept on this machine, so --server selects a
+// fleet server's local history rather than routing the command over SSH.
+func reportSnapshotDir() (string, error) {
+	base := report.SnapshotDir("")
+	if serverName == "" {
+		return base, nil
+	}
+	server := cfg.FindServer(serverName)
+	if server == nil {
+		return "", fmt.Errorf("server %q not found in config. Available servers: %s", serverName, listServerNames(cfg))
+	}
+	return report.ServerSnapshotDir(base, *server), nil
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/Higangssh/homebutler/internal/config"
 	"github.com/Higangssh/homebutler/internal/remote"
+	"github.com/Higangssh/homebutler/internal/util"
 	"github.com/spf13/cobra"
 )
 
@@ -55,6 +56,7 @@ func init() {
 		newInitCmd(),
 		newInventoryCmd(),
 		newReportCmd(),
+		newHistoryCmd(),
 		newDoctorCmd(),
 		newConfigCmd(),
 	)
@@ -68,6 +70,7 @@ func loadConfig() error {
 	if err != nil {
 		return fmt.Errorf("config error: %w", err)
 	}
+	util.SetContainerRuntime(cfg.Runtime)
 	return nil
 }
 
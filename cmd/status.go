@@ -23,6 +23,7 @@ func newStatusCmd() *cobra.Command {
 			if err != nil {
 				return fmt.Errorf("failed to get system status: %w", err)
 			}
+			recordStatus(info)
 			return output(info, jsonOutput)
 		},
 	}
@@ -6,16 +6,16 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"regexp"
 	"strconv"
 	"strings"
-	"sync"
 	"syscall"
 	"time"
 
 	"github.com/Higangssh/homebutler/internal/alerts"
 	"github.com/Higangssh/homebutler/internal/docker"
+	"github.com/Higangssh/homebutler/internal/kube"
 	"github.com/Higangssh/homebutler/internal/tui"
-	"github.com/Higangssh/homebutler/internal/util"
 	"github.com/Higangssh/homebutler/internal/watch"
 	isatty "github.com/mattn/go-isatty"
 	"github.com/spf13/cobra"
@@ -35,8 +35,15 @@ Subcommands:
   remove     Remove a container from the watch list
   check      Run a one-shot restart check
   start      Start continuous monitoring
+  daemon     Run monitoring in the background with a control socket
+  install-service  Write a systemd unit for the daemon
   history    List restart history (alias: incidents)
-  show       Show details for a specific restart event`,
+  export     Export incidents as JSON Lines or CSV
+  show       Show details for a specific restart event
+  ack        Acknowledge incidents
+  note       Add a note to an incident
+  resolve    Resolve incidents with a cause
+  remediation  Show remediation policies and recent actions`,
 	}
 
 	watchCmd.AddCommand(
@@ -46,8 +53,15 @@ Subcommands:
 		newWatchRemoveCmd(),
 		newWatchCheckCmd(),
 		newWatchStartCmd(),
+		newWatchDaemonCmd(),
+		newWatchInstallServiceCmd(),
 		newWatchHistoryCmd(),
+		newWatchExportCmd(),
 		newWatchShowCmd(),
+		newWatchAckCmd(),
+		newWatchNoteCmd(),
+		newWatchResolveCmd(),
+		newWatchRemediationCmd(),
 	)
 
 	return watchCmd
@@ -68,20 +82,43 @@ func newWatchTUICmd() *cobra.Command {
 }
 
 func newWatchAddCmd() *cobra.Command {
-	var kind string
+	var kind, namespace, pidFile, match, logFile, probe string
 
 	cmd := &cobra.Command{
 		Use:   "add [name]",
 		Short: "Add a container/service to the watch list",
-		Long: `Add a Docker container, systemd unit, or PM2 app to the watch list.
+		Long: `Add a Docker container, systemd unit, PM2 app, Kubernetes workload, plain
+process, or supervisord program to the watch list.
 If no name is given and kind is docker, lists running containers for reference.
 When a name is given without --kind, an interactive prompt lets you choose the type.
 
+A k8s target is a Deployment, StatefulSet, DaemonSet, pod, or app label value
+in --namespace (by default the kubeconfig context's namespace), reached through $KUBECONFIG, ~/.kube/config, or the k3s
+kubeconfig at /etc/rancher/k3s/k3s.yaml.
+
+A process target is found through /proc by --pidfile or by a --match regexp
+against its command line. A supervisord target is a program name (or
+group:name), read over supervisord's XML-RPC socket ($SUPERVISOR_SERVER_URL
+overrides it). Both tail --log into the incident when given; supervisord
+falls back to the log it keeps for the program.
+
+After each restart, watch start and watch daemon check that the target came
+back healthy: Docker's health status (or running state), or the systemd
+unit's ActiveState. --probe replaces that with an HTTP check (any 2xx or 3xx
+answer) or a TCP connect, and is the only check for the other kinds. Adding
+an existing target again with --probe updates its probe.
+
 Examples:
   homebutler watch add nginx                      # interactive type selection
   homebutler watch add --kind docker nginx        # non-interactive
   homebutler watch add --kind systemd nginx.service
-  homebutler watch add --kind pm2 my-api`,
+  homebutler watch add --kind pm2 my-api
+  homebutler watch add --kind k8s --namespace apps immich-server
+  homebutler watch add --kind process --pidfile /run/legacy.pid --log /var/log/legacy.log legacy
+  homebutler watch add --kind process --match 'java .*billing\.jar' billing
+  homebutler watch add --kind supervisord queue:worker_00
+  homebutler watch add --kind docker --probe http://localhost:8080/health nextcloud
+  homebutler watch add --kind pm2 --probe tcp://127.0.0.1:3000 my-api`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			kindChanged := cmd.Flags().Changed("kind")
@@ -89,9 +126,9 @@ Examples:
 			// If --kind was explicitly provided, validate it
 			if kindChanged {
 				switch kind {
-				case "docker", "systemd", "pm2":
+				case "docker", "systemd", "pm2", "k8s", "process", "supervisord":
 				default:
-					return fmt.Errorf("invalid kind %q: must be docker, systemd, or pm2", kind)
+					return fmt.Errorf("invalid kind %q: must be docker, systemd, pm2, k8s, process, or supervisord", kind)
 				}
 			}
 
@@ -128,17 +165,49 @@ Examples:
 				if !isatty.IsTerminal(os.Stdin.Fd()) && !isatty.IsCygwinTerminal(os.Stdin.Fd()) {
 					return fmt.Errorf("--kind flag is required when stdin is not a terminal")
 				}
-				options := []string{"docker", "systemd", "pm2"}
-				labels := []string{"Docker container", "systemd service", "PM2 application"}
+				options := []string{"docker", "systemd", "pm2", "k8s", "process", "supervisord"}
+				labels := []string{"Docker container", "systemd service", "PM2 application", "Kubernetes workload", "Plain process (PID file or command line)", "supervisord program"}
 				idx, err := promptSelect(bufio.NewScanner(os.Stdin), "Select process type", options, labels)
 				if err != nil {
 					return err
 				}
 				kind = options[idx]
 			}
-			if !isValidTargetName(name) {
+			// supervisord names a program in a group "group:name".
+			if !isValidTargetName(name) && (kind != "supervisord" || !isValidTargetName(strings.Replace(name, ":", "-", 1))) {
 				return fmt.Errorf("invalid name: %s", name)
 			}
+			unit := name
+			switch kind {
+			case "k8s":
+				if namespace == "" {
+					namespace = kube.DefaultNamespace()
+				}
+				if !isValidTargetName(namespace) {
+					return fmt.Errorf("invalid namespace: %s", namespace)
+				}
+				unit = namespace + "/" + name
+			case "process":
+				if (pidFile == "") == (match == "") {
+					return fmt.Errorf("a process target needs exactly one of --pidfile or --match")
+				}
+				if match != "" {
+					if _, err := regexp.Compile(match); err != nil {
+						return fmt.Errorf("invalid --match pattern: %w", err)
+					}
+				}
+			}
+			if (pidFile != "" || match != "") && kind != "process" {
+				return fmt.Errorf("--pidfile and --match only apply to --kind process")
+			}
+			if logFile != "" && kind != "process" && kind != "supervisord" {
+				return fmt.Errorf("--log only applies to --kind process or supervisord")
+			}
+			if probe != "" {
+				if _, err := watch.ParseProbe(probe); err != nil {
+					return err
+				}
+			}
 
 			dir, err := watch.WatchDir()
 			if err != nil {
@@ -150,8 +219,16 @@ Examples:
 				return err
 			}
 
-			for _, t := range targets {
-				if t.Container == name && t.EffectiveKind() == kind {
+			for i, t := range targets {
+				if t.Container == name && t.EffectiveKind() == kind && t.EffectiveUnit() == unit {
+					if probe != "" && probe != t.Probe {
+						targets[i].Probe = probe
+						if err := watch.SaveTargets(dir, targets); err != nil {
+							return err
+						}
+						fmt.Printf("Updated the probe of %s %q to %s.\n", kind, name, probe)
+						return nil
+					}
 					fmt.Printf("%s %q is already being watched.\n", kind, name)
 					return nil
 				}
@@ -160,7 +237,11 @@ Examples:
 			targets = append(targets, watch.Target{
 				Container: name,
 				Kind:      kind,
-				Unit:      name,
+				Unit:      unit,
+				PIDFile:   pidFile,
+				Match:     match,
+				LogFile:   logFile,
+				Probe:     probe,
 				AddedAt:   time.Now(),
 			})
 			if err := watch.SaveTargets(dir, targets); err != nil {
@@ -190,7 +271,12 @@ Examples:
 		},
 	}
 
-	cmd.Flags().StringVar(&kind, "kind", "docker", "Target kind: docker, systemd, or pm2")
+	cmd.Flags().StringVar(&kind, "kind", "docker", "Target kind: docker, systemd, pm2, k8s, process, or supervisord")
+	cmd.Flags().StringVar(&namespace, "namespace", "", "Kubernetes namespace of a k8s target (default: the kubeconfig context's namespace, else \"default\")")
+	cmd.Flags().StringVar(&pidFile, "pidfile", "", "PID file of a process target")
+	cmd.Flags().StringVar(&match, "match", "", "Regexp matched against the command line of a process target")
+	cmd.Flags().StringVar(&logFile, "log", "", "Log file tailed into incidents of a process or supervisord target")
+	cmd.Flags().StringVar(&probe, "probe", "", "Health check after a restart: http(s)://host/path or tcp://host:port")
 	return cmd
 }
 
@@ -214,7 +300,7 @@ func newWatchListCmd() *cobra.Command {
 			}
 
 			states, _ := watch.LoadState(dir)
-			fmt.Printf("%-25s %-10s %-22s %-10s %s\n", "NAME", "KIND", "ADDED", "RESTARTS", "LAST CHECKED")
+			fmt.Printf("%-25s %-10s %-22s %-10s %-13s %s\n", "NAME", "KIND", "ADDED", "RESTARTS", "LAST CHECKED", "PROBE")
 			for _, t := range targets {
 				added := t.AddedAt.Format("2006-01-02 15:04")
 				restarts := "-"
@@ -225,7 +311,11 @@ func newWatchListCmd() *cobra.Command {
 						lastChecked = s.LastChecked.Format("15:04:05")
 					}
 				}
-				fmt.Printf("%-25s %-10s %-22s %-10s %s\n", t.Container, t.EffectiveKind(), added, restarts, lastChecked)
+				probe := "-"
+				if t.Probe != "" {
+					probe = t.Probe
+				}
+				fmt.Printf("%-25s %-10s %-22s %-10s %-13s %s\n", t.Container, t.EffectiveKind(), added, restarts, lastChecked, probe)
 			}
 			return nil
 		},
@@ -333,20 +423,12 @@ func newWatchStartCmd() *cobra.Command {
 		Use:   "start",
 		Short: "Start continuous monitoring",
 		Long: `Start foreground monitoring using event-based or polling monitors.
-Docker targets use docker events (real-time). Systemd and PM2 targets use polling.`,
+Docker targets use docker events (real-time). Systemd, PM2, k8s, process and
+supervisord targets use polling.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// Other commands load the global config here; this one didn't,
-			// so config.yaml's notify settings were never reachable below.
-			if err := loadConfig(); err != nil {
-				return err
-			}
-
-			dur, err := time.ParseDuration(interval)
+			dur, err := parseWatchInterval(interval)
 			if err != nil {
-				return fmt.Errorf("invalid interval %q: %w", interval, err)
-			}
-			if dur < 5*time.Second {
-				return fmt.Errorf("interval must be at least 5s")
+				return err
 			}
 
 			dir, err := watch.WatchDir()
@@ -354,169 +436,141 @@ Docker targets use docker events (real-time). Systemd and PM2 targets use pollin
 				return err
 			}
 
-			targets, err := watch.LoadTargets(dir)
+			rt, err := loadWatchRuntime(dir)
 			if err != nil {
 				return err
 			}
-			if len(targets) == 0 {
+			if len(rt.targets) == 0 {
 				fmt.Println("No targets being watched. Use 'homebutler watch add <name>' to add one.")
 				return nil
 			}
 
-			// Load watch config (config.yaml preferred, watch/config.json fallback)
-			watchCfg, cfgErr := watch.LoadWatchConfig(dir)
-			if cfgErr != nil {
-				fmt.Fprintf(os.Stderr, "warning: cannot load watch config: %v, using defaults\n", cfgErr)
-				defaultCfg := watch.DefaultWatchConfig()
-				watchCfg = &defaultCfg
-			}
-			if cfg != nil {
-				watchCfg.Notify = cfg.Watch.Notify
-				watchCfg.Flapping = cfg.Watch.Flapping
-				watchCfg.Retention = cfg.Watch.Retention
-			}
-			watchCfg.Retention.Normalize()
-
-			var notifier *watch.WatchNotifier
-			if watchCfg.Notify.Enabled {
-				providers := &alerts.NotifyConfig{}
-				if cfg != nil {
-					providers = &cfg.Notify
-				}
-				if providers.IsEmpty() {
-					if alertsCfg, err := loadAlertsConfig(""); err == nil && alertsCfg != nil {
-						providers = alerts.ResolveNotifyConfig(alertsCfg)
-					}
-				}
-				notifier = watch.NewWatchNotifier(watchCfg.Notify, providers)
-			}
-
-			// Group targets by kind
-			var dockerTargets, systemdTargets, pm2Targets []watch.Target
-			for _, t := range targets {
-				switch t.EffectiveKind() {
-				case "systemd":
-					systemdTargets = append(systemdTargets, t)
-				case "pm2":
-					pm2Targets = append(pm2Targets, t)
-				default:
-					dockerTargets = append(dockerTargets, t)
-				}
-			}
-
+			groups := watch.GroupByKind(rt.targets)
 			fmt.Printf("Starting monitors (polling interval=%s). Press Ctrl+C to stop.\n", dur)
-			fmt.Printf("  Docker targets: %d, Systemd targets: %d, PM2 targets: %d\n",
-				len(dockerTargets), len(systemdTargets), len(pm2Targets))
+			fmt.Printf("  Docker targets: %d, Systemd targets: %d, PM2 targets: %d, k8s targets: %d, Process targets: %d, supervisord targets: %d\n",
+				len(groups["docker"]), len(groups["systemd"]), len(groups["pm2"]), len(groups["k8s"]), len(groups["process"]), len(groups["supervisord"]))
 
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
 
 			sig := make(chan os.Signal, 1)
 			signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+			go func() {
+				<-sig
+				fmt.Println("\nStopping all monitors.")
+				cancel()
+			}()
 
-			incCh := make(chan watch.Incident, 64)
-
-			// Generic command runner for systemd/pm2 (non-docker)
-			genericRunner := func(name string, args ...string) (string, error) {
-				return util.RunCmd(name, args...)
-			}
-
-			// Launch monitors with WaitGroup to track goroutine completion
-			var wg sync.WaitGroup
-			monitorCount := 0
-
-			if len(dockerTargets) > 0 {
-				monitorCount++
-				wg.Add(1)
-				dm := &watch.DockerMonitor{Dir: dir, PostLogDelay: 5 * time.Second}
-				go func() {
-					defer wg.Done()
-					if err := dm.Watch(ctx, dockerTargets, incCh); err != nil && ctx.Err() == nil {
-						fmt.Fprintf(os.Stderr, "[docker-monitor] error: %v\n", err)
-					}
-				}()
-			}
-
-			if len(systemdTargets) > 0 {
-				monitorCount++
-				wg.Add(1)
-				sm := &watch.SystemdMonitor{Run: genericRunner, Dir: dir, Interval: dur}
-				go func() {
-					defer wg.Done()
-					if err := sm.Watch(ctx, systemdTargets, incCh); err != nil && ctx.Err() == nil {
-						fmt.Fprintf(os.Stderr, "[systemd-monitor] error: %v\n", err)
+			runner := &watch.Runner{
+				Dir:      dir,
+				Interval: dur,
+				Config:   rt.config,
+				Notifier: rt.notifier,
+				OnIncident: func(inc watch.Incident, flap watch.FlappingResult, summary watch.CrashSummary) {
+					ts := time.Now().Format("15:04:05")
+					fmt.Printf("[%s] INCIDENT: %s (incident %s)\n", ts, inc.Container, inc.ID)
+					fmt.Printf("  Crash: %s (%s, confidence: %s)\n", summary.Reason, summary.Category, summary.Confidence)
+					if flap.IsFlapping {
+						fmt.Printf("  ⚠ FLAPPING: %s (%d restarts in %s window)\n", flap.Level, flap.Count, flap.Window)
 					}
-				}()
-			}
-
-			if len(pm2Targets) > 0 {
-				monitorCount++
-				wg.Add(1)
-				pm := &watch.PM2Monitor{Run: genericRunner, Dir: dir, Interval: dur}
-				go func() {
-					defer wg.Done()
-					if err := pm.Watch(ctx, pm2Targets, incCh); err != nil && ctx.Err() == nil {
-						fmt.Fprintf(os.Stderr, "[pm2-monitor] error: %v\n", err)
+				},
+				OnRecovery: func(inc watch.Incident) {
+					fmt.Printf("[%s] %s: %s (incident %s)\n", time.Now().Format("15:04:05"), inc.Container, inc.Recovery.Summary(), inc.ID)
+				},
+				OnRemediation: func(inc watch.Incident) {
+					for _, act := range inc.Remediation {
+						fmt.Printf("[%s] %s: remediation %s (incident %s)\n", time.Now().Format("15:04:05"), inc.Container, act.Summary(), inc.ID)
 					}
-				}()
+				},
 			}
-
-			if monitorCount == 0 {
-				fmt.Println("No monitors to start.")
-				return nil
+			if err := runner.Run(ctx, rt.targets); err == nil {
+				fmt.Println("\nAll monitors stopped.")
 			}
+			return nil
+		},
+	}
 
-			// Close incCh when all monitors are done
-			go func() {
-				wg.Wait()
-				close(incCh)
-			}()
+	cmd.Flags().StringVar(&interval, "interval", "30s", "Check/poll interval (e.g. 30s, 1m, 5m)")
+	return cmd
+}
 
-			// Print incidents as they arrive
-			for {
-				select {
-				case inc, ok := <-incCh:
-					if !ok {
-						fmt.Println("\nAll monitors stopped.")
-						return nil
-					}
+// parseWatchInterval parses the --interval flag of watch start and watch daemon.
+func parseWatchInterval(interval string) (time.Duration, error) {
+	dur, err := time.ParseDuration(interval)
+	if err != nil {
+		return 0, fmt.Errorf("invalid interval %q: %w", interval, err)
+	}
+	if dur < 5*time.Second {
+		return 0, fmt.Errorf("interval must be at least 5s")
+	}
+	return dur, nil
+}
 
-					crashInfo := watch.CrashInfo{
-						ErrorLog: inc.PreLogs,
-						Backend:  getBackendKind(inc.Container, targets),
-					}
-					summary := watch.Analyze(crashInfo)
-					inc.CrashAnalysis = &summary
+// watchRuntime is what the monitors run with: the targets, the watch
+// settings and the notifier built from them.
+type watchRuntime struct {
+	targets  []watch.Target
+	config   *watch.WatchConfig
+	notifier *watch.WatchNotifier
+}
 
-					allIncs, _ := watch.ListIncidents(dir)
-					flapResult := watchCfg.Flapping.Check(inc.Container, allIncs, time.Now())
-					if flapResult.IsFlapping {
-						inc.Flapping = &flapResult
-					}
+// loadWatchRuntime reads config.yaml and targets.json. The daemon calls it
+// again on every reload.
+func loadWatchRuntime(dir string) (*watchRuntime, error) {
+	// Other commands load the global config here; this one didn't,
+	// so config.yaml's notify settings were never reachable below.
+	if err := loadConfig(); err != nil {
+		return nil, err
+	}
 
-					_ = watch.SaveIncident(dir, &inc, watchCfg.Retention.MaxIncidents)
+	targets, err := watch.LoadTargets(dir)
+	if err != nil {
+		return nil, err
+	}
 
-					if notifier != nil {
-						_ = notifier.NotifyIncident(inc, flapResult, &summary, time.Now())
-					}
+	// Load watch config (config.yaml preferred, watch/config.json fallback)
+	watchCfg, cfgErr := watch.LoadWatchConfig(dir)
+	if cfgErr != nil {
+		fmt.Fprintf(os.Stderr, "warning: cannot load watch config: %v, using defaults\n", cfgErr)
+		defaultCfg := watch.DefaultWatchConfig()
+		watchCfg = &defaultCfg
+	}
+	if cfg != nil {
+		watchCfg.Notify = cfg.Watch.Notify
+		watchCfg.Flapping = cfg.Watch.Flapping
+		watchCfg.Retention = cfg.Watch.Retention
+		watchCfg.Recovery = cfg.Watch.Recovery
+		watchCfg.Remediation = cfg.Watch.Remediation
+		if len(cfg.Watch.Analysis.Rules) > 0 {
+			watchCfg.Analysis = cfg.Watch.Analysis
+		}
+	}
+	watchCfg.Retention.Normalize()
+	watchCfg.Recovery.Normalize()
+	watchCfg.Remediation.Normalize()
+	for i, p := range watchCfg.Remediation.Policies {
+		if err := p.Validate(watchCfg.Remediation.Playbooks); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: watch.remediation.policies[%d]: skipping: %v\n", i, err)
+		}
+	}
+	if err := watch.SetAnalysisRules(watchCfg.Analysis.Rules); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: watch.analysis: skipping invalid rules: %v\n", err)
+	}
 
-					ts := time.Now().Format("15:04:05")
-					fmt.Printf("[%s] INCIDENT: %s (incident %s)\n", ts, inc.Container, inc.ID)
-					fmt.Printf("  Crash: %s (%s, confidence: %s)\n", summary.Reason, summary.Category, summary.Confidence)
-					if flapResult.IsFlapping {
-						fmt.Printf("  ⚠ FLAPPING: %s (%d restarts in %s window)\n", flapResult.Level, flapResult.Count, flapResult.Window)
-					}
-				case <-sig:
-					fmt.Println("\nStopping all monitors.")
-					cancel()
-					return nil
-				}
+	var notifier *watch.WatchNotifier
+	if watchCfg.Notify.Enabled {
+		providers := &alerts.NotifyConfig{}
+		if cfg != nil {
+			providers = &cfg.Notify
+		}
+		if providers.IsEmpty() {
+			if alertsCfg, err := loadAlertsConfig(""); err == nil && alertsCfg != nil {
+				providers = alerts.ResolveNotifyConfig(alertsCfg)
 			}
-		},
+		}
+		notifier = watch.NewWatchNotifier(watchCfg.Notify, providers)
 	}
-
-	cmd.Flags().StringVar(&interval, "interval", "30s", "Check/poll interval (e.g. 30s, 1m, 5m)")
-	return cmd
+	return &watchRuntime{targets: targets, config: watchCfg, notifier: notifier}, nil
 }
 
 // resolveIncidentCap resolves the incident retention cap the way watch start
@@ -536,16 +590,19 @@ func resolveIncidentCap(dir string) int {
 }
 
 func newWatchHistoryCmd() *cobra.Command {
-	return &cobra.Command{
+	var filters incidentFilterFlags
+
+	cmd := &cobra.Command{
 		Use:     "history",
 		Aliases: []string{"incidents"},
 		Short:   "List restart history",
+		Example: `  homebutler watch history --status open
+  homebutler watch history --target nginx --category oom --since 24h
+  homebutler watch history --flapping --since 7d
+  homebutler watch history --search "connection refused"`,
+		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			dir, err := watch.WatchDir()
-			if err != nil {
-				return err
-			}
-			incidents, err := watch.ListIncidents(dir)
+			incidents, err := loadFilteredIncidents(&filters)
 			if err != nil {
 				return err
 			}
@@ -553,11 +610,15 @@ func newWatchHistoryCmd() *cobra.Command {
 				return output(incidents, true)
 			}
 			if len(incidents) == 0 {
+				if filters.filtered() {
+					fmt.Println("No incidents match.")
+					return nil
+				}
 				fmt.Println("No restart history recorded.")
 				return nil
 			}
 
-			fmt.Printf("%-20s  %-36s  %-20s  %s\n", "CONTAINER", "INCIDENT ID", "DETECTED", "INFO")
+			fmt.Printf("%-20s  %-36s  %-20s  %-8s  %s\n", "CONTAINER", "INCIDENT ID", "DETECTED", "STATUS", "INFO")
 			for _, inc := range incidents {
 				id := inc.ID
 				if inc.RestartCount > 0 {
@@ -570,14 +631,29 @@ func newWatchHistoryCmd() *cobra.Command {
 				if inc.CrashAnalysis != nil {
 					info += inc.CrashAnalysis.Category
 				}
-				fmt.Printf("%-20s  %-36s  %-20s  %s\n",
+				if inc.Recovery != nil {
+					info += " → " + inc.Recovery.Verdict
+				}
+				for _, act := range inc.Remediation {
+					if act.Outcome != watch.RemediationSkipped {
+						info += fmt.Sprintf(" [%s %s]", act.Action, act.Outcome)
+					}
+				}
+				fmt.Printf("%-20s  %-36s  %-20s  %-8s  %s\n",
 					inc.Container, id,
 					inc.DetectedAt.Format("2006-01-02 15:04:05"),
+					inc.EffectiveStatus(),
 					info)
+				if line := watch.SearchMatch(inc, filters.search); line != "" {
+					fmt.Printf("%-20s  ↳ %s\n", "", truncateRunes(line, 100))
+				}
 			}
 			return nil
 		},
 	}
+
+	filters.register(cmd)
+	return cmd
 }
 
 func newWatchShowCmd() *cobra.Command {
@@ -606,6 +682,7 @@ func newWatchShowCmd() *cobra.Command {
 			}
 			fmt.Printf("Previous Start: %s\n", inc.PrevStarted)
 			fmt.Printf("Current Start:  %s\n", inc.CurrStarted)
+			printIncidentLifecycle(inc)
 			if inc.CrashAnalysis != nil {
 				fmt.Println()
 				fmt.Println("=== Crash Analysis ===")
@@ -618,6 +695,18 @@ func newWatchShowCmd() *cobra.Command {
 				if len(inc.CrashAnalysis.Patterns) > 0 {
 					fmt.Printf("Patterns:   %s\n", strings.Join(inc.CrashAnalysis.Patterns, ", "))
 				}
+				if k := inc.CrashAnalysis.OOMKill; k != nil {
+					fmt.Printf("OOM Kill:   %s\n", k.Summary())
+				}
+			}
+			if rec := inc.Recovery; rec != nil {
+				fmt.Println()
+				fmt.Println("=== Recovery ===")
+				fmt.Printf("Verdict:    %s\n", rec.Summary())
+				fmt.Printf("Checked:    %s with %s\n", rec.CheckedAt.Format("2006-01-02 15:04:05"), rec.Check)
+				if rec.Detail != "" {
+					fmt.Printf("Detail:     %s\n", rec.Detail)
+				}
 			}
 			if inc.Flapping != nil {
 				fmt.Println()
@@ -625,6 +714,8 @@ func newWatchShowCmd() *cobra.Command {
 					inc.Flapping.Level, inc.Flapping.Count, inc.Flapping.Window,
 					inc.Flapping.Since.Format("15:04:05"))
 			}
+			printIncidentRemediation(inc)
+			printIncidentNotes(inc)
 			fmt.Println()
 			if inc.PreLogs != "" {
 				fmt.Println("=== Pre-Death Logs ===")
@@ -648,15 +739,6 @@ func newWatchShowCmd() *cobra.Command {
 	}
 }
 
-func getBackendKind(container string, targets []watch.Target) string {
-	for _, t := range targets {
-		if t.Container == container || t.EffectiveUnit() == container {
-			return t.EffectiveKind()
-		}
-	}
-	return "docker"
-}
-
 func restartLabel(count int) string {
 	if count > 0 {
 		return fmt.Sprintf("restart #%d", count)
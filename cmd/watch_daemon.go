@@ -0,0 +1,350 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Higangssh/homebutler/internal/config"
+	"github.com/Higangssh/homebutler/internal/watch"
+	"github.com/spf13/cobra"
+)
+
+func newWatchDaemonCmd() *cobra.Command {
+	var interval string
+
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Run monitoring in the background with a control socket",
+		Long: `Run the same monitors as 'watch start', as a long-running service.
+
+The daemon writes its PID to ~/.homebutler/watch/daemon.pid and serves a
+control API on ~/.homebutler/watch/daemon.sock, which the subcommands use.
+SIGHUP (systemctl reload) re-reads targets.json and the config file.
+Use 'watch install-service' to run it under systemd.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dur, err := parseWatchInterval(interval)
+			if err != nil {
+				return err
+			}
+			dir, err := watch.WatchDir()
+			if err != nil {
+				return err
+			}
+
+			d := &watch.Daemon{
+				Dir:      dir,
+				Interval: dur,
+				Load: func() (*watch.DaemonConfig, error) {
+					rt, err := loadWatchRuntime(dir)
+					if err != nil {
+						return nil, err
+					}
+					return &watch.DaemonConfig{Targets: rt.targets, Config: rt.config, Notifier: rt.notifier}, nil
+				},
+				Logf: func(format string, args ...any) {
+					fmt.Printf("[%s] %s\n", time.Now().Format("15:04:05"), fmt.Sprintf(format, args...))
+				},
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			sig := make(chan os.Signal, 1)
+			signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+			go func() {
+				for s := range sig {
+					if s == syscall.SIGHUP {
+						_ = d.Reload()
+						continue
+					}
+					fmt.Println("Stopping watch daemon.")
+					cancel()
+					return
+				}
+			}()
+
+			fmt.Printf("watch daemon started (pid %d, polling interval=%s, socket %s)\n", os.Getpid(), dur, watch.SocketPath(dir))
+			return d.Run(ctx)
+		},
+	}
+	cmd.Flags().StringVar(&interval, "interval", "30s", "Check/poll interval (e.g. 30s, 1m, 5m)")
+
+	cmd.AddCommand(
+		newWatchDaemonStatusCmd(),
+		newWatchDaemonReloadCmd(),
+		newWatchDaemonPauseCmd("pause", true),
+		newWatchDaemonPauseCmd("resume", false),
+		newWatchDaemonIncidentsCmd(),
+	)
+	return cmd
+}
+
+// daemonClient returns a client for the daemon of this user's watch directory.
+func daemonClient() (*watch.DaemonClient, error) {
+	dir, err := watch.WatchDir()
+	if err != nil {
+		return nil, err
+	}
+	return watch.NewDaemonClient(dir), nil
+}
+
+func printDaemonStatus(st *watch.DaemonStatus) error {
+	if jsonOutput {
+		return output(st, true)
+	}
+	fmt.Printf("watch daemon: running (pid %d, up %s, polling interval=%s)\n",
+		st.PID, time.Since(st.StartedAt).Round(time.Second), st.Interval)
+	if st.LastReload != nil {
+		fmt.Printf("Reloads:   %d (last %s)\n", st.Reloads, st.LastReload.Format("2006-01-02 15:04:05"))
+	}
+	fmt.Printf("Incidents: %d since start", st.Incidents)
+	if st.Last != nil {
+		fmt.Printf(" (last: %s at %s)", st.Last.Container, st.Last.DetectedAt.Format("15:04:05"))
+	}
+	fmt.Println()
+	fmt.Println()
+	fmt.Printf("%-25s %-12s %s\n", "NAME", "KIND", "STATE")
+	for _, t := range st.Targets {
+		state := "watching"
+		if t.Paused {
+			state = "paused"
+		}
+		fmt.Printf("%-25s %-12s %s\n", t.Name, t.Kind, state)
+	}
+	return nil
+}
+
+func newWatchDaemonStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show whether the daemon is running and what it watches",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := daemonClient()
+			if err != nil {
+				return err
+			}
+			st, err := c.Status(cmd.Context())
+			if err != nil {
+				return err
+			}
+			return printDaemonStatus(st)
+		},
+	}
+}
+
+func newWatchDaemonReloadCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "reload",
+		Short: "Re-read targets.json and the config file",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := daemonClient()
+			if err != nil {
+				return err
+			}
+			st, err := c.Reload(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("reload failed: %w", err)
+			}
+			if jsonOutput {
+				return output(st, true)
+			}
+			fmt.Printf("Reloaded: watching %d targets.\n", len(st.Targets))
+			return nil
+		},
+	}
+}
+
+func newWatchDaemonPauseCmd(action string, pause bool) *cobra.Command {
+	short := "Ignore a target's incidents until it is resumed"
+	if !pause {
+		short = "Record a paused target's incidents again"
+	}
+	return &cobra.Command{
+		Use:   action + " <target>",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := daemonClient()
+			if err != nil {
+				return err
+			}
+			st, err := c.SetPaused(cmd.Context(), args[0], pause)
+			if err != nil {
+				return err
+			}
+			if jsonOutput {
+				return output(st, true)
+			}
+			if pause {
+				fmt.Printf("Paused %s. Resume with 'homebutler watch daemon resume %s'.\n", args[0], args[0])
+			} else {
+				fmt.Printf("Resumed %s.\n", args[0])
+			}
+			return nil
+		},
+	}
+}
+
+func newWatchDaemonIncidentsCmd() *cobra.Command {
+	var limit int
+	cmd := &cobra.Command{
+		Use:   "incidents",
+		Short: "List recent incidents from the daemon",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := daemonClient()
+			if err != nil {
+				return err
+			}
+			incs, err := c.Incidents(cmd.Context(), limit)
+			if err != nil {
+				return err
+			}
+			if jsonOutput {
+				return output(incs, true)
+			}
+			if len(incs) == 0 {
+				fmt.Println("No incidents recorded.")
+				return nil
+			}
+			fmt.Printf("%-40s %-20s %-20s %s\n", "ID", "CONTAINER", "DETECTED", "CATEGORY")
+			for _, inc := range incs {
+				category := inc.Category
+				if inc.Flapping != "" {
+					category += " [FLAPPING]"
+				}
+				fmt.Printf("%-40s %-20s %-20s %s\n", inc.ID, inc.Container, inc.DetectedAt.Format("2006-01-02 15:04:05"), category)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().IntVar(&limit, "limit", 20, "Number of incidents to show")
+	return cmd
+}
+
+func newWatchInstallServiceCmd() *cobra.Command {
+	var (
+		interval string
+		userUnit bool
+		printOut bool
+		force    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "install-service",
+		Short: "Write a systemd unit that runs 'watch daemon'",
+		Long: `Write a systemd unit for 'watch daemon' so monitoring survives reboots.
+
+By default this writes /etc/systemd/system/homebutler-watch.service (run it
+with sudo); the daemon runs as the user who invoked sudo, so it uses that
+user's ~/.homebutler/watch and config. --user writes a systemd --user unit
+instead. Nothing is enabled or started; the commands to do that are printed.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := parseWatchInterval(interval); err != nil {
+				return err
+			}
+			exe, err := os.Executable()
+			if err != nil {
+				return fmt.Errorf("cannot find the homebutler binary: %w", err)
+			}
+			if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+				exe = resolved
+			}
+
+			opts := watch.ServiceOptions{Exe: exe, UserUnit: userUnit}
+			if interval != "30s" {
+				opts.Args = append(opts.Args, "--interval", interval)
+			}
+			// ~/.config/homebutler/config.yaml is found through $HOME; any
+			// other config has to be named, since the unit has neither the
+			// environment nor the working directory of this shell.
+			if path, source := config.ResolveWithSource(cfgPath); source != config.SourceNone && source != config.SourceXDG {
+				abs, err := filepath.Abs(path)
+				if err != nil {
+					return err
+				}
+				opts.Args = append(opts.Args, "--config", abs)
+			}
+
+			u, err := serviceUser()
+			if err != nil {
+				return err
+			}
+			opts.User, opts.Home = u.Username, u.HomeDir
+
+			unit := watch.SystemdUnit(opts)
+			if printOut {
+				fmt.Print(unit)
+				return nil
+			}
+
+			path, err := servicePath(userUnit, u.HomeDir)
+			if err != nil {
+				return err
+			}
+			if existing, err := os.ReadFile(path); err == nil && string(existing) != unit && !force {
+				return fmt.Errorf("%s already exists with different contents (use --force to overwrite, or --print to compare)", path)
+			}
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(path, []byte(unit), 0o644); err != nil {
+				if errors.Is(err, os.ErrPermission) && !userUnit {
+					return fmt.Errorf("cannot write %s: run with sudo, or use --user for a user unit", path)
+				}
+				return err
+			}
+
+			systemctl := "sudo systemctl"
+			if userUnit {
+				systemctl = "systemctl --user"
+			}
+			name := strings.TrimSuffix(watch.ServiceName, ".service")
+			fmt.Printf("Wrote %s\n\nEnable and start it with:\n  %s daemon-reload\n  %s enable --now %s\n", path, systemctl, systemctl, name)
+			if userUnit {
+				fmt.Printf("\nTo keep it running after you log out:\n  sudo loginctl enable-linger %s\n", u.Username)
+			}
+			fmt.Printf("\nCheck on it with 'homebutler watch daemon status' or '%s status %s'.\n", systemctl, name)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&interval, "interval", "30s", "Check/poll interval for the daemon")
+	cmd.Flags().BoolVar(&userUnit, "user", false, "Write a systemd --user unit instead of a system unit")
+	cmd.Flags().BoolVar(&printOut, "print", false, "Print the unit instead of writing it")
+	cmd.Flags().BoolVar(&force, "force", false, "Overwrite an existing unit file")
+	return cmd
+}
+
+// serviceUser is who the daemon should run as: the user behind sudo, or
+// the current user.
+func serviceUser() (*user.User, error) {
+	if name := os.Getenv("SUDO_USER"); name != "" && os.Geteuid() == 0 {
+		if u, err := user.Lookup(name); err == nil {
+			return u, nil
+		}
+	}
+	u, err := user.Current()
+	if err != nil {
+		return nil, fmt.Errorf("cannot determine the current user: %w", err)
+	}
+	return u, nil
+}
+
+func servicePath(userUnit bool, home string) (string, error) {
+	if !userUnit {
+		return filepath.Join("/etc/systemd/system", watch.ServiceName), nil
+	}
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" || os.Geteuid() == 0 {
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, "systemd", "user", watch.ServiceName), nil
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Higangssh/homebutler/internal/history"
+	"github.com/Higangssh/homebutler/internal/watch"
+	"github.com/spf13/cobra"
+)
+
+// incidentFilterFlags are the filters shared by `watch history` and
+// `watch export`.
+type incidentFilterFlags struct {
+	target   string
+	category string
+	since    string
+	flapping bool
+	status   string
+	search   string
+	limit    int
+}
+
+func (f *incidentFilterFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.target, "target", "", "Only incidents of this watched target")
+	cmd.Flags().StringVar(&f.category, "category", "", "Only incidents with this crash category (e.g. oom, panic, dependency)")
+	cmd.Flags().StringVar(&f.since, "since", "", "Only incidents detected within this window (e.g. 6h, 7d, 2w)")
+	cmd.Flags().BoolVar(&f.flapping, "flapping", false, "Only incidents recorded while the target was flapping")
+	cmd.Flags().StringVar(&f.status, "status", "", "Only incidents with this status (open, acked, resolved)")
+	cmd.Flags().StringVar(&f.search, "search", "", "Only incidents whose pre-death or post-restart logs contain this text (case-insensitive)")
+	cmd.Flags().IntVar(&f.limit, "limit", 0, "At most this many incidents, newest first (0 for all)")
+}
+
+// filter turns the flags into a watch.IncidentFilter, relative to now.
+func (f *incidentFilterFlags) filter(now time.Time) (watch.IncidentFilter, error) {
+	if f.status != "" && !watch.ValidStatus(f.status) {
+		return watch.IncidentFilter{}, fmt.Errorf("invalid --status %q (use %s)", f.status, strings.Join(watch.Statuses, ", "))
+	}
+	filter := watch.IncidentFilter{
+		Target:   f.target,
+		Category: f.category,
+		Flapping: f.flapping,
+		Status:   f.status,
+		Search:   f.search,
+		Limit:    f.limit,
+	}
+	if f.since != "" {
+		window, err := history.ParseDuration(f.since)
+		if err != nil {
+			return watch.IncidentFilter{}, fmt.Errorf("--since: %w", err)
+		}
+		filter.Since = now.Add(-window)
+	}
+	return filter, nil
+}
+
+// filtered reports whether any filter is set.
+func (f *incidentFilterFlags) filtered() bool {
+	return f.target != "" || f.category != "" || f.since != "" || f.flapping || f.status != "" || f.search != ""
+}
+
+// loadFilteredIncidents lists stored incidents that pass the flags.
+func loadFilteredIncidents(f *incidentFilterFlags) ([]watch.Incident, error) {
+	filter, err := f.filter(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	dir, err := watch.WatchDir()
+	if err != nil {
+		return nil, err
+	}
+	incidents, err := watch.ListIncidents(dir)
+	if err != nil {
+		return nil, err
+	}
+	return watch.FilterIncidents(incidents, filter), nil
+}
+
+func newWatchExportCmd() *cobra.Command {
+	var (
+		filters incidentFilterFlags
+		format  string
+		outPath string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export incidents as JSON Lines or CSV",
+		Long: `Write incidents, newest first, for a spreadsheet or a log platform.
+
+jsonl writes one complete incident per line, as stored. csv writes one row
+per incident with the crash analysis and lifecycle fields flattened into
+columns and the logs last. The filters are the same as 'watch history'.`,
+		Example: `  homebutler watch export --format csv --since 7d > incidents.csv
+  homebutler watch export --format jsonl --target nginx --output nginx.jsonl`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != watch.ExportJSONL && format != watch.ExportCSV {
+				return fmt.Errorf("invalid --format %q (use %s or %s)", format, watch.ExportJSONL, watch.ExportCSV)
+			}
+			incidents, err := loadFilteredIncidents(&filters)
+			if err != nil {
+				return err
+			}
+
+			if outPath != "" && outPath != "-" {
+				f, err := os.Create(outPath)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				bw := bufio.NewWriter(f)
+				if err := watch.WriteIncidents(bw, incidents, format); err != nil {
+					return err
+				}
+				if err := bw.Flush(); err != nil {
+					return err
+				}
+				if err := f.Close(); err != nil {
+					return err
+				}
+				fmt.Fprintf(os.Stderr, "Exported %d incidents to %s\n", len(incidents), outPath)
+				return nil
+			}
+			return watch.WriteIncidents(os.Stdout, incidents, format)
+		},
+	}
+
+	filters.register(cmd)
+	cmd.Flags().StringVar(&format, "format", watch.ExportJSONL, "Export format: jsonl or csv")
+	cmd.Flags().StringVarP(&outPath, "output", "o", "", "Write to this file instead of stdout")
+	return cmd
+}
+
+// truncateRunes shortens s to max runes, marking the cut with "…".
+func truncateRunes(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max-1]) + "…"
+}
@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Higangssh/homebutler/internal/watch"
+	"github.com/spf13/cobra"
+)
+
+func newWatchAckCmd() *cobra.Command {
+	var (
+		container string
+		by        string
+		note      string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "ack [incident-id...]",
+		Short: "Acknowledge incidents",
+		Long: `Mark incidents as acknowledged so they drop out of 'watch history --status open'.
+
+--container acknowledges every open incident of a target at once. While a
+target's latest incident stays acknowledged, new incidents for it are
+recorded as acknowledged too, linked to the first one, until it is resolved.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, ids, err := lifecycleTargets(args, container, watch.StatusOpen)
+			if err != nil {
+				return err
+			}
+			by = lifecycleUser(by)
+			now := time.Now()
+
+			var updated []*watch.Incident
+			for _, id := range ids {
+				inc, err := watch.AckIncident(dir, id, by, now)
+				if err != nil {
+					return err
+				}
+				if note != "" {
+					if inc, err = watch.AddIncidentNote(dir, id, by, note, now); err != nil {
+						return err
+					}
+				}
+				updated = append(updated, inc)
+			}
+			if jsonOutput {
+				return output(updated, true)
+			}
+			for _, inc := range updated {
+				fmt.Printf("Acknowledged %s (%s) as %s.\n", inc.ID, inc.Container, inc.AckedBy)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&container, "container", "", "Acknowledge all open incidents of this target")
+	cmd.Flags().StringVar(&by, "by", "", "Who is acknowledging (default: current user)")
+	cmd.Flags().StringVar(&note, "note", "", "Add a note while acknowledging")
+	return cmd
+}
+
+func newWatchNoteCmd() *cobra.Command {
+	var by string
+
+	cmd := &cobra.Command{
+		Use:   "note <incident-id> <text...>",
+		Short: "Add a note to an incident",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := watch.WatchDir()
+			if err != nil {
+				return err
+			}
+			inc, err := watch.AddIncidentNote(dir, args[0], lifecycleUser(by), strings.Join(args[1:], " "), time.Now())
+			if err != nil {
+				return err
+			}
+			if jsonOutput {
+				return output(inc, true)
+			}
+			fmt.Printf("Added note to %s (%d notes).\n", inc.ID, len(inc.Notes))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&by, "by", "", "Who wrote the note (default: current user)")
+	return cmd
+}
+
+func newWatchResolveCmd() *cobra.Command {
+	var (
+		container string
+		by        string
+		cause     string
+		root      string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "resolve [incident-id...]",
+		Short: "Resolve incidents with the cause that was found",
+		Long: `Close incidents with a resolution cause.
+
+--root links each incident to the incident that explains it, e.g. the first
+crash of a flapping night. --container resolves every unresolved incident of
+a target, which also ends acknowledgement of its future incidents.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if cause == "" && root == "" {
+				return fmt.Errorf("--cause or --root is required")
+			}
+			dir, ids, err := lifecycleTargets(args, container, watch.StatusOpen, watch.StatusAcked)
+			if err != nil {
+				return err
+			}
+			by = lifecycleUser(by)
+			now := time.Now()
+
+			var updated []*watch.Incident
+			for _, id := range ids {
+				if id == root {
+					continue
+				}
+				inc, err := watch.ResolveIncident(dir, id, by, cause, root, now)
+				if err != nil {
+					return err
+				}
+				updated = append(updated, inc)
+			}
+			if jsonOutput {
+				return output(updated, true)
+			}
+			for _, inc := range updated {
+				fmt.Printf("Resolved %s (%s)", inc.ID, inc.Container)
+				if inc.RootCause != "" {
+					fmt.Printf(", root cause %s", inc.RootCause)
+				}
+				fmt.Println(".")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&container, "container", "", "Resolve all unresolved incidents of this target")
+	cmd.Flags().StringVar(&by, "by", "", "Who is resolving (default: current user)")
+	cmd.Flags().StringVar(&cause, "cause", "", "What caused the incident")
+	cmd.Flags().StringVar(&root, "root", "", "ID of the incident that is the root cause")
+	return cmd
+}
+
+// lifecycleTargets returns the watch directory and the incident IDs a
+// lifecycle command applies to: the IDs given, plus every incident of
+// container that is in one of statuses.
+func lifecycleTargets(args []string, container string, statuses ...string) (string, []string, error) {
+	if len(args) == 0 && container == "" {
+		return "", nil, fmt.Errorf("give an incident ID or --container")
+	}
+	dir, err := watch.WatchDir()
+	if err != nil {
+		return "", nil, err
+	}
+	ids := append([]string(nil), args...)
+	if container != "" {
+		incidents, err := watch.ListIncidents(dir)
+		if err != nil {
+			return "", nil, err
+		}
+		found := 0
+		for _, status := range statuses {
+			for _, inc := range watch.FilterByStatus(incidents, status) {
+				if inc.Container == container {
+					ids = append(ids, inc.ID)
+					found++
+				}
+			}
+		}
+		if found == 0 && len(args) == 0 {
+			return "", nil, fmt.Errorf("no %s incidents for %q", strings.Join(statuses, " or "), container)
+		}
+	}
+	return dir, ids, nil
+}
+
+// lifecycleUser returns by, or the name of the current user.
+func lifecycleUser(by string) string {
+	if by != "" {
+		return by
+	}
+	return watch.CurrentUser()
+}
+
+// printIncidentLifecycle prints the status, acknowledgement and resolution
+// of an incident for `watch show`.
+func printIncidentLifecycle(inc *watch.Incident) {
+	fmt.Printf("Status:    %s\n", inc.EffectiveStatus())
+	if inc.AckedAt != nil {
+		fmt.Printf("Acked:     %s by %s\n", inc.AckedAt.Format("2006-01-02 15:04:05"), inc.AckedBy)
+	}
+	if inc.ResolvedAt != nil {
+		fmt.Printf("Resolved:  %s by %s\n", inc.ResolvedAt.Format("2006-01-02 15:04:05"), inc.ResolvedBy)
+	}
+	if inc.Resolution != "" {
+		fmt.Printf("Cause:     %s\n", inc.Resolution)
+	}
+	if inc.RootCause != "" {
+		fmt.Printf("Root Cause: %s\n", inc.RootCause)
+	}
+}
+
+// printIncidentNotes prints the notes of an incident for `watch show`.
+func printIncidentNotes(inc *watch.Incident) {
+	if len(inc.Notes) == 0 {
+		return
+	}
+	fmt.Println()
+	fmt.Println("=== Notes ===")
+	for _, n := range inc.Notes {
+		fmt.Printf("[%s] %s: %s\n", n.Time.Format("2006-01-02 15:04"), n.User, n.Text)
+	}
+}
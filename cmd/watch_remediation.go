@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Higangssh/homebutler/internal/watch"
+	"github.com/spf13/cobra"
+)
+
+func newWatchRemediationCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "remediation",
+		Short: "Show remediation policies, the action budget and recent actions",
+		Long: `Show the watch.remediation policies from config.yaml, how much of the daily
+action budget is used, and the actions of the last week.
+
+Policies fire while watch start or watch daemon runs, when a target's
+flapping reaches the policy's level. Each action is also recorded on the
+incident that triggered it; see 'watch show <id>'.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := loadConfig(); err != nil {
+				return err
+			}
+			dir, err := watch.WatchDir()
+			if err != nil {
+				return err
+			}
+			rem := cfg.Watch.Remediation
+			rem.Normalize()
+			log, err := watch.LoadRemediationLog(dir)
+			if err != nil {
+				return err
+			}
+			used := watch.BudgetUsed(log, rem.DryRun, time.Now())
+
+			if jsonOutput {
+				return output(map[string]any{
+					"dry_run":             rem.DryRun,
+					"max_actions_per_day": rem.MaxActionsPerDay,
+					"used_today":          used,
+					"cooldown":            rem.Cooldown.String(),
+					"policies":            rem.Policies,
+					"actions":             log,
+				}, true)
+			}
+
+			mode := "live"
+			if rem.DryRun {
+				mode = "dry run"
+			}
+			fmt.Printf("Mode: %s · budget %d/%d actions in the last 24h · cooldown %s\n\n",
+				mode, used, rem.MaxActionsPerDay, rem.Cooldown)
+
+			if len(rem.Policies) == 0 {
+				fmt.Println("No policies. Add them under watch.remediation.policies in config.yaml.")
+			} else {
+				fmt.Printf("%-20s  %-12s  %-8s  %-8s  %s\n", "TARGET", "ACTION", "LEVEL", "RESTARTS", "ARGUMENT")
+				for _, p := range rem.Policies {
+					level := p.Level
+					if level == "" {
+						level = "chronic"
+					}
+					arg := p.Memory + p.Playbook
+					if err := p.Validate(rem.Playbooks); err != nil {
+						arg = "invalid: " + err.Error()
+					}
+					fmt.Printf("%-20s  %-12s  %-8s  %-8d  %s\n", p.Target, p.Action, level, p.Restarts, arg)
+				}
+			}
+
+			if len(log) == 0 {
+				return nil
+			}
+			fmt.Println()
+			fmt.Printf("%-16s  %-20s  %-12s  %-8s  %s\n", "TIME", "TARGET", "ACTION", "OUTCOME", "DETAIL")
+			for i := len(log) - 1; i >= 0; i-- {
+				a := log[i]
+				fmt.Printf("%-16s  %-20s  %-12s  %-8s  %s\n",
+					a.Time.Format("2006-01-02 15:04"), a.Target, a.Action, a.Outcome, truncateRunes(a.Detail, 80))
+			}
+			return nil
+		},
+	}
+}
+
+// printIncidentRemediation prints the remediation actions of an incident
+// for `watch show`.
+func printIncidentRemediation(inc *watch.Incident) {
+	if len(inc.Remediation) == 0 {
+		return
+	}
+	fmt.Println()
+	fmt.Println("=== Remediation ===")
+	for _, a := range inc.Remediation {
+		fmt.Printf("[%s] %s (%s flapping)\n", a.Time.Format("15:04:05"), a.Summary(), a.Level)
+		for _, c := range a.Commands {
+			fmt.Printf("  $ %s\n", c)
+		}
+		if a.Output != "" {
+			fmt.Printf("  → %s\n", a.Output)
+		}
+	}
+}
@@ -28,15 +28,20 @@ func NotifyAll(cfg *NotifyConfig, event NotifyEvent) []error {
 	if cfg == nil {
 		return nil
 	}
-	t, _ := time.Parse("2006-01-02 15:04:05", event.Time)
-	ne := notify.Event{
+	return notify.SendAll(cfg, event.Event())
+}
+
+// Event converts the alert event for the notify package.
+func (e NotifyEvent) Event() notify.Event {
+	t, _ := time.Parse("2006-01-02 15:04:05", e.Time)
+	return notify.Event{
+		Kind:    notify.KindAlert,
 		Source:  "alerts",
-		Name:    event.RuleName,
-		Status:  event.Status,
-		Details: event.Details,
-		Action:  event.Action,
-		Result:  event.Result,
+		Name:    e.RuleName,
+		Status:  e.Status,
+		Details: e.Details,
+		Action:  e.Action,
+		Result:  e.Result,
 		Time:    t,
 	}
-	return notify.SendAll(cfg, ne)
 }
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/Higangssh/homebutler/internal/docker"
+	"github.com/Higangssh/homebutler/internal/util"
 )
 
 // ActionResult holds the outcome of a playbook action.
@@ -52,33 +53,9 @@ func (ct *cooldownTracker) MarkFired(ruleName string) {
 	ct.fired[ruleName] = time.Now()
 }
 
-// dangerousPatterns contains shell commands that should never be executed.
-var dangerousPatterns = []string{
-	"rm -rf /",
-	"rm -rf /*",
-	"mkfs",
-	"dd if=",
-	":(){:|:&};:",
-	"shutdown",
-	"reboot",
-	"init 0",
-	"init 6",
-	"halt",
-	"poweroff",
-	"> /dev/sda",
-}
-
-// IsDangerousCommand checks if a command matches known dangerous patterns.
-// NOTE: This is a best-effort blocklist and is NOT a complete security boundary.
-// It serves as a supplementary safety net; do not rely on it as the sole defense.
+// IsDangerousCommand reports util.IsDangerousCommand; kept for existing callers.
 func IsDangerousCommand(cmd string) bool {
-	lower := strings.ToLower(strings.TrimSpace(cmd))
-	for _, pattern := range dangerousPatterns {
-		if strings.Contains(lower, pattern) {
-			return true
-		}
-	}
-	return false
+	return util.IsDangerousCommand(cmd)
 }
 
 // ExecuteAction runs the appropriate playbook action for a triggered rule.
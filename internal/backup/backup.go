@@ -1,13 +1,16 @@
 package backup
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/Higangssh/homebutler/internal/docker"
 	"github.com/Higangssh/homebutler/internal/util"
 )
 
@@ -50,7 +53,8 @@ type ListEntry struct {
 	CreatedAt string `json:"created_at"`
 }
 
-// ComposeProject represents a docker compose project from `docker compose ls`.
+// ComposeProject represents a running docker compose project, as `docker
+// compose ls` would report it.
 type ComposeProject struct {
 	Name       string `json:"Name"`
 	Status     string `json:"Status"`
@@ -59,7 +63,11 @@ type ComposeProject struct {
 
 // Run performs a backup of all (or filtered) Docker services.
 func Run(backupDir, service string) (*BackupResult, error) {
-	projects, err := listComposeProjects()
+	client, err := docker.DefaultClient()
+	if err != nil {
+		return nil, err
+	}
+	projects, err := listComposeProjects(client)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list compose projects: %w", err)
 	}
@@ -90,7 +98,7 @@ func Run(backupDir, service string) (*BackupResult, error) {
 	var allServices []ServiceInfo
 
 	for _, proj := range projects {
-		services, err := inspectProject(proj, service)
+		services, err := inspectProject(client, proj, service)
 		if err != nil {
 			return nil, err
 		}
@@ -197,44 +205,72 @@ func List(backupDir string) ([]ListEntry, error) {
 	return backups, nil
 }
 
-// listComposeProjects discovers running compose projects.
-func listComposeProjects() ([]ComposeProject, error) {
-	out, err := util.RunCmd("docker", "compose", "ls", "--format", "json")
+// helperImage runs tar against named volumes. It is fully qualified because
+// Podman refuses short image names when it cannot prompt for a registry.
+const helperImage = "docker.io/library/alpine"
+
+// Labels docker compose sets on the containers it creates.
+const (
+	composeProjectLabel     = "com.docker.compose.project"
+	composeServiceLabel     = "com.docker.compose.service"
+	composeConfigFilesLabel = "com.docker.compose.project.config_files"
+)
+
+// listComposeProjects discovers running compose projects from the labels on
+// their containers, the same information `docker compose ls` reports.
+func listComposeProjects(c *docker.Client) ([]ComposeProject, error) {
+	containers, err := c.ContainerList(context.Background(), docker.ListOptions{Labels: []string{composeProjectLabel}})
 	if err != nil {
-		return nil, fmt.Errorf("docker compose ls failed: %w", err)
+		return nil, err
 	}
-	if out == "" {
-		return nil, nil
+	return composeProjectsFrom(containers), nil
+}
+
+// composeProjectsFrom groups running containers into compose projects,
+// sorted by name.
+func composeProjectsFrom(containers []docker.ContainerSummary) []ComposeProject {
+	byName := map[string]*ComposeProject{}
+	counts := map[string]int{}
+	var names []string
+	for _, ctr := range containers {
+		name := ctr.Labels[composeProjectLabel]
+		if name == "" {
+			continue
+		}
+		p, ok := byName[name]
+		if !ok {
+			p = &ComposeProject{Name: name}
+			byName[name] = p
+			names = append(names, name)
+		}
+		if p.ConfigFile == "" {
+			p.ConfigFile = ctr.Labels[composeConfigFilesLabel]
+		}
+		counts[name]++
 	}
+	sort.Strings(names)
 
-	var projects []ComposeProject
-	if err := json.Unmarshal([]byte(out), &projects); err != nil {
-		return nil, fmt.Errorf("failed to parse compose ls output: %w", err)
+	projects := make([]ComposeProject, 0, len(names))
+	for _, name := range names {
+		p := byName[name]
+		p.Status = fmt.Sprintf("running(%d)", counts[name])
+		projects = append(projects, *p)
 	}
-	return projects, nil
+	return projects
 }
 
 // inspectProject discovers services and mounts for a compose project.
-func inspectProject(proj ComposeProject, filterService string) ([]ServiceInfo, error) {
-	// Get container IDs for this project
-	out, err := util.RunCmd("docker", "compose", "-p", proj.Name, "ps", "-q")
+func inspectProject(c *docker.Client, proj ComposeProject, filterService string) ([]ServiceInfo, error) {
+	containers, err := c.ContainerList(context.Background(), docker.ListOptions{
+		Labels: []string{composeProjectLabel + "=" + proj.Name},
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list containers for project %s: %w", proj.Name, err)
 	}
-	if out == "" {
-		return nil, nil
-	}
-
-	containerIDs := strings.Split(strings.TrimSpace(out), "\n")
 
 	var services []ServiceInfo
-	for _, cid := range containerIDs {
-		cid = strings.TrimSpace(cid)
-		if cid == "" {
-			continue
-		}
-
-		svc, err := inspectContainer(cid)
+	for _, ctr := range containers {
+		svc, err := inspectContainer(c, ctr.ID)
 		if err != nil {
 			continue // skip containers we can't inspect
 		}
@@ -248,46 +284,25 @@ func inspectProject(proj ComposeProject, filterService string) ([]ServiceInfo, e
 	return services, nil
 }
 
-// dockerInspectResult is the subset of docker inspect JSON we need.
-type dockerInspectResult struct {
-	Name   string `json:"Name"`
-	Config struct {
-		Image  string            `json:"Image"`
-		Labels map[string]string `json:"Labels"`
-	} `json:"Config"`
-	Mounts []struct {
-		Type        string `json:"Type"`
-		Name        string `json:"Name"`
-		Source      string `json:"Source"`
-		Destination string `json:"Destination"`
-	} `json:"Mounts"`
-}
-
-// inspectContainer returns service info from docker inspect.
-func inspectContainer(containerID string) (*ServiceInfo, error) {
-	out, err := util.RunCmd("docker", "inspect", containerID)
+// inspectContainer returns service info from the container's inspect data.
+func inspectContainer(c *docker.Client, containerID string) (*ServiceInfo, error) {
+	info, err := c.ContainerInspect(context.Background(), containerID)
 	if err != nil {
 		return nil, fmt.Errorf("docker inspect failed for %s: %w", containerID, err)
 	}
+	return serviceFromInspect(containerID, info), nil
+}
 
-	var results []dockerInspectResult
-	if err := json.Unmarshal([]byte(out), &results); err != nil {
-		return nil, fmt.Errorf("failed to parse docker inspect: %w", err)
-	}
-	if len(results) == 0 {
-		return nil, fmt.Errorf("no inspect data for %s", containerID)
-	}
-
-	r := results[0]
-
-	// Use compose service label for the name
-	name := r.Config.Labels["com.docker.compose.service"]
+// serviceFromInspect builds a ServiceInfo, naming it after its compose
+// service when it has one.
+func serviceFromInspect(containerID string, info *docker.ContainerInfo) *ServiceInfo {
+	name := info.Config.Labels[composeServiceLabel]
 	if name == "" {
-		name = strings.TrimPrefix(r.Name, "/")
+		name = strings.TrimPrefix(info.Name, "/")
 	}
 
 	var mounts []Mount
-	for _, m := range r.Mounts {
+	for _, m := range info.Mounts {
 		mt := Mount{
 			Type:        m.Type,
 			Source:      m.Source,
@@ -304,9 +319,9 @@ func inspectContainer(containerID string) (*ServiceInfo, error) {
 	return &ServiceInfo{
 		Name:      name,
 		Container: containerID,
-		Image:     r.Config.Image,
+		Image:     info.Config.Image,
 		Mounts:    mounts,
-	}, nil
+	}
 }
 
 // backupMount backs up a single mount (volume or bind) to the destination directory.
@@ -318,10 +333,10 @@ func backupMount(m Mount, destDir string) error {
 	switch m.Type {
 	case "volume":
 		// Named volume: use docker run alpine tar pattern
-		_, err := util.RunCmd("docker", "run", "--rm",
+		_, err := util.DockerCmd("run", "--rm",
 			"-v", m.Name+":/source:ro",
 			"-v", destDir+":/backup",
-			"alpine",
+			helperImage,
 			"tar", "czf", "/backup/"+archiveName, "-C", "/source", ".")
 		if err != nil {
 			return fmt.Errorf("failed to backup volume %s: %w", m.Name, err)
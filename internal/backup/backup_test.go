@@ -2,9 +2,14 @@ package backup
 
 import (
 	"encoding/json"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/Higangssh/homebutler/internal/docker"
+	"github.com/Higangssh/homebutler/internal/docker/dockertest"
 )
 
 func TestSanitizeName(t *testing.T) {
@@ -192,3 +197,64 @@ func TestCopyComposeFiles(t *testing.T) {
 		t.Error(".env was not copied")
 	}
 }
+
+func TestDiscoverComposeServices(t *testing.T) {
+	srv := dockertest.NewServer(t)
+	srv.Handle("GET /containers/json", func(w http.ResponseWriter, r *http.Request) {
+		filters := r.URL.Query().Get("filters")
+		all := []map[string]any{
+			{"Id": "c1", "Labels": map[string]string{"com.docker.compose.project": "media", "com.docker.compose.project.config_files": "/srv/media/compose.yml"}},
+			{"Id": "c2", "Labels": map[string]string{"com.docker.compose.project": "media"}},
+			{"Id": "c3", "Labels": map[string]string{"com.docker.compose.project": "auth", "com.docker.compose.project.config_files": "/srv/auth/compose.yml"}},
+		}
+		if strings.Contains(filters, "media") {
+			all = all[:2]
+		}
+		dockertest.WriteJSON(w, all)
+	})
+	srv.Handle("GET /containers/{id}/json", func(w http.ResponseWriter, r *http.Request) {
+		switch r.PathValue("id") {
+		case "c1":
+			dockertest.WriteJSON(w, map[string]any{
+				"Name":   "/media-jellyfin-1",
+				"Config": map[string]any{"Image": "jellyfin/jellyfin", "Labels": map[string]string{"com.docker.compose.service": "jellyfin"}},
+				"Mounts": []map[string]string{
+					{"Type": "volume", "Name": "media_config", "Source": "/var/lib/docker/volumes/media_config/_data", "Destination": "/config"},
+					{"Type": "bind", "Source": "/mnt/media", "Destination": "/media"},
+				},
+			})
+		default:
+			dockertest.Error(w, http.StatusNotFound, "No such container")
+		}
+	})
+
+	c, err := docker.NewClient(srv.Host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	projects, err := listComposeProjects(c)
+	if err != nil {
+		t.Fatalf("listComposeProjects: %v", err)
+	}
+	if len(projects) != 2 || projects[0].Name != "auth" || projects[1].Name != "media" ||
+		projects[1].Status != "running(2)" || projects[1].ConfigFile != "/srv/media/compose.yml" {
+		t.Errorf("projects = %+v", projects)
+	}
+
+	services, err := inspectProject(c, projects[1], "")
+	if err != nil {
+		t.Fatalf("inspectProject: %v", err)
+	}
+	if len(services) != 1 {
+		t.Fatalf("services = %+v (a container that cannot be inspected is skipped)", services)
+	}
+	svc := services[0]
+	if svc.Name != "jellyfin" || svc.Image != "jellyfin/jellyfin" || len(svc.Mounts) != 2 ||
+		svc.Mounts[0].Name != "media_config" || svc.Mounts[1].Name != "/mnt/media" {
+		t.Errorf("service = %+v", svc)
+	}
+
+	if services, _ := inspectProject(c, projects[1], "sonarr"); len(services) != 0 {
+		t.Errorf("filter should exclude jellyfin, got %+v", services)
+	}
+}
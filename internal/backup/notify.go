@@ -0,0 +1,67 @@
+package backup
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/Higangssh/homebutler/internal/notify"
+)
+
+// FailureEvent is the notification for a backup run that failed. service
+// is "" when every service was being backed up.
+func FailureEvent(service string, err error, now time.Time) notify.Event {
+	name := service
+	if name == "" {
+		name = "backup"
+	}
+	return notify.Event{
+		Kind:        notify.KindBackup,
+		Source:      "backup",
+		Name:        name,
+		Status:      "failed",
+		Details:     "backup failed: " + err.Error(),
+		Action:      "backup",
+		Result:      "failed",
+		Time:        now,
+		Fingerprint: "backup:" + name,
+	}
+}
+
+// Event is the notification for a drill: "ok" when it passed, "failed"
+// with the drilled container's logs otherwise. A drill that could not start
+// is a DrillResult with just App and Error.
+func (r *DrillResult) Event(now time.Time) notify.Event {
+	event := notify.Event{
+		Kind:        notify.KindBackup,
+		Source:      "backup",
+		Name:        r.App,
+		Status:      "ok",
+		Details:     "backup drill passed",
+		Action:      "drill",
+		Result:      "success",
+		Time:        now,
+		Fingerprint: "backup-drill:" + r.App,
+	}
+	if r.Archive != "" {
+		event.Fields = append(event.Fields, notify.Field{Name: "Archive", Value: filepath.Base(r.Archive)})
+	}
+	if !r.Passed {
+		event.Status = "failed"
+		event.Result = "failed"
+		event.Details = "backup drill failed"
+		if r.Error != "" {
+			event.Details += ": " + r.Error
+		}
+		event.Excerpt = r.Logs
+	}
+	switch {
+	case r.HealthStatus > 0:
+		event.Fields = append(event.Fields, notify.Field{Name: "Health", Value: "HTTP " + strconv.Itoa(r.HealthStatus)})
+	case r.Booted:
+		event.Fields = append(event.Fields, notify.Field{Name: "Health", Value: "no response"})
+	}
+	event.Fields = append(event.Fields, notify.Field{Name: "Duration", Value: fmt.Sprintf("%ds", r.TotalSeconds)})
+	return event
+}
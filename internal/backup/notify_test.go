@@ -0,0 +1,52 @@
+package backup
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Higangssh/homebutler/internal/notify"
+)
+
+func TestDrillResultEvent(t *testing.T) {
+	now := time.Date(2026, 10, 18, 3, 0, 0, 0, time.UTC)
+	r := &DrillResult{
+		App:          "uptime-kuma",
+		Archive:      "/backups/backup_2026-10-17.tar.gz",
+		Integrity:    true,
+		Booted:       true,
+		HealthStatus: 503,
+		Error:        "health check returned HTTP 503",
+		Logs:         "SQLITE_CORRUPT",
+		TotalSeconds: 41,
+	}
+	e := r.Event(now)
+	if e.Kind != notify.KindBackup || e.Status != "failed" || e.Name != "uptime-kuma" || e.Severity() != notify.SeverityCritical {
+		t.Errorf("event = %+v", e)
+	}
+	if e.Details != "backup drill failed: health check returned HTTP 503" || e.Excerpt != "SQLITE_CORRUPT" {
+		t.Errorf("details/excerpt = %q/%q", e.Details, e.Excerpt)
+	}
+	fields := map[string]string{}
+	for _, f := range e.Fields {
+		fields[f.Name] = f.Value
+	}
+	if fields["Archive"] != "backup_2026-10-17.tar.gz" || fields["Health"] != "HTTP 503" || fields["Duration"] != "41s" {
+		t.Errorf("fields = %+v", e.Fields)
+	}
+
+	passed := (&DrillResult{App: "gitea", Archive: "/b/x.tar.gz", Passed: true, HealthStatus: 200}).Event(now)
+	if passed.Status != "ok" || passed.Excerpt != "" || passed.Severity() != notify.SeverityInfo {
+		t.Errorf("passed drill = %+v", passed)
+	}
+}
+
+func TestFailureEvent(t *testing.T) {
+	e := FailureEvent("", errors.New("no running compose projects"), time.Now())
+	if e.Kind != notify.KindBackup || e.Name != "backup" || e.Status != "failed" || e.Details != "backup failed: no running compose projects" {
+		t.Errorf("event = %+v", e)
+	}
+	if e := FailureEvent("gitea", errors.New("x"), time.Now()); e.Name != "gitea" {
+		t.Errorf("service event name = %q", e.Name)
+	}
+}
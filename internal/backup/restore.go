@@ -112,10 +112,10 @@ func restoreMount(m Mount, volDir string) error {
 	switch m.Type {
 	case "volume":
 		// Restore named volume using docker run alpine tar pattern
-		_, err := util.RunCmd("docker", "run", "--rm",
+		_, err := util.DockerCmd("run", "--rm",
 			"-v", m.Name+":/target",
 			"-v", volDir+":/backup:ro",
-			"alpine",
+			helperImage,
 			"sh", "-c", "cd /target && tar xzf /backup/"+safeName+".tar.gz")
 		if err != nil {
 			return fmt.Errorf("failed to restore volume %s: %w", m.Name, err)
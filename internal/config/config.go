@@ -2,10 +2,14 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"time"
 
+	"github.com/Higangssh/homebutler/internal/history"
 	"github.com/Higangssh/homebutler/internal/notify"
 	"github.com/Higangssh/homebutler/internal/watch"
 	"gopkg.in/yaml.v3"
@@ -19,21 +23,138 @@ type Config struct {
 	Notify    notify.ProviderConfig `yaml:"notify,omitempty"`
 	Watch     WatchRuntimeConfig    `yaml:"watch,omitempty"`
 	BackupDir string                `yaml:"backup_dir,omitempty"`
+	History   history.Config        `yaml:"history,omitempty"`
+	Report    ReportConfig          `yaml:"report,omitempty"`
+	Doctor    DoctorConfig          `yaml:"doctor,omitempty"`
+	// Runtime forces the container runtime: "docker" or "podman". Empty or
+	// "auto" detects it from DOCKER_HOST and the sockets present.
+	Runtime string `yaml:"runtime,omitempty"`
+}
+
+// ReportConfig tunes `homebutler report`.
+type ReportConfig struct {
+	// ForecastHorizon raises a disk-full forecast under Needs Attention when
+	// the disk is projected to fill within it, e.g. "14d".
+	ForecastHorizon string `yaml:"forecast_horizon,omitempty"`
+}
+
+// DoctorConfig extends `homebutler doctor` with house-specific checks.
+type DoctorConfig struct {
+	Checks []DoctorCheck      `yaml:"checks,omitempty"`
+	TLS    DoctorTLSConfig    `yaml:"tls,omitempty"`
+	Docker DoctorDockerConfig `yaml:"docker,omitempty"`
+}
+
+// DoctorDockerConfig tunes the Docker hygiene checks.
+type DoctorDockerConfig struct {
+	// LogSizeLimit flags containers whose unbounded json-file log has grown
+	// past this size, e.g. "500MB" (the default).
+	LogSizeLimit string `yaml:"log_size_limit,omitempty"`
+}
+
+// DoctorTLSConfig tunes the TLS certificate checks. Publicly bound ports are
+// always probed; Hosts adds names served elsewhere, such as a reverse proxy
+// reached through its DNS name, as "host" (port 443) or "host:port".
+type DoctorTLSConfig struct {
+	ExpiryDays int      `yaml:"expiry_days,omitempty"` // warn this many days ahead, default 14
+	Hosts      []string `yaml:"hosts,omitempty"`
+}
+
+// Doctor check types.
+const (
+	DoctorCheckHTTP    = "http"
+	DoctorCheckFile    = "file"
+	DoctorCheckTCP     = "tcp"
+	DoctorCheckCommand = "command"
+)
+
+// DoctorCheck is one user-defined doctor check. Which target field applies
+// depends on Type: URL for http, Path for file, Address for tcp and Run for
+// command. Title, Action and Command are shown on the finding when the check
+// fails.
+type DoctorCheck struct {
+	Name     string `yaml:"name"`
+	Type     string `yaml:"type"`
+	URL      string `yaml:"url,omitempty"`
+	Status   int    `yaml:"status,omitempty"`  // http: expected status code, default 200
+	Path     string `yaml:"path,omitempty"`    // file: must exist and be newer than MaxAge
+	MaxAge   string `yaml:"max_age,omitempty"` // file: e.g. "26h" or "2d"
+	Address  string `yaml:"address,omitempty"` // tcp: host:port
+	Run      string `yaml:"run,omitempty"`     // command: shell command that must exit 0
+	Timeout  string `yaml:"timeout,omitempty"` // default 10s
+	Severity string `yaml:"severity,omitempty"`
+	Title    string `yaml:"title,omitempty"`
+	Action   string `yaml:"action,omitempty"`
+	Command  string `yaml:"command,omitempty"`
+}
+
+// Validate reports the first problem that would stop the check from running.
+func (c DoctorCheck) Validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	switch c.Type {
+	case DoctorCheckHTTP:
+		if !strings.HasPrefix(c.URL, "http://") && !strings.HasPrefix(c.URL, "https://") {
+			return fmt.Errorf("http check needs a url starting with http:// or https://")
+		}
+		if c.Status != 0 && (c.Status < 100 || c.Status > 599) {
+			return fmt.Errorf("status %d is not an HTTP status code", c.Status)
+		}
+	case DoctorCheckFile:
+		if c.Path == "" {
+			return fmt.Errorf("file check needs a path")
+		}
+		if c.MaxAge != "" {
+			if _, err := history.ParseDuration(c.MaxAge); err != nil {
+				return fmt.Errorf("invalid max_age %q", c.MaxAge)
+			}
+		}
+	case DoctorCheckTCP:
+		if _, _, err := net.SplitHostPort(c.Address); err != nil {
+			return fmt.Errorf("tcp check needs an address in host:port form")
+		}
+	case DoctorCheckCommand:
+		if strings.TrimSpace(c.Run) == "" {
+			return fmt.Errorf("command check needs run")
+		}
+	case "":
+		return fmt.Errorf("type is required (http, file, tcp or command)")
+	default:
+		return fmt.Errorf("unknown type %q (expected http, file, tcp or command)", c.Type)
+	}
+	if c.Timeout != "" {
+		if d, err := time.ParseDuration(c.Timeout); err != nil || d <= 0 {
+			return fmt.Errorf("invalid timeout %q", c.Timeout)
+		}
+	}
+	switch c.Severity {
+	case "", "warn", "fail":
+	default:
+		return fmt.Errorf("unknown severity %q (expected warn or fail)", c.Severity)
+	}
+	return nil
 }
 
 type WatchRuntimeConfig struct {
-	Notify    watch.NotifySettings  `yaml:"notify,omitempty"`
-	Flapping  watch.FlappingConfig  `yaml:"flapping,omitempty"`
-	Retention watch.RetentionConfig `yaml:"retention,omitempty"`
+	Notify      watch.NotifySettings    `yaml:"notify,omitempty"`
+	Flapping    watch.FlappingConfig    `yaml:"flapping,omitempty"`
+	Retention   watch.RetentionConfig   `yaml:"retention,omitempty"`
+	Analysis    watch.AnalysisConfig    `yaml:"analysis,omitempty"`
+	Recovery    watch.RecoveryConfig    `yaml:"recovery,omitempty"`
+	Remediation watch.RemediationConfig `yaml:"remediation,omitempty"`
 }
 
 // watchRuntimeYAML is the decode target for WatchRuntimeConfig. It carries the
 // canonical nested shape plus the flat keys, as pointers so that "absent" and
 // "set to the zero value" stay distinguishable.
 type watchRuntimeYAML struct {
-	Notify    watch.NotifySettings  `yaml:"notify,omitempty"`
-	Flapping  watch.FlappingConfig  `yaml:"flapping,omitempty"`
-	Retention watch.RetentionConfig `yaml:"retention,omitempty"`
+	Notify      watch.NotifySettings    `yaml:"notify,omitempty"`
+	Flapping    watch.FlappingConfig    `yaml:"flapping,omitempty"`
+	Retention   watch.RetentionConfig   `yaml:"retention,omitempty"`
+	Analysis    watch.AnalysisConfig    `yaml:"analysis,omitempty"`
+	Recovery    watch.RecoveryConfig    `yaml:"recovery,omitempty"`
+	Remediation watch.RemediationConfig `yaml:"remediation,omitempty"`
 
 	Enabled    *bool   `yaml:"enabled,omitempty"`
 	NotifyOn   *string `yaml:"notify_on,omitempty"`
@@ -61,13 +182,16 @@ type watchRuntimeYAML struct {
 func (w *WatchRuntimeConfig) UnmarshalYAML(node *yaml.Node) error {
 	// Seeded with the current value so that defaults applied before decoding
 	// survive keys the file does not mention.
-	raw := watchRuntimeYAML{Notify: w.Notify, Flapping: w.Flapping, Retention: w.Retention}
+	raw := watchRuntimeYAML{Notify: w.Notify, Flapping: w.Flapping, Retention: w.Retention, Analysis: w.Analysis, Recovery: w.Recovery, Remediation: w.Remediation}
 	if err := node.Decode(&raw); err != nil {
 		return err
 	}
 	w.Notify = raw.Notify
 	w.Flapping = raw.Flapping
 	w.Retention = raw.Retention
+	w.Analysis = raw.Analysis
+	w.Recovery = raw.Recovery
+	w.Remediation = raw.Remediation
 
 	if hasMappingKey(node, "notify") {
 		return nil
@@ -216,6 +340,7 @@ func newDefaultConfig() *Config {
 			Notify:    defaultWatch.Notify,
 			Flapping:  defaultWatch.Flapping,
 			Retention: defaultWatch.Retention,
+			Recovery:  defaultWatch.Recovery,
 		},
 	}
 }
@@ -241,6 +366,8 @@ func Load(path string) (*Config, error) {
 
 	cfg.Watch.Notify.Normalize()
 	cfg.Watch.Retention.Normalize()
+	cfg.Watch.Recovery.Normalize()
+	cfg.Watch.Remediation.Normalize()
 
 	cfg.Path = path
 
@@ -257,13 +384,17 @@ func Load(path string) (*Config, error) {
 	return cfg, nil
 }
 
-// hasSecrets returns true if any server uses password auth.
+// hasSecrets returns true if any server uses password auth or the email
+// provider has an SMTP password.
 func hasSecrets(cfg *Config) bool {
 	for _, s := range cfg.Servers {
 		if s.Password != "" {
 			return true
 		}
 	}
+	if e := cfg.Notify.Email; e != nil && e.Password != "" {
+		return true
+	}
 	return false
 }
 
@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/Higangssh/homebutler/internal/notify"
 )
 
 func TestLoadDefaults(t *testing.T) {
@@ -338,6 +340,7 @@ func TestHasSecrets(t *testing.T) {
 		{"no-passwords", &Config{Servers: []ServerConfig{{Name: "a"}}}, false},
 		{"with-password", &Config{Servers: []ServerConfig{{Name: "a", Password: "secret"}}}, true},
 		{"mixed", &Config{Servers: []ServerConfig{{Name: "a"}, {Name: "b", Password: "secret"}}}, true},
+		{"smtp-password", &Config{Notify: notify.ProviderConfig{Email: &notify.EmailConfig{Password: "secret"}}}, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
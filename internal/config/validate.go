@@ -3,6 +3,7 @@ package config
 import (
 	"bytes"
 	"fmt"
+	"maps"
 	"net"
 	"os"
 	"path/filepath"
@@ -12,6 +13,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Higangssh/homebutler/internal/docker"
+	"github.com/Higangssh/homebutler/internal/history"
+	"github.com/Higangssh/homebutler/internal/notify"
+	"github.com/Higangssh/homebutler/internal/util"
 	"gopkg.in/yaml.v3"
 )
 
@@ -79,7 +84,7 @@ func (r *ValidationResult) add(severity, field, message, hint string) {
 }
 
 // topLevelKeys mirrors the yaml tags on Config, in the order they are reported.
-var topLevelKeys = []string{"servers", "wake", "alerts", "notify", "watch", "backup_dir"}
+var topLevelKeys = []string{"servers", "wake", "alerts", "notify", "watch", "backup_dir", "history", "report", "doctor", "runtime"}
 
 // Validate resolves the config the same way every other command does, then
 // reports what it found without applying it. It never contacts a remote
@@ -147,6 +152,10 @@ func Validate(explicit string) *ValidationResult {
 	r.checkNotify(cfg)
 	r.checkWatch(cfg)
 	r.checkBackupDir(cfg)
+	r.checkHistory(cfg)
+	r.checkReport(cfg)
+	r.checkDoctor(cfg)
+	r.checkRuntime(cfg)
 
 	r.Valid = r.Errors() == 0
 	return r
@@ -215,8 +224,23 @@ func cleanYAMLError(msg string) string {
 		{"type notify.SlackConfig", "notify.slack"},
 		{"type notify.DiscordConfig", "notify.discord"},
 		{"type notify.WebhookConfig", "notify.webhook"},
+		{"type notify.EmailConfig", "notify.email"},
+		{"type notify.NtfyConfig", "notify.ntfy"},
+		{"type notify.GotifyConfig", "notify.gotify"},
+		{"type notify.PushoverConfig", "notify.pushover"},
 		{"type watch.NotifySettings", "watch.notify"},
 		{"type watch.FlappingConfig", "watch.flapping"},
+		{"type watch.AnalysisConfig", "watch.analysis"},
+		{"type watch.AnalysisRule", "a watch.analysis.rules[] entry"},
+		{"type watch.RecoveryConfig", "watch.recovery"},
+		{"type watch.RemediationConfig", "watch.remediation"},
+		{"type watch.RemediationPolicy", "a watch.remediation.policies[] entry"},
+		{"type history.Config", "history"},
+		{"type config.ReportConfig", "report"},
+		{"type config.DoctorConfig", "doctor"},
+		{"type config.DoctorCheck", "a doctor.checks[] entry"},
+		{"type config.DoctorTLSConfig", "doctor.tls"},
+		{"type config.DoctorDockerConfig", "doctor.docker"},
 	}
 	for _, rep := range replacements {
 		msg = strings.ReplaceAll(msg, rep.from, rep.to)
@@ -261,10 +285,15 @@ func editDistance(a, b string) int {
 // Keys accepted under watch:. The flat spellings are the compatibility path
 // described on WatchRuntimeConfig.UnmarshalYAML.
 var (
-	watchKeys          = []string{"notify", "flapping", "retention", "enabled", "notify_on", "on_incident", "on_flapping", "cooldown"}
+	watchKeys          = []string{"notify", "flapping", "retention", "analysis", "recovery", "remediation", "enabled", "notify_on", "on_incident", "on_flapping", "cooldown"}
 	watchNotifyKeys    = []string{"enabled", "notify_on", "on_incident", "on_flapping", "cooldown"}
 	watchFlapKeys      = []string{"short_window", "short_threshold", "long_window", "long_threshold"}
 	watchRetentionKeys = []string{"max_incidents"}
+	watchAnalysisKeys  = []string{"rules"}
+	watchRuleKeys      = []string{"name", "pattern", "category", "reason", "confidence"}
+	watchRecoveryKeys  = []string{"settle", "timeout"}
+	watchRemedyKeys    = []string{"dry_run", "max_actions_per_day", "cooldown", "policies", "playbooks"}
+	watchPolicyKeys    = []string{"target", "action", "level", "restarts", "memory", "playbook"}
 )
 
 // checkWatchKeys inspects the watch subtree by hand.
@@ -290,6 +319,24 @@ func (r *ValidationResult) checkWatchKeys(rawTop map[string]yaml.Node) {
 			r.reportUnknownKeys(node.Content[i+1], "watch.flapping", watchFlapKeys)
 		case "retention":
 			r.reportUnknownKeys(node.Content[i+1], "watch.retention", watchRetentionKeys)
+		case "recovery":
+			r.reportUnknownKeys(node.Content[i+1], "watch.recovery", watchRecoveryKeys)
+		case "remediation":
+			remediation := node.Content[i+1]
+			r.reportUnknownKeys(remediation, "watch.remediation", watchRemedyKeys)
+			if policies := mappingValue(remediation, "policies"); policies != nil && policies.Kind == yaml.SequenceNode {
+				for j, policy := range policies.Content {
+					r.reportUnknownKeys(policy, fmt.Sprintf("watch.remediation.policies[%d]", j), watchPolicyKeys)
+				}
+			}
+		case "analysis":
+			analysis := node.Content[i+1]
+			r.reportUnknownKeys(analysis, "watch.analysis", watchAnalysisKeys)
+			if rules := mappingValue(analysis, "rules"); rules != nil && rules.Kind == yaml.SequenceNode {
+				for j, rule := range rules.Content {
+					r.reportUnknownKeys(rule, fmt.Sprintf("watch.analysis.rules[%d]", j), watchRuleKeys)
+				}
+			}
 		default:
 			if slices.Contains(watchKeys, key) {
 				flat = append(flat, key)
@@ -304,6 +351,19 @@ func (r *ValidationResult) checkWatchKeys(rawTop map[string]yaml.Node) {
 	}
 }
 
+// mappingValue returns the value node of key in a YAML mapping, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
 // reportUnknownKeys flags mapping keys that are not in the accepted set.
 func (r *ValidationResult) reportUnknownKeys(node *yaml.Node, prefix string, known []string) {
 	if node == nil || node.Kind != yaml.MappingNode {
@@ -389,6 +449,22 @@ func sectionSummary(name string, present bool, cfg *Config) string {
 			notifyOn = "flapping"
 		}
 		s := fmt.Sprintf("enabled=%t · notify_on=%s · cooldown=%s", n.Enabled, notifyOn, n.Cooldown)
+		if rules := len(cfg.Watch.Analysis.Rules); rules > 0 {
+			s += " · " + plural(rules, "analysis rule")
+		}
+		if !cfg.Watch.Recovery.Enabled() {
+			s += " · recovery checks off"
+		}
+		if policies := len(cfg.Watch.Remediation.Policies); policies > 0 {
+			if policies == 1 {
+				s += " · 1 remediation policy"
+			} else {
+				s += fmt.Sprintf(" · %d remediation policies", policies)
+			}
+			if cfg.Watch.Remediation.DryRun {
+				s += " (dry run)"
+			}
+		}
 		if !present {
 			return s + " (defaults)"
 		}
@@ -399,6 +475,42 @@ func sectionSummary(name string, present bool, cfg *Config) string {
 			return cfg.ResolveBackupDir() + " (default)"
 		}
 		return cfg.BackupDir
+
+	case "history":
+		retention, raw := cfg.History.Retention, cfg.History.RawRetention
+		if retention == "" {
+			retention = "90d"
+		}
+		if raw == "" {
+			raw = "48h"
+		}
+		s := fmt.Sprintf("retention %s · full resolution %s", retention, raw)
+		if !present {
+			return s + " (defaults)"
+		}
+		return s
+
+	case "report":
+		if cfg.Report.ForecastHorizon == "" {
+			return "forecast horizon 14d (default)"
+		}
+		return "forecast horizon " + cfg.Report.ForecastHorizon
+
+	case "doctor":
+		s := "built-in checks only"
+		if len(cfg.Doctor.Checks) > 0 {
+			s = plural(len(cfg.Doctor.Checks), "custom check")
+		}
+		if n := len(cfg.Doctor.TLS.Hosts); n > 0 {
+			s += " · " + plural(n, "TLS host")
+		}
+		return s
+
+	case "runtime":
+		if cfg.Runtime == "" || cfg.Runtime == "auto" {
+			return "auto (detected: " + util.ContainerRuntime().Name + ")"
+		}
+		return cfg.Runtime
 	}
 	return ""
 }
@@ -543,6 +655,12 @@ func (r *ValidationResult) checkNotify(cfg *Config) {
 	if w := cfg.Notify.Webhook; w != nil {
 		urls = append(urls, struct{ field, url string }{"notify.webhook.url", w.URL})
 	}
+	if n := cfg.Notify.Ntfy; n != nil {
+		urls = append(urls, struct{ field, url string }{"notify.ntfy.url", n.URL})
+	}
+	if g := cfg.Notify.Gotify; g != nil {
+		urls = append(urls, struct{ field, url string }{"notify.gotify.url", g.URL})
+	}
 	for _, u := range urls {
 		provider := strings.Split(u.field, ".")[1]
 		switch {
@@ -554,6 +672,56 @@ func (r *ValidationResult) checkNotify(cfg *Config) {
 				fmt.Sprintf("URL %q does not start with http:// or https://.", u.url), "")
 		}
 	}
+
+	if e := cfg.Notify.Email; e != nil {
+		err := e.Validate()
+		switch {
+		case e.Host == "" || e.From == "" || len(e.To) == 0:
+			r.add(SeverityWarning, "notify.email",
+				strings.ToUpper(err.Error()[:1])+err.Error()[1:]+", so email stays disabled.", "")
+		case err != nil:
+			r.add(SeverityError, "notify.email", strings.ToUpper(err.Error()[:1])+err.Error()[1:]+".", "")
+		case e.Username != "" && e.EffectiveSecurity() == notify.EmailNone && e.Host != "localhost" && e.Host != "127.0.0.1":
+			r.add(SeverityError, "notify.email.security",
+				"SMTP auth over an unencrypted connection is refused for anything but localhost.",
+				`Use security: starttls or tls, or a relay that needs no login.`)
+		}
+	}
+
+	if n := cfg.Notify.Ntfy; n != nil && (n.Priority < 0 || n.Priority > 5) {
+		r.add(SeverityError, "notify.ntfy.priority",
+			fmt.Sprintf("Priority %d is out of range.", n.Priority), "ntfy priorities run from 1 (min) to 5 (urgent); leave it unset to follow the event severity.")
+	}
+	if g := cfg.Notify.Gotify; g != nil {
+		if g.URL != "" && g.Token == "" {
+			r.add(SeverityWarning, "notify.gotify.token", "url is set but the app token is missing, so Gotify stays disabled.", "")
+		}
+		if g.Priority < 0 || g.Priority > 10 {
+			r.add(SeverityError, "notify.gotify.priority",
+				fmt.Sprintf("Priority %d is out of range.", g.Priority), "Gotify priorities run from 1 to 10; leave it unset to follow the event severity.")
+		}
+	}
+	if p := cfg.Notify.Pushover; p != nil {
+		switch {
+		case p.Token == "" && p.User == "":
+			r.add(SeverityWarning, "notify.pushover", "Pushover block is empty, so Pushover stays disabled.", "")
+		case p.Token == "":
+			r.add(SeverityWarning, "notify.pushover.token", "user is set but the app token is missing, so Pushover stays disabled.", "")
+		case p.User == "":
+			r.add(SeverityWarning, "notify.pushover.user", "token is set but the user key is missing, so Pushover stays disabled.", "")
+		}
+	}
+
+	checkTemplates := func(field string, t notify.Templates) {
+		if err := t.Validate(); err != nil {
+			r.add(SeverityError, field, fmt.Sprintf("Template error: %s.", err),
+				"Run 'homebutler notify preview --kind <kind>' to see what each provider would send.")
+		}
+	}
+	checkTemplates("notify.templates", cfg.Notify.Templates)
+	for _, ch := range notify.Channels {
+		checkTemplates("notify."+string(ch)+".templates", cfg.Notify.ProviderTemplates(ch))
+	}
 }
 
 func (r *ValidationResult) checkWatch(cfg *Config) {
@@ -587,6 +755,45 @@ func (r *ValidationResult) checkWatch(cfg *Config) {
 	if f.ShortWindow < 0 || f.LongWindow < 0 {
 		r.add(SeverityError, "watch.flapping", "Flapping windows cannot be negative.", "")
 	}
+
+	if cfg.Watch.Recovery.Settle < 0 {
+		r.add(SeverityWarning, "watch.recovery.settle", "Settle period cannot be negative.",
+			"The default of 10s is used instead.")
+	}
+
+	rem := cfg.Watch.Remediation
+	if rem.MaxActionsPerDay < 0 {
+		r.add(SeverityWarning, "watch.remediation.max_actions_per_day", "The action budget cannot be negative.",
+			"The default of 3 actions a day is used instead.")
+	}
+	for i, p := range rem.Policies {
+		if err := p.Validate(rem.Playbooks); err != nil {
+			r.add(SeverityError, fmt.Sprintf("watch.remediation.policies[%d]", i),
+				strings.ToUpper(err.Error()[:1])+err.Error()[1:]+".",
+				"watch start skips this policy and keeps the others.")
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(rem.Playbooks)) {
+		field := "watch.remediation.playbooks." + name
+		if len(rem.Playbooks[name]) == 0 {
+			r.add(SeverityWarning, field, "Playbook has no commands.", "")
+		}
+		for _, c := range rem.Playbooks[name] {
+			if util.IsDangerousCommand(c) {
+				r.add(SeverityError, field,
+					fmt.Sprintf("Command %q matches the dangerous command blocklist.", c),
+					"The playbook is refused when a policy runs it.")
+			}
+		}
+	}
+
+	for i, rule := range cfg.Watch.Analysis.Rules {
+		if err := rule.Validate(); err != nil {
+			r.add(SeverityError, fmt.Sprintf("watch.analysis.rules[%d]", i),
+				strings.ToUpper(err.Error()[:1])+err.Error()[1:]+".",
+				"watch start skips this rule and keeps the others.")
+		}
+	}
 }
 
 func (r *ValidationResult) checkBackupDir(cfg *Config) {
@@ -604,6 +811,85 @@ func (r *ValidationResult) checkBackupDir(cfg *Config) {
 	}
 }
 
+func (r *ValidationResult) checkHistory(cfg *Config) {
+	durations := []struct{ field, value string }{
+		{"history.retention", cfg.History.Retention},
+		{"history.raw_retention", cfg.History.RawRetention},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		if _, err := history.ParseDuration(d.value); err != nil {
+			r.add(SeverityError, d.field,
+				fmt.Sprintf("Invalid duration %q.", d.value), `Use a duration such as "48h", "7d", or "2w".`)
+		}
+	}
+
+	store, err := history.Open("", cfg.History)
+	if err != nil {
+		return // already reported above
+	}
+	if store.RawRetention > store.Retention {
+		r.add(SeverityWarning, "history.raw_retention",
+			"Full-resolution window is longer than the retention window.",
+			"History is deleted at the retention limit, so samples are never averaged into hourly points.")
+	}
+}
+
+func (r *ValidationResult) checkReport(cfg *Config) {
+	if h := cfg.Report.ForecastHorizon; h != "" {
+		if _, err := history.ParseDuration(h); err != nil {
+			r.add(SeverityError, "report.forecast_horizon",
+				fmt.Sprintf("Invalid duration %q.", h), `Use a duration such as "14d" or "2w".`)
+		}
+	}
+}
+
+func (r *ValidationResult) checkRuntime(cfg *Config) {
+	switch cfg.Runtime {
+	case "", "auto", util.RuntimeDocker, util.RuntimePodman:
+	default:
+		r.add(SeverityError, "runtime", fmt.Sprintf("Unknown container runtime %q.", cfg.Runtime),
+			`Use "docker", "podman", or "auto" to detect it.`)
+	}
+}
+
+func (r *ValidationResult) checkDoctor(cfg *Config) {
+	seen := map[string]int{}
+	for i, c := range cfg.Doctor.Checks {
+		field := fmt.Sprintf("doctor.checks[%d]", i)
+		if err := c.Validate(); err != nil {
+			r.add(SeverityError, field, strings.ToUpper(err.Error()[:1])+err.Error()[1:]+".",
+				"Doctor reports this check as misconfigured instead of running it.")
+		}
+		if c.Name == "" {
+			continue
+		}
+		if first, dup := seen[c.Name]; dup {
+			r.add(SeverityWarning, field+".name",
+				fmt.Sprintf("Duplicate check name %q (first defined at doctor.checks[%d]).", c.Name, first),
+				"Both run, but their findings are hard to tell apart.")
+		} else {
+			seen[c.Name] = i
+		}
+	}
+
+	if cfg.Doctor.TLS.ExpiryDays < 0 {
+		r.add(SeverityError, "doctor.tls.expiry_days", "Expiry warning window cannot be negative.", "Omit the key to use 14 days.")
+	}
+	if l := cfg.Doctor.Docker.LogSizeLimit; l != "" && docker.ParseSize(l) <= 0 {
+		r.add(SeverityError, "doctor.docker.log_size_limit",
+			fmt.Sprintf("Invalid size %q.", l), `Use a size such as "500MB" or "2GB".`)
+	}
+	for i, h := range cfg.Doctor.TLS.Hosts {
+		if strings.TrimSpace(h) == "" || strings.Contains(h, "://") {
+			r.add(SeverityError, fmt.Sprintf("doctor.tls.hosts[%d]", i),
+				fmt.Sprintf("Invalid host %q.", h), `Use a bare host name such as "cloud.example.com" or "cloud.example.com:8443".`)
+		}
+	}
+}
+
 // expandHome resolves a leading ~ so that paths written the way users write
 // them in YAML can actually be checked.
 func expandHome(path string) string {
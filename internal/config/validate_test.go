@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 // writeConfig writes content to a temp file and returns its path.
@@ -328,6 +329,58 @@ notify:
 	}
 }
 
+func TestValidatePushProviders(t *testing.T) {
+	path := writeConfig(t, `
+notify:
+  ntfy:
+    url: https://ntfy.sh/homelab
+    priority: 7
+    tags: [homelab]
+  gotify:
+    url: gotify.lan
+  pushover:
+    user: "ukey"
+`)
+
+	r := Validate(path)
+
+	requireFinding(t, r, "notify.ntfy.priority", SeverityError)
+	requireFinding(t, r, "notify.gotify.url", SeverityWarning)
+	requireFinding(t, r, "notify.gotify.token", SeverityWarning)
+	requireFinding(t, r, "notify.pushover.token", SeverityWarning)
+	for _, s := range r.Sections {
+		if s.Name == "notify" && s.Summary != "ntfy" {
+			t.Errorf("notify summary = %q, want only the complete channel", s.Summary)
+		}
+	}
+}
+
+func TestValidateNotifyTemplates(t *testing.T) {
+	path := writeConfig(t, `
+notify:
+  templates:
+    incident: "{{.Name}} {{.Status}}"
+    restart: "{{.Name}}"
+  slack:
+    webhook_url: https://hooks.slack.com/services/x
+    templates:
+      default: "{{.Name"
+  telegram:
+    bot_token: "token"
+    chat_id: "123"
+    templates:
+      flapping: "{{.Name}} is {{.Severity}}"
+`)
+
+	r := Validate(path)
+
+	requireFinding(t, r, "notify.templates", SeverityError)
+	requireFinding(t, r, "notify.slack.templates", SeverityError)
+	if _, found := findingFor(r, "notify.telegram.templates"); found {
+		t.Errorf("valid telegram template flagged: %+v", r.Findings)
+	}
+}
+
 func TestValidateInvalidYAML(t *testing.T) {
 	path := writeConfig(t, "servers: [unclosed\n")
 
@@ -561,3 +614,186 @@ watch:
 
 	requireFinding(t, r, "max_incident", SeverityWarning)
 }
+
+func TestValidateHistoryDurations(t *testing.T) {
+	r := Validate(writeConfig(t, `
+history:
+  retention: forever
+  raw_retention: 12h
+`))
+	requireFinding(t, r, `Invalid duration "forever"`, SeverityError)
+
+	r = Validate(writeConfig(t, `
+history:
+  retention: 2d
+  raw_retention: 7d
+`))
+	requireFinding(t, r, "longer than the retention window", SeverityWarning)
+}
+
+func TestValidateDoctorChecks(t *testing.T) {
+	r := Validate(writeConfig(t, `
+doctor:
+  checks:
+    - name: nextcloud
+      type: http
+      url: https://cloud.lan/status.php
+    - name: nightly-dump
+      type: file
+      path: /srv/backups/db.sql.gz
+      max_age: 26h
+      severity: fail
+`))
+	if r.Errors() != 0 {
+		t.Fatalf("valid checks reported errors: %+v", r.Findings)
+	}
+
+	r = Validate(writeConfig(t, `
+doctor:
+  checks:
+    - name: mqtt
+      type: tcp
+      address: "1883"
+    - name: mqtt
+      type: ping
+`))
+	requireFinding(t, r, "host:port", SeverityError)
+	requireFinding(t, r, `Unknown type "ping"`, SeverityError)
+	requireFinding(t, r, `Duplicate check name "mqtt"`, SeverityWarning)
+}
+
+func TestValidateDoctorTLS(t *testing.T) {
+	r := Validate(writeConfig(t, `
+doctor:
+  tls:
+    expiry_days: -1
+    hosts:
+      - https://cloud.example.com
+`))
+	requireFinding(t, r, "cannot be negative", SeverityError)
+	requireFinding(t, r, `Invalid host "https://cloud.example.com"`, SeverityError)
+}
+
+func TestValidateDoctorLogSizeLimit(t *testing.T) {
+	r := Validate(writeConfig(t, `
+doctor:
+  docker:
+    log_size_limit: lots
+`))
+	requireFinding(t, r, `Invalid size "lots"`, SeverityError)
+}
+
+func TestValidateRuntime(t *testing.T) {
+	r := Validate(writeConfig(t, "runtime: containerd\n"))
+	requireFinding(t, r, `Unknown container runtime "containerd"`, SeverityError)
+
+	for _, ok := range []string{"docker", "podman", "auto"} {
+		r := Validate(writeConfig(t, "runtime: "+ok+"\n"))
+		if _, found := findingFor(r, "container runtime"); found {
+			t.Errorf("runtime %q should be accepted", ok)
+		}
+	}
+}
+
+func TestValidateWatchAnalysisRules(t *testing.T) {
+	path := writeConfig(t, `
+watch:
+  analysis:
+    rules:
+      - name: immich ml
+        pattern: "Failed to load model"
+        category: model
+        confidence: high
+      - pattern: "(unclosed"
+        category: broken
+      - pattern: "x"
+        category: y
+        severity: high
+`)
+
+	r := Validate(path)
+
+	if _, ok := findingFor(r, "watch.analysis.rules[0]"); ok {
+		t.Errorf("valid rule should not be reported, got %+v", r.Findings)
+	}
+	f := requireFinding(t, r, "watch.analysis.rules[1]", SeverityError)
+	if !strings.Contains(f.Message, "Invalid pattern") {
+		t.Errorf("message = %q", f.Message)
+	}
+	requireFinding(t, r, "severity", SeverityWarning)
+}
+
+func TestValidateWatchRecovery(t *testing.T) {
+	path := writeConfig(t, `
+watch:
+  recovery:
+    settle: 15s
+    timeout: 3m
+    probe: http://localhost
+`)
+
+	r := Validate(path)
+
+	requireFinding(t, r, "probe", SeverityWarning)
+	if _, ok := findingFor(r, "watch.recovery.settle"); ok {
+		t.Errorf("valid settle should not be reported, got %+v", r.Findings)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Watch.Recovery.Settle != 15*time.Second || cfg.Watch.Recovery.Timeout != 3*time.Minute {
+		t.Errorf("recovery = %+v", cfg.Watch.Recovery)
+	}
+}
+
+func TestValidateWatchRemediation(t *testing.T) {
+	path := writeConfig(t, `
+watch:
+  remediation:
+    dry_run: true
+    max_actions_per_day: 5
+    cooldown: 2h
+    policies:
+      - target: postgres
+        action: stop
+        restarts: 8
+      - target: worker
+        action: scale_memory
+        memory: x1.5
+        level: acute
+      - target: api
+        action: playbook
+        playbook: missing
+      - target: web
+        action: rollback
+        when: always
+    playbooks:
+      wipe:
+        - rm -rf /
+`)
+
+	r := Validate(path)
+
+	requireFinding(t, r, "when", SeverityWarning)
+	requireFinding(t, r, "watch.remediation.policies[2]", SeverityError)
+	requireFinding(t, r, "watch.remediation.playbooks.wipe", SeverityError)
+	for _, field := range []string{"watch.remediation.policies[0]", "watch.remediation.policies[1]"} {
+		if f, ok := findingFor(r, field); ok {
+			t.Errorf("valid policy reported: %+v", f)
+		}
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	rem := cfg.Watch.Remediation
+	if !rem.DryRun || rem.MaxActionsPerDay != 5 || rem.Cooldown != 2*time.Hour || len(rem.Policies) != 4 {
+		t.Errorf("remediation = %+v", rem)
+	}
+	if rem.Policies[0].Restarts != 8 || rem.Policies[1].Memory != "x1.5" {
+		t.Errorf("policies = %+v", rem.Policies)
+	}
+}
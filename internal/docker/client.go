@@ -0,0 +1,351 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Higangssh/homebutler/internal/util"
+)
+
+// requestTimeout bounds every non-streaming API call. A one-shot stats
+// request takes the daemon a second or two, so this is generous.
+const requestTimeout = 30 * time.Second
+
+// Client talks to the Docker Engine API directly over the daemon's socket,
+// instead of running the docker CLI and parsing its text output. Podman's
+// Docker-compatible API works the same way.
+type Client struct {
+	host string // as configured, for error messages
+	base string // URL prefix for requests
+	http *http.Client
+}
+
+// NewClient returns a client for host, which takes the same form as
+// DOCKER_HOST: unix:///path/to/docker.sock or tcp://host:port. TLS and
+// ssh:// hosts are not supported.
+func NewClient(host string) (*Client, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid docker host %q: %w", host, err)
+	}
+	c := &Client{host: host}
+	switch u.Scheme {
+	case "unix":
+		path := u.Path
+		if path == "" {
+			path = u.Opaque
+		}
+		dialer := &net.Dialer{Timeout: 5 * time.Second}
+		c.base = "http://docker"
+		c.http = &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, "unix", path)
+			},
+		}}
+	case "tcp", "http":
+		if os.Getenv("DOCKER_TLS_VERIFY") != "" {
+			return nil, fmt.Errorf("docker host %s uses TLS, which is not supported", host)
+		}
+		c.base = "http://" + u.Host
+		c.http = &http.Client{}
+	default:
+		return nil, fmt.Errorf("unsupported docker host %q (expected unix:// or tcp://)", host)
+	}
+	return c, nil
+}
+
+// DefaultHost returns the API endpoint of the detected container runtime:
+// DOCKER_HOST when set, otherwise the first Docker or Podman socket found.
+func DefaultHost() string {
+	return util.ContainerRuntime().Host
+}
+
+// DefaultClient returns a client for DefaultHost. It fails with a "not
+// installed" error, naming the runtime and how to start it, when the host is
+// a socket that does not exist.
+func DefaultClient() (*Client, error) {
+	rt := util.ContainerRuntime()
+	if !rt.Found {
+		return nil, fmt.Errorf("%s is not installed or not running (no socket at %s); try: %s", rt.Name, rt.Socket, rt.SocketHint())
+	}
+	return NewClient(rt.Host)
+}
+
+// APIError is a non-2xx response from the daemon.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("docker API returned %d", e.StatusCode)
+	}
+	return e.Message
+}
+
+// IsNotFound reports whether err is the daemon saying the object does not exist.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// do sends a GET request and returns the response body, which the caller
+// must close.
+func (c *Client) do(ctx context.Context, path string, query url.Values) (io.ReadCloser, error) {
+	u := c.base + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("docker daemon is not running at %s: %w", c.host, err)
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		var body struct {
+			Message string `json:"message"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		if json.Unmarshal(data, &body) != nil {
+			body.Message = strings.TrimSpace(string(data))
+		}
+		return nil, &APIError{StatusCode: resp.StatusCode, Message: body.Message}
+	}
+	return resp.Body, nil
+}
+
+// getJSON decodes the response to a GET request into v.
+func (c *Client) getJSON(ctx context.Context, path string, query url.Values, v any) error {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	body, err := c.do(ctx, path, query)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	if err := json.NewDecoder(body).Decode(v); err != nil {
+		return fmt.Errorf("decode %s response: %w", path, err)
+	}
+	return nil
+}
+
+// ContainerSummary is one entry of GET /containers/json.
+type ContainerSummary struct {
+	ID      string            `json:"Id"`
+	Names   []string          `json:"Names"`
+	Image   string            `json:"Image"`
+	State   string            `json:"State"`
+	Status  string            `json:"Status"`
+	Ports   []PortSummary     `json:"Ports"`
+	Labels  map[string]string `json:"Labels"`
+	Created int64             `json:"Created"`
+}
+
+// Name returns the container's primary name without the leading slash.
+// Containers reached through legacy links carry extra "/other/alias" names,
+// which are skipped.
+func (s ContainerSummary) Name() string {
+	for _, n := range s.Names {
+		n = strings.TrimPrefix(n, "/")
+		if !strings.Contains(n, "/") {
+			return n
+		}
+	}
+	if len(s.Names) > 0 {
+		return strings.TrimPrefix(s.Names[0], "/")
+	}
+	return ""
+}
+
+// PortSummary is one port of a ContainerSummary.
+type PortSummary struct {
+	IP          string `json:"IP"`
+	PrivatePort int    `json:"PrivatePort"`
+	PublicPort  int    `json:"PublicPort"`
+	Type        string `json:"Type"`
+}
+
+// ListOptions filters ContainerList.
+type ListOptions struct {
+	All    bool                // include stopped containers
+	Labels []string            // "key" or "key=value"; all must match
+	Extra  map[string][]string // any other API filters
+}
+
+// ContainerList returns the containers the daemon knows about.
+func (c *Client) ContainerList(ctx context.Context, opts ListOptions) ([]ContainerSummary, error) {
+	q := url.Values{}
+	if opts.All {
+		q.Set("all", "1")
+	}
+	filters := map[string][]string{}
+	for k, v := range opts.Extra {
+		filters[k] = v
+	}
+	if len(opts.Labels) > 0 {
+		filters["label"] = opts.Labels
+	}
+	if len(filters) > 0 {
+		data, _ := json.Marshal(filters)
+		q.Set("filters", string(data))
+	}
+	var out []ContainerSummary
+	if err := c.getJSON(ctx, "/containers/json", q, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ContainerInfo is the subset of GET /containers/{id}/json that homebutler
+// reads.
+type ContainerInfo struct {
+	ID           string `json:"Id"`
+	Name         string `json:"Name"`
+	Image        string `json:"Image"` // content digest of the running image
+	RestartCount int    `json:"RestartCount"`
+	LogPath      string `json:"LogPath"`
+	State        struct {
+		Status     string `json:"Status"`
+		Running    bool   `json:"Running"`
+		ExitCode   int    `json:"ExitCode"`
+		OOMKilled  bool   `json:"OOMKilled"`
+		StartedAt  string `json:"StartedAt"`
+		FinishedAt string `json:"FinishedAt"`
+		Health     *struct {
+			Status string `json:"Status"`
+		} `json:"Health"`
+	} `json:"State"`
+	Config struct {
+		Image       string            `json:"Image"`
+		Env         []string          `json:"Env"`
+		Labels      map[string]string `json:"Labels"`
+		Tty         bool              `json:"Tty"`
+		Healthcheck *struct {
+			Test []string `json:"Test"`
+		} `json:"Healthcheck"`
+	} `json:"Config"`
+	HostConfig struct {
+		RestartPolicy struct {
+			Name string `json:"Name"`
+		} `json:"RestartPolicy"`
+		LogConfig struct {
+			Type   string            `json:"Type"`
+			Config map[string]string `json:"Config"`
+		} `json:"LogConfig"`
+		Memory int64 `json:"Memory"`
+	} `json:"HostConfig"`
+	Mounts []struct {
+		Type        string `json:"Type"`
+		Name        string `json:"Name"`
+		Source      string `json:"Source"`
+		Destination string `json:"Destination"`
+	} `json:"Mounts"`
+	NetworkSettings struct {
+		Ports map[string][]struct {
+			HostIP   string `json:"HostIp"`
+			HostPort string `json:"HostPort"`
+		} `json:"Ports"`
+	} `json:"NetworkSettings"`
+}
+
+// ContainerInspect returns the full state and configuration of a container,
+// by name or ID.
+func (c *Client) ContainerInspect(ctx context.Context, name string) (*ContainerInfo, error) {
+	if !isValidName(name) {
+		return nil, fmt.Errorf("invalid container name: %s", name)
+	}
+	var info ContainerInfo
+	if err := c.getJSON(ctx, "/containers/"+name+"/json", nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// StatsJSON is one sample of GET /containers/{id}/stats.
+type StatsJSON struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	CPUStats    CPUStats `json:"cpu_stats"`
+	PreCPUStats CPUStats `json:"precpu_stats"`
+	MemoryStats struct {
+		Usage uint64            `json:"usage"`
+		Limit uint64            `json:"limit"`
+		Stats map[string]uint64 `json:"stats"`
+	} `json:"memory_stats"`
+	Networks map[string]struct {
+		RxBytes uint64 `json:"rx_bytes"`
+		TxBytes uint64 `json:"tx_bytes"`
+	} `json:"networks"`
+	BlkioStats struct {
+		IOServiceBytesRecursive []struct {
+			Op    string `json:"op"`
+			Value uint64 `json:"value"`
+		} `json:"io_service_bytes_recursive"`
+	} `json:"blkio_stats"`
+	PidsStats struct {
+		Current uint64 `json:"current"`
+	} `json:"pids_stats"`
+}
+
+// CPUStats is the CPU part of a StatsJSON sample.
+type CPUStats struct {
+	CPUUsage struct {
+		TotalUsage  uint64   `json:"total_usage"`
+		PercpuUsage []uint64 `json:"percpu_usage"`
+	} `json:"cpu_usage"`
+	SystemUsage uint64 `json:"system_cpu_usage"`
+	OnlineCPUs  uint32 `json:"online_cpus"`
+}
+
+// ContainerStats returns a single stats sample for a running container. The
+// daemon takes two readings a moment apart so that CPU usage can be computed.
+func (c *Client) ContainerStats(ctx context.Context, id string) (*StatsJSON, error) {
+	if !isValidName(id) {
+		return nil, fmt.Errorf("invalid container name: %s", id)
+	}
+	var s StatsJSON
+	if err := c.getJSON(ctx, "/containers/"+id+"/stats", url.Values{"stream": {"false"}}, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Event is one message of the GET /events stream.
+type Event struct {
+	Type   string `json:"Type"`
+	Action string `json:"Action"`
+	Status string `json:"status"` // legacy alias of Action for container events
+	ID     string `json:"id"`
+	Actor  struct {
+		ID         string            `json:"ID"`
+		Attributes map[string]string `json:"Attributes"`
+	} `json:"Actor"`
+	Time     int64 `json:"time"`
+	TimeNano int64 `json:"timeNano"`
+}
+
+// Events opens the daemon's event stream. The body is newline-delimited JSON,
+// one Event per line, and stays open until ctx is cancelled or the daemon
+// goes away. filters uses the API's form, e.g. {"event": {"die"}}.
+func (c *Client) Events(ctx context.Context, filters map[string][]string) (io.ReadCloser, error) {
+	q := url.Values{}
+	if len(filters) > 0 {
+		data, _ := json.Marshal(filters)
+		q.Set("filters", string(data))
+	}
+	return c.do(ctx, "/events", q)
+}
@@ -0,0 +1,239 @@
+package docker
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Higangssh/homebutler/internal/docker/dockertest"
+)
+
+func TestNewClientHosts(t *testing.T) {
+	t.Setenv("DOCKER_TLS_VERIFY", "")
+	for _, host := range []string{"unix:///var/run/docker.sock", "tcp://10.0.0.5:2375"} {
+		if _, err := NewClient(host); err != nil {
+			t.Errorf("NewClient(%q): %v", host, err)
+		}
+	}
+	for _, host := range []string{"ssh://me@nas", "npipe:////./pipe/docker_engine"} {
+		if _, err := NewClient(host); err == nil {
+			t.Errorf("NewClient(%q) should fail", host)
+		}
+	}
+}
+
+func TestDefaultClientMissingSocket(t *testing.T) {
+	t.Setenv("DOCKER_HOST", "unix://"+filepath.Join(t.TempDir(), "docker.sock"))
+	_, err := List()
+	if err == nil || !strings.Contains(err.Error(), "not installed") {
+		t.Fatalf("err = %v, want a not-installed error", err)
+	}
+}
+
+func TestListFromEngine(t *testing.T) {
+	srv := dockertest.NewServer(t).Use(t)
+	srv.Handle("GET /containers/json", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("all") != "1" {
+			t.Errorf("List should include stopped containers, query = %s", r.URL.RawQuery)
+		}
+		dockertest.WriteJSON(w, []map[string]any{
+			{
+				"Id": "a1b2c3d4e5f6a1b2c3d4e5f6", "Names": []string{"/proxy/web", "/nginx"}, "Image": "nginx:1.25",
+				"State": "running", "Status": "Up 4 days",
+				"Ports": []map[string]any{
+					{"IP": "::", "PrivatePort": 80, "PublicPort": 8080, "Type": "tcp"},
+					{"IP": "0.0.0.0", "PrivatePort": 80, "PublicPort": 8080, "Type": "tcp"},
+					{"PrivatePort": 443, "Type": "tcp"},
+					{"IP": "0.0.0.0", "PrivatePort": 80, "PublicPort": 8080, "Type": "tcp"},
+				},
+			},
+			{"Id": "c3d4e5f6a1b2", "Names": []string{"/backup\ttool"}, "Image": "restic:0.16", "State": "exited", "Status": "Exited (0) 6 hours ago"},
+			{"Id": "d4e5f6a1b2c3", "Names": []string{"/app3"}, "Image": "app:v3", "State": "paused", "Status": "Up 2 days (Paused)"},
+		})
+	})
+
+	containers, err := List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(containers) != 3 {
+		t.Fatalf("got %d containers, want 3", len(containers))
+	}
+	nginx := containers[0]
+	if nginx.ID != "a1b2c3d4e5f6" || nginx.Name != "nginx" || nginx.Status != "Running · 4d" {
+		t.Errorf("nginx = %+v", nginx)
+	}
+	if nginx.Ports != "0.0.0.0:8080->80/tcp, [::]:8080->80/tcp, 443/tcp" {
+		t.Errorf("Ports = %q", nginx.Ports)
+	}
+	// A name the old tab-separated parsing would have split.
+	if containers[1].Name != "backup\ttool" || containers[1].Status != "Stopped · 6h ago" || containers[1].Ports != "" {
+		t.Errorf("exited = %+v", containers[1])
+	}
+	if containers[2].State != "paused" || containers[2].Status != "Up 2 days (Paused)" {
+		t.Errorf("paused = %+v", containers[2])
+	}
+}
+
+func TestListDaemonError(t *testing.T) {
+	srv := dockertest.NewServer(t).Use(t)
+	srv.Handle("GET /containers/json", func(w http.ResponseWriter, r *http.Request) {
+		dockertest.Error(w, http.StatusInternalServerError, "something broke")
+	})
+	if _, err := List(); err == nil || err.Error() != "something broke" {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestStatsFromEngine(t *testing.T) {
+	srv := dockertest.NewServer(t).Use(t)
+	srv.Handle("GET /containers/json", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("all") != "" {
+			t.Errorf("Stats should only list running containers")
+		}
+		dockertest.WriteJSON(w, []map[string]any{
+			{"Id": "aaaaaaaaaaaaaaaa", "Names": []string{"/nginx"}, "State": "running"},
+			{"Id": "bbbbbbbbbbbbbbbb", "Names": []string{"/gone"}, "State": "running"},
+		})
+	})
+	srv.Handle("GET /containers/{id}/stats", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("stream") != "false" {
+			t.Errorf("stats must not stream, query = %s", r.URL.RawQuery)
+		}
+		if r.PathValue("id") != "aaaaaaaaaaaaaaaa" {
+			dockertest.Error(w, http.StatusNotFound, "No such container: "+r.PathValue("id"))
+			return
+		}
+		w.Write([]byte(`{
+			"id": "aaaaaaaaaaaaaaaa", "name": "/nginx",
+			"cpu_stats": {"cpu_usage": {"total_usage": 300000000}, "system_cpu_usage": 20000000000, "online_cpus": 4},
+			"precpu_stats": {"cpu_usage": {"total_usage": 200000000}, "system_cpu_usage": 10000000000},
+			"memory_stats": {"usage": 20971520, "limit": 2147483648, "stats": {"inactive_file": 10485760}},
+			"networks": {"eth0": {"rx_bytes": 1200, "tx_bytes": 3400}, "eth1": {"rx_bytes": 0, "tx_bytes": 0}},
+			"blkio_stats": {"io_service_bytes_recursive": [{"op": "read", "value": 5600000}, {"op": "write", "value": 7800000}, {"op": "Read", "value": 0}]},
+			"pids_stats": {"current": 2}
+		}`))
+	})
+
+	stats, err := Stats()
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if len(stats) != 1 {
+		t.Fatalf("got %d stats, want 1 (the container that vanished is skipped): %+v", len(stats), stats)
+	}
+	want := ContainerStats{
+		ID:       "aaaaaaaaaaaa",
+		Name:     "nginx",
+		CPUPerc:  "4.00%",
+		MemUsage: "10MiB / 2GiB",
+		MemPerc:  "0.49%",
+		NetIO:    "1.2kB / 3.4kB",
+		BlockIO:  "5.6MB / 7.8MB",
+		PIDs:     "2",
+	}
+	if stats[0] != want {
+		t.Errorf("stats = %+v\nwant    %+v", stats[0], want)
+	}
+}
+
+func TestStatsFromSampleUnits(t *testing.T) {
+	s := &StatsJSON{ID: "abc", Name: "/db"}
+	s.CPUStats.CPUUsage.TotalUsage = 2_000_000_000
+	s.CPUStats.CPUUsage.PercpuUsage = []uint64{1, 1}
+	s.CPUStats.SystemUsage = 2_000_000_000
+	s.MemoryStats.Usage = 1_610_612_736
+	s.MemoryStats.Limit = 2_147_483_648
+	s.MemoryStats.Stats = map[string]uint64{"total_inactive_file": 0}
+
+	got := statsFromSample(s)
+	// No previous reading, so CPU is measured from zero across both cores.
+	if got.CPUPerc != "200.00%" || got.MemUsage != "1.5GiB / 2GiB" || got.MemPerc != "75.00%" {
+		t.Errorf("got %+v", got)
+	}
+	if got.NetIO != "0B / 0B" || got.BlockIO != "0B / 0B" || got.PIDs != "0" {
+		t.Errorf("empty counters = %+v", got)
+	}
+	for b, want := range map[uint64]string{0: "0B", 999: "999B", 1000: "1kB", 1_234_567: "1.23MB", 987_654_321_000: "988GB"} {
+		if got := decimalSize(b); got != want {
+			t.Errorf("decimalSize(%d) = %q, want %q", b, got, want)
+		}
+	}
+	for b, want := range map[uint64]string{512: "512B", 1536: "1.5KiB", 11_010_048: "10.5MiB", 2_087_354_106: "1.944GiB"} {
+		if got := binarySize(b); got != want {
+			t.Errorf("binarySize(%d) = %q, want %q", b, got, want)
+		}
+	}
+}
+
+func TestInspectFromEngine(t *testing.T) {
+	srv := dockertest.NewServer(t).Use(t)
+	srv.Handle("GET /containers/{name}/json", func(w http.ResponseWriter, r *http.Request) {
+		switch r.PathValue("name") {
+		case "web":
+			w.Write([]byte(`{"Id": "0123456789abcdef", "Name": "/web", "Image": "sha256:feed",
+				"Config": {"Image": "nginx:1.25"}, "HostConfig": {"RestartPolicy": {"Name": "always"}}}`))
+		default:
+			dockertest.Error(w, http.StatusNotFound, "No such container: "+r.PathValue("name"))
+		}
+	})
+
+	details, err := Inspect("web", "removed")
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	if len(details) != 1 || details[0].ID != "0123456789ab" || details[0].Name != "web" || details[0].RestartPolicy != "always" {
+		t.Errorf("details = %+v", details)
+	}
+
+	c, _ := NewClient(srv.Host)
+	_, err = c.ContainerInspect(context.Background(), "removed")
+	if !IsNotFound(err) || err.Error() != "No such container: removed" {
+		t.Errorf("err = %v, want not found", err)
+	}
+	if _, err := c.ContainerInspect(context.Background(), "../images/json"); err == nil {
+		t.Error("expected invalid name to be rejected before any request")
+	}
+}
+
+func TestEventsStream(t *testing.T) {
+	srv := dockertest.NewServer(t)
+	srv.Handle("GET /events", func(w http.ResponseWriter, r *http.Request) {
+		var filters map[string][]string
+		_ = json.Unmarshal([]byte(r.URL.Query().Get("filters")), &filters)
+		if strings.Join(filters["event"], ",") != "die" {
+			t.Errorf("filters = %v", filters)
+		}
+		w.Write([]byte(`{"Type":"container","Action":"die","status":"die","id":"abc","Actor":{"ID":"abc","Attributes":{"name":"web","exitCode":"137"}},"time":1700000000}` + "\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+
+	c, err := NewClient(srv.Host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	body, err := c.Events(ctx, map[string][]string{"event": {"die"}})
+	if err != nil {
+		t.Fatalf("Events: %v", err)
+	}
+	defer body.Close()
+
+	scanner := bufio.NewScanner(body)
+	if !scanner.Scan() {
+		t.Fatalf("no event: %v", scanner.Err())
+	}
+	var ev Event
+	if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+		t.Fatal(err)
+	}
+	if ev.Action != "die" || ev.Actor.Attributes["name"] != "web" || ev.Time != 1700000000 {
+		t.Errorf("event = %+v", ev)
+	}
+}
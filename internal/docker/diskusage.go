@@ -0,0 +1,118 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Higangssh/homebutler/internal/util"
+)
+
+// DiskUsage is the space Docker holds that no container is using.
+type DiskUsage struct {
+	DanglingImages []ImageUsage  `json:"dangling_images"`
+	UnusedVolumes  []VolumeUsage `json:"unused_volumes"`
+}
+
+// ImageUsage is one image and the space only it occupies.
+type ImageUsage struct {
+	ID   string `json:"id"`
+	Size int64  `json:"size_bytes"`
+}
+
+// VolumeUsage is one volume and its size. Size is 0 when Docker cannot
+// measure it, as with volumes on non-local drivers.
+type VolumeUsage struct {
+	Name string `json:"name"`
+	Size int64  `json:"size_bytes"`
+}
+
+// SystemDiskUsage reports dangling images and volumes no container uses.
+func SystemDiskUsage() (*DiskUsage, error) {
+	out, err := util.DockerCmd("system", "df", "-v", "--format", "{{json .}}")
+	if err != nil {
+		return nil, fmt.Errorf("docker system df: %w", err)
+	}
+	return parseSystemDF(out)
+}
+
+// flexCount decodes a count that Docker prints as a string in some versions
+// and as a number in others.
+type flexCount int
+
+func (c *flexCount) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		n = 0 // "N/A"
+	}
+	*c = flexCount(n)
+	return nil
+}
+
+func parseSystemDF(out string) (*DiskUsage, error) {
+	var raw struct {
+		Images []struct {
+			ID         string `json:"ID"`
+			Repository string `json:"Repository"`
+			Tag        string `json:"Tag"`
+			Size       string `json:"Size"`
+			UniqueSize string `json:"UniqueSize"`
+		} `json:"Images"`
+		Volumes []struct {
+			Name  string    `json:"Name"`
+			Links flexCount `json:"Links"`
+			Size  string    `json:"Size"`
+		} `json:"Volumes"`
+	}
+	if err := json.Unmarshal([]byte(out), &raw); err != nil {
+		return nil, fmt.Errorf("parse docker system df output: %w", err)
+	}
+
+	du := &DiskUsage{DanglingImages: []ImageUsage{}, UnusedVolumes: []VolumeUsage{}}
+	for _, img := range raw.Images {
+		if img.Repository != "<none>" || img.Tag != "<none>" {
+			continue
+		}
+		size := img.UniqueSize
+		if size == "" {
+			size = img.Size
+		}
+		du.DanglingImages = append(du.DanglingImages, ImageUsage{
+			ID:   strings.TrimPrefix(img.ID, "sha256:"),
+			Size: ParseSize(size),
+		})
+	}
+	for _, v := range raw.Volumes {
+		if v.Links != 0 {
+			continue
+		}
+		du.UnusedVolumes = append(du.UnusedVolumes, VolumeUsage{Name: v.Name, Size: ParseSize(v.Size)})
+	}
+	return du, nil
+}
+
+// ParseSize converts a size as Docker prints it ("1.23GB", "512MB", "0B",
+// "12.3kB") to bytes. Docker uses decimal units. Unparseable values such as
+// "N/A" return 0.
+func ParseSize(s string) int64 {
+	s = strings.TrimSpace(s)
+	units := []struct {
+		suffix string
+		mult   float64
+	}{
+		{"TB", 1e12}, {"GB", 1e9}, {"MB", 1e6}, {"kB", 1e3}, {"KB", 1e3}, {"B", 1},
+	}
+	for _, u := range units {
+		if !strings.HasSuffix(s, u.suffix) {
+			continue
+		}
+		v, err := strconv.ParseFloat(strings.TrimSuffix(s, u.suffix), 64)
+		if err != nil {
+			return 0
+		}
+		return int64(v * u.mult)
+	}
+	return 0
+}
@@ -1,8 +1,10 @@
 package docker
 
 import (
+	"context"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/Higangssh/homebutler/internal/util"
@@ -17,19 +19,21 @@ type Container struct {
 	Ports  string `json:"ports"`
 }
 
+// List returns every container, running or not.
 func List() ([]Container, error) {
-	// Check if docker binary exists
-	if _, lookErr := util.RunCmd("which", "docker"); lookErr != nil {
-		return nil, fmt.Errorf("docker is not installed (binary not found in PATH)")
+	c, err := DefaultClient()
+	if err != nil {
+		return nil, err
 	}
-
-	out, err := util.DockerCmd("ps", "-a",
-		"--format", "{{.ID}}\t{{.Names}}\t{{.Image}}\t{{.Status}}\t{{.State}}\t{{.Ports}}")
+	summaries, err := c.ContainerList(context.Background(), ListOptions{All: true})
 	if err != nil {
-		return nil, fmt.Errorf("docker daemon is not running: %w", err)
+		return nil, err
 	}
-
-	return parseDockerPS(out), nil
+	containers := make([]Container, 0, len(summaries))
+	for _, s := range summaries {
+		containers = append(containers, containerFromSummary(s))
+	}
+	return containers, nil
 }
 
 // ActionResult holds the result of a docker action.
@@ -50,6 +54,27 @@ func Restart(name string) (*ActionResult, error) {
 	return &ActionResult{Action: "restart", Container: name, Status: "ok"}, nil
 }
 
+func Start(name string) (*ActionResult, error) {
+	if !isValidName(name) {
+		return nil, fmt.Errorf("invalid container name: %s", name)
+	}
+	out, err := util.DockerCmd("start", name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start %s: %s", name, out)
+	}
+	return &ActionResult{Action: "start", Container: name, Status: "ok"}, nil
+}
+
+// PruneImages removes dangling images and returns docker's summary line.
+func PruneImages() (string, error) {
+	out, err := util.DockerCmd("image", "prune", "-f")
+	if err != nil {
+		return "", fmt.Errorf("failed to prune images: %s", out)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	return lines[len(lines)-1], nil
+}
+
 func Stop(name string) (*ActionResult, error) {
 	if !isValidName(name) {
 		return nil, fmt.Errorf("invalid container name: %s", name)
@@ -85,34 +110,51 @@ func Logs(name string, lines string) (*LogsResult, error) {
 	return &LogsResult{Container: name, Lines: lines, Logs: out}, nil
 }
 
-// parseDockerPS parses the output of docker ps -a --format with tab separators.
-func parseDockerPS(out string) []Container {
-	containers := make([]Container, 0)
-	for _, line := range splitLines(out) {
-		if line == "" {
-			continue
+// containerFromSummary converts an API container listing into a Container.
+func containerFromSummary(s ContainerSummary) Container {
+	id := s.ID
+	if len(id) > 12 {
+		id = id[:12]
+	}
+	return Container{
+		ID:     id,
+		Name:   s.Name(),
+		Image:  s.Image,
+		Status: friendlyStatus(s.Status, s.State),
+		State:  s.State,
+		Ports:  formatPorts(s.Ports),
+	}
+}
+
+// formatPorts renders ports the way `docker ps` does:
+// "0.0.0.0:8080->80/tcp, [::]:8080->80/tcp, 5432/tcp".
+func formatPorts(ports []PortSummary) string {
+	seen := make(map[string]bool, len(ports))
+	var published, exposed []string
+	for _, p := range ports {
+		var s string
+		if p.PublicPort != 0 {
+			host := p.IP
+			if strings.Contains(host, ":") {
+				host = "[" + host + "]"
+			}
+			s = fmt.Sprintf("%s:%d->%d/%s", host, p.PublicPort, p.PrivatePort, p.Type)
+		} else {
+			s = fmt.Sprintf("%d/%s", p.PrivatePort, p.Type)
 		}
-		fields := splitTabs(line)
-		if len(fields) < 5 {
+		if seen[s] {
 			continue
 		}
-		id := fields[0]
-		if len(id) > 12 {
-			id = id[:12]
+		seen[s] = true
+		if p.PublicPort != 0 {
+			published = append(published, s)
+		} else {
+			exposed = append(exposed, s)
 		}
-		c := Container{
-			ID:     id,
-			Name:   fields[1],
-			Image:  fields[2],
-			Status: friendlyStatus(fields[3], fields[4]),
-			State:  fields[4],
-		}
-		if len(fields) > 5 {
-			c.Ports = fields[5]
-		}
-		containers = append(containers, c)
 	}
-	return containers
+	sort.Strings(published)
+	sort.Strings(exposed)
+	return strings.Join(append(published, exposed...), ", ")
 }
 
 var exitedRe = regexp.MustCompile(`(?i)exited\s*\(\d+\)\s*(.+)\s*ago`)
@@ -160,81 +202,3 @@ func isValidName(name string) bool {
 	}
 	return len(name) > 0 && len(name) <= 128
 }
-
-func splitLines(s string) []string {
-	return split(s, '\n')
-}
-
-func splitTabs(s string) []string {
-	return split(s, '\t')
-}
-
-// ContainerStats holds resource usage statistics for a running container.
-type ContainerStats struct {
-	ID       string `json:"id"`
-	Name     string `json:"name"`
-	CPUPerc  string `json:"cpu_percent"`
-	MemUsage string `json:"mem_usage"`
-	MemPerc  string `json:"mem_percent"`
-	NetIO    string `json:"net_io"`
-	BlockIO  string `json:"block_io"`
-	PIDs     string `json:"pids"`
-}
-
-// Stats returns resource usage statistics for all running containers.
-func Stats() ([]ContainerStats, error) {
-	// Check if docker binary exists
-	if _, lookErr := util.RunCmd("which", "docker"); lookErr != nil {
-		return nil, fmt.Errorf("docker is not installed (binary not found in PATH)")
-	}
-
-	out, err := util.DockerCmd("stats", "--no-stream",
-		"--format", "{{.ID}}\t{{.Name}}\t{{.CPUPerc}}\t{{.MemUsage}}\t{{.MemPerc}}\t{{.NetIO}}\t{{.BlockIO}}\t{{.PIDs}}")
-	if err != nil {
-		return nil, fmt.Errorf("docker daemon is not running: %w", err)
-	}
-
-	return parseDockerStats(out), nil
-}
-
-// parseDockerStats parses the output of docker stats --no-stream --format with tab separators.
-func parseDockerStats(out string) []ContainerStats {
-	stats := make([]ContainerStats, 0)
-	for _, line := range splitLines(out) {
-		if line == "" {
-			continue
-		}
-		fields := splitTabs(line)
-		if len(fields) < 8 {
-			continue
-		}
-		id := fields[0]
-		if len(id) > 12 {
-			id = id[:12]
-		}
-		stats = append(stats, ContainerStats{
-			ID:       id,
-			Name:     fields[1],
-			CPUPerc:  fields[2],
-			MemUsage: fields[3],
-			MemPerc:  fields[4],
-			NetIO:    fields[5],
-			BlockIO:  fields[6],
-			PIDs:     fields[7],
-		})
-	}
-	return stats
-}
-
-func split(s string, sep byte) []string {
-	var result []string
-	start := 0
-	for i := 0; i < len(s); i++ {
-		if s[i] == sep {
-			result = append(result, s[start:i])
-			start = i + 1
-		}
-	}
-	result = append(result, s[start:])
-	return result
-}
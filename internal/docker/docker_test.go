@@ -1,6 +1,11 @@
 package docker
 
-import "testing"
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
 
 func TestIsValidName(t *testing.T) {
 	tests := []struct {
@@ -36,25 +41,6 @@ func TestIsValidName(t *testing.T) {
 	}
 }
 
-func TestSplitLines(t *testing.T) {
-	input := "line1\nline2\nline3"
-	lines := splitLines(input)
-	if len(lines) != 3 {
-		t.Errorf("expected 3 lines, got %d", len(lines))
-	}
-}
-
-func TestSplitTabs(t *testing.T) {
-	input := "a\tb\tc"
-	parts := splitTabs(input)
-	if len(parts) != 3 {
-		t.Errorf("expected 3 parts, got %d", len(parts))
-	}
-	if parts[0] != "a" || parts[1] != "b" || parts[2] != "c" {
-		t.Errorf("unexpected parts: %v", parts)
-	}
-}
-
 func TestFriendlyStatus(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -116,53 +102,6 @@ func TestShortenDuration(t *testing.T) {
 	}
 }
 
-func TestSplitLinesEdgeCases(t *testing.T) {
-	// Empty string should return single empty element
-	lines := splitLines("")
-	if len(lines) != 1 || lines[0] != "" {
-		t.Errorf("splitLines(\"\") = %v, want [\"\"]", lines)
-	}
-
-	// Single line (no newline)
-	lines = splitLines("hello")
-	if len(lines) != 1 || lines[0] != "hello" {
-		t.Errorf("splitLines(\"hello\") = %v", lines)
-	}
-
-	// Trailing newline
-	lines = splitLines("a\nb\n")
-	if len(lines) != 3 {
-		t.Errorf("expected 3 elements, got %d: %v", len(lines), lines)
-	}
-}
-
-func TestSplitTabsEdgeCases(t *testing.T) {
-	// Single field (no tabs)
-	parts := splitTabs("single")
-	if len(parts) != 1 || parts[0] != "single" {
-		t.Errorf("splitTabs(\"single\") = %v", parts)
-	}
-
-	// Empty field between tabs
-	parts = splitTabs("a\t\tc")
-	if len(parts) != 3 {
-		t.Errorf("expected 3 parts, got %d: %v", len(parts), parts)
-	}
-	if parts[1] != "" {
-		t.Errorf("middle part = %q, want empty", parts[1])
-	}
-}
-
-func TestSplit(t *testing.T) {
-	result := split("a,b,c", ',')
-	if len(result) != 3 {
-		t.Errorf("expected 3 parts, got %d", len(result))
-	}
-	if result[0] != "a" || result[1] != "b" || result[2] != "c" {
-		t.Errorf("unexpected result: %v", result)
-	}
-}
-
 func TestIsValidNameMaxLength(t *testing.T) {
 	// Exactly 128 valid characters should be valid
 	name := make([]byte, 128)
@@ -208,107 +147,6 @@ func TestActionResultStruct(t *testing.T) {
 	}
 }
 
-func TestParseDockerPS(t *testing.T) {
-	input := "a1b2c3d4e5f6\tnginx\tnginx:1.25\tUp 4 days\trunning\t0.0.0.0:80->80/tcp\n" +
-		"b2c3d4e5f6a1\tpostgres\tpostgres:16\tUp 4 days\trunning\t5432/tcp\n" +
-		"c3d4e5f6a1b2\tbackup\trestic:0.16\tExited (0) 6 hours ago\texited\t\n"
-
-	containers := parseDockerPS(input)
-	if len(containers) != 3 {
-		t.Fatalf("expected 3 containers, got %d", len(containers))
-	}
-
-	// Running container
-	if containers[0].Name != "nginx" {
-		t.Errorf("expected nginx, got %s", containers[0].Name)
-	}
-	if containers[0].Status != "Running · 4d" {
-		t.Errorf("expected 'Running · 4d', got %s", containers[0].Status)
-	}
-	if containers[0].Ports != "0.0.0.0:80->80/tcp" {
-		t.Errorf("expected ports, got %s", containers[0].Ports)
-	}
-	if containers[0].ID != "a1b2c3d4e5f6" {
-		t.Errorf("expected ID a1b2c3d4e5f6, got %s", containers[0].ID)
-	}
-
-	// Exited container
-	if containers[2].Name != "backup" {
-		t.Errorf("expected backup, got %s", containers[2].Name)
-	}
-	if containers[2].State != "exited" {
-		t.Errorf("expected exited state, got %s", containers[2].State)
-	}
-	if containers[2].Status != "Stopped · 6h ago" {
-		t.Errorf("expected 'Stopped · 6h ago', got %s", containers[2].Status)
-	}
-}
-
-func TestParseDockerPS_Empty(t *testing.T) {
-	containers := parseDockerPS("")
-	if len(containers) != 0 {
-		t.Fatalf("expected 0 containers, got %d", len(containers))
-	}
-}
-
-func TestParseDockerPS_ShortFields(t *testing.T) {
-	// Lines with less than 5 fields should be skipped
-	input := "a1b2c3\tnginx\tnginx:latest\n"
-	containers := parseDockerPS(input)
-	if len(containers) != 0 {
-		t.Fatalf("expected 0 containers for short fields, got %d", len(containers))
-	}
-}
-
-func TestParseDockerPS_NoPorts(t *testing.T) {
-	input := "a1b2c3d4e5f6\tredis\tredis:7\tUp 2 days\trunning\n"
-	containers := parseDockerPS(input)
-	if len(containers) != 1 {
-		t.Fatalf("expected 1 container, got %d", len(containers))
-	}
-	if containers[0].Ports != "" {
-		t.Errorf("expected empty ports, got %s", containers[0].Ports)
-	}
-}
-
-func TestParseDockerPS_LongID(t *testing.T) {
-	// ID longer than 12 chars should be truncated
-	input := "a1b2c3d4e5f6a1b2c3d4e5f6\tnginx\tnginx:latest\tUp 1 hour\trunning\t80/tcp\n"
-	containers := parseDockerPS(input)
-	if len(containers) != 1 {
-		t.Fatalf("expected 1 container, got %d", len(containers))
-	}
-	if containers[0].ID != "a1b2c3d4e5f6" {
-		t.Errorf("expected truncated ID, got %s", containers[0].ID)
-	}
-}
-
-func TestParseDockerPS_MultipleStates(t *testing.T) {
-	input := "abc123456789\tapp1\tapp:v1\tUp 2 weeks\trunning\t8080/tcp\n" +
-		"def123456789\tapp2\tapp:v2\tExited (137) 2 days ago\texited\t\n" +
-		"ghi123456789\tapp3\tapp:v3\tPaused\tpaused\t\n"
-
-	containers := parseDockerPS(input)
-	if len(containers) != 3 {
-		t.Fatalf("expected 3 containers, got %d", len(containers))
-	}
-	if containers[0].State != "running" {
-		t.Errorf("expected running, got %s", containers[0].State)
-	}
-	if containers[1].State != "exited" {
-		t.Errorf("expected exited, got %s", containers[1].State)
-	}
-	if containers[2].State != "paused" {
-		t.Errorf("expected paused, got %s", containers[2].State)
-	}
-	// Paused should preserve raw status
-	if containers[2].Status != "Paused" {
-		t.Errorf("expected 'Paused', got %s", containers[2].Status)
-	}
-}
-
-// --- ContainerStats tests ---
-
 func TestContainerStatsStruct(t *testing.T) {
 	s := ContainerStats{
 		ID:       "a1b2c3d4e5f6",
@@ -328,107 +166,122 @@ func TestContainerStatsStruct(t *testing.T) {
 	}
 }
 
-func TestParseDockerStats_Empty(t *testing.T) {
-	stats := parseDockerStats("")
-	if len(stats) != 0 {
-		t.Fatalf("expected 0 stats, got %d", len(stats))
-	}
-}
-
-func TestParseDockerStats_Normal(t *testing.T) {
-	input := "a1b2c3d4e5f6\tnginx\t0.50%\t10.5MiB / 1.94GiB\t0.53%\t1.2kB / 3.4kB\t0B / 0B\t2\n" +
-		"b2c3d4e5f6a1\tpostgres\t1.20%\t256MiB / 1.94GiB\t12.89%\t5.6MB / 7.8MB\t100MB / 50MB\t15\n"
-
-	stats := parseDockerStats(input)
-	if len(stats) != 2 {
-		t.Fatalf("expected 2 stats, got %d", len(stats))
-	}
-
-	if stats[0].Name != "nginx" {
-		t.Errorf("expected nginx, got %s", stats[0].Name)
-	}
-	if stats[0].CPUPerc != "0.50%" {
-		t.Errorf("expected 0.50%%, got %s", stats[0].CPUPerc)
-	}
-	if stats[0].MemUsage != "10.5MiB / 1.94GiB" {
-		t.Errorf("expected mem usage, got %s", stats[0].MemUsage)
-	}
-	if stats[0].MemPerc != "0.53%" {
-		t.Errorf("expected 0.53%%, got %s", stats[0].MemPerc)
-	}
-	if stats[0].NetIO != "1.2kB / 3.4kB" {
-		t.Errorf("expected net io, got %s", stats[0].NetIO)
-	}
-	if stats[0].BlockIO != "0B / 0B" {
-		t.Errorf("expected block io, got %s", stats[0].BlockIO)
-	}
-	if stats[0].PIDs != "2" {
-		t.Errorf("expected 2, got %s", stats[0].PIDs)
+func TestLogsResultStruct(t *testing.T) {
+	r := LogsResult{
+		Container: "nginx",
+		Lines:     "50",
+		Logs:      "some log output",
 	}
-
-	if stats[1].Name != "postgres" {
-		t.Errorf("expected postgres, got %s", stats[1].Name)
+	if r.Container != "nginx" {
+		t.Errorf("Container = %q, want %q", r.Container, "nginx")
 	}
-	if stats[1].CPUPerc != "1.20%" {
-		t.Errorf("expected 1.20%%, got %s", stats[1].CPUPerc)
+	if r.Lines != "50" {
+		t.Errorf("Lines = %q, want %q", r.Lines, "50")
 	}
 }
 
-func TestParseDockerStats_ShortFields(t *testing.T) {
-	// Lines with less than 8 fields should be skipped
-	input := "a1b2c3\tnginx\t0.50%\n"
-	stats := parseDockerStats(input)
-	if len(stats) != 0 {
-		t.Fatalf("expected 0 stats for short fields, got %d", len(stats))
+func TestNewContainerDetails(t *testing.T) {
+	out := `{
+		"Id": "4f1c2d3e4f5a6b7c8d9e",
+		"Name": "/vaultwarden",
+		"Image": "sha256:aaaabbbbccccdddd",
+		"Config": {"Image": "vaultwarden/server:latest", "Env": ["TZ=UTC", "ADMIN_TOKEN=secret", "PATH=/usr/bin"], "Healthcheck": {"Test": ["CMD", "/healthcheck.sh"]}},
+		"LogPath": "/var/lib/docker/containers/4f1c/4f1c-json.log",
+		"HostConfig": {"RestartPolicy": {"Name": "unless-stopped"}, "LogConfig": {"Type": "json-file", "Config": {"max-size": "10m"}}},
+		"Mounts": [
+			{"Type": "volume", "Source": "/var/lib/docker/volumes/vw/_data", "Destination": "/data"},
+			{"Type": "bind", "Source": "/etc/localtime", "Destination": "/etc/localtime"}
+		],
+		"NetworkSettings": {"Ports": {
+			"80/tcp": [{"HostIp": "0.0.0.0", "HostPort": "8080"}, {"HostIp": "::", "HostPort": "8080"}],
+			"3012/tcp": null
+		}}
+	}`
+
+	var info ContainerInfo
+	if err := json.Unmarshal([]byte(out), &info); err != nil {
+		t.Fatal(err)
+	}
+	d := newContainerDetails(&info)
+	if d.ID != "4f1c2d3e4f5a" || d.Name != "vaultwarden" || d.Image != "vaultwarden/server:latest" || d.ImageID != "sha256:aaaabbbbccccdddd" {
+		t.Errorf("identity = %+v", d)
+	}
+	if d.RestartPolicy != "unless-stopped" {
+		t.Errorf("RestartPolicy = %q", d.RestartPolicy)
+	}
+	if strings.Join(d.EnvKeys, ",") != "ADMIN_TOKEN,PATH,TZ" {
+		t.Errorf("EnvKeys = %v", d.EnvKeys)
+	}
+	if strings.Contains(fmt.Sprint(d), "secret") {
+		t.Error("env values must not be recorded")
+	}
+	if len(d.Mounts) != 2 || d.Mounts[0].Destination != "/data" || d.Mounts[1].Type != "bind" {
+		t.Errorf("Mounts = %+v", d.Mounts)
+	}
+	if strings.Join(d.PublishedPorts, ",") != "0.0.0.0:8080->80/tcp,:::8080->80/tcp" {
+		t.Errorf("PublishedPorts = %v", d.PublishedPorts)
+	}
+	if !d.Healthcheck || d.LogDriver != "json-file" || d.LogMaxSize != "10m" || !strings.HasSuffix(d.LogPath, "-json.log") {
+		t.Errorf("healthcheck/logging = %+v", d)
 	}
 }
 
-func TestParseDockerStats_LongID(t *testing.T) {
-	input := "a1b2c3d4e5f6a1b2c3d4e5f6\tnginx\t0.50%\t10MiB / 1GiB\t0.53%\t1kB / 2kB\t0B / 0B\t2\n"
-	stats := parseDockerStats(input)
-	if len(stats) != 1 {
-		t.Fatalf("expected 1 stat, got %d", len(stats))
+func TestContainerDetailsPolicies(t *testing.T) {
+	tests := []struct {
+		image  string
+		latest bool
+	}{
+		{"nginx", true},
+		{"nginx:latest", true},
+		{"ghcr.io/home-assistant/home-assistant:latest", true},
+		{"registry.lan:5000/app", true},
+		{"registry.lan:5000/app:1.2", false},
+		{"vaultwarden/server:1.30.5", false},
+		{"nginx@sha256:abcdef", false},
 	}
-	if stats[0].ID != "a1b2c3d4e5f6" {
-		t.Errorf("expected truncated ID, got %s", stats[0].ID)
+	for _, tt := range tests {
+		if got := (ContainerDetails{Image: tt.image}).UsesLatestTag(); got != tt.latest {
+			t.Errorf("UsesLatestTag(%q) = %v, want %v", tt.image, got, tt.latest)
+		}
+	}
+	for policy, want := range map[string]bool{"": false, "no": false, "always": true, "on-failure": true} {
+		if got := (ContainerDetails{RestartPolicy: policy}).HasRestartPolicy(); got != want {
+			t.Errorf("HasRestartPolicy(%q) = %v, want %v", policy, got, want)
+		}
 	}
 }
 
-func TestParseDockerStats_VariousFormats(t *testing.T) {
-	// Test with different memory/network formats
-	input := "abc123456789\tapp1\t99.99%\t1.5GiB / 2GiB\t75.00%\t1.2GB / 3.4GB\t500MB / 1GB\t100\n" +
-		"def123456789\tapp2\t0.00%\t512KiB / 2GiB\t0.02%\t0B / 0B\t0B / 0B\t1\n"
+func TestParseSystemDF(t *testing.T) {
+	out := `{"Images":[
+		{"ID":"sha256:aaa","Repository":"<none>","Tag":"<none>","Size":"1.2GB","UniqueSize":"1.1GB","Containers":"0"},
+		{"ID":"sha256:bbb","Repository":"nginx","Tag":"latest","Size":"187MB","UniqueSize":"0B","Containers":"1"},
+		{"ID":"sha256:ccc","Repository":"<none>","Tag":"<none>","Size":"45.3MB","Containers":"0"}],
+	"Containers":[],
+	"Volumes":[
+		{"Name":"old_db","Links":"0","Size":"2.5GB"},
+		{"Name":"media","Links":"2","Size":"10GB"},
+		{"Name":"nfs_share","Links":0,"Size":"N/A"}],
+	"BuildCache":[]}`
 
-	stats := parseDockerStats(input)
-	if len(stats) != 2 {
-		t.Fatalf("expected 2 stats, got %d", len(stats))
+	du, err := parseSystemDF(out)
+	if err != nil {
+		t.Fatalf("parseSystemDF: %v", err)
 	}
-	if stats[0].CPUPerc != "99.99%" {
-		t.Errorf("expected 99.99%%, got %s", stats[0].CPUPerc)
+	if len(du.DanglingImages) != 2 || du.DanglingImages[0].ID != "aaa" || du.DanglingImages[0].Size != 1_100_000_000 || du.DanglingImages[1].Size != 45_300_000 {
+		t.Errorf("DanglingImages = %+v", du.DanglingImages)
 	}
-	if stats[1].CPUPerc != "0.00%" {
-		t.Errorf("expected 0.00%%, got %s", stats[1].CPUPerc)
+	if len(du.UnusedVolumes) != 2 || du.UnusedVolumes[0].Name != "old_db" || du.UnusedVolumes[0].Size != 2_500_000_000 || du.UnusedVolumes[1].Size != 0 {
+		t.Errorf("UnusedVolumes = %+v", du.UnusedVolumes)
 	}
-}
-
-func TestParseDockerStats_TrailingNewline(t *testing.T) {
-	input := "a1b2c3d4e5f6\tnginx\t0.50%\t10MiB / 1GiB\t0.53%\t1kB / 2kB\t0B / 0B\t2\n\n"
-	stats := parseDockerStats(input)
-	if len(stats) != 1 {
-		t.Fatalf("expected 1 stat, got %d", len(stats))
+	if _, err := parseSystemDF("Error: permission denied"); err == nil {
+		t.Error("expected error for non-JSON output")
 	}
 }
 
-func TestLogsResultStruct(t *testing.T) {
-	r := LogsResult{
-		Container: "nginx",
-		Lines:     "50",
-		Logs:      "some log output",
-	}
-	if r.Container != "nginx" {
-		t.Errorf("Container = %q, want %q", r.Container, "nginx")
-	}
-	if r.Lines != "50" {
-		t.Errorf("Lines = %q, want %q", r.Lines, "50")
+func TestParseSize(t *testing.T) {
+	for in, want := range map[string]int64{"0B": 0, "512B": 512, "12.3kB": 12300, "187MB": 187_000_000, "1.5GB": 1_500_000_000, "N/A": 0, "": 0} {
+		if got := ParseSize(in); got != want {
+			t.Errorf("ParseSize(%q) = %d, want %d", in, got, want)
+		}
 	}
 }
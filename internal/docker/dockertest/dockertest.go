@@ -0,0 +1,80 @@
+// Package dockertest serves a fake Docker Engine API on a unix socket, so
+// code that talks to the daemon can be tested without Docker installed.
+package dockertest
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Server is a fake Docker daemon. Register handlers with Handle or JSON
+// using Go's ServeMux patterns, e.g. "GET /containers/{name}/json"; anything
+// unregistered answers 404 with a Docker-style error body.
+type Server struct {
+	// Host is the DOCKER_HOST value that reaches the server.
+	Host string
+
+	mux *http.ServeMux
+}
+
+// NewServer starts a fake daemon that is shut down when the test ends.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	// Unix socket paths are limited to about 100 bytes, which t.TempDir can
+	// exceed for long test names.
+	dir, err := os.MkdirTemp("", "dockertest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	sock := filepath.Join(dir, "docker.sock")
+
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{Host: "unix://" + sock, mux: http.NewServeMux()}
+	s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		Error(w, http.StatusNotFound, "page not found")
+	})
+	srv := &http.Server{Handler: s.mux}
+	go srv.Serve(ln) //nolint:errcheck // returns when closed
+	t.Cleanup(func() { srv.Close() })
+	return s
+}
+
+// Use points DOCKER_HOST at the server for the rest of the test.
+func (s *Server) Use(t testing.TB) *Server {
+	t.Helper()
+	t.Setenv("DOCKER_HOST", s.Host)
+	return s
+}
+
+// Handle registers a handler for pattern.
+func (s *Server) Handle(pattern string, h http.HandlerFunc) {
+	s.mux.HandleFunc(pattern, h)
+}
+
+// JSON registers pattern to answer with v encoded as JSON.
+func (s *Server) JSON(pattern string, v any) {
+	s.Handle(pattern, func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, v)
+	})
+}
+
+// WriteJSON writes v as a JSON response.
+func WriteJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// Error writes a Docker-style error response.
+func Error(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
@@ -0,0 +1,130 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ContainerDetails is the configuration of a container that report keeps in
+// its snapshots to notice drift: a silently updated image, a lost mount, a
+// changed restart policy.
+type ContainerDetails struct {
+	ID             string   `json:"id"`
+	Name           string   `json:"name"`
+	Image          string   `json:"image"`    // reference as configured, e.g. vaultwarden/server:latest
+	ImageID        string   `json:"image_id"` // content digest of the image actually running
+	RestartPolicy  string   `json:"restart_policy,omitempty"`
+	EnvKeys        []string `json:"env_keys,omitempty"` // names only; values are never recorded
+	Mounts         []Mount  `json:"mounts,omitempty"`
+	PublishedPorts []string `json:"published_ports,omitempty"` // "0.0.0.0:8080->80/tcp"
+
+	Healthcheck bool   `json:"healthcheck,omitempty"`  // a healthcheck is configured, by the image or the container
+	LogDriver   string `json:"log_driver,omitempty"`   // e.g. json-file, journald
+	LogMaxSize  string `json:"log_max_size,omitempty"` // the max-size log option; empty means unbounded
+	LogPath     string `json:"log_path,omitempty"`
+}
+
+// HasRestartPolicy reports whether the container is restarted automatically.
+func (d ContainerDetails) HasRestartPolicy() bool {
+	return d.RestartPolicy != "" && d.RestartPolicy != "no"
+}
+
+// UsesLatestTag reports whether the container was created from an image
+// reference that floats: an explicit :latest or no tag at all. Images pinned
+// by digest never float.
+func (d ContainerDetails) UsesLatestTag() bool {
+	ref := d.Image
+	if ref == "" || strings.Contains(ref, "@") {
+		return false
+	}
+	if i := strings.LastIndex(ref, "/"); i >= 0 {
+		ref = ref[i+1:]
+	}
+	_, tag, ok := strings.Cut(ref, ":")
+	return !ok || tag == "latest"
+}
+
+// Mount is one volume or bind mount of a container.
+type Mount struct {
+	Type        string `json:"type"`
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+}
+
+// Inspect returns the configuration details of the named containers.
+// Containers that no longer exist are left out.
+func Inspect(names ...string) ([]ContainerDetails, error) {
+	if len(names) == 0 {
+		return []ContainerDetails{}, nil
+	}
+	for _, n := range names {
+		if !isValidName(n) {
+			return nil, fmt.Errorf("invalid container name: %s", n)
+		}
+	}
+	c, err := DefaultClient()
+	if err != nil {
+		return nil, err
+	}
+	details := make([]ContainerDetails, 0, len(names))
+	for _, n := range names {
+		info, err := c.ContainerInspect(context.Background(), n)
+		if IsNotFound(err) {
+			continue // removed since it was listed
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", n, err)
+		}
+		details = append(details, newContainerDetails(info))
+	}
+	return details, nil
+}
+
+// newContainerDetails reduces an inspect result to ContainerDetails with
+// every list sorted, so that two snapshots of an unchanged container compare
+// equal.
+func newContainerDetails(c *ContainerInfo) ContainerDetails {
+	id := c.ID
+	if len(id) > 12 {
+		id = id[:12]
+	}
+	d := ContainerDetails{
+		ID:            id,
+		Name:          strings.TrimPrefix(c.Name, "/"),
+		Image:         c.Config.Image,
+		ImageID:       c.Image,
+		RestartPolicy: c.HostConfig.RestartPolicy.Name,
+		LogDriver:     c.HostConfig.LogConfig.Type,
+		LogMaxSize:    c.HostConfig.LogConfig.Config["max-size"],
+		LogPath:       c.LogPath,
+	}
+	if hc := c.Config.Healthcheck; hc != nil && len(hc.Test) > 0 && hc.Test[0] != "NONE" {
+		d.Healthcheck = true
+	}
+	for _, e := range c.Config.Env {
+		key, _, _ := strings.Cut(e, "=")
+		if key != "" {
+			d.EnvKeys = append(d.EnvKeys, key)
+		}
+	}
+	sort.Strings(d.EnvKeys)
+
+	for _, m := range c.Mounts {
+		d.Mounts = append(d.Mounts, Mount{Type: m.Type, Source: m.Source, Destination: m.Destination})
+	}
+	sort.Slice(d.Mounts, func(i, j int) bool { return d.Mounts[i].Destination < d.Mounts[j].Destination })
+
+	for port, bindings := range c.NetworkSettings.Ports {
+		for _, b := range bindings {
+			host := b.HostIP
+			if host == "" {
+				host = "0.0.0.0"
+			}
+			d.PublishedPorts = append(d.PublishedPorts, fmt.Sprintf("%s:%s->%s", host, b.HostPort, port))
+		}
+	}
+	sort.Strings(d.PublishedPorts)
+	return d
+}
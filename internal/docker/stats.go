@@ -0,0 +1,157 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// statsConcurrency caps parallel stats requests. Each one makes the daemon
+// wait for a second sample, so a serial loop over a large host is slow.
+const statsConcurrency = 16
+
+// ContainerStats holds resource usage statistics for a running container.
+type ContainerStats struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	CPUPerc  string `json:"cpu_percent"`
+	MemUsage string `json:"mem_usage"`
+	MemPerc  string `json:"mem_percent"`
+	NetIO    string `json:"net_io"`
+	BlockIO  string `json:"block_io"`
+	PIDs     string `json:"pids"`
+}
+
+// Stats returns resource usage statistics for all running containers.
+// Containers that stop while being sampled are left out.
+func Stats() ([]ContainerStats, error) {
+	c, err := DefaultClient()
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	running, err := c.ContainerList(ctx, ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([]*StatsJSON, len(running))
+	sem := make(chan struct{}, statsConcurrency)
+	var wg sync.WaitGroup
+	for i, s := range running {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			samples[i], _ = c.ContainerStats(ctx, id)
+		}(i, s.ID)
+	}
+	wg.Wait()
+
+	stats := make([]ContainerStats, 0, len(running))
+	for i, sample := range samples {
+		if sample == nil {
+			continue
+		}
+		if sample.Name == "" {
+			sample.Name = running[i].Name()
+		}
+		if sample.ID == "" {
+			sample.ID = running[i].ID
+		}
+		stats = append(stats, statsFromSample(sample))
+	}
+	return stats, nil
+}
+
+// statsFromSample computes the figures `docker stats` shows from a raw
+// sample, formatted the same way.
+func statsFromSample(s *StatsJSON) ContainerStats {
+	id := s.ID
+	if len(id) > 12 {
+		id = id[:12]
+	}
+
+	mem := memoryWithoutCache(s)
+	memPerc := 0.0
+	if s.MemoryStats.Limit > 0 {
+		memPerc = float64(mem) / float64(s.MemoryStats.Limit) * 100
+	}
+
+	var rx, tx uint64
+	for _, n := range s.Networks {
+		rx += n.RxBytes
+		tx += n.TxBytes
+	}
+	var read, write uint64
+	for _, b := range s.BlkioStats.IOServiceBytesRecursive {
+		switch strings.ToLower(b.Op) {
+		case "read":
+			read += b.Value
+		case "write":
+			write += b.Value
+		}
+	}
+
+	return ContainerStats{
+		ID:       id,
+		Name:     strings.TrimPrefix(s.Name, "/"),
+		CPUPerc:  fmt.Sprintf("%.2f%%", cpuPercent(s)),
+		MemUsage: binarySize(mem) + " / " + binarySize(s.MemoryStats.Limit),
+		MemPerc:  fmt.Sprintf("%.2f%%", memPerc),
+		NetIO:    decimalSize(rx) + " / " + decimalSize(tx),
+		BlockIO:  decimalSize(read) + " / " + decimalSize(write),
+		PIDs:     fmt.Sprintf("%d", s.PidsStats.Current),
+	}
+}
+
+// cpuPercent is the container's share of host CPU between the two readings
+// in a sample, where 100% is one full core.
+func cpuPercent(s *StatsJSON) float64 {
+	cpuDelta := float64(s.CPUStats.CPUUsage.TotalUsage) - float64(s.PreCPUStats.CPUUsage.TotalUsage)
+	sysDelta := float64(s.CPUStats.SystemUsage) - float64(s.PreCPUStats.SystemUsage)
+	cpus := float64(s.CPUStats.OnlineCPUs)
+	if cpus == 0 {
+		cpus = float64(len(s.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta <= 0 || sysDelta <= 0 {
+		return 0
+	}
+	return cpuDelta / sysDelta * cpus * 100
+}
+
+// memoryWithoutCache subtracts reclaimable page cache from memory usage, as
+// the docker CLI does: cgroup v1 reports it as total_inactive_file, v2 as
+// inactive_file.
+func memoryWithoutCache(s *StatsJSON) uint64 {
+	usage := s.MemoryStats.Usage
+	for _, key := range []string{"total_inactive_file", "inactive_file"} {
+		if v, ok := s.MemoryStats.Stats[key]; ok && v < usage {
+			return usage - v
+		}
+	}
+	return usage
+}
+
+// binarySize formats bytes with binary units and four significant digits,
+// e.g. "10.5MiB" or "1.944GiB".
+func binarySize(b uint64) string {
+	return humanSize(float64(b), 1024, []string{"B", "KiB", "MiB", "GiB", "TiB"}, 4)
+}
+
+// decimalSize formats bytes with decimal units and three significant digits,
+// e.g. "1.2kB" or "5.6MB".
+func decimalSize(b uint64) string {
+	return humanSize(float64(b), 1000, []string{"B", "kB", "MB", "GB", "TB"}, 3)
+}
+
+func humanSize(size, base float64, units []string, precision int) string {
+	i := 0
+	for size >= base && i < len(units)-1 {
+		size /= base
+		i++
+	}
+	return fmt.Sprintf("%.*g%s", precision, size, units[i])
+}
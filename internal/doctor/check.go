@@ -0,0 +1,79 @@
+package doctor
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/Higangssh/homebutler/internal/config"
+	"github.com/Higangssh/homebutler/internal/inventory"
+)
+
+// Env is everything a check may look at. It is collected once per run and
+// shared by every check, so checks never re-scan the host themselves.
+type Env struct {
+	Config    *config.Config
+	Inventory *inventory.Inventory
+	Funcs     CollectFuncs
+	Options   Options
+}
+
+// Check is one doctor diagnosis. Run returns only problems; a check that
+// finds nothing wrong returns no findings.
+type Check interface {
+	Name() string
+	Run(env *Env) []Finding
+}
+
+// CheckFunc adapts a function to the Check interface.
+type CheckFunc struct {
+	CheckName string
+	Fn        func(env *Env) []Finding
+}
+
+func (c CheckFunc) Name() string           { return c.CheckName }
+func (c CheckFunc) Run(env *Env) []Finding { return c.Fn(env) }
+
+var (
+	registryMu sync.Mutex
+	registry   []Check
+)
+
+// Register adds a check to every doctor run. Checks run in registration
+// order, which is also the order their findings are listed in. Registering
+// two checks with the same name panics, as it is a programming error.
+func Register(c Check) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, existing := range registry {
+		if existing.Name() == c.Name() {
+			panic(fmt.Sprintf("doctor: check %q registered twice", c.Name()))
+		}
+	}
+	registry = append(registry, c)
+}
+
+// Checks returns the registered checks in run order.
+func Checks() []Check {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	return append([]Check(nil), registry...)
+}
+
+// builtin wraps one of the original checks, which append to a Result.
+func builtin(name string, fn func(r *Result, env *Env)) Check {
+	return CheckFunc{CheckName: name, Fn: func(env *Env) []Finding {
+		r := &Result{}
+		fn(r, env)
+		return r.Findings
+	}}
+}
+
+func init() {
+	Register(builtin("collection", func(r *Result, env *Env) { checkCollectionWarnings(r, env.Inventory) }))
+	Register(builtin("system", func(r *Result, env *Env) { checkSystem(r, env.Config, env.Inventory) }))
+	Register(builtin("containers", func(r *Result, env *Env) { checkContainers(r, env.Inventory) }))
+	Register(builtin("public-ports", func(r *Result, env *Env) { checkPublicPorts(r, env.Inventory.Ports) }))
+	Register(builtin("backups", func(r *Result, env *Env) { checkBackups(r, env.Config, env.Funcs.BackupListFn, env.Options) }))
+	Register(builtin("notifications", func(r *Result, env *Env) { checkNotifications(r, env.Config) }))
+	Register(builtin("report-baseline", func(r *Result, env *Env) { checkReportBaseline(r, env.Funcs.SnapshotDir) }))
+}
@@ -0,0 +1,61 @@
+package doctor
+
+import (
+	"testing"
+
+	"github.com/Higangssh/homebutler/internal/backup"
+	"github.com/Higangssh/homebutler/internal/config"
+	"github.com/Higangssh/homebutler/internal/docker"
+	"github.com/Higangssh/homebutler/internal/ports"
+)
+
+// withRegistry restores the global registry when the test ends.
+func withRegistry(t *testing.T) {
+	t.Helper()
+	saved := Checks()
+	t.Cleanup(func() {
+		registryMu.Lock()
+		registry = saved
+		registryMu.Unlock()
+	})
+}
+
+func TestBuiltinChecksRegisteredInOrder(t *testing.T) {
+	want := []string{"collection", "system", "containers", "public-ports", "backups", "notifications", "report-baseline"}
+	got := Checks()
+	if len(got) < len(want) {
+		t.Fatalf("got %d checks, want at least %d", len(got), len(want))
+	}
+	for i, name := range want {
+		if got[i].Name() != name {
+			t.Errorf("check %d = %q, want %q", i, got[i].Name(), name)
+		}
+	}
+}
+
+func TestRegisteredCheckRuns(t *testing.T) {
+	withRegistry(t)
+	Register(CheckFunc{CheckName: "test-extra", Fn: func(env *Env) []Finding {
+		return []Finding{{Severity: SeverityFail, Category: "extra", Title: "from " + env.Inventory.ServerName}}
+	}})
+
+	fns := doctorFuncs(healthyStatus(), []docker.Container{}, []ports.PortInfo{}, nil, []backup.ListEntry{})
+	r, err := Run(&config.Config{Servers: []config.ServerConfig{{Name: "lab", Local: true}}}, fns, Options{Now: fixedNow})
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := r.Findings[len(r.Findings)-1]
+	if last.Category != "extra" || last.Title != "from lab" || r.Status != SeverityFail {
+		t.Errorf("registered check finding missing or misplaced: %+v", r.Findings)
+	}
+}
+
+func TestRegisterDuplicatePanics(t *testing.T) {
+	withRegistry(t)
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic registering a duplicate check name")
+		}
+	}()
+	Register(CheckFunc{CheckName: "system", Fn: func(*Env) []Finding { return nil }})
+}
@@ -0,0 +1,187 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/Higangssh/homebutler/internal/alerts"
+	"github.com/Higangssh/homebutler/internal/config"
+	"github.com/Higangssh/homebutler/internal/history"
+)
+
+const defaultCustomTimeout = 10 * time.Second
+
+// userCheck runs one check declared under doctor.checks in the config.
+type userCheck struct {
+	def config.DoctorCheck
+}
+
+// userChecks returns the checks declared in cfg, in config order. They run
+// after the built-in checks.
+func userChecks(cfg *config.Config) []Check {
+	if cfg == nil {
+		return nil
+	}
+	checks := make([]Check, 0, len(cfg.Doctor.Checks))
+	for _, def := range cfg.Doctor.Checks {
+		checks = append(checks, userCheck{def: def})
+	}
+	return checks
+}
+
+func (c userCheck) Name() string { return c.def.Name }
+
+func (c userCheck) Run(env *Env) []Finding {
+	if err := c.def.Validate(); err != nil {
+		name := c.def.Name
+		if name == "" {
+			name = "(unnamed)"
+		}
+		return []Finding{{
+			Severity: SeverityWarn,
+			Category: "custom",
+			Title:    fmt.Sprintf("Custom check %s is misconfigured", name),
+			Detail:   err.Error(),
+			Action:   "Fix the entry under doctor.checks in your config.",
+			Command:  "homebutler config validate",
+		}}
+	}
+
+	timeout := defaultCustomTimeout
+	if c.def.Timeout != "" {
+		timeout, _ = time.ParseDuration(c.def.Timeout)
+	}
+
+	var problem string
+	switch c.def.Type {
+	case config.DoctorCheckHTTP:
+		problem = checkHTTP(c.def.URL, c.def.Status, timeout)
+	case config.DoctorCheckFile:
+		problem = checkFileAge(c.def.Path, c.def.MaxAge, env.Options.Now)
+	case config.DoctorCheckTCP:
+		problem = checkTCP(c.def.Address, timeout)
+	case config.DoctorCheckCommand:
+		problem = checkCommand(c.def.Run, timeout)
+	}
+	if problem == "" {
+		return nil
+	}
+
+	severity := SeverityWarn
+	if c.def.Severity == SeverityFail {
+		severity = SeverityFail
+	}
+	title := c.def.Title
+	if title == "" {
+		title = fmt.Sprintf("%s: %s check failed", c.def.Name, c.def.Type)
+	}
+	return []Finding{{
+		Severity: severity,
+		Category: "custom",
+		Title:    title,
+		Detail:   problem,
+		Action:   c.def.Action,
+		Command:  c.def.Command,
+	}}
+}
+
+// checkHTTP returns why url did not answer with the expected status, or "".
+func checkHTTP(url string, want int, timeout time.Duration) string {
+	if want == 0 {
+		want = http.StatusOK
+	}
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return fmt.Sprintf("GET %s failed: %v", url, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != want {
+		return fmt.Sprintf("GET %s returned %d, expected %d.", url, resp.StatusCode, want)
+	}
+	return ""
+}
+
+// checkFileAge returns why path is missing or stale, or "".
+func checkFileAge(path, maxAge string, now time.Time) string {
+	path = expandHome(path)
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Sprintf("%s does not exist.", path)
+		}
+		return fmt.Sprintf("Cannot read %s: %v", path, err)
+	}
+	if maxAge == "" {
+		return ""
+	}
+	limit, _ := history.ParseDuration(maxAge)
+	if age := now.Sub(info.ModTime()); age > limit {
+		return fmt.Sprintf("%s was last modified %s ago; expected within %s.", path, roundDuration(age), roundDuration(limit))
+	}
+	return ""
+}
+
+// checkTCP returns why address did not accept a connection, or "".
+func checkTCP(address string, timeout time.Duration) string {
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return fmt.Sprintf("Cannot connect to %s: %v", address, err)
+	}
+	conn.Close()
+	return ""
+}
+
+// checkCommand returns why run did not exit 0, or "". Commands matching the
+// alerts blocklist are refused rather than run.
+func checkCommand(run string, timeout time.Duration) string {
+	if alerts.IsDangerousCommand(run) {
+		return fmt.Sprintf("Refused to run %q: it matches the dangerous command blocklist.", run)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "sh", "-c", run).CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Sprintf("%q timed out after %s.", run, timeout)
+	}
+	if err == nil {
+		return ""
+	}
+	detail := fmt.Sprintf("%q failed: %v", run, err)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		detail = fmt.Sprintf("%q exited with status %d.", run, exitErr.ExitCode())
+	}
+	if last := lastLine(string(out)); last != "" {
+		detail += " Last output: " + last
+	}
+	return detail
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	last := strings.TrimSpace(lines[len(lines)-1])
+	if len(last) > 200 {
+		last = last[:200] + "…"
+	}
+	return last
+}
+
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return home + path[1:]
+}
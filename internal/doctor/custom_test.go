@@ -0,0 +1,105 @@
+package doctor
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Higangssh/homebutler/internal/config"
+)
+
+func runUserCheck(t *testing.T, def config.DoctorCheck) []Finding {
+	t.Helper()
+	return userCheck{def: def}.Run(&Env{Options: Options{Now: fixedNow}})
+}
+
+func TestUserCheckHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	if f := runUserCheck(t, config.DoctorCheck{Name: "up", Type: "http", URL: srv.URL + "/ok"}); len(f) != 0 {
+		t.Errorf("healthy endpoint produced %+v", f)
+	}
+
+	f := runUserCheck(t, config.DoctorCheck{
+		Name: "nextcloud", Type: "http", URL: srv.URL + "/down", Severity: "fail",
+		Action: "Restart the stack.", Command: "docker compose up -d",
+	})
+	if len(f) != 1 {
+		t.Fatalf("got %d findings, want 1", len(f))
+	}
+	if f[0].Severity != SeverityFail || f[0].Category != "custom" || f[0].Title != "nextcloud: http check failed" {
+		t.Errorf("finding = %+v", f[0])
+	}
+	if !strings.Contains(f[0].Detail, "returned 503, expected 200") || f[0].Command != "docker compose up -d" {
+		t.Errorf("finding = %+v", f[0])
+	}
+}
+
+func TestUserCheckFileAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.sql.gz")
+	if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, fixedNow.Add(-30*time.Hour), fixedNow.Add(-30*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if f := runUserCheck(t, config.DoctorCheck{Name: "dump", Type: "file", Path: path, MaxAge: "2d"}); len(f) != 0 {
+		t.Errorf("fresh file produced %+v", f)
+	}
+	f := runUserCheck(t, config.DoctorCheck{Name: "dump", Type: "file", Path: path, MaxAge: "26h", Title: "Nightly DB dump is stale"})
+	if len(f) != 1 || f[0].Title != "Nightly DB dump is stale" || f[0].Severity != SeverityWarn || !strings.Contains(f[0].Detail, "1d 6h ago") {
+		t.Errorf("stale file findings = %+v", f)
+	}
+	f = runUserCheck(t, config.DoctorCheck{Name: "dump", Type: "file", Path: path + ".missing"})
+	if len(f) != 1 || !strings.Contains(f[0].Detail, "does not exist") {
+		t.Errorf("missing file findings = %+v", f)
+	}
+}
+
+func TestUserCheckTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	if f := runUserCheck(t, config.DoctorCheck{Name: "mqtt", Type: "tcp", Address: addr}); len(f) != 0 {
+		t.Errorf("open port produced %+v", f)
+	}
+	ln.Close()
+	f := runUserCheck(t, config.DoctorCheck{Name: "mqtt", Type: "tcp", Address: addr, Timeout: "1s"})
+	if len(f) != 1 || !strings.Contains(f[0].Detail, "Cannot connect to "+addr) {
+		t.Errorf("closed port findings = %+v", f)
+	}
+}
+
+func TestUserCheckCommand(t *testing.T) {
+	if f := runUserCheck(t, config.DoctorCheck{Name: "ok", Type: "command", Run: "true"}); len(f) != 0 {
+		t.Errorf("successful command produced %+v", f)
+	}
+	f := runUserCheck(t, config.DoctorCheck{Name: "zfs", Type: "command", Run: "echo pool degraded; exit 3"})
+	if len(f) != 1 || !strings.Contains(f[0].Detail, "exited with status 3") || !strings.Contains(f[0].Detail, "pool degraded") {
+		t.Errorf("failing command findings = %+v", f)
+	}
+	f = runUserCheck(t, config.DoctorCheck{Name: "bad", Type: "command", Run: "rm -rf /"})
+	if len(f) != 1 || !strings.Contains(f[0].Detail, "Refused") {
+		t.Errorf("dangerous command findings = %+v", f)
+	}
+}
+
+func TestUserCheckMisconfigured(t *testing.T) {
+	f := runUserCheck(t, config.DoctorCheck{Name: "x", Type: "ping"})
+	if len(f) != 1 || !strings.Contains(f[0].Title, "misconfigured") || f[0].Command != "homebutler config validate" {
+		t.Errorf("findings = %+v", f)
+	}
+}
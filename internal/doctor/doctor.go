@@ -10,9 +10,11 @@ import (
 
 	"github.com/Higangssh/homebutler/internal/backup"
 	"github.com/Higangssh/homebutler/internal/config"
+	"github.com/Higangssh/homebutler/internal/docker"
 	"github.com/Higangssh/homebutler/internal/inventory"
 	"github.com/Higangssh/homebutler/internal/ports"
 	"github.com/Higangssh/homebutler/internal/style"
+	"github.com/Higangssh/homebutler/internal/system"
 	"github.com/charmbracelet/lipgloss"
 )
 
@@ -30,6 +32,9 @@ type Finding struct {
 	Detail   string `json:"detail,omitempty"`
 	Action   string `json:"action,omitempty"`
 	Command  string `json:"command,omitempty"`
+
+	// Fixes are the known remediations `doctor --fix` can apply.
+	Fixes []Remediation `json:"fixes,omitempty"`
 }
 
 // Result is the structured output of a doctor run.
@@ -60,6 +65,13 @@ type CollectFuncs struct {
 	InventoryFns inventory.CollectFuncs
 	BackupListFn func(string) ([]backup.ListEntry, error)
 	SnapshotDir  string
+
+	// CertFetchFn is optional; when nil, TLS certificates are not checked.
+	CertFetchFn CertFetchFunc
+	// StorageFn is optional; when nil, drive and filesystem health are not checked.
+	StorageFn func() (*system.StorageHealth, error)
+	// DockerDiskUsageFn is optional; when nil, reclaimable Docker space is not checked.
+	DockerDiskUsageFn func() (*docker.DiskUsage, error)
 }
 
 // DefaultCollectFuncs returns real doctor data sources.
@@ -68,6 +80,10 @@ func DefaultCollectFuncs() CollectFuncs {
 		InventoryFns: inventory.DefaultCollectFuncs(),
 		BackupListFn: backup.List,
 		SnapshotDir:  defaultSnapshotDir(),
+		CertFetchFn:  FetchCertificates,
+		StorageFn:    system.Storage,
+
+		DockerDiskUsageFn: docker.SystemDiskUsage,
 	}
 }
 
@@ -97,13 +113,13 @@ func Run(cfg *config.Config, fns CollectFuncs, opts Options) (*Result, error) {
 		Findings:   []Finding{},
 	}
 
-	checkCollectionWarnings(r, inv)
-	checkSystem(r, cfg, inv)
-	checkContainers(r, inv)
-	checkPublicPorts(r, inv.Ports)
-	checkBackups(r, cfg, fns.BackupListFn, opts)
-	checkNotifications(r, cfg)
-	checkReportBaseline(r, fns.SnapshotDir)
+	env := &Env{Config: cfg, Inventory: inv, Funcs: fns, Options: opts}
+	for _, c := range Checks() {
+		r.Findings = append(r.Findings, c.Run(env)...)
+	}
+	for _, c := range userChecks(cfg) {
+		r.Findings = append(r.Findings, c.Run(env)...)
+	}
 
 	if len(r.Findings) == 0 {
 		r.Findings = append(r.Findings, Finding{
@@ -171,6 +187,9 @@ func checkContainers(r *Result, inv *inventory.Inventory) {
 	sort.Strings(stopped)
 	command := "homebutler docker logs " + stopped[0]
 	r.add(SeverityWarn, "docker", fmt.Sprintf("%d container(s) are stopped", len(stopped)), strings.Join(stopped, ", "), "Check the logs before restarting; some stopped containers may be intentional.", command)
+	for _, name := range stopped {
+		r.attachFix(Remediation{Kind: FixStartContainer, Target: name})
+	}
 }
 
 func checkPublicPorts(r *Result, pp []ports.PortInfo) {
@@ -212,6 +231,7 @@ func checkBackups(r *Result, cfg *config.Config, listFn func(string) ([]backup.L
 	}
 	if len(entries) == 0 {
 		r.add(SeverityWarn, "backup", "No backups found", fmt.Sprintf("No .tar.gz backups found in %s.", backupDir), "Create your first backup, then verify at least one important app with a drill.", "homebutler backup")
+		r.attachFix(Remediation{Kind: FixRunBackup})
 		return
 	}
 
@@ -223,12 +243,13 @@ func checkBackups(r *Result, cfg *config.Config, listFn func(string) ([]backup.L
 	age := opts.Now.Sub(latest)
 	if age > opts.BackupMaxAge {
 		r.add(SeverityWarn, "backup", "Latest backup is older than expected", fmt.Sprintf("Latest backup is %s old; expected within %s.", roundDuration(age), roundDuration(opts.BackupMaxAge)), "Run a fresh backup. If this app matters, follow up with a backup drill.", "homebutler backup")
+		r.attachFix(Remediation{Kind: FixRunBackup})
 	}
 }
 
 func checkNotifications(r *Result, cfg *config.Config) {
 	if cfg == nil || len(cfg.Notify.EnabledChannels()) == 0 {
-		r.add(SeverityWarn, "notifications", "No notification channel configured", "If something crashes, homebutler can only report it locally.", "Configure Telegram, Slack, Discord, or webhook notifications if you want alerts away from the terminal.", "homebutler notify test")
+		r.add(SeverityWarn, "notifications", "No notification channel configured", "If something crashes, homebutler can only report it locally.", "Configure Telegram, Slack, Discord, email, ntfy, Gotify, Pushover, or webhook notifications if you want alerts away from the terminal.", "homebutler notify test")
 	}
 }
 
@@ -237,6 +258,7 @@ func checkReportBaseline(r *Result, snapshotDir string) {
 	if err != nil {
 		if os.IsNotExist(err) {
 			r.add(SeverityWarn, "report", "No report baseline yet", "Doctor did not find previous report snapshots.", "Run report once so homebutler can notice what changes later.", "homebutler report")
+			r.attachFix(Remediation{Kind: FixCreateBaseline})
 		}
 		return
 	}
@@ -246,12 +268,22 @@ func checkReportBaseline(r *Result, snapshotDir string) {
 		}
 	}
 	r.add(SeverityWarn, "report", "No report baseline yet", "Snapshot directory exists, but no report snapshots were found.", "Run report once so homebutler can notice what changes later.", "homebutler report")
+	r.attachFix(Remediation{Kind: FixCreateBaseline})
 }
 
 func (r *Result) add(severity, category, title, detail, action, command string) {
 	r.Findings = append(r.Findings, Finding{Severity: severity, Category: category, Title: title, Detail: detail, Action: action, Command: command})
 }
 
+// attachFix offers remediations for the finding added last.
+func (r *Result) attachFix(fixes ...Remediation) {
+	f := &r.Findings[len(r.Findings)-1]
+	for _, fix := range fixes {
+		fix.Command = fix.command()
+		f.Fixes = append(f.Fixes, fix)
+	}
+}
+
 func summarize(findings []Finding) Summary {
 	var s Summary
 	for _, f := range findings {
@@ -372,5 +404,9 @@ func FormatHuman(r *Result) string {
 		fmt.Fprintln(&b)
 	}
 
+	if n := FixableCount(r); n > 0 {
+		fmt.Fprintf(&b, "%s\n", style.Dim.Render(fmt.Sprintf("%d fix(es) available — run homebutler doctor --fix to review and apply them.", n)))
+	}
+
 	return b.String()
 }
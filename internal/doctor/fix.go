@@ -0,0 +1,275 @@
+package doctor
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Higangssh/homebutler/internal/alerts"
+	"github.com/Higangssh/homebutler/internal/backup"
+	"github.com/Higangssh/homebutler/internal/config"
+	"github.com/Higangssh/homebutler/internal/docker"
+	"github.com/Higangssh/homebutler/internal/report"
+	"github.com/Higangssh/homebutler/internal/style"
+)
+
+// Remediation kinds. `doctor --fix` only ever runs these; a finding's
+// free-text Command is advice for a human and is never executed.
+const (
+	FixStartContainer = "start-container"
+	FixCreateBaseline = "create-baseline"
+	FixRunBackup      = "run-backup"
+	FixPruneImages    = "prune-images"
+)
+
+// unattendedFixes may be applied by `doctor --fix --yes` without asking.
+// Starting a container is left out: a stopped container may be stopped on
+// purpose, and only a person can tell.
+var unattendedFixes = map[string]bool{
+	FixCreateBaseline: true,
+	FixRunBackup:      true,
+	FixPruneImages:    true,
+}
+
+// Remediation is a known, safe fix for a finding.
+type Remediation struct {
+	Kind    string `json:"kind"`
+	Target  string `json:"target,omitempty"`
+	Command string `json:"command"` // what the fix is equivalent to, for display and audit
+}
+
+func (f Remediation) command() string {
+	switch f.Kind {
+	case FixStartContainer:
+		return "docker start " + f.Target
+	case FixCreateBaseline:
+		return "homebutler report"
+	case FixRunBackup:
+		return "homebutler backup"
+	case FixPruneImages:
+		return "docker image prune -f"
+	}
+	return f.Kind
+}
+
+func (f Remediation) describe() string {
+	switch f.Kind {
+	case FixStartContainer:
+		return "Start container " + f.Target
+	case FixCreateBaseline:
+		return "Create the report baseline"
+	case FixRunBackup:
+		return "Run a backup now"
+	case FixPruneImages:
+		return "Remove dangling images"
+	}
+	return f.Kind
+}
+
+// Fixer applies one kind of remediation and returns a short result.
+type Fixer func(target string) (string, error)
+
+// DefaultFixers returns the real remediation implementations.
+func DefaultFixers(cfg *config.Config, fns CollectFuncs) map[string]Fixer {
+	return map[string]Fixer{
+		FixStartContainer: func(name string) (string, error) {
+			if _, err := docker.Start(name); err != nil {
+				return "", err
+			}
+			return "started", nil
+		},
+		FixCreateBaseline: func(string) (string, error) {
+			r, err := report.Run(cfg, fns.InventoryFns, report.Options{SnapshotDir: fns.SnapshotDir})
+			if err != nil {
+				return "", err
+			}
+			if !r.SnapshotSaved {
+				return "", fmt.Errorf("report ran but no snapshot was saved")
+			}
+			return "baseline snapshot saved", nil
+		},
+		FixRunBackup: func(string) (string, error) {
+			dir := ""
+			if cfg != nil {
+				dir = cfg.ResolveBackupDir()
+			}
+			if dir == "" {
+				dir = defaultBackupDir()
+			}
+			res, err := backup.Run(dir, "")
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%s (%s)", res.Archive, res.Size), nil
+		},
+		FixPruneImages: func(string) (string, error) {
+			return docker.PruneImages()
+		},
+	}
+}
+
+// FixOptions controls a `doctor --fix` session.
+type FixOptions struct {
+	In  io.Reader
+	Out io.Writer
+	// Yes applies unattended-safe fixes without asking and skips the rest.
+	Yes    bool
+	Fixers map[string]Fixer
+	// Record is called for every offered fix, whatever its outcome. Nil
+	// records to the alerts history file.
+	Record func(alerts.HistoryEntry) error
+	Now    func() time.Time
+}
+
+// FixStep is the outcome of one offered remediation.
+type FixStep struct {
+	Finding string `json:"finding"`
+	Kind    string `json:"kind"`
+	Target  string `json:"target,omitempty"`
+	Command string `json:"command"`
+	Outcome string `json:"outcome"` // applied, failed, declined, skipped, refused
+	Output  string `json:"output,omitempty"`
+}
+
+// Fix outcomes.
+const (
+	FixApplied  = "applied"
+	FixFailed   = "failed"
+	FixDeclined = "declined"
+	FixSkipped  = "skipped"
+	FixRefused  = "refused"
+)
+
+// ApplyFixes walks the remediations attached to r's findings, asks before
+// each one (or, with Yes, applies only the unattended-safe kinds), and
+// records every step to the alerts history, refused, declined and skipped
+// ones included. A step that cannot be recorded is warned about on Out.
+//
+// Only registered Fixers are ever run. A remediation whose kind has no
+// Fixer, or whose command trips the dangerous-command blocklist, is refused
+// rather than improvised.
+func ApplyFixes(r *Result, opts FixOptions) []FixStep {
+	if opts.Record == nil {
+		opts.Record = alerts.RecordHistory
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	scanner := bufio.NewScanner(opts.In)
+	var steps []FixStep
+	quit := false
+
+	// done records step to the history and adds it to steps.
+	done := func(f Finding, step FixStep) {
+		result := step.Outcome
+		if step.Output != "" {
+			result += ": " + step.Output
+		}
+		err := opts.Record(alerts.HistoryEntry{
+			Timestamp:    opts.Now(),
+			Rule:         "doctor --fix",
+			Metric:       f.Category,
+			Details:      f.Title,
+			ActionTaken:  step.Command,
+			ActionResult: result,
+		})
+		if err != nil {
+			fmt.Fprintf(opts.Out, "   %s %s\n", style.Warn.Render("!"), style.Dim.Render("could not record this in the alerts history: "+err.Error()))
+		}
+		steps = append(steps, step)
+	}
+
+	for _, f := range r.Findings {
+		for _, fix := range f.Fixes {
+			step := FixStep{Finding: f.Title, Kind: fix.Kind, Target: fix.Target, Command: fix.Command}
+			if quit {
+				step.Outcome = FixSkipped
+				done(f, step)
+				continue
+			}
+
+			fixer, known := opts.Fixers[fix.Kind]
+			switch {
+			case !known || alerts.IsDangerousCommand(fix.Command):
+				step.Outcome = FixRefused
+				step.Output = "no fixer for kind " + fix.Kind
+				if known {
+					step.Output = "matches the dangerous-command blocklist"
+				}
+				fmt.Fprintf(opts.Out, "   %s %s\n", style.Fail.Render("✗"), style.Dim.Render("refused: "+fix.Command))
+				done(f, step)
+				continue
+			case opts.Yes && !unattendedFixes[fix.Kind]:
+				step.Outcome = FixSkipped
+				fmt.Fprintf(opts.Out, "   %s %s %s\n", style.Dim.Render("–"), fix.describe(), style.Dim.Render("(needs confirmation; run without --yes)"))
+				done(f, step)
+				continue
+			case !opts.Yes:
+				answer := ask(scanner, opts.Out, fmt.Sprintf("%s? %s [y/N/q] ", fix.describe(), style.Dim.Render("$ "+fix.Command)))
+				switch answer {
+				case "y", "yes":
+				case "q", "quit":
+					quit = true
+					step.Outcome = FixSkipped
+					done(f, step)
+					continue
+				default:
+					step.Outcome = FixDeclined
+					done(f, step)
+					continue
+				}
+			}
+
+			out, err := fixer(fix.Target)
+			if err != nil {
+				step.Outcome = FixFailed
+				step.Output = err.Error()
+				fmt.Fprintf(opts.Out, "   %s %s: %s\n", style.Fail.Render("✗"), fix.describe(), err)
+			} else {
+				step.Outcome = FixApplied
+				step.Output = out
+				fmt.Fprintf(opts.Out, "   %s %s %s\n", style.OK.Render("✓"), fix.describe(), style.Dim.Render(out))
+			}
+			done(f, step)
+		}
+	}
+	return steps
+}
+
+func ask(scanner *bufio.Scanner, w io.Writer, prompt string) string {
+	fmt.Fprintf(w, "   %s %s", style.Accent.Render("?"), prompt)
+	if !scanner.Scan() {
+		fmt.Fprintln(w)
+		return "q"
+	}
+	return strings.ToLower(strings.TrimSpace(scanner.Text()))
+}
+
+// FixableCount returns how many remediations r offers.
+func FixableCount(r *Result) int {
+	n := 0
+	for _, f := range r.Findings {
+		n += len(f.Fixes)
+	}
+	return n
+}
+
+// FormatFixSummary renders a one-line tally of a fix session.
+func FormatFixSummary(steps []FixStep) string {
+	counts := map[string]int{}
+	for _, s := range steps {
+		counts[s.Outcome]++
+	}
+	var parts []string
+	for _, o := range []string{FixApplied, FixFailed, FixDeclined, FixSkipped, FixRefused} {
+		if counts[o] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[o], o))
+		}
+	}
+	if len(parts) == 0 {
+		return "No fixes offered."
+	}
+	return "Fixes: " + strings.Join(parts, ", ") + "."
+}
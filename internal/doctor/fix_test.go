@@ -0,0 +1,193 @@
+package doctor
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Higangssh/homebutler/internal/alerts"
+)
+
+// fixableResult has one finding per remediation kind, in a fixed order.
+func fixableResult() *Result {
+	r := &Result{}
+	r.add(SeverityWarn, "containers", "Container web is stopped", "", "", "homebutler docker start web")
+	r.attachFix(Remediation{Kind: FixStartContainer, Target: "web"})
+	r.add(SeverityWarn, "report", "No report baseline", "", "", "homebutler report")
+	r.attachFix(Remediation{Kind: FixCreateBaseline})
+	r.add(SeverityWarn, "hygiene", "1.2 GB reclaimable from 3 dangling image(s)", "", "", "docker image prune")
+	r.attachFix(Remediation{Kind: FixPruneImages})
+	return r
+}
+
+type fakeFixers struct {
+	ran []string
+}
+
+func (f *fakeFixers) fixers(failKind string) map[string]Fixer {
+	m := map[string]Fixer{}
+	for _, kind := range []string{FixStartContainer, FixCreateBaseline, FixRunBackup, FixPruneImages} {
+		kind := kind
+		m[kind] = func(target string) (string, error) {
+			f.ran = append(f.ran, strings.TrimSpace(kind+" "+target))
+			if kind == failKind {
+				return "", errors.New("boom")
+			}
+			return "ok", nil
+		}
+	}
+	return m
+}
+
+func outcomes(steps []FixStep) string {
+	var parts []string
+	for _, s := range steps {
+		parts = append(parts, s.Kind+"="+s.Outcome)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestAttachFixFillsCommand(t *testing.T) {
+	r := fixableResult()
+	if got := r.Findings[0].Fixes[0].Command; got != "docker start web" {
+		t.Errorf("command = %q", got)
+	}
+	if FixableCount(r) != 3 {
+		t.Errorf("FixableCount = %d, want 3", FixableCount(r))
+	}
+}
+
+func TestApplyFixesInteractive(t *testing.T) {
+	fake := &fakeFixers{}
+	var recorded []alerts.HistoryEntry
+	var out bytes.Buffer
+	steps := ApplyFixes(fixableResult(), FixOptions{
+		In:     strings.NewReader("y\nn\ny\n"),
+		Out:    &out,
+		Fixers: fake.fixers(FixPruneImages),
+		Record: func(e alerts.HistoryEntry) error { recorded = append(recorded, e); return nil },
+	})
+
+	want := "start-container=applied create-baseline=declined prune-images=failed"
+	if got := outcomes(steps); got != want {
+		t.Errorf("outcomes = %q, want %q", got, want)
+	}
+	if strings.Join(fake.ran, ",") != "start-container web,prune-images" {
+		t.Errorf("ran = %v", fake.ran)
+	}
+	if len(recorded) != 3 {
+		t.Fatalf("recorded %d entries, want one per step", len(recorded))
+	}
+	if recorded[0].Rule != "doctor --fix" || recorded[0].ActionTaken != "docker start web" || recorded[0].ActionResult != "applied: ok" {
+		t.Errorf("entry = %+v", recorded[0])
+	}
+	if recorded[1].Metric != "report" || recorded[1].ActionResult != "declined" {
+		t.Errorf("entry = %+v", recorded[1])
+	}
+	if recorded[2].Metric != "hygiene" || recorded[2].ActionResult != "failed: boom" {
+		t.Errorf("entry = %+v", recorded[2])
+	}
+	if !strings.Contains(out.String(), "[y/N/q]") {
+		t.Errorf("expected a prompt, got:\n%s", out.String())
+	}
+}
+
+func TestApplyFixesQuitAndEOFSkipTheRest(t *testing.T) {
+	fake := &fakeFixers{}
+	steps := ApplyFixes(fixableResult(), FixOptions{
+		In:     strings.NewReader("q\n"),
+		Out:    &bytes.Buffer{},
+		Fixers: fake.fixers(""),
+		Record: func(alerts.HistoryEntry) error { return nil },
+	})
+	if got := outcomes(steps); got != "start-container=skipped create-baseline=skipped prune-images=skipped" {
+		t.Errorf("outcomes = %q", got)
+	}
+
+	steps = ApplyFixes(fixableResult(), FixOptions{
+		In:     strings.NewReader(""),
+		Out:    &bytes.Buffer{},
+		Fixers: fake.fixers(""),
+		Record: func(alerts.HistoryEntry) error { return nil },
+	})
+	if len(fake.ran) != 0 || steps[2].Outcome != FixSkipped {
+		t.Errorf("closed stdin should apply nothing, ran %v", fake.ran)
+	}
+}
+
+func TestApplyFixesYesOnlyAppliesUnattendedSafe(t *testing.T) {
+	fake := &fakeFixers{}
+	var out bytes.Buffer
+	steps := ApplyFixes(fixableResult(), FixOptions{
+		In:     strings.NewReader(""),
+		Out:    &out,
+		Yes:    true,
+		Fixers: fake.fixers(""),
+		Record: func(alerts.HistoryEntry) error { return nil },
+	})
+	if got := outcomes(steps); got != "start-container=skipped create-baseline=applied prune-images=applied" {
+		t.Errorf("outcomes = %q", got)
+	}
+	if !strings.Contains(out.String(), "needs confirmation") {
+		t.Errorf("expected a note about the skipped fix, got:\n%s", out.String())
+	}
+	if got := FormatFixSummary(steps); got != "Fixes: 2 applied, 1 skipped." {
+		t.Errorf("summary = %q", got)
+	}
+}
+
+func TestApplyFixesRefusesUnknownAndDangerous(t *testing.T) {
+	r := &Result{}
+	r.add(SeverityWarn, "custom", "Something odd", "", "", "")
+	r.Findings[0].Fixes = []Remediation{
+		{Kind: "reformat", Command: "mkfs.ext4 /dev/sda"},
+		{Kind: FixStartContainer, Target: "web; rm -rf /", Command: "docker start web; rm -rf /"},
+	}
+	fake := &fakeFixers{}
+	var recorded []alerts.HistoryEntry
+	var out bytes.Buffer
+	steps := ApplyFixes(r, FixOptions{
+		In:     strings.NewReader("y\ny\n"),
+		Out:    &out,
+		Yes:    false,
+		Fixers: fake.fixers(""),
+		Record: func(e alerts.HistoryEntry) error {
+			recorded = append(recorded, e)
+			return errors.New("history file is read-only")
+		},
+	})
+	if got := outcomes(steps); got != "reformat=refused start-container=refused" {
+		t.Errorf("outcomes = %q", got)
+	}
+	if len(fake.ran) != 0 {
+		t.Errorf("refused fixes ran: %v", fake.ran)
+	}
+	// Refusals are what an audit most needs, so they are recorded too, and
+	// a failed write is not silent.
+	if len(recorded) != 2 || recorded[0].ActionResult != "refused: no fixer for kind reformat" ||
+		recorded[1].ActionResult != "refused: matches the dangerous-command blocklist" {
+		t.Errorf("recorded = %+v", recorded)
+	}
+	if strings.Count(out.String(), "history file is read-only") != 2 {
+		t.Errorf("expected a warning per failed record, got:\n%s", out.String())
+	}
+}
+
+func TestRunAttachesFixes(t *testing.T) {
+	funcs := doctorFuncs(healthyStatus(), nil, nil, nil, nil)
+	funcs.SnapshotDir = t.TempDir()
+	result, err := Run(nil, funcs, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, title := range []string{"No backups found", "No report baseline yet"} {
+		f := findTitle(result.Findings, title)
+		if f == nil {
+			t.Fatalf("missing finding %q in:\n%s", title, findingsText(result.Findings))
+		}
+		if len(f.Fixes) != 1 {
+			t.Errorf("%q fixes = %+v", title, f.Fixes)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package doctor
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Higangssh/homebutler/internal/docker"
+)
+
+const defaultLogSizeLimit = "500MB"
+
+// logFileSize returns the size of a container's log file. Overridden in tests;
+// the real files live under /var/lib/docker and usually need root to stat.
+var logFileSize = func(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func init() {
+	Register(CheckFunc{CheckName: "docker-hygiene", Fn: checkDockerHygiene})
+}
+
+// checkDockerHygiene reports the things that quietly eat disk space or make
+// containers fragile: dangling images, unused volumes, unbounded logs,
+// floating :latest tags, and containers that will not come back on their
+// own or cannot tell anyone they are unhealthy.
+func checkDockerHygiene(env *Env) []Finding {
+	r := &Result{}
+	if env.Funcs.DockerDiskUsageFn != nil && len(env.Inventory.Containers) > 0 {
+		if du, err := env.Funcs.DockerDiskUsageFn(); err == nil {
+			checkReclaimable(r, du)
+		}
+	}
+
+	limit := docker.ParseSize(defaultLogSizeLimit)
+	if env.Config != nil {
+		if l := docker.ParseSize(env.Config.Doctor.Docker.LogSizeLimit); l > 0 {
+			limit = l
+		}
+	}
+	details := append([]docker.ContainerDetails(nil), env.Inventory.ContainerDetails...)
+	sort.Slice(details, func(i, j int) bool { return details[i].Name < details[j].Name })
+	checkLogSizes(r, details, limit)
+	checkContainerConfig(r, details)
+	return r.Findings
+}
+
+func checkReclaimable(r *Result, du *docker.DiskUsage) {
+	if n := len(du.DanglingImages); n > 0 {
+		var total int64
+		for _, img := range du.DanglingImages {
+			total += img.Size
+		}
+		r.add(SeverityWarn, "hygiene", fmt.Sprintf("%s reclaimable from %d dangling image(s)", formatSize(total), n),
+			"Old image layers left behind by updates; no container uses them.",
+			"Remove them; nothing running depends on them.", "docker image prune")
+		r.attachFix(Remediation{Kind: FixPruneImages})
+	}
+
+	if n := len(du.UnusedVolumes); n > 0 {
+		var total int64
+		names := make([]string, 0, n)
+		for _, v := range du.UnusedVolumes {
+			total += v.Size
+			names = append(names, v.Name)
+		}
+		sort.Strings(names)
+		r.add(SeverityWarn, "hygiene", fmt.Sprintf("%s held by %d unused volume(s)", formatSize(total), n),
+			summarizeNames(names, 5),
+			"No container mounts these. Check each one before deleting — an unused volume can still be the only copy of old data.",
+			"docker volume ls --filter dangling=true")
+	}
+}
+
+func checkLogSizes(r *Result, details []docker.ContainerDetails, limit int64) {
+	var big []string
+	first := ""
+	for _, d := range details {
+		if d.LogDriver != "json-file" || d.LogMaxSize != "" || d.LogPath == "" {
+			continue
+		}
+		size, err := logFileSize(d.LogPath)
+		if err != nil || size < limit {
+			continue
+		}
+		big = append(big, fmt.Sprintf("%s (%s)", d.Name, formatSize(size)))
+		if first == "" {
+			first = d.Name
+		}
+	}
+	if len(big) == 0 {
+		return
+	}
+	r.add(SeverityWarn, "hygiene", fmt.Sprintf("%d container(s) have unbounded logs over %s", len(big), formatSize(limit)),
+		strings.Join(big, ", "),
+		`Add log rotation (logging: options: max-size: "10m", max-file: "3" in compose, or log-opts in /etc/docker/daemon.json) and recreate the container.`,
+		"docker inspect --format '{{.LogPath}}' "+first)
+}
+
+func checkContainerConfig(r *Result, details []docker.ContainerDetails) {
+	var latest, noRestart, noHealth []string
+	for _, d := range details {
+		if d.UsesLatestTag() {
+			latest = append(latest, d.Name)
+		}
+		if !d.HasRestartPolicy() {
+			noRestart = append(noRestart, d.Name)
+		}
+		if !d.Healthcheck {
+			noHealth = append(noHealth, d.Name)
+		}
+	}
+
+	if len(latest) > 0 {
+		r.add(SeverityWarn, "hygiene", fmt.Sprintf("%d container(s) use :latest images", len(latest)),
+			summarizeNames(latest, 8),
+			"Pin a version tag so updates happen when you choose, not whenever the image is pulled.",
+			`docker inspect --format '{{index .Config.Labels "org.opencontainers.image.version"}}' `+latest[0])
+	}
+	if len(noRestart) > 0 {
+		r.add(SeverityWarn, "hygiene", fmt.Sprintf("%d container(s) have no restart policy", len(noRestart)),
+			summarizeNames(noRestart, 8),
+			"They stay down after a crash or reboot. Set restart: unless-stopped for anything that should always run.",
+			"docker update --restart unless-stopped "+noRestart[0])
+	}
+	if len(noHealth) > 0 {
+		r.add(SeverityWarn, "hygiene", fmt.Sprintf("%d container(s) have no healthcheck", len(noHealth)),
+			summarizeNames(noHealth, 8),
+			"Without one, a hung service still shows as running. Add a healthcheck to the services that matter most.",
+			"docker inspect --format '{{json .Config.Healthcheck}}' "+noHealth[0])
+	}
+}
+
+// summarizeNames lists up to max names and counts the rest.
+func summarizeNames(names []string, max int) string {
+	if len(names) <= max {
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(names[:max], ", "), len(names)-max)
+}
+
+// formatSize formats bytes in decimal units, as Docker itself does.
+func formatSize(bytes int64) string {
+	const (
+		KB = 1000
+		MB = KB * 1000
+		GB = MB * 1000
+	)
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.1f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.1f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.1f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
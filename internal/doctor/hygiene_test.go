@@ -0,0 +1,99 @@
+package doctor
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Higangssh/homebutler/internal/config"
+	"github.com/Higangssh/homebutler/internal/docker"
+	"github.com/Higangssh/homebutler/internal/inventory"
+)
+
+func TestCheckDockerHygiene(t *testing.T) {
+	sizes := map[string]int64{
+		"/logs/jellyfin.log": 2_300_000_000,
+		"/logs/sonarr.log":   100_000_000,
+	}
+	saved := logFileSize
+	logFileSize = func(path string) (int64, error) {
+		if s, ok := sizes[path]; ok {
+			return s, nil
+		}
+		return 0, errors.New("permission denied")
+	}
+	t.Cleanup(func() { logFileSize = saved })
+
+	env := &Env{
+		Config: &config.Config{},
+		Inventory: &inventory.Inventory{
+			Containers: []docker.Container{{Name: "jellyfin"}, {Name: "sonarr"}, {Name: "vaultwarden"}},
+			ContainerDetails: []docker.ContainerDetails{
+				{Name: "jellyfin", Image: "jellyfin/jellyfin", RestartPolicy: "unless-stopped", Healthcheck: true,
+					LogDriver: "json-file", LogPath: "/logs/jellyfin.log"},
+				{Name: "sonarr", Image: "linuxserver/sonarr:4.0.9", RestartPolicy: "no",
+					LogDriver: "json-file", LogPath: "/logs/sonarr.log"},
+				{Name: "vaultwarden", Image: "vaultwarden/server:1.30.5", RestartPolicy: "always", Healthcheck: true,
+					LogDriver: "json-file", LogMaxSize: "10m", LogPath: "/logs/jellyfin.log"},
+			},
+		},
+		Funcs: CollectFuncs{DockerDiskUsageFn: func() (*docker.DiskUsage, error) {
+			return &docker.DiskUsage{
+				DanglingImages: []docker.ImageUsage{{ID: "a", Size: 1_100_000_000}, {ID: "b", Size: 400_000_000}},
+				UnusedVolumes:  []docker.VolumeUsage{{Name: "old_db", Size: 2_500_000_000}},
+			}, nil
+		}},
+	}
+
+	findings := checkDockerHygiene(env)
+	byTitle := map[string]Finding{}
+	for _, f := range findings {
+		if f.Category != "hygiene" || f.Severity != SeverityWarn {
+			t.Errorf("unexpected category/severity: %+v", f)
+		}
+		byTitle[f.Title] = f
+	}
+
+	want := map[string]string{
+		"1.5 GB reclaimable from 2 dangling image(s)":      "docker image prune",
+		"2.5 GB held by 1 unused volume(s)":                "docker volume ls --filter dangling=true",
+		"1 container(s) have unbounded logs over 500.0 MB": "docker inspect --format '{{.LogPath}}' jellyfin",
+		"1 container(s) have no restart policy":            "docker update --restart unless-stopped sonarr",
+		"1 container(s) have no healthcheck":               "docker inspect --format '{{json .Config.Healthcheck}}' sonarr",
+	}
+	for title, command := range want {
+		f, ok := byTitle[title]
+		if !ok {
+			t.Errorf("missing finding %q; got %v", title, titles(findings))
+			continue
+		}
+		if f.Command != command {
+			t.Errorf("%q command = %q, want %q", title, f.Command, command)
+		}
+	}
+	if f := byTitle["1 container(s) use :latest images"]; f.Detail != "jellyfin" {
+		t.Errorf(":latest finding = %+v", f)
+	}
+	if f := byTitle["1 container(s) have unbounded logs over 500.0 MB"]; !strings.Contains(f.Detail, "jellyfin (2.3 GB)") {
+		t.Errorf("log finding detail = %q", f.Detail)
+	}
+}
+
+func TestCheckDockerHygieneLogLimitFromConfig(t *testing.T) {
+	saved := logFileSize
+	logFileSize = func(string) (int64, error) { return 100_000_000, nil }
+	t.Cleanup(func() { logFileSize = saved })
+
+	cfg := &config.Config{}
+	cfg.Doctor.Docker.LogSizeLimit = "50MB"
+	env := &Env{
+		Config: cfg,
+		Inventory: &inventory.Inventory{ContainerDetails: []docker.ContainerDetails{
+			{Name: "app", Image: "app:1", RestartPolicy: "always", Healthcheck: true, LogDriver: "json-file", LogPath: "/x"},
+		}},
+	}
+	f := checkDockerHygiene(env)
+	if len(f) != 1 || !strings.Contains(f[0].Title, "over 50.0 MB") {
+		t.Errorf("findings = %+v", f)
+	}
+}
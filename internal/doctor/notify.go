@@ -0,0 +1,61 @@
+package doctor
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Higangssh/homebutler/internal/notify"
+)
+
+// Event builds the notification for a run: the summary, and one field per
+// failure and then per warning. A passing run is an "ok" event.
+func (r *Result) Event() notify.Event {
+	t, _ := time.Parse(time.RFC3339, r.Timestamp)
+	event := notify.Event{
+		Kind:        notify.KindDoctor,
+		Source:      "doctor",
+		Name:        "doctor",
+		Status:      "ok",
+		Details:     "all checks passed",
+		Action:      "diagnose",
+		Result:      "success",
+		Time:        t,
+		Server:      r.ServerName,
+		Fingerprint: "doctor:" + r.ServerName,
+	}
+	switch {
+	case r.Summary.Fail > 0:
+		event.Status = "failing"
+	case r.Summary.Warn > 0:
+		event.Status = "warning"
+	}
+	if event.Status == "ok" {
+		return event
+	}
+	event.Result = event.Status
+
+	var counts []string
+	if r.Summary.Fail > 0 {
+		counts = append(counts, plural(r.Summary.Fail, "failure"))
+	}
+	if r.Summary.Warn > 0 {
+		counts = append(counts, plural(r.Summary.Warn, "warning"))
+	}
+	event.Details = strings.Join(counts, ", ")
+	for _, sev := range []string{SeverityFail, SeverityWarn} {
+		for _, f := range r.Findings {
+			if f.Severity == sev {
+				event.Fields = append(event.Fields, notify.Field{Name: f.Category, Value: f.Title})
+			}
+		}
+	}
+	return event
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
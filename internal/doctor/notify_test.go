@@ -0,0 +1,47 @@
+package doctor
+
+import (
+	"testing"
+
+	"github.com/Higangssh/homebutler/internal/notify"
+)
+
+func TestResultEvent(t *testing.T) {
+	r := &Result{
+		Timestamp:  "2026-10-18T03:00:00Z",
+		ServerName: "nas",
+		Status:     SeverityFail,
+		Summary:    Summary{Pass: 3, Warn: 1, Fail: 2},
+		Findings: []Finding{
+			{Severity: SeverityPass, Category: "memory", Title: "Memory is fine"},
+			{Severity: SeverityWarn, Category: "backups", Title: "Last backup is 9 days old"},
+			{Severity: SeverityFail, Category: "disk", Title: "/ is 97% full"},
+			{Severity: SeverityFail, Category: "containers", Title: "vaultwarden is exited"},
+		},
+	}
+	e := r.Event()
+	if e.Kind != notify.KindDoctor || e.Status != "failing" || e.Severity() != notify.SeverityCritical {
+		t.Errorf("event = %+v", e)
+	}
+	if e.Details != "2 failures, 1 warning" || e.Server != "nas" || e.Time.IsZero() {
+		t.Errorf("details/server/time = %q/%q/%v", e.Details, e.Server, e.Time)
+	}
+	want := []notify.Field{
+		{Name: "disk", Value: "/ is 97% full"},
+		{Name: "containers", Value: "vaultwarden is exited"},
+		{Name: "backups", Value: "Last backup is 9 days old"},
+	}
+	if len(e.Fields) != len(want) {
+		t.Fatalf("fields = %+v", e.Fields)
+	}
+	for i := range want {
+		if e.Fields[i] != want[i] {
+			t.Errorf("field %d = %+v, want %+v (failures first)", i, e.Fields[i], want[i])
+		}
+	}
+
+	pass := (&Result{Status: SeverityPass, Summary: Summary{Pass: 5}}).Event()
+	if pass.Status != "ok" || len(pass.Fields) != 0 || pass.Severity() != notify.SeverityInfo {
+		t.Errorf("passing run = %+v", pass)
+	}
+}
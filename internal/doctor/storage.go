@@ -0,0 +1,135 @@
+package doctor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Higangssh/homebutler/internal/system"
+	"github.com/Higangssh/homebutler/internal/util"
+)
+
+// Drive temperatures above which a drive is running hot. Spinning disks age
+// noticeably faster past the mid-fifties; NVMe controllers tolerate more
+// before they start throttling.
+const (
+	hotDiskC = 55
+	hotNVMeC = 70
+)
+
+func init() {
+	Register(CheckFunc{CheckName: "storage", Fn: checkStorage})
+}
+
+// checkStorage reports failing drives, filesystems that went read-only, and
+// inode exhaustion.
+func checkStorage(env *Env) []Finding {
+	if env.Funcs.StorageFn == nil {
+		return nil
+	}
+	h, err := env.Funcs.StorageFn()
+	if err != nil || h == nil {
+		return nil
+	}
+	r := &Result{}
+	checkSmart(r, h)
+	checkReadOnlyMounts(r, h.Mounts)
+	checkInodes(r, env, h.Inodes)
+	return r.Findings
+}
+
+func checkSmart(r *Result, h *system.StorageHealth) {
+	var unreadable []string
+	for _, d := range h.Smart {
+		if !d.Readable {
+			unreadable = append(unreadable, d.Device)
+			continue
+		}
+		name := driveName(d)
+		command := "sudo smartctl -a " + d.Device
+
+		if !d.Passed {
+			detail := "The drive's own SMART self-assessment failed."
+			if len(d.FailingAttributes) > 0 {
+				detail += " Failing: " + strings.Join(d.FailingAttributes, ", ") + "."
+			}
+			r.add(SeverityFail, "smart", name+" is failing", detail, "Back up what is on it now and plan to replace the drive.", command)
+		} else if len(d.FailingAttributes) > 0 {
+			r.add(SeverityWarn, "smart", name+" has SMART attributes past their threshold", strings.Join(d.FailingAttributes, ", "), "Watch this drive closely and make sure its data is backed up.", command)
+		}
+
+		switch {
+		case d.PendingSectors > 0 || d.Uncorrectable > 0:
+			r.add(SeverityFail, "smart", name+" has unreadable sectors",
+				fmt.Sprintf("%d pending, %d uncorrectable, %d reallocated sector(s).", d.PendingSectors, d.Uncorrectable, d.ReallocatedSectors),
+				"Data in those sectors may already be lost. Back up now and replace the drive.", command)
+		case d.ReallocatedSectors > 0:
+			r.add(SeverityWarn, "smart", name+" has reallocated sectors",
+				fmt.Sprintf("%d sector(s) have been remapped to spares.", d.ReallocatedSectors),
+				"A small stable count is survivable; a growing one means the drive is wearing out. Run doctor again in a week and compare.", command)
+		}
+
+		if d.CriticalWarning != 0 {
+			r.add(SeverityFail, "smart", name+" reports a critical warning", fmt.Sprintf("NVMe critical warning flags: 0x%02x.", d.CriticalWarning), "Back up the drive and check the full health log.", command)
+		}
+		if d.MediaErrors > 0 {
+			r.add(SeverityWarn, "smart", name+" has media errors", fmt.Sprintf("%d unrecovered media error(s).", d.MediaErrors), "Make sure the drive's data is backed up.", command)
+		}
+
+		limit := hotDiskC
+		if d.Protocol == "NVMe" {
+			limit = hotNVMeC
+		}
+		if d.TemperatureC >= limit {
+			r.add(SeverityWarn, "smart", name+" is running hot", fmt.Sprintf("%d°C; above %d°C shortens its life.", d.TemperatureC, limit), "Check airflow, fans, and dust around the drive bay.", command)
+		}
+	}
+
+	if len(unreadable) > 0 && len(unreadable) == len(h.Smart) {
+		r.add(SeverityWarn, "smart", "SMART data could not be read", strings.Join(unreadable, ", "), "smartctl usually needs root. Run doctor with sudo to check drive health.", "sudo homebutler doctor")
+	}
+}
+
+func driveName(d system.SmartDevice) string {
+	if d.Model != "" {
+		return fmt.Sprintf("Drive %s (%s)", d.Device, d.Model)
+	}
+	return "Drive " + d.Device
+}
+
+// checkReadOnlyMounts flags disk filesystems that are mounted read-only. One
+// carrying errors=remount-ro was almost certainly remounted by the kernel
+// after an error; others may be intentional.
+func checkReadOnlyMounts(r *Result, mounts []system.MountInfo) {
+	for _, m := range mounts {
+		if !m.IsDiskFilesystem() || !m.ReadOnly() {
+			continue
+		}
+		detail := fmt.Sprintf("%s (%s on %s) is mounted read-only.", m.Mount, m.FSType, m.Device)
+		if m.HasOption("errors=remount-ro") {
+			r.add(SeverityFail, "filesystem", "Filesystem "+m.Mount+" went read-only",
+				detail+" It is configured to remount read-only on errors, so the kernel likely did this after an I/O or filesystem error.",
+				"Check the kernel log for the cause, then repair the filesystem before remounting it read-write.", "sudo dmesg | grep -iE 'ext4|xfs|btrfs|i/o error'")
+			continue
+		}
+		r.add(SeverityWarn, "filesystem", "Filesystem "+m.Mount+" is read-only", detail,
+			"If this is not intentional, check the kernel log for errors before remounting.", "sudo dmesg | grep -iE 'ext4|xfs|btrfs|i/o error'")
+	}
+}
+
+// checkInodes flags filesystems running out of inodes, using the same
+// threshold as disk space.
+func checkInodes(r *Result, env *Env, usage []system.InodeUsage) {
+	limit := 90.0
+	if env.Config != nil && env.Config.Alerts.Disk > 0 {
+		limit = env.Config.Alerts.Disk
+	}
+	for _, u := range usage {
+		if u.Percent < limit {
+			continue
+		}
+		r.add(SeverityFail, "filesystem", "Filesystem "+u.Mount+" is running out of inodes",
+			fmt.Sprintf("%.0f%% of inodes used (%d free). New files fail with \"No space left on device\" even though space is free.", u.Percent, u.Free),
+			"Find directories full of tiny files — caches, mail queues, build artifacts — and clean them up.",
+			"sudo du --inodes -x -d1 "+util.ShellQuote(u.Mount)+" | sort -rn | head")
+	}
+}
@@ -0,0 +1,103 @@
+package doctor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Higangssh/homebutler/internal/config"
+	"github.com/Higangssh/homebutler/internal/system"
+)
+
+func storageEnv(h *system.StorageHealth) *Env {
+	return &Env{
+		Config: &config.Config{},
+		Funcs: CollectFuncs{StorageFn: func() (*system.StorageHealth, error) {
+			return h, nil
+		}},
+	}
+}
+
+func titles(findings []Finding) string {
+	var ts []string
+	for _, f := range findings {
+		ts = append(ts, f.Severity+": "+f.Title)
+	}
+	return strings.Join(ts, "\n")
+}
+
+func TestCheckStorageSmart(t *testing.T) {
+	h := &system.StorageHealth{
+		SmartAvailable: true,
+		Smart: []system.SmartDevice{
+			{Device: "/dev/sdb", Model: "WDC WD40EFRX", Readable: true, Passed: false,
+				ReallocatedSectors: 1848, PendingSectors: 24, FailingAttributes: []string{"Reallocated_Sector_Ct (now)"}, TemperatureC: 40},
+			{Device: "/dev/sda", Readable: true, Passed: true, ReallocatedSectors: 8, TemperatureC: 58},
+			{Device: "/dev/nvme0", Protocol: "NVMe", Readable: true, Passed: true, TemperatureC: 65},
+			{Device: "/dev/sdc", Readable: false, Error: "Permission denied"},
+		},
+	}
+	got := titles(checkStorage(storageEnv(h)))
+	for _, want := range []string{
+		"fail: Drive /dev/sdb (WDC WD40EFRX) is failing",
+		"fail: Drive /dev/sdb (WDC WD40EFRX) has unreadable sectors",
+		"warn: Drive /dev/sda has reallocated sectors",
+		"warn: Drive /dev/sda is running hot",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "nvme0") {
+		t.Errorf("65°C is within NVMe limits:\n%s", got)
+	}
+	if strings.Contains(got, "could not be read") {
+		t.Errorf("one unreadable drive among readable ones should not warn:\n%s", got)
+	}
+}
+
+func TestCheckStorageSmartNeedsRoot(t *testing.T) {
+	h := &system.StorageHealth{
+		SmartAvailable: true,
+		Smart:          []system.SmartDevice{{Device: "/dev/sda", Error: "Permission denied"}},
+	}
+	f := checkStorage(storageEnv(h))
+	if len(f) != 1 || f[0].Title != "SMART data could not be read" || f[0].Command != "sudo homebutler doctor" {
+		t.Errorf("findings = %+v", f)
+	}
+}
+
+func TestCheckStorageFilesystems(t *testing.T) {
+	h := &system.StorageHealth{
+		Mounts: system.ParseProcMounts(`/dev/sda2 / ext4 rw,relatime,errors=remount-ro 0 0
+/dev/sdb1 /mnt/media ext4 ro,relatime,errors=remount-ro 0 0
+/dev/sdc1 /mnt/archive xfs ro,relatime 0 0
+/dev/sdd1 /mnt/scratch ext4 ro,relatime,errors=continue 0 0
+/dev/loop3 /snap/core22/1380 squashfs ro,nodev,relatime 0 0`),
+		Inodes: []system.InodeUsage{
+			{Mount: "/", Percent: 98.1, Free: 117535},
+			{Mount: "/mnt/media", Percent: 1},
+		},
+	}
+	got := titles(checkStorage(storageEnv(h)))
+	for _, want := range []string{
+		"fail: Filesystem /mnt/media went read-only",
+		"warn: Filesystem /mnt/archive is read-only",
+		"warn: Filesystem /mnt/scratch is read-only",
+		"fail: Filesystem / is running out of inodes",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "snap") || strings.Contains(got, "/mnt/media is running out") || strings.Contains(got, "/mnt/scratch went read-only") {
+		t.Errorf("unexpected findings:\n%s", got)
+	}
+}
+
+func TestCheckInodesCommand(t *testing.T) {
+	h := &system.StorageHealth{Inodes: []system.InodeUsage{{Mount: "/mnt/my data", Percent: 97}}}
+	f := checkStorage(storageEnv(h))
+	if len(f) != 1 || f[0].Command != "sudo du --inodes -x -d1 '/mnt/my data' | sort -rn | head" {
+		t.Errorf("findings = %+v", f)
+	}
+}
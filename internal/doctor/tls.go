@@ -0,0 +1,207 @@
+package doctor
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Higangssh/homebutler/internal/inventory"
+	"github.com/Higangssh/homebutler/internal/ports"
+)
+
+const (
+	defaultTLSExpiryDays = 14
+	tlsProbeTimeout      = 3 * time.Second
+)
+
+// CertFetchFunc completes a TLS handshake with address, sending serverName
+// as SNI when it is not empty, and returns the certificates presented. An
+// error usually just means the port does not speak TLS.
+type CertFetchFunc func(address, serverName string) ([]*x509.Certificate, error)
+
+// FetchCertificates is the default CertFetchFunc. The certificate is
+// deliberately not verified during the handshake: an expired or self-signed
+// certificate is exactly what the check wants to see.
+func FetchCertificates(address, serverName string) ([]*x509.Certificate, error) {
+	dialer := &net.Dialer{Timeout: tlsProbeTimeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", address, &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: true, //nolint:gosec // inspecting, not trusting
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates, nil
+}
+
+// tlsTarget is one endpoint to handshake with.
+type tlsTarget struct {
+	address    string
+	serverName string
+	label      string // how the finding names it
+	owner      string // container owning the port, if known
+}
+
+func init() {
+	Register(CheckFunc{CheckName: "tls", Fn: checkTLS})
+}
+
+// checkTLS handshakes with every publicly bound port and every configured
+// host and reports certificates that are expired, self-signed, or expire
+// within the warning window. Ports that do not speak TLS are skipped.
+func checkTLS(env *Env) []Finding {
+	fetch := env.Funcs.CertFetchFn
+	if fetch == nil {
+		return nil
+	}
+	days := defaultTLSExpiryDays
+	var hosts []string
+	if env.Config != nil {
+		hosts = env.Config.Doctor.TLS.Hosts
+		if env.Config.Doctor.TLS.ExpiryDays > 0 {
+			days = env.Config.Doctor.TLS.ExpiryDays
+		}
+	}
+
+	targets := tlsTargets(env.Inventory, hosts)
+	certs := make([][]*x509.Certificate, len(targets))
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		wg.Add(1)
+		go func(i int, t tlsTarget) {
+			defer wg.Done()
+			certs[i], _ = fetch(t.address, t.serverName)
+		}(i, t)
+	}
+	wg.Wait()
+
+	var findings []Finding
+	for i, t := range targets {
+		if len(certs[i]) == 0 {
+			continue
+		}
+		findings = append(findings, judgeCertificate(t, certs[i][0], env.Options.Now, days)...)
+	}
+	return findings
+}
+
+// tlsTargets lists the exposed ports, dialled on loopback, followed by the
+// configured hosts.
+func tlsTargets(inv *inventory.Inventory, hosts []string) []tlsTarget {
+	var targets []tlsTarget
+	if inv != nil {
+		owners := inventory.PortOwners(inv.Containers)
+		seen := map[string]bool{}
+		for _, p := range inv.Ports {
+			if !ports.IsPublicBind(p.Address) || p.Port == "" || seen[p.Port] {
+				continue
+			}
+			seen[p.Port] = true
+			loopback := "127.0.0.1"
+			if strings.Trim(p.Address, "[]") == "::" {
+				loopback = "::1"
+			}
+			t := tlsTarget{
+				address: net.JoinHostPort(loopback, p.Port),
+				label:   "port " + p.Port,
+				owner:   strings.Join(owners[p.Port], ", "),
+			}
+			switch {
+			case t.owner != "":
+				t.label += " (" + t.owner + ")"
+			case p.Process != "":
+				t.label += " (" + p.Process + ")"
+			}
+			targets = append(targets, t)
+		}
+		sort.SliceStable(targets, func(i, j int) bool { return targets[i].address < targets[j].address })
+	}
+
+	for _, h := range hosts {
+		h = strings.TrimSpace(h)
+		if h == "" {
+			continue
+		}
+		host, port, err := net.SplitHostPort(h)
+		if err != nil {
+			host, port = h, "443"
+		}
+		targets = append(targets, tlsTarget{
+			address:    net.JoinHostPort(host, port),
+			serverName: host,
+			label:      h,
+		})
+	}
+	return targets
+}
+
+// judgeCertificate reports what is wrong with a leaf certificate, if anything.
+func judgeCertificate(t tlsTarget, leaf *x509.Certificate, now time.Time, days int) []Finding {
+	var findings []Finding
+	subject := certName(leaf)
+	command := "openssl s_client -connect " + t.address
+	if t.serverName != "" {
+		command += " -servername " + t.serverName
+	}
+	command += " </dev/null | openssl x509 -noout -subject -issuer -dates"
+	if t.owner != "" {
+		command = "homebutler docker logs " + strings.Split(t.owner, ", ")[0]
+	}
+
+	left := leaf.NotAfter.Sub(now)
+	switch {
+	case left <= 0:
+		findings = append(findings, Finding{
+			Severity: SeverityFail,
+			Category: "tls",
+			Title:    "TLS certificate expired on " + t.label,
+			Detail:   fmt.Sprintf("%s expired %s (%s ago).", subject, leaf.NotAfter.UTC().Format("2006-01-02"), roundDuration(-left)),
+			Action:   "Renew the certificate; if a reverse proxy manages it, check why automatic renewal stopped.",
+			Command:  command,
+		})
+	case left <= time.Duration(days)*24*time.Hour:
+		findings = append(findings, Finding{
+			Severity: SeverityWarn,
+			Category: "tls",
+			Title:    "TLS certificate expires soon on " + t.label,
+			Detail:   fmt.Sprintf("%s expires %s (in %s).", subject, leaf.NotAfter.UTC().Format("2006-01-02"), roundDuration(left)),
+			Action:   "Renew it before it lapses; automatic renewal normally runs well before this point.",
+			Command:  command,
+		})
+	}
+
+	if isSelfSigned(leaf) {
+		findings = append(findings, Finding{
+			Severity: SeverityWarn,
+			Category: "tls",
+			Title:    "Self-signed TLS certificate on " + t.label,
+			Detail:   fmt.Sprintf("%s is signed by itself, so browsers and clients will not trust it.", subject),
+			Action:   "Fine for a lab-only service; anything reached by other people or devices should get a real certificate.",
+			Command:  command,
+		})
+	}
+	return findings
+}
+
+func certName(c *x509.Certificate) string {
+	if c.Subject.CommonName != "" {
+		return "Certificate for " + c.Subject.CommonName
+	}
+	if len(c.DNSNames) > 0 {
+		return "Certificate for " + c.DNSNames[0]
+	}
+	return "Certificate"
+}
+
+func isSelfSigned(c *x509.Certificate) bool {
+	if c.Subject.String() != c.Issuer.String() {
+		return false
+	}
+	return c.CheckSignatureFrom(c) == nil
+}
@@ -0,0 +1,144 @@
+package doctor
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Higangssh/homebutler/internal/config"
+	"github.com/Higangssh/homebutler/internal/docker"
+	"github.com/Higangssh/homebutler/internal/inventory"
+	"github.com/Higangssh/homebutler/internal/ports"
+)
+
+// testCert issues a certificate for name valid until notAfter. With a nil
+// parent it is self-signed; otherwise parent signs it.
+func testCert(t *testing.T, name string, notAfter time.Time, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             notAfter.Add(-90 * 24 * time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  parent == nil,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	signer, signerKey := tmpl, key
+	if parent != nil {
+		signer, signerKey = parent, parentKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func TestCheckTLSReportsExpiredExpiringAndSelfSigned(t *testing.T) {
+	ca, caKey := testCert(t, "Homelab CA", fixedNow.Add(3650*24*time.Hour), nil, nil)
+	expired, _ := testCert(t, "proxy.lan", fixedNow.Add(-72*time.Hour), ca, caKey)
+	expiring, _ := testCert(t, "cloud.example.com", fixedNow.Add(5*24*time.Hour), ca, caKey)
+	selfSigned, _ := testCert(t, "nas.lan", fixedNow.Add(365*24*time.Hour), nil, nil)
+
+	byAddr := map[string]*x509.Certificate{
+		"127.0.0.1:443":         expired,
+		"cloud.example.com:443": expiring,
+		"[::1]:5001":            selfSigned,
+	}
+	var sni []string
+	fetch := func(address, serverName string) ([]*x509.Certificate, error) {
+		if serverName != "" {
+			sni = append(sni, serverName)
+		}
+		if c, ok := byAddr[address]; ok {
+			return []*x509.Certificate{c}, nil
+		}
+		return nil, errTLS("not TLS")
+	}
+
+	cfg := &config.Config{}
+	cfg.Doctor.TLS.Hosts = []string{"cloud.example.com"}
+	env := &Env{
+		Config: cfg,
+		Inventory: &inventory.Inventory{
+			Containers: []docker.Container{{Name: "traefik", Ports: "0.0.0.0:443->443/tcp"}},
+			Ports: []ports.PortInfo{
+				{Address: "0.0.0.0", Port: "443", Process: "docker-proxy"},
+				{Address: "::", Port: "5001", Process: "nginx"},
+				{Address: "0.0.0.0", Port: "22", Process: "sshd"},
+				{Address: "127.0.0.1", Port: "8443", Process: "internal"},
+			},
+		},
+		Funcs:   CollectFuncs{CertFetchFn: fetch},
+		Options: Options{Now: fixedNow},
+	}
+
+	findings := checkTLS(env)
+	if len(findings) != 3 {
+		t.Fatalf("got %d findings, want 3: %+v", len(findings), findings)
+	}
+	if f := findings[0]; f.Severity != SeverityFail || f.Title != "TLS certificate expired on port 443 (traefik)" ||
+		!strings.Contains(f.Detail, "proxy.lan expired") || f.Command != "homebutler docker logs traefik" {
+		t.Errorf("expired finding = %+v", f)
+	}
+	if f := findings[1]; f.Severity != SeverityWarn || f.Title != "Self-signed TLS certificate on port 5001 (nginx)" {
+		t.Errorf("self-signed finding = %+v", f)
+	}
+	if f := findings[2]; f.Severity != SeverityWarn || f.Title != "TLS certificate expires soon on cloud.example.com" ||
+		!strings.Contains(f.Command, "-servername cloud.example.com") {
+		t.Errorf("expiring finding = %+v", f)
+	}
+	if len(sni) != 1 || sni[0] != "cloud.example.com" {
+		t.Errorf("SNI sent = %v, want only the configured host", sni)
+	}
+}
+
+func TestCheckTLSExpiryWindowFromConfig(t *testing.T) {
+	ca, caKey := testCert(t, "CA", fixedNow.Add(3650*24*time.Hour), nil, nil)
+	leaf, _ := testCert(t, "svc.lan", fixedNow.Add(20*24*time.Hour), ca, caKey)
+	fetch := func(string, string) ([]*x509.Certificate, error) { return []*x509.Certificate{leaf}, nil }
+
+	cfg := &config.Config{}
+	cfg.Doctor.TLS.Hosts = []string{"svc.lan:8443"}
+	env := &Env{Config: cfg, Funcs: CollectFuncs{CertFetchFn: fetch}, Options: Options{Now: fixedNow}}
+	if f := checkTLS(env); len(f) != 0 {
+		t.Errorf("20 days left is outside the default window: %+v", f)
+	}
+	cfg.Doctor.TLS.ExpiryDays = 30
+	if f := checkTLS(env); len(f) != 1 || !strings.Contains(f[0].Title, "svc.lan:8443") {
+		t.Errorf("30-day window findings = %+v", f)
+	}
+}
+
+func TestFetchCertificatesHandshake(t *testing.T) {
+	srv := httptest.NewTLSServer(nil)
+	defer srv.Close()
+
+	certs, err := FetchCertificates(srv.Listener.Addr().String(), "")
+	if err != nil {
+		t.Fatalf("FetchCertificates: %v", err)
+	}
+	if len(certs) == 0 || !certs[0].NotAfter.After(fixedNow) {
+		t.Errorf("unexpected certificates: %d", len(certs))
+	}
+}
+
+type errTLS string
+
+func (e errTLS) Error() string { return string(e) }
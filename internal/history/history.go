@@ -0,0 +1,487 @@
+// Package history keeps an embedded, append-only time series of host and
+// container metrics, so questions like "when did the disk start filling up?"
+// can be answered without running a metrics server.
+//
+// Samples are appended to one JSON-lines file per UTC day. Once a day falls
+// outside the full-resolution window it is rewritten as hourly averages, and
+// once it falls outside the retention window it is deleted. Appends never
+// rewrite existing lines, so a crash mid-write costs at most the last sample.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Higangssh/homebutler/internal/docker"
+	"github.com/Higangssh/homebutler/internal/system"
+)
+
+const (
+	defaultRetention    = 90 * 24 * time.Hour
+	defaultRawRetention = 48 * time.Hour
+
+	rawPrefix    = "raw-"
+	hourlyPrefix = "hourly-"
+	fileSuffix   = ".jsonl"
+	dayLayout    = "20060102"
+)
+
+// Config controls how long history is kept. Durations accept the same forms
+// as ParseDuration, so "90d" and "2w" work alongside Go durations.
+type Config struct {
+	// Retention is how long any history is kept at all.
+	Retention string `yaml:"retention,omitempty" json:"retention,omitempty"`
+	// RawRetention is how long samples stay at full resolution before they
+	// are averaged into hourly points.
+	RawRetention string `yaml:"raw_retention,omitempty" json:"raw_retention,omitempty"`
+}
+
+// Sample is one observation of every metric collected at the same moment.
+type Sample struct {
+	Time   time.Time          `json:"t"`
+	Values map[string]float64 `json:"v"`
+}
+
+// Point is one value of a single metric.
+type Point struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// Store is a directory of daily sample files.
+type Store struct {
+	Dir          string
+	Retention    time.Duration
+	RawRetention time.Duration
+}
+
+// Dir returns the default history directory, ~/.homebutler/history.
+func Dir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine home directory: %w", err)
+	}
+	return filepath.Join(home, ".homebutler", "history"), nil
+}
+
+// Open returns a store rooted at dir using the retention settings in cfg.
+// Unset durations take the defaults: 90 days overall, 48 hours at full
+// resolution.
+func Open(dir string, cfg Config) (*Store, error) {
+	s := &Store{Dir: dir, Retention: defaultRetention, RawRetention: defaultRawRetention}
+	if cfg.Retention != "" {
+		d, err := ParseDuration(cfg.Retention)
+		if err != nil {
+			return nil, fmt.Errorf("history.retention: %w", err)
+		}
+		s.Retention = d
+	}
+	if cfg.RawRetention != "" {
+		d, err := ParseDuration(cfg.RawRetention)
+		if err != nil {
+			return nil, fmt.Errorf("history.raw_retention: %w", err)
+		}
+		s.RawRetention = d
+	}
+	return s, nil
+}
+
+// Metric names. Disk and container metrics carry the mount or container name
+// so that each one is its own series.
+const (
+	MetricCPU    = "cpu"
+	MetricMemory = "memory"
+)
+
+// DiskMetric names the usage series for a mount point.
+func DiskMetric(mount string) string { return "disk:" + mount }
+
+// ContainerCPUMetric names the CPU series for a container.
+func ContainerCPUMetric(name string) string { return "container:" + name + ":cpu" }
+
+// ContainerMemoryMetric names the memory series for a container.
+func ContainerMemoryMetric(name string) string { return "container:" + name + ":memory" }
+
+// NewSample flattens a status reading and optional container stats into a
+// sample. All values are percentages.
+func NewSample(t time.Time, info *system.StatusInfo, stats []docker.ContainerStats) Sample {
+	s := Sample{Time: t.UTC(), Values: map[string]float64{}}
+	if info != nil {
+		s.Values[MetricCPU] = info.CPU.UsagePercent
+		s.Values[MetricMemory] = info.Memory.Percent
+		for _, d := range info.Disks {
+			s.Values[DiskMetric(d.Mount)] = d.Percent
+		}
+	}
+	for _, c := range stats {
+		if v, ok := parsePercent(c.CPUPerc); ok {
+			s.Values[ContainerCPUMetric(c.Name)] = v
+		}
+		if v, ok := parsePercent(c.MemPerc); ok {
+			s.Values[ContainerMemoryMetric(c.Name)] = v
+		}
+	}
+	return s
+}
+
+// parsePercent reads docker's "12.34%" notation.
+func parsePercent(s string) (float64, bool) {
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "%"))
+	if s == "" || s == "--" {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+// Append writes a sample to the day file it belongs to, then compacts
+// anything that has aged out of the full-resolution or retention windows.
+func (s *Store) Append(sample Sample) error {
+	if len(sample.Values) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+	line, err := json.Marshal(sample)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(s.Dir, rawPrefix+sample.Time.UTC().Format(dayLayout)+fileSuffix)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return s.Compact(sample.Time)
+}
+
+// segment is one day file on disk.
+type segment struct {
+	name   string
+	day    time.Time
+	hourly bool
+}
+
+func (s *Store) segments() ([]segment, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var segs []segment
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+		var prefix string
+		switch {
+		case strings.HasPrefix(name, rawPrefix):
+			prefix = rawPrefix
+		case strings.HasPrefix(name, hourlyPrefix):
+			prefix = hourlyPrefix
+		default:
+			continue
+		}
+		day, err := time.Parse(dayLayout, strings.TrimSuffix(strings.TrimPrefix(name, prefix), fileSuffix))
+		if err != nil {
+			continue
+		}
+		segs = append(segs, segment{name: name, day: day, hourly: prefix == hourlyPrefix})
+	}
+	sort.Slice(segs, func(i, j int) bool { return segs[i].day.Before(segs[j].day) })
+	return segs, nil
+}
+
+// Compact deletes day files older than the retention window and rewrites raw
+// day files older than the full-resolution window as hourly averages.
+//
+// A day is only acted on once all of it is outside the window, so a partial
+// day is never averaged while samples are still arriving for it.
+func (s *Store) Compact(now time.Time) error {
+	segs, err := s.segments()
+	if err != nil {
+		return err
+	}
+	for _, seg := range segs {
+		dayEnd := seg.day.Add(24 * time.Hour)
+		switch {
+		case s.Retention > 0 && now.Sub(dayEnd) > s.Retention:
+			if err := os.Remove(filepath.Join(s.Dir, seg.name)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		case !seg.hourly && now.Sub(dayEnd) > s.RawRetention:
+			if err := s.downsample(seg); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// downsample replaces a raw day file with hourly averages. The hourly file is
+// written before the raw one is removed, so an interruption leaves both on
+// disk rather than neither; the next compaction merges them.
+func (s *Store) downsample(seg segment) error {
+	raw, err := readSamples(filepath.Join(s.Dir, seg.name))
+	if err != nil {
+		return err
+	}
+	hourlyPath := filepath.Join(s.Dir, hourlyPrefix+seg.day.Format(dayLayout)+fileSuffix)
+	existing, err := readSamples(hourlyPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	merged := averageSamples(append(existing, raw...), time.Hour)
+
+	tmp := hourlyPath + ".tmp"
+	if err := writeSamples(tmp, merged); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, hourlyPath); err != nil {
+		return err
+	}
+	return os.Remove(filepath.Join(s.Dir, seg.name))
+}
+
+// averageSamples buckets samples by step and averages each metric within a
+// bucket. Metrics missing from some samples are averaged over the samples
+// that have them.
+func averageSamples(samples []Sample, step time.Duration) []Sample {
+	type acc struct {
+		sum   map[string]float64
+		count map[string]int
+	}
+	buckets := map[time.Time]*acc{}
+	for _, smp := range samples {
+		b := smp.Time.UTC().Truncate(step)
+		a, ok := buckets[b]
+		if !ok {
+			a = &acc{sum: map[string]float64{}, count: map[string]int{}}
+			buckets[b] = a
+		}
+		for k, v := range smp.Values {
+			a.sum[k] += v
+			a.count[k]++
+		}
+	}
+
+	out := make([]Sample, 0, len(buckets))
+	for t, a := range buckets {
+		values := make(map[string]float64, len(a.sum))
+		for k, sum := range a.sum {
+			values[k] = round2(sum / float64(a.count[k]))
+		}
+		out = append(out, Sample{Time: t, Values: values})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Time.Before(out[j].Time) })
+	return out
+}
+
+func readSamples(path string) ([]Sample, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var samples []Sample
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var smp Sample
+		// A torn final line from an interrupted append is skipped rather
+		// than failing the whole day.
+		if err := json.Unmarshal(line, &smp); err != nil {
+			continue
+		}
+		samples = append(samples, smp)
+	}
+	return samples, sc.Err()
+}
+
+func writeSamples(path string, samples []Sample) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, smp := range samples {
+		line, err := json.Marshal(smp)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		w.Write(line)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// load reads every sample at or after since, oldest first.
+func (s *Store) load(since time.Time) ([]Sample, error) {
+	segs, err := s.segments()
+	if err != nil {
+		return nil, err
+	}
+	var all []Sample
+	for _, seg := range segs {
+		if seg.day.Add(24 * time.Hour).Before(since) {
+			continue
+		}
+		samples, err := readSamples(filepath.Join(s.Dir, seg.name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue // compacted between listing and reading
+			}
+			return nil, err
+		}
+		for _, smp := range samples {
+			if !smp.Time.Before(since) {
+				all = append(all, smp)
+			}
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Time.Before(all[j].Time) })
+	return all, nil
+}
+
+// Query returns the points recorded for metric at or after since, oldest
+// first. Older stretches come back at hourly resolution.
+func (s *Store) Query(metric string, since time.Time) ([]Point, error) {
+	samples, err := s.load(since)
+	if err != nil {
+		return nil, err
+	}
+	var points []Point
+	for _, smp := range samples {
+		if v, ok := smp.Values[metric]; ok {
+			points = append(points, Point{Time: smp.Time, Value: v})
+		}
+	}
+	return points, nil
+}
+
+// MetricInfo describes one series in the store.
+type MetricInfo struct {
+	Name   string    `json:"name"`
+	Points int       `json:"points"`
+	First  time.Time `json:"first"`
+	Last   time.Time `json:"last"`
+	Latest float64   `json:"latest"`
+}
+
+// Metrics lists every series recorded at or after since, sorted by name.
+func (s *Store) Metrics(since time.Time) ([]MetricInfo, error) {
+	samples, err := s.load(since)
+	if err != nil {
+		return nil, err
+	}
+	byName := map[string]*MetricInfo{}
+	for _, smp := range samples {
+		for k, v := range smp.Values {
+			m, ok := byName[k]
+			if !ok {
+				m = &MetricInfo{Name: k, First: smp.Time}
+				byName[k] = m
+			}
+			m.Points++
+			m.Last = smp.Time
+			m.Latest = v
+		}
+	}
+	out := make([]MetricInfo, 0, len(byName))
+	for _, m := range byName {
+		out = append(out, *m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// Downsample averages points into buckets of step. A step of zero or less
+// returns the points unchanged.
+func Downsample(points []Point, step time.Duration) []Point {
+	if step <= 0 || len(points) == 0 {
+		return points
+	}
+	var out []Point
+	var bucket time.Time
+	var sum float64
+	var n int
+	flush := func() {
+		if n > 0 {
+			out = append(out, Point{Time: bucket, Value: round2(sum / float64(n))})
+		}
+	}
+	for _, p := range points {
+		b := p.Time.UTC().Truncate(step)
+		if n > 0 && !b.Equal(bucket) {
+			flush()
+			sum, n = 0, 0
+		}
+		bucket = b
+		sum += p.Value
+		n++
+	}
+	flush()
+	return out
+}
+
+// ParseDuration accepts Go durations plus whole days ("7d") and weeks ("2w"),
+// which is how retention and --since windows are usually written.
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+	unit := s[len(s)-1]
+	if unit == 'd' || unit == 'w' {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		d := time.Duration(n) * 24 * time.Hour
+		if unit == 'w' {
+			d *= 7
+		}
+		return d, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q (use e.g. 30m, 12h, 7d, 2w)", s)
+	}
+	return d, nil
+}
+
+func round2(f float64) float64 {
+	return math.Round(f*100) / 100
+}
@@ -0,0 +1,277 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Higangssh/homebutler/internal/docker"
+	"github.com/Higangssh/homebutler/internal/system"
+)
+
+func testStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(t.TempDir(), Config{})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	return s
+}
+
+func sample(t time.Time, values map[string]float64) Sample {
+	return Sample{Time: t, Values: values}
+}
+
+func TestNewSampleFlattensStatusAndStats(t *testing.T) {
+	info := &system.StatusInfo{
+		CPU:    system.CPUInfo{UsagePercent: 12.5},
+		Memory: system.MemInfo{Percent: 40},
+		Disks:  []system.DiskInfo{{Mount: "/", Percent: 60}, {Mount: "/mnt/data", Percent: 81}},
+	}
+	stats := []docker.ContainerStats{
+		{Name: "jellyfin", CPUPerc: "3.25%", MemPerc: "11.50%"},
+		{Name: "broken", CPUPerc: "--", MemPerc: ""},
+	}
+
+	s := NewSample(time.Now(), info, stats)
+
+	want := map[string]float64{
+		"cpu":                       12.5,
+		"memory":                    40,
+		"disk:/":                    60,
+		"disk:/mnt/data":            81,
+		"container:jellyfin:cpu":    3.25,
+		"container:jellyfin:memory": 11.5,
+	}
+	if len(s.Values) != len(want) {
+		t.Fatalf("got %d values, want %d: %v", len(s.Values), len(want), s.Values)
+	}
+	for k, v := range want {
+		if s.Values[k] != v {
+			t.Errorf("%s = %v, want %v", k, s.Values[k], v)
+		}
+	}
+}
+
+func TestAppendAndQuery(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UTC()
+
+	for i := 0; i < 3; i++ {
+		ts := now.Add(time.Duration(i-3) * time.Minute)
+		if err := s.Append(sample(ts, map[string]float64{"disk:/": float64(50 + i)})); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	if err := s.Append(sample(now, map[string]float64{"cpu": 7})); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	points, err := s.Query("disk:/", now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(points) != 3 {
+		t.Fatalf("got %d points, want 3", len(points))
+	}
+	if points[0].Value != 50 || points[2].Value != 52 {
+		t.Errorf("points out of order: %+v", points)
+	}
+
+	points, _ = s.Query("disk:/", now.Add(-90*time.Second))
+	if len(points) != 1 {
+		t.Errorf("since filter: got %d points, want 1", len(points))
+	}
+}
+
+func TestAppendSkipsEmptySample(t *testing.T) {
+	s := testStore(t)
+	if err := s.Append(sample(time.Now(), nil)); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if entries, _ := os.ReadDir(s.Dir); len(entries) != 0 {
+		t.Errorf("empty sample should not be written, found %d files", len(entries))
+	}
+}
+
+func TestCompactDownsamplesOldDays(t *testing.T) {
+	s := testStore(t)
+	day := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	// Two samples in the same hour average together; a third lands in the next hour.
+	for _, smp := range []Sample{
+		sample(day.Add(10*time.Minute), map[string]float64{"cpu": 10, "memory": 30}),
+		sample(day.Add(40*time.Minute), map[string]float64{"cpu": 20}),
+		sample(day.Add(70*time.Minute), map[string]float64{"cpu": 50}),
+	} {
+		if err := s.Append(smp); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+
+	if err := s.Compact(day.Add(5 * 24 * time.Hour)); err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(s.Dir, "raw-20261001.jsonl")); !os.IsNotExist(err) {
+		t.Error("raw file should be removed after downsampling")
+	}
+	if _, err := os.Stat(filepath.Join(s.Dir, "hourly-20261001.jsonl")); err != nil {
+		t.Fatalf("hourly file missing: %v", err)
+	}
+
+	points, err := s.Query("cpu", day)
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(points) != 2 {
+		t.Fatalf("got %d hourly points, want 2: %+v", len(points), points)
+	}
+	if points[0].Value != 15 || !points[0].Time.Equal(day) {
+		t.Errorf("first hour = %+v, want 15 at %v", points[0], day)
+	}
+	if points[1].Value != 50 {
+		t.Errorf("second hour = %v, want 50", points[1].Value)
+	}
+
+	// memory appears in only one sample and is averaged over that one.
+	mem, _ := s.Query("memory", day)
+	if len(mem) != 1 || mem[0].Value != 30 {
+		t.Errorf("memory = %+v, want a single 30", mem)
+	}
+}
+
+func TestCompactKeepsRecentDaysRaw(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UTC()
+	if err := s.Append(sample(now, map[string]float64{"cpu": 1})); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Compact(now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(s.Dir, "raw-"+now.Format(dayLayout)+".jsonl")); err != nil {
+		t.Errorf("today's raw file should survive compaction: %v", err)
+	}
+}
+
+func TestCompactDeletesExpiredDays(t *testing.T) {
+	s, err := Open(t.TempDir(), Config{Retention: "7d"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	if err := s.Append(sample(old, map[string]float64{"cpu": 1})); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Compact(old.Add(30 * 24 * time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, _ := os.ReadDir(s.Dir)
+	if len(entries) != 0 {
+		t.Errorf("expected expired day to be deleted, found %d files", len(entries))
+	}
+}
+
+func TestQuerySkipsTornLine(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UTC()
+	if err := s.Append(sample(now, map[string]float64{"cpu": 5})); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(s.Dir, "raw-"+now.Format(dayLayout)+".jsonl")
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	f.WriteString(`{"t":"2026-`)
+	f.Close()
+
+	points, err := s.Query("cpu", now.Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(points) != 1 {
+		t.Errorf("got %d points, want 1", len(points))
+	}
+}
+
+func TestMetricsListsSeries(t *testing.T) {
+	s := testStore(t)
+	now := time.Now().UTC()
+	s.Append(sample(now.Add(-time.Minute), map[string]float64{"cpu": 5, "disk:/": 40}))
+	s.Append(sample(now, map[string]float64{"cpu": 9}))
+
+	metrics, err := s.Metrics(now.Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metrics) != 2 || metrics[0].Name != "cpu" || metrics[1].Name != "disk:/" {
+		t.Fatalf("unexpected metrics: %+v", metrics)
+	}
+	if metrics[0].Points != 2 || metrics[0].Latest != 9 {
+		t.Errorf("cpu info = %+v, want 2 points, latest 9", metrics[0])
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	base := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	points := []Point{
+		{Time: base, Value: 10},
+		{Time: base.Add(20 * time.Minute), Value: 20},
+		{Time: base.Add(time.Hour), Value: 40},
+	}
+	got := Downsample(points, time.Hour)
+	if len(got) != 2 || got[0].Value != 15 || got[1].Value != 40 {
+		t.Errorf("Downsample = %+v", got)
+	}
+	if len(Downsample(points, 0)) != 3 {
+		t.Error("zero step should return points unchanged")
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{"7d", 7 * 24 * time.Hour, true},
+		{"2w", 14 * 24 * time.Hour, true},
+		{"12h", 12 * time.Hour, true},
+		{"30m", 30 * time.Minute, true},
+		{"", 0, false},
+		{"xd", 0, false},
+		{"-3h", 0, false},
+		{"soon", 0, false},
+	}
+	for _, tc := range tests {
+		got, err := ParseDuration(tc.in)
+		if (err == nil) != tc.ok || got != tc.want {
+			t.Errorf("ParseDuration(%q) = %v, %v; want %v, ok=%v", tc.in, got, err, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestOpenRejectsBadRetention(t *testing.T) {
+	if _, err := Open(t.TempDir(), Config{Retention: "forever"}); err == nil {
+		t.Error("expected error for invalid retention")
+	}
+}
+
+func TestAutoStep(t *testing.T) {
+	if got := AutoStep(24 * time.Hour); got != time.Hour {
+		t.Errorf("AutoStep(24h) = %v, want 1h", got)
+	}
+	if got := AutoStep(7 * 24 * time.Hour); got != 6*time.Hour {
+		t.Errorf("AutoStep(7d) = %v, want 6h", got)
+	}
+}
+
+func TestRound2(t *testing.T) {
+	for in, want := range map[float64]float64{0.29: 0.29, 1.005: 1, 42.456: 42.46, -1.236: -1.24, 0: 0} {
+		if got := round2(in); got != want {
+			t.Errorf("round2(%v) = %v, want %v", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package history
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Higangssh/homebutler/internal/style"
+)
+
+// maxRows bounds the human table; longer series are averaged down to fit.
+const maxRows = 30
+
+// niceSteps are the bucket sizes the human table picks from.
+var niceSteps = []time.Duration{
+	time.Minute, 5 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 3 * time.Hour, 6 * time.Hour, 12 * time.Hour, 24 * time.Hour,
+}
+
+// AutoStep picks the smallest bucket size that fits a window into maxRows.
+func AutoStep(window time.Duration) time.Duration {
+	for _, s := range niceSteps {
+		if window/s <= maxRows {
+			return s
+		}
+	}
+	return niceSteps[len(niceSteps)-1]
+}
+
+var sparkBlocks = []rune{'▁', '▂', '▃', '▄', '▅', '▆', '▇', '█'}
+
+// sparkline renders percentages (0-100) as block characters.
+func sparkline(points []Point) string {
+	var b strings.Builder
+	for _, p := range points {
+		v := min(max(p.Value, 0), 100)
+		b.WriteRune(sparkBlocks[min(int(v/100*7), 7)])
+	}
+	return b.String()
+}
+
+// FormatSeries renders one metric as a summary line, a sparkline, and a table
+// of bucketed values.
+func FormatSeries(metric, window string, points []Point, step time.Duration) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "📈 %s\n", style.Title.Render(metric+" — last "+window))
+
+	if len(points) == 0 {
+		fmt.Fprintf(&b, "   %s\n", style.Dim.Render("No samples recorded in this window."))
+		return b.String()
+	}
+
+	lo, hi := points[0], points[0]
+	for _, p := range points {
+		if p.Value < lo.Value {
+			lo = p
+		}
+		if p.Value > hi.Value {
+			hi = p
+		}
+	}
+	first, last := points[0], points[len(points)-1]
+	fmt.Fprintf(&b, "   %s\n\n", style.Dim.Render(fmt.Sprintf("%d samples · %s → %s",
+		len(points), first.Time.Local().Format("2006-01-02 15:04"), last.Time.Local().Format("2006-01-02 15:04"))))
+
+	b.WriteString(style.LabelledBlock([]string{
+		fmt.Sprintf("Latest: %.1f%%", last.Value),
+		fmt.Sprintf("Change: %+.1f pts since %s", last.Value-first.Value, first.Time.Local().Format("Jan 2 15:04")),
+		fmt.Sprintf("Min: %.1f%% at %s", lo.Value, lo.Time.Local().Format("Jan 2 15:04")),
+		fmt.Sprintf("Max: %.1f%% at %s", hi.Value, hi.Time.Local().Format("Jan 2 15:04")),
+	}, "   "))
+	fmt.Fprintln(&b)
+
+	rows := Downsample(points, step)
+	fmt.Fprintf(&b, "   %s\n\n", style.Accent.Render(sparkline(rows)))
+	for _, p := range rows {
+		fmt.Fprintf(&b, "   %s  %6.1f%%\n", style.Label.Render(p.Time.Local().Format("2006-01-02 15:04")), p.Value)
+	}
+	return b.String()
+}
+
+// FormatMetrics lists the recorded series.
+func FormatMetrics(metrics []MetricInfo) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "📈 %s\n\n", style.Title.Render("Recorded metrics"))
+	if len(metrics) == 0 {
+		fmt.Fprintf(&b, "   %s\n", style.Dim.Render("No history yet. Run 'homebutler status' or 'homebutler report' to record samples."))
+		return b.String()
+	}
+	width := 0
+	for _, m := range metrics {
+		width = max(width, len(m.Name))
+	}
+	for _, m := range metrics {
+		fmt.Fprintf(&b, "   %-*s  %6.1f%%  %s\n", width, m.Name, m.Latest,
+			style.Dim.Render(fmt.Sprintf("%d samples since %s", m.Points, m.First.Local().Format("2006-01-02"))))
+	}
+	return b.String()
+}
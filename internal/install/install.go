@@ -475,24 +475,41 @@ var (
 func PreCheck(app App, port string) []string {
 	var issues []string
 
-	// Check docker binary exists
-	out, err := util.RunCmd("docker", "--version")
-	if err != nil || !strings.Contains(out, "Docker") {
+	rt := util.ContainerRuntime()
+
+	// Check the runtime's CLI exists
+	out, err := util.RunCmd(rt.Binary, "--version")
+	if err != nil || !(strings.Contains(out, "Docker") || strings.Contains(strings.ToLower(out), "podman")) {
+		if rt.Name == util.RuntimePodman {
+			issues = append(issues, "podman is not installed.\n"+
+				"    Install: https://podman.io/docs/installation")
+			return issues
+		}
 		issues = append(issues, "docker is not installed.\n"+
 			"    Install: https://docs.docker.com/engine/install/")
 		return issues
 	}
 
-	// Check docker daemon is running
+	// Check the engine is reachable
 	if _, err := util.DockerCmd("info"); err != nil {
+		if rt.Name == util.RuntimePodman {
+			issues = append(issues, "podman is not working.\n"+
+				"    Check: podman info")
+			return issues
+		}
 		issues = append(issues, "docker daemon is not running.\n"+
 			"    Try: sudo systemctl start docker   (Linux)\n"+
 			"         colima start                   (macOS)")
 		return issues
 	}
 
-	// Check docker compose is available
+	// Check compose is available
 	if _, err := util.DockerCmd("compose", "version"); err != nil {
+		if rt.Name == util.RuntimePodman {
+			issues = append(issues, "podman compose is not available.\n"+
+				"    Install a compose provider: sudo dnf install podman-compose   (or docker-compose)")
+			return issues
+		}
 		issues = append(issues, "docker compose is not available.\n"+
 			"    Install: https://docs.docker.com/compose/install/")
 		return issues
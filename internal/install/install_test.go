@@ -8,6 +8,8 @@ import (
 	"strings"
 	"testing"
 	"text/template"
+
+	"github.com/Higangssh/homebutler/internal/util"
 )
 
 func TestRegistryHasApps(t *testing.T) {
@@ -555,6 +557,30 @@ exit 0
 	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
 }
 
+func TestPreCheckPodmanWithoutCompose(t *testing.T) {
+	dir := t.TempDir()
+	script := `#!/bin/sh
+case "$1" in
+  --version) echo "podman version 5.1.2" ;;
+  info) echo "host:" ;;
+  compose) echo "Error: looking up compose provider failed" >&2; exit 125 ;;
+esac
+exit 0
+`
+	if err := os.WriteFile(filepath.Join(dir, "podman"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	util.SetContainerRuntime(util.RuntimePodman)
+	t.Cleanup(func() { util.SetContainerRuntime("") })
+
+	app := Registry["uptime-kuma"]
+	issues := PreCheck(app, app.DefaultPort)
+	if len(issues) != 1 || !strings.Contains(issues[0], "podman compose is not available") {
+		t.Errorf("issues = %v", issues)
+	}
+}
+
 func TestPreCheckDNSPort53InUse(t *testing.T) {
 	withFakeDocker(t)
 	// Port 53 is busy → should warn for both pi-hole and adguard-home
@@ -1,11 +1,15 @@
 package inventory
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"os"
 
 	"github.com/Higangssh/homebutler/internal/config"
 	"github.com/Higangssh/homebutler/internal/docker"
 	"github.com/Higangssh/homebutler/internal/ports"
+	"github.com/Higangssh/homebutler/internal/remote"
 	"github.com/Higangssh/homebutler/internal/system"
 )
 
@@ -17,6 +21,10 @@ type Inventory struct {
 	Containers []docker.Container `json:"containers"`
 	Ports      []ports.PortInfo   `json:"ports"`
 	Warnings   []string           `json:"warnings,omitempty"`
+
+	// ContainerDetails is the inspected configuration of each container,
+	// kept so report can notice drift between snapshots.
+	ContainerDetails []docker.ContainerDetails `json:"container_details,omitempty"`
 }
 
 // CollectFuncs allows injecting data sources for testing.
@@ -24,6 +32,9 @@ type CollectFuncs struct {
 	StatusFn     func() (*system.StatusInfo, error)
 	DockerListFn func() ([]docker.Container, error)
 	PortsListFn  func() (*ports.Result, error)
+
+	// DockerInspectFn is optional; when nil, container details are not collected.
+	DockerInspectFn func(names ...string) ([]docker.ContainerDetails, error)
 }
 
 // DefaultCollectFuncs returns the real system/docker/ports functions.
@@ -32,6 +43,8 @@ func DefaultCollectFuncs() CollectFuncs {
 		StatusFn:     system.Status,
 		DockerListFn: docker.List,
 		PortsListFn:  ports.List,
+
+		DockerInspectFn: docker.Inspect,
 	}
 }
 
@@ -59,6 +72,7 @@ func Collect(cfg *config.Config, fns CollectFuncs) (*Inventory, error) {
 		inv.Warnings = append(inv.Warnings, "docker: "+err.Error())
 	} else {
 		inv.Containers = containers
+		inv.ContainerDetails = inspectContainers(fns, containers, &inv.Warnings)
 	}
 
 	// Ports: best-effort.
@@ -72,6 +86,87 @@ func Collect(cfg *config.Config, fns CollectFuncs) (*Inventory, error) {
 	return inv, nil
 }
 
+// inspectContainers collects configuration details for containers.
+// Failure is recorded as a warning; the rest of the inventory is still useful.
+func inspectContainers(fns CollectFuncs, containers []docker.Container, warnings *[]string) []docker.ContainerDetails {
+	if fns.DockerInspectFn == nil || len(containers) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(containers))
+	for _, c := range containers {
+		names = append(names, c.Name)
+	}
+	details, err := fns.DockerInspectFn(names...)
+	if err != nil {
+		*warnings = append(*warnings, "docker inspect: "+err.Error())
+		return nil
+	}
+	return details
+}
+
+// CollectRemote runs `inventory scan --json` on a remote server over SSH and
+// decodes the result. The server name is taken from the local config rather
+// than the remote one, so that every server is filed under the name the user
+// knows it by.
+func CollectRemote(server *config.ServerConfig) (*Inventory, error) {
+	out, err := remote.Run(server, "inventory", "scan", "--json")
+	if err != nil {
+		return nil, err
+	}
+	return decodeRemote(server, out)
+}
+
+// decodeRemote parses remote inventory output. SSH combines stdout and
+// stderr, so the output can hold more than the JSON document: a warning
+// from the remote binary, or a login banner that may itself contain braces.
+// The last complete JSON object in the output is the one decoded.
+func decodeRemote(server *config.ServerConfig, out []byte) (*Inventory, error) {
+	doc := lastJSONObject(out)
+	if doc == nil {
+		return nil, fmt.Errorf("[%s] remote inventory returned no JSON", server.Name)
+	}
+	var inv Inventory
+	if err := json.Unmarshal(doc, &inv); err != nil {
+		return nil, fmt.Errorf("[%s] cannot parse remote inventory: %w", server.Name, err)
+	}
+	if inv.System == nil {
+		return nil, fmt.Errorf("[%s] remote inventory has no system status", server.Name)
+	}
+	inv.ServerName = server.Name
+	if server.Host != "" {
+		inv.Host = server.Host
+	}
+	if inv.Containers == nil {
+		inv.Containers = []docker.Container{}
+	}
+	if inv.Ports == nil {
+		inv.Ports = []ports.PortInfo{}
+	}
+	return &inv, nil
+}
+
+// lastJSONObject returns the last complete JSON object in out, or nil when
+// there is none. Braces that do not start a valid object are skipped.
+func lastJSONObject(out []byte) json.RawMessage {
+	var last json.RawMessage
+	for i := 0; i < len(out); {
+		start := bytes.IndexByte(out[i:], '{')
+		if start < 0 {
+			break
+		}
+		start += i
+		var obj json.RawMessage
+		dec := json.NewDecoder(bytes.NewReader(out[start:]))
+		if err := dec.Decode(&obj); err != nil {
+			i = start + 1
+			continue
+		}
+		last = obj
+		i = start + int(dec.InputOffset())
+	}
+	return last
+}
+
 // resolveServer picks the local server name/host from config,
 // falling back to os.Hostname.
 func resolveServer(cfg *config.Config) (name, host string) {
@@ -103,6 +103,38 @@ func TestCollect_PortsFailure(t *testing.T) {
 	}
 }
 
+func TestCollect_InspectsContainers(t *testing.T) {
+	containers := []docker.Container{{Name: "web", State: "running"}, {Name: "db", State: "exited"}}
+	fns := fakeFuncs(containers, nil, nil, nil)
+	var asked []string
+	fns.DockerInspectFn = func(names ...string) ([]docker.ContainerDetails, error) {
+		asked = names
+		return []docker.ContainerDetails{{Name: "web"}, {Name: "db"}}, nil
+	}
+
+	inv, err := Collect(&config.Config{}, fns)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(asked, ",") != "web,db" || len(inv.ContainerDetails) != 2 {
+		t.Errorf("inspected %v, details %+v", asked, inv.ContainerDetails)
+	}
+
+	fns.DockerInspectFn = func(...string) ([]docker.ContainerDetails, error) {
+		return nil, errTest("inspect failed")
+	}
+	inv, err = Collect(&config.Config{}, fns)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(inv.Warnings) != 1 || !strings.Contains(inv.Warnings[0], "inspect failed") {
+		t.Errorf("expected inspect warning, got %v", inv.Warnings)
+	}
+	if len(inv.Containers) != 2 {
+		t.Error("containers should survive an inspect failure")
+	}
+}
+
 func TestCollect_NoConfig(t *testing.T) {
 	inv, err := Collect(nil, fakeFuncs(nil, nil, nil, nil))
 	if err != nil {
@@ -363,3 +395,51 @@ func TestJSON_Roundtrip(t *testing.T) {
 type errTest string
 
 func (e errTest) Error() string { return string(e) }
+
+func TestDecodeRemote(t *testing.T) {
+	server := &config.ServerConfig{Name: "nas", Host: "192.168.1.20"}
+	out := []byte("warning: something on stderr\n" +
+		`{"server_name":"nas-hostname","host":"nas-hostname","system":{"hostname":"nas-hostname","cpu":{"usage_percent":5,"cores":2}},"containers":null,"ports":null}`)
+
+	inv, err := decodeRemote(server, out)
+	if err != nil {
+		t.Fatalf("decodeRemote: %v", err)
+	}
+	if inv.ServerName != "nas" || inv.Host != "192.168.1.20" {
+		t.Errorf("name/host = %q/%q, want the configured nas/192.168.1.20", inv.ServerName, inv.Host)
+	}
+	if inv.Containers == nil || inv.Ports == nil {
+		t.Error("nil slices should be normalized to empty")
+	}
+	if inv.System.CPU.Cores != 2 {
+		t.Errorf("system not decoded: %+v", inv.System)
+	}
+}
+
+func TestDecodeRemoteSkipsBannerWithBraces(t *testing.T) {
+	server := &config.ServerConfig{Name: "nas"}
+	out := []byte("Welcome to {nas}! Last login: {\"never\": true}\n" +
+		`{"server_name":"nas-hostname","system":{"hostname":"nas-hostname","cpu":{"usage_percent":5,"cores":4}}}` +
+		"\nbash: warning: setlocale: LC_ALL: cannot change locale {en_US.UTF-8}\n")
+
+	inv, err := decodeRemote(server, out)
+	if err != nil {
+		t.Fatalf("decodeRemote: %v", err)
+	}
+	if inv.System == nil || inv.System.CPU.Cores != 4 {
+		t.Errorf("system not decoded: %+v", inv.System)
+	}
+}
+
+func TestDecodeRemoteRejectsBadOutput(t *testing.T) {
+	server := &config.ServerConfig{Name: "nas"}
+	for name, out := range map[string]string{
+		"no json":   "bash: homebutler: command not found",
+		"truncated": `{"server_name":`,
+		"no status": `{"server_name":"nas"}`,
+	} {
+		if _, err := decodeRemote(server, []byte(out)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
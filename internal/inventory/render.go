@@ -312,6 +312,12 @@ func exposureIcon(p ports.PortInfo) string {
 
 var mappedPortRe = regexp.MustCompile(`(?:^|[\s,])(?:[\d.:\[\]]+:)?(\d+)->(\d+)/(tcp|udp)`)
 
+// PortOwners maps each host port published by a container to the names of
+// the containers publishing it.
+func PortOwners(containers []docker.Container) map[string][]string {
+	return dockerPortLinks(containers)
+}
+
 func dockerPortLinks(containers []docker.Container) map[string][]string {
 	links := make(map[string][]string)
 	seen := make(map[string]map[string]bool)
@@ -0,0 +1,233 @@
+package kube
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// requestTimeout bounds every API call.
+const requestTimeout = 30 * time.Second
+
+// maxLogBytes caps how much of a log response is read.
+const maxLogBytes = 1 << 20
+
+// Client talks to the Kubernetes API server over HTTPS.
+type Client struct {
+	server string
+	token  string
+	http   *http.Client
+}
+
+// NewClient returns a client for cfg.
+func NewClient(cfg *Config) (*Client, error) {
+	tlsCfg := &tls.Config{InsecureSkipVerify: cfg.Insecure} //nolint:gosec // opt-in via kubeconfig
+	if len(cfg.CAData) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(cfg.CAData) {
+			return nil, fmt.Errorf("kubeconfig %s: certificate authority is not valid PEM", cfg.Path)
+		}
+		tlsCfg.RootCAs = pool
+	}
+	if len(cfg.CertData) > 0 || len(cfg.KeyData) > 0 {
+		cert, err := tls.X509KeyPair(cfg.CertData, cfg.KeyData)
+		if err != nil {
+			return nil, fmt.Errorf("kubeconfig %s: client certificate: %w", cfg.Path, err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	return &Client{
+		server: strings.TrimSuffix(cfg.Server, "/"),
+		token:  cfg.Token,
+		http:   &http.Client{Transport: &http.Transport{TLSClientConfig: tlsCfg}},
+	}, nil
+}
+
+// DefaultClient loads the default kubeconfig and returns a client for its
+// current context.
+func DefaultClient() (*Client, error) {
+	cfg, err := LoadConfig("")
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(cfg)
+}
+
+// APIError is a non-2xx response from the API server.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("kubernetes API returned %d", e.StatusCode)
+	}
+	return e.Message
+}
+
+// IsNotFound reports whether err is the API server saying the object does
+// not exist.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// get sends a GET request and returns the response body.
+func (c *Client) get(ctx context.Context, path string, query url.Values, limit int64) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	u := c.server + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes API server unreachable at %s: %w", c.server, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		// Errors come back as a Status object.
+		var status struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &status) != nil {
+			status.Message = strings.TrimSpace(string(data))
+		}
+		return nil, &APIError{StatusCode: resp.StatusCode, Message: status.Message}
+	}
+	return data, nil
+}
+
+// Pod is the subset of a pod object homebutler reads.
+type Pod struct {
+	Metadata struct {
+		Name            string            `json:"name"`
+		Namespace       string            `json:"namespace"`
+		UID             string            `json:"uid"`
+		Labels          map[string]string `json:"labels"`
+		OwnerReferences []OwnerReference  `json:"ownerReferences"`
+	} `json:"metadata"`
+	Status struct {
+		Phase             string            `json:"phase"`
+		ContainerStatuses []ContainerStatus `json:"containerStatuses"`
+	} `json:"status"`
+}
+
+// OwnerReference names the controller that created a pod.
+type OwnerReference struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// ContainerStatus is the status of one container in a pod.
+type ContainerStatus struct {
+	Name         string         `json:"name"`
+	RestartCount int            `json:"restartCount"`
+	State        ContainerState `json:"state"`
+	LastState    ContainerState `json:"lastState"`
+}
+
+// ContainerState is a container's current or previous state; at most one
+// field is set.
+type ContainerState struct {
+	Running *struct {
+		StartedAt string `json:"startedAt"`
+	} `json:"running,omitempty"`
+	Waiting *struct {
+		Reason  string `json:"reason"`
+		Message string `json:"message"`
+	} `json:"waiting,omitempty"`
+	Terminated *Termination `json:"terminated,omitempty"`
+}
+
+// Termination describes how a container last exited.
+type Termination struct {
+	ExitCode    int    `json:"exitCode"`
+	Signal      int    `json:"signal"`
+	Reason      string `json:"reason"` // e.g. "OOMKilled", "Error", "Completed"
+	Message     string `json:"message"`
+	StartedAt   string `json:"startedAt"`
+	FinishedAt  string `json:"finishedAt"`
+	ContainerID string `json:"containerID"` // "<runtime>://<id>"
+}
+
+// ListPods returns the pods in namespace.
+func (c *Client) ListPods(ctx context.Context, namespace string) ([]Pod, error) {
+	data, err := c.get(ctx, "/api/v1/namespaces/"+url.PathEscape(namespace)+"/pods", nil, 64<<20)
+	if err != nil {
+		return nil, err
+	}
+	var list struct {
+		Items []Pod `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("decode pod list: %w", err)
+	}
+	return list.Items, nil
+}
+
+// LogOptions selects which container log PodLogs reads.
+type LogOptions struct {
+	Container string
+	Previous  bool // the log of the container's previous, terminated instance
+	TailLines int
+}
+
+// PodLogs returns the tail of a container's log.
+func (c *Client) PodLogs(ctx context.Context, namespace, pod string, opts LogOptions) (string, error) {
+	q := url.Values{}
+	if opts.Container != "" {
+		q.Set("container", opts.Container)
+	}
+	if opts.Previous {
+		q.Set("previous", "true")
+	}
+	if opts.TailLines > 0 {
+		q.Set("tailLines", strconv.Itoa(opts.TailLines))
+	}
+	path := "/api/v1/namespaces/" + url.PathEscape(namespace) + "/pods/" + url.PathEscape(pod) + "/log"
+	data, err := c.get(ctx, path, q, maxLogBytes)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// WorkloadName returns the name of the Deployment, StatefulSet, DaemonSet
+// or Job that owns the pod, or the pod's own name when it has no owner.
+// A Deployment's pods are owned by a ReplicaSet named "<deployment>-<hash>".
+func (p Pod) WorkloadName() string {
+	for _, o := range p.Metadata.OwnerReferences {
+		switch o.Kind {
+		case "ReplicaSet":
+			if i := strings.LastIndex(o.Name, "-"); i > 0 {
+				return o.Name[:i]
+			}
+			return o.Name
+		case "StatefulSet", "DaemonSet", "Job":
+			return o.Name
+		}
+	}
+	return p.Metadata.Name
+}
@@ -0,0 +1,118 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClientOverTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"kind": "Status", "message": "Unauthorized"})
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v1/namespaces/apps/pods":
+			w.Write([]byte(`{"items": [{
+				"metadata": {"name": "web-7d9f8c6b5-x2x9k", "namespace": "apps",
+					"ownerReferences": [{"kind": "ReplicaSet", "name": "web-7d9f8c6b5"}]},
+				"status": {"phase": "Running", "containerStatuses": [{
+					"name": "web", "restartCount": 3,
+					"state": {"running": {"startedAt": "2026-10-18T03:14:30Z"}},
+					"lastState": {"terminated": {"exitCode": 137, "reason": "OOMKilled",
+						"startedAt": "2026-10-18T03:00:00Z", "finishedAt": "2026-10-18T03:14:22Z"}}
+				}]}
+			}]}`))
+		case "/api/v1/namespaces/apps/pods/web-7d9f8c6b5-x2x9k/log":
+			q := r.URL.Query()
+			if q.Get("container") != "web" || q.Get("previous") != "true" || q.Get("tailLines") != "100" {
+				t.Errorf("log query = %s", r.URL.RawQuery)
+			}
+			w.Write([]byte("java.lang.OutOfMemoryError: Java heap space\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"kind": "Status", "message": `pods "x" not found`})
+		}
+	}))
+	defer srv.Close()
+
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	c, err := NewClient(&Config{Server: srv.URL + "/", CAData: ca, Token: "tok"})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	ctx := context.Background()
+
+	pods, err := c.ListPods(ctx, "apps")
+	if err != nil {
+		t.Fatalf("ListPods: %v", err)
+	}
+	if len(pods) != 1 {
+		t.Fatalf("got %d pods", len(pods))
+	}
+	p := pods[0]
+	if p.WorkloadName() != "web" {
+		t.Errorf("WorkloadName() = %q, want web", p.WorkloadName())
+	}
+	cs := p.Status.ContainerStatuses[0]
+	if cs.RestartCount != 3 || cs.LastState.Terminated == nil || cs.LastState.Terminated.Reason != "OOMKilled" || cs.State.Running == nil {
+		t.Errorf("container status = %+v", cs)
+	}
+
+	logs, err := c.PodLogs(ctx, "apps", p.Metadata.Name, LogOptions{Container: "web", Previous: true, TailLines: 100})
+	if err != nil || !strings.Contains(logs, "OutOfMemoryError") {
+		t.Errorf("PodLogs = %q, %v", logs, err)
+	}
+
+	_, err = c.PodLogs(ctx, "apps", "x", LogOptions{})
+	if !IsNotFound(err) || err.Error() != `pods "x" not found` {
+		t.Errorf("err = %v, want not found", err)
+	}
+
+	anon, _ := NewClient(&Config{Server: srv.URL, CAData: ca})
+	if _, err := anon.ListPods(ctx, "apps"); err == nil || err.Error() != "Unauthorized" {
+		t.Errorf("unauthenticated err = %v", err)
+	}
+
+	// Without the CA the server's certificate is not trusted.
+	untrusted, _ := NewClient(&Config{Server: srv.URL, Token: "tok"})
+	if _, err := untrusted.ListPods(ctx, "apps"); err == nil || !strings.Contains(err.Error(), "unreachable") {
+		t.Errorf("untrusted err = %v", err)
+	}
+}
+
+func TestNewClientRejectsBadCredentials(t *testing.T) {
+	if _, err := NewClient(&Config{Server: "https://x", CAData: []byte("not pem")}); err == nil {
+		t.Error("expected an error for a CA that is not PEM")
+	}
+	if _, err := NewClient(&Config{Server: "https://x", CertData: []byte("CERT"), KeyData: []byte("KEY")}); err == nil {
+		t.Error("expected an error for an invalid client key pair")
+	}
+}
+
+func TestWorkloadName(t *testing.T) {
+	tests := []struct {
+		owner, ownerName, want string
+	}{
+		{"ReplicaSet", "immich-server-5f7c9d8b4", "immich-server"},
+		{"StatefulSet", "postgres", "postgres"},
+		{"DaemonSet", "node-exporter", "node-exporter"},
+		{"", "", "standalone"},
+	}
+	for _, tt := range tests {
+		var p Pod
+		p.Metadata.Name = "standalone"
+		if tt.owner != "" {
+			p.Metadata.OwnerReferences = []OwnerReference{{Kind: tt.owner, Name: tt.ownerName}}
+		}
+		if got := p.WorkloadName(); got != tt.want {
+			t.Errorf("%s %q: WorkloadName() = %q, want %q", tt.owner, tt.ownerName, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+// Package kube is a minimal Kubernetes API client: enough to list pods and
+// read their logs using a kubeconfig, without pulling in client-go.
+package kube
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// k3sConfigPath is where k3s writes its admin kubeconfig.
+const k3sConfigPath = "/etc/rancher/k3s/k3s.yaml"
+
+// Config is the resolved connection settings of one kubeconfig context.
+type Config struct {
+	Path      string // kubeconfig file it came from, for error messages
+	Context   string
+	Server    string
+	Namespace string // context's default namespace; "" means "default"
+	CAData    []byte
+	Insecure  bool
+	Token     string
+	CertData  []byte
+	KeyData   []byte
+}
+
+// kubeconfig is the subset of the kubeconfig file format homebutler reads.
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthority     string `yaml:"certificate-authority"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			Token                 string    `yaml:"token"`
+			TokenFile             string    `yaml:"tokenFile"`
+			ClientCertificate     string    `yaml:"client-certificate"`
+			ClientCertificateData string    `yaml:"client-certificate-data"`
+			ClientKey             string    `yaml:"client-key"`
+			ClientKeyData         string    `yaml:"client-key-data"`
+			Exec                  yaml.Node `yaml:"exec"`
+			AuthProvider          yaml.Node `yaml:"auth-provider"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster   string `yaml:"cluster"`
+			User      string `yaml:"user"`
+			Namespace string `yaml:"namespace"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+}
+
+// DefaultConfigPath returns the kubeconfig to use: the first file in
+// $KUBECONFIG, else ~/.kube/config, else the k3s admin kubeconfig. It
+// returns the ~/.kube/config path when none of them exist.
+func DefaultConfigPath() string {
+	if env := os.Getenv("KUBECONFIG"); env != "" {
+		return filepath.SplitList(env)[0]
+	}
+	home, _ := os.UserHomeDir()
+	user := filepath.Join(home, ".kube", "config")
+	for _, p := range []string{user, k3sConfigPath} {
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return user
+}
+
+// DefaultNamespace returns the namespace of the default kubeconfig's
+// current context, or "default" when it sets none or cannot be read.
+func DefaultNamespace() string {
+	if cfg, err := LoadConfig(""); err == nil && cfg.Namespace != "" {
+		return cfg.Namespace
+	}
+	return "default"
+}
+
+// LoadConfig reads the current context of the kubeconfig at path, or of
+// DefaultConfigPath when path is "". Credentials that need an external
+// plugin (exec, auth-provider) are not supported.
+func LoadConfig(path string) (*Config, error) {
+	if path == "" {
+		path = DefaultConfigPath()
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("no kubeconfig at %s (set KUBECONFIG, or on k3s: sudo chmod 644 %s)", path, k3sConfigPath)
+		}
+		return nil, fmt.Errorf("read kubeconfig: %w", err)
+	}
+	var kc kubeconfig
+	if err := yaml.Unmarshal(data, &kc); err != nil {
+		return nil, fmt.Errorf("parse kubeconfig %s: %w", path, err)
+	}
+
+	cfg := &Config{Path: path, Context: kc.CurrentContext}
+	if cfg.Context == "" && len(kc.Contexts) == 1 {
+		cfg.Context = kc.Contexts[0].Name
+	}
+	var clusterName, userName string
+	found := false
+	for _, c := range kc.Contexts {
+		if c.Name == cfg.Context {
+			clusterName, userName, cfg.Namespace = c.Context.Cluster, c.Context.User, c.Context.Namespace
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("kubeconfig %s: context %q not found", path, cfg.Context)
+	}
+
+	dir := filepath.Dir(path)
+	found = false
+	for _, c := range kc.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+		found = true
+		cfg.Server = c.Cluster.Server
+		cfg.Insecure = c.Cluster.InsecureSkipTLSVerify
+		if cfg.CAData, err = inlineOrFile(c.Cluster.CertificateAuthorityData, c.Cluster.CertificateAuthority, dir); err != nil {
+			return nil, fmt.Errorf("kubeconfig %s: certificate authority: %w", path, err)
+		}
+		break
+	}
+	if !found || cfg.Server == "" {
+		return nil, fmt.Errorf("kubeconfig %s: cluster %q has no server", path, clusterName)
+	}
+
+	for _, u := range kc.Users {
+		if u.Name != userName {
+			continue
+		}
+		if !u.User.Exec.IsZero() || !u.User.AuthProvider.IsZero() {
+			return nil, fmt.Errorf("kubeconfig %s: user %q authenticates through a plugin, which is not supported; use a token or client certificate", path, userName)
+		}
+		cfg.Token = u.User.Token
+		if cfg.Token == "" && u.User.TokenFile != "" {
+			tok, err := os.ReadFile(resolvePath(u.User.TokenFile, dir))
+			if err != nil {
+				return nil, fmt.Errorf("kubeconfig %s: token file: %w", path, err)
+			}
+			cfg.Token = string(trimNewline(tok))
+		}
+		if cfg.CertData, err = inlineOrFile(u.User.ClientCertificateData, u.User.ClientCertificate, dir); err != nil {
+			return nil, fmt.Errorf("kubeconfig %s: client certificate: %w", path, err)
+		}
+		if cfg.KeyData, err = inlineOrFile(u.User.ClientKeyData, u.User.ClientKey, dir); err != nil {
+			return nil, fmt.Errorf("kubeconfig %s: client key: %w", path, err)
+		}
+		break
+	}
+	return cfg, nil
+}
+
+// inlineOrFile returns base64 data when set, else the contents of file.
+func inlineOrFile(data, file, dir string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if file == "" {
+		return nil, nil
+	}
+	return os.ReadFile(resolvePath(file, dir))
+}
+
+// resolvePath resolves p against the kubeconfig's directory, as kubectl does.
+func resolvePath(p, dir string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(dir, p)
+}
+
+func trimNewline(b []byte) []byte {
+	for len(b) > 0 && (b[len(b)-1] == '\n' || b[len(b)-1] == '\r') {
+		b = b[:len(b)-1]
+	}
+	return b
+}
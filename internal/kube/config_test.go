@@ -0,0 +1,140 @@
+package kube
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// k3sStyleConfig is shaped like /etc/rancher/k3s/k3s.yaml.
+const k3sStyleConfig = `apiVersion: v1
+clusters:
+- cluster:
+    certificate-authority-data: Q0EtREFUQQ==
+    server: https://127.0.0.1:6443
+  name: default
+contexts:
+- context:
+    cluster: default
+    user: default
+  name: default
+current-context: default
+kind: Config
+preferences: {}
+users:
+- name: default
+  user:
+    client-certificate-data: Q0VSVA==
+    client-key-data: S0VZ
+`
+
+func writeKubeconfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigK3s(t *testing.T) {
+	cfg, err := LoadConfig(writeKubeconfig(t, k3sStyleConfig))
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.Server != "https://127.0.0.1:6443" || cfg.Context != "default" || cfg.Namespace != "" {
+		t.Errorf("cfg = %+v", cfg)
+	}
+	if string(cfg.CAData) != "CA-DATA" || string(cfg.CertData) != "CERT" || string(cfg.KeyData) != "KEY" {
+		t.Errorf("credentials = %q %q %q", cfg.CAData, cfg.CertData, cfg.KeyData)
+	}
+}
+
+func TestLoadConfigCurrentContextAndFiles(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "ca.crt"), []byte("CA-FILE"), 0o600)
+	os.WriteFile(filepath.Join(dir, "token"), []byte("s3cret\n"), 0o600)
+	path := filepath.Join(dir, "config")
+	os.WriteFile(path, []byte(`
+current-context: lab
+clusters:
+- name: prod
+  cluster: {server: "https://prod:6443"}
+- name: lab
+  cluster: {server: "https://lab:6443", certificate-authority: ca.crt}
+contexts:
+- name: prod
+  context: {cluster: prod, user: admin}
+- name: lab
+  context: {cluster: lab, user: bot, namespace: apps}
+users:
+- name: admin
+  user: {token: wrong}
+- name: bot
+  user: {tokenFile: token}
+`), 0o600)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.Server != "https://lab:6443" || cfg.Namespace != "apps" {
+		t.Errorf("cfg = %+v", cfg)
+	}
+	if string(cfg.CAData) != "CA-FILE" || cfg.Token != "s3cret" {
+		t.Errorf("CA = %q, token = %q", cfg.CAData, cfg.Token)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name, content, want string
+	}{
+		{"missing context", "current-context: nope\ncontexts: []\n", `context "nope" not found`},
+		{"no server", "current-context: a\ncontexts:\n- name: a\n  context: {cluster: c}\n", "has no server"},
+		{"exec plugin", `current-context: a
+clusters: [{name: c, cluster: {server: "https://x"}}]
+contexts: [{name: a, context: {cluster: c, user: u}}]
+users: [{name: u, user: {exec: {command: aws}}}]
+`, "plugin, which is not supported"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig(writeKubeconfig(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	_, err := LoadConfig(filepath.Join(t.TempDir(), "missing"))
+	if err == nil || !strings.Contains(err.Error(), "no kubeconfig at") {
+		t.Errorf("missing file err = %v", err)
+	}
+}
+
+func TestDefaultConfigPathFromEnv(t *testing.T) {
+	a, b := "/tmp/a.yaml", "/tmp/b.yaml"
+	t.Setenv("KUBECONFIG", a+string(os.PathListSeparator)+b)
+	if got := DefaultConfigPath(); got != a {
+		t.Errorf("DefaultConfigPath() = %q, want %q", got, a)
+	}
+}
+
+func TestDefaultNamespace(t *testing.T) {
+	t.Setenv("KUBECONFIG", writeKubeconfig(t, strings.Replace(k3sStyleConfig,
+		"    user: default\n  name: default", "    user: default\n    namespace: media\n  name: default", 1)))
+	if got := DefaultNamespace(); got != "media" {
+		t.Errorf("DefaultNamespace() = %q, want the context's namespace", got)
+	}
+
+	t.Setenv("KUBECONFIG", writeKubeconfig(t, k3sStyleConfig))
+	if got := DefaultNamespace(); got != "default" {
+		t.Errorf("DefaultNamespace() without one = %q", got)
+	}
+	t.Setenv("KUBECONFIG", filepath.Join(t.TempDir(), "missing"))
+	if got := DefaultNamespace(); got != "default" {
+		t.Errorf("DefaultNamespace() without a kubeconfig = %q", got)
+	}
+}
@@ -237,7 +237,7 @@ var capabilityRegistry = []capability{
 		remoteSupport: true,
 		tool: toolDef{
 			Name:        "watch_check",
-			Description: "Run a one-shot restart check on watched targets and report restarts detected since the last check. Only docker targets can be inspected this way; systemd and pm2 targets are reported as skipped rather than assumed healthy",
+			Description: "Run a one-shot restart check on watched targets and report restarts detected since the last check. Only docker targets can be inspected this way; systemd, pm2 and k8s targets are reported as skipped rather than assumed healthy",
 			InputSchema: inputSchema{
 				Type: "object",
 				Properties: map[string]propDef{
@@ -246,6 +246,82 @@ var capabilityRegistry = []capability{
 			},
 		},
 	},
+	{
+		risk:          riskRead,
+		remoteSupport: true,
+		tool: toolDef{
+			Name:        "watch_incidents",
+			Description: "List recorded watch incidents, newest first, with crash analysis and lifecycle status (open, acked, resolved). Filter by status=open to see what still needs review, or search the captured logs",
+			InputSchema: inputSchema{
+				Type: "object",
+				Properties: map[string]propDef{
+					"status":   {Type: "string", Description: "Only incidents with this status: open, acked or resolved (optional)"},
+					"target":   {Type: "string", Description: "Only incidents of this watched target (optional)"},
+					"category": {Type: "string", Description: "Only incidents with this crash category, e.g. oom or panic (optional)"},
+					"since":    {Type: "string", Description: "Only incidents within this window, e.g. 24h or 7d (optional)"},
+					"flapping": {Type: "boolean", Description: "Only incidents recorded while the target was flapping (optional)"},
+					"search":   {Type: "string", Description: "Case-insensitive text to find in pre-death or post-restart logs (optional)"},
+					"limit":    {Type: "number", Description: "Maximum number of incidents to return (default: 20)"},
+					"server":   {Type: "string", Description: "Remote server name from config (optional, runs locally if omitted)"},
+				},
+			},
+		},
+	},
+	{
+		risk:          riskWrite,
+		remoteSupport: true,
+		tool: toolDef{
+			Name:        "watch_ack",
+			Description: "Acknowledge a watch incident so it no longer shows as open. Later incidents of the same target arrive acknowledged until one is resolved",
+			InputSchema: inputSchema{
+				Type: "object",
+				Properties: map[string]propDef{
+					"id":     {Type: "string", Description: "Incident ID"},
+					"note":   {Type: "string", Description: "Note to add while acknowledging (optional)"},
+					"by":     {Type: "string", Description: "Who is acknowledging (optional, defaults to the current user)"},
+					"server": {Type: "string", Description: "Remote server name from config (optional, runs locally if omitted)"},
+				},
+				Required: []string{"id"},
+			},
+		},
+	},
+	{
+		risk:          riskWrite,
+		remoteSupport: true,
+		tool: toolDef{
+			Name:        "watch_note",
+			Description: "Add a free-text note to a watch incident",
+			InputSchema: inputSchema{
+				Type: "object",
+				Properties: map[string]propDef{
+					"id":     {Type: "string", Description: "Incident ID"},
+					"text":   {Type: "string", Description: "Note text"},
+					"by":     {Type: "string", Description: "Who wrote the note (optional, defaults to the current user)"},
+					"server": {Type: "string", Description: "Remote server name from config (optional, runs locally if omitted)"},
+				},
+				Required: []string{"id", "text"},
+			},
+		},
+	},
+	{
+		risk:          riskWrite,
+		remoteSupport: true,
+		tool: toolDef{
+			Name:        "watch_resolve",
+			Description: "Resolve a watch incident with the cause that was found, optionally linking the incident that is its root cause. Needs cause or root",
+			InputSchema: inputSchema{
+				Type: "object",
+				Properties: map[string]propDef{
+					"id":     {Type: "string", Description: "Incident ID"},
+					"cause":  {Type: "string", Description: "What caused the incident"},
+					"root":   {Type: "string", Description: "ID of the incident that is the root cause (optional)"},
+					"by":     {Type: "string", Description: "Who is resolving (optional, defaults to the current user)"},
+					"server": {Type: "string", Description: "Remote server name from config (optional, runs locally if omitted)"},
+				},
+				Required: []string{"id"},
+			},
+		},
+	},
 	{
 		risk:          riskWrite,
 		remoteSupport: true,
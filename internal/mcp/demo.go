@@ -97,6 +97,46 @@ func (s *Server) executeDemoTool(name string, args map[string]any) (any, error)
 				{"name": "caddy.service", "kind": "systemd"},
 			},
 		}, nil
+	case "watch_incidents":
+		return []map[string]any{
+			{
+				"id":          "demo-nextcloud-20260430T120000Z",
+				"container":   "nextcloud",
+				"detected_at": "2026-04-30T12:00:00Z",
+				"status":      "open",
+				"crash_analysis": map[string]any{
+					"category": "dependency", "reason": "Database connection failed", "confidence": "high",
+				},
+			},
+			{
+				"id":          "demo-jellyfin-20260429T031500Z",
+				"container":   "jellyfin",
+				"detected_at": "2026-04-29T03:15:00Z",
+				"status":      "acked",
+				"acked_by":    "admin",
+				"root_cause":  "demo-jellyfin-20260429T010200Z",
+			},
+		}, nil
+	case "watch_ack", "watch_note", "watch_resolve":
+		id, ok := requireString(args, "id")
+		if !ok {
+			return nil, fmt.Errorf("missing required parameter: id")
+		}
+		inc := map[string]any{"id": id, "container": "nextcloud", "status": "open"}
+		switch name {
+		case "watch_ack":
+			inc["status"], inc["acked_by"] = "acked", "admin"
+		case "watch_note":
+			text, ok := requireString(args, "text")
+			if !ok {
+				return nil, fmt.Errorf("missing required parameter: text")
+			}
+			inc["notes"] = []map[string]any{{"user": "admin", "text": text}}
+		default:
+			inc["status"], inc["resolved_by"] = "resolved", "admin"
+			inc["resolution"] = stringArg(args, "cause")
+		}
+		return inc, nil
 	case "backup_create":
 		service := stringArg(args, "service")
 		if service == "" {
@@ -95,8 +95,8 @@ func TestToolsList(t *testing.T) {
 		t.Fatalf("unmarshal toolsListResult: %v", err)
 	}
 
-	if len(list.Tools) != 24 {
-		t.Errorf("expected 24 tools, got %d", len(list.Tools))
+	if len(list.Tools) != 28 {
+		t.Errorf("expected 28 tools, got %d", len(list.Tools))
 	}
 
 	expectedTools := map[string]bool{
@@ -115,6 +115,10 @@ func TestToolsList(t *testing.T) {
 		"report":            false,
 		"doctor":            false,
 		"watch_check":       false,
+		"watch_incidents":   false,
+		"watch_ack":         false,
+		"watch_note":        false,
+		"watch_resolve":     false,
 		"backup_create":     false,
 		"backup_list":       false,
 		"backup_drill":      false,
@@ -311,6 +315,9 @@ func TestToolDefinitionsHaveRequiredFields(t *testing.T) {
 		"install_uninstall": {"app"},
 		"install_purge":     {"app"},
 		"backup_restore":    {"archive"},
+		"watch_ack":         {"id"},
+		"watch_note":        {"id", "text"},
+		"watch_resolve":     {"id"},
 	}
 
 	for _, tool := range tools {
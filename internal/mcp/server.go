@@ -14,6 +14,7 @@ import (
 	"github.com/Higangssh/homebutler/internal/config"
 	"github.com/Higangssh/homebutler/internal/docker"
 	"github.com/Higangssh/homebutler/internal/doctor"
+	"github.com/Higangssh/homebutler/internal/history"
 	"github.com/Higangssh/homebutler/internal/install"
 	"github.com/Higangssh/homebutler/internal/inventory"
 	"github.com/Higangssh/homebutler/internal/network"
@@ -301,6 +302,22 @@ func (s *Server) executeTool(name string, args map[string]any) (any, error) {
 			return nil, err
 		}
 		return watch.CheckTargets(dir, s.resolveIncidentCap(dir))
+	case "watch_incidents":
+		filter, err := incidentFilter(args, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		dir, err := watch.WatchDir()
+		if err != nil {
+			return nil, err
+		}
+		incidents, err := watch.ListIncidents(dir)
+		if err != nil {
+			return nil, err
+		}
+		return watch.FilterIncidents(incidents, filter), nil
+	case "watch_ack", "watch_note", "watch_resolve":
+		return s.updateIncident(name, args)
 	case "backup_create":
 		backupDir := stringArg(args, "to")
 		if backupDir == "" {
@@ -386,6 +403,71 @@ func (s *Server) executeTool(name string, args map[string]any) (any, error) {
 	}
 }
 
+// incidentFilter builds the watch_incidents filter from the tool arguments.
+func incidentFilter(args map[string]any, now time.Time) (watch.IncidentFilter, error) {
+	filter := watch.IncidentFilter{
+		Target:   stringArg(args, "target"),
+		Category: stringArg(args, "category"),
+		Flapping: boolArg(args, "flapping"),
+		Status:   stringArg(args, "status"),
+		Search:   stringArg(args, "search"),
+		Limit:    intArg(args, "limit", 20),
+	}
+	if filter.Status != "" && !watch.ValidStatus(filter.Status) {
+		return filter, fmt.Errorf("invalid status: %q (supported: open, acked, resolved)", filter.Status)
+	}
+	if since := stringArg(args, "since"); since != "" {
+		window, err := history.ParseDuration(since)
+		if err != nil {
+			return filter, fmt.Errorf("invalid since: %w", err)
+		}
+		filter.Since = now.Add(-window)
+	}
+	return filter, nil
+}
+
+// updateIncident runs the lifecycle tools: watch_ack, watch_note and
+// watch_resolve.
+func (s *Server) updateIncident(tool string, args map[string]any) (any, error) {
+	id, ok := requireString(args, "id")
+	if !ok {
+		return nil, fmt.Errorf("missing required parameter: id")
+	}
+	dir, err := watch.WatchDir()
+	if err != nil {
+		return nil, err
+	}
+	by := stringArg(args, "by")
+	if by == "" {
+		by = watch.CurrentUser()
+	}
+	now := time.Now()
+
+	switch tool {
+	case "watch_ack":
+		inc, err := watch.AckIncident(dir, id, by, now)
+		if err != nil {
+			return nil, err
+		}
+		if note := stringArg(args, "note"); note != "" {
+			return watch.AddIncidentNote(dir, id, by, note, now)
+		}
+		return inc, nil
+	case "watch_note":
+		text, ok := requireString(args, "text")
+		if !ok {
+			return nil, fmt.Errorf("missing required parameter: text")
+		}
+		return watch.AddIncidentNote(dir, id, by, text, now)
+	default:
+		cause, root := stringArg(args, "cause"), stringArg(args, "root")
+		if cause == "" && root == "" {
+			return nil, fmt.Errorf("missing required parameter: cause (or root)")
+		}
+		return watch.ResolveIncident(dir, id, by, cause, root, now)
+	}
+}
+
 // resolveIncidentCap resolves the incident retention cap the same way the
 // watch commands do: config.yaml wins over watch/config.json, and an unset
 // value takes the default rather than reading as unlimited.
@@ -451,6 +533,45 @@ func (s *Server) executeRemote(srv *config.ServerConfig, tool string, args map[s
 		remoteArgs = []string{"doctor", "--json", "--backup-max-age", fmt.Sprintf("%dh", intArg(args, "backup_max_age_hours", 168))}
 	case "watch_check":
 		remoteArgs = []string{"watch", "check", "--json"}
+	case "watch_incidents":
+		remoteArgs = []string{"watch", "history", "--json", "--limit", strconv.Itoa(intArg(args, "limit", 20))}
+		for _, key := range []string{"status", "target", "category", "since", "search"} {
+			if v := stringArg(args, key); v != "" {
+				remoteArgs = append(remoteArgs, "--"+key, v)
+			}
+		}
+		if boolArg(args, "flapping") {
+			remoteArgs = append(remoteArgs, "--flapping")
+		}
+	case "watch_ack", "watch_note", "watch_resolve":
+		id, ok := requireString(args, "id")
+		if !ok {
+			return nil, fmt.Errorf("missing required parameter: id")
+		}
+		switch tool {
+		case "watch_ack":
+			remoteArgs = []string{"watch", "ack", id, "--json"}
+			if note := stringArg(args, "note"); note != "" {
+				remoteArgs = append(remoteArgs, "--note", note)
+			}
+		case "watch_note":
+			text, ok := requireString(args, "text")
+			if !ok {
+				return nil, fmt.Errorf("missing required parameter: text")
+			}
+			remoteArgs = []string{"watch", "note", "--json", "--", id, text}
+		default:
+			remoteArgs = []string{"watch", "resolve", id, "--json"}
+			if cause := stringArg(args, "cause"); cause != "" {
+				remoteArgs = append(remoteArgs, "--cause", cause)
+			}
+			if root := stringArg(args, "root"); root != "" {
+				remoteArgs = append(remoteArgs, "--root", root)
+			}
+		}
+		if by := stringArg(args, "by"); by != "" {
+			remoteArgs = append(remoteArgs, "--by", by)
+		}
 	case "backup_list":
 		remoteArgs = []string{"backup", "list", "--json"}
 	case "backup_create":
@@ -0,0 +1,102 @@
+package mcp
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Higangssh/homebutler/internal/config"
+	"github.com/Higangssh/homebutler/internal/watch"
+)
+
+func TestWatchLifecycleTools(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".homebutler", "watch")
+
+	now := time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC)
+	inc := &watch.Incident{ID: watch.GenerateIncidentID("nginx", now), Container: "nginx", DetectedAt: now}
+	if err := watch.SaveIncident(dir, inc, 0); err != nil {
+		t.Fatalf("SaveIncident: %v", err)
+	}
+
+	s := NewServer(&config.Config{}, "test")
+
+	if _, err := s.executeTool("watch_ack", map[string]any{"id": inc.ID, "by": "agent", "note": "known flapper"}); err != nil {
+		t.Fatalf("watch_ack: %v", err)
+	}
+	res, err := s.executeTool("watch_incidents", map[string]any{"status": "acked"})
+	if err != nil {
+		t.Fatalf("watch_incidents: %v", err)
+	}
+	acked, ok := res.([]watch.Incident)
+	if !ok || len(acked) != 1 {
+		t.Fatalf("watch_incidents(acked) = %#v", res)
+	}
+	if acked[0].AckedBy != "agent" || len(acked[0].Notes) != 1 {
+		t.Errorf("acked incident = %+v", acked[0])
+	}
+
+	if _, err := s.executeTool("watch_resolve", map[string]any{"id": inc.ID}); err == nil {
+		t.Error("watch_resolve without cause or root should fail")
+	}
+	if _, err := s.executeTool("watch_resolve", map[string]any{"id": inc.ID, "cause": "bad healthcheck"}); err != nil {
+		t.Fatalf("watch_resolve: %v", err)
+	}
+	res, err = s.executeTool("watch_incidents", map[string]any{"status": "open"})
+	if err != nil {
+		t.Fatalf("watch_incidents: %v", err)
+	}
+	if open, _ := res.([]watch.Incident); len(open) != 0 {
+		t.Errorf("expected no open incidents, got %d", len(open))
+	}
+
+	if _, err := s.executeTool("watch_incidents", map[string]any{"status": "closed"}); err == nil {
+		t.Error("expected an error for an unknown status")
+	}
+}
+
+func TestWatchLifecycleCapabilities(t *testing.T) {
+	want := map[string]capabilityRisk{
+		"watch_incidents": riskRead,
+		"watch_ack":       riskWrite,
+		"watch_note":      riskWrite,
+		"watch_resolve":   riskWrite,
+	}
+	for _, c := range capabilityRegistry {
+		risk, ok := want[c.tool.Name]
+		if !ok {
+			continue
+		}
+		delete(want, c.tool.Name)
+		if c.risk != risk {
+			t.Errorf("%s risk = %q, want %q", c.tool.Name, c.risk, risk)
+		}
+		if !c.remoteSupport {
+			t.Errorf("%s should be routable to a remote server", c.tool.Name)
+		}
+	}
+	for name := range want {
+		t.Errorf("%s is not registered", name)
+	}
+}
+
+func TestIncidentFilterArgs(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	f, err := incidentFilter(map[string]any{
+		"target": "nginx", "category": "oom", "since": "2d", "flapping": true, "search": "killed",
+	}, now)
+	if err != nil {
+		t.Fatalf("incidentFilter: %v", err)
+	}
+	if f.Target != "nginx" || f.Category != "oom" || !f.Flapping || f.Search != "killed" || f.Limit != 20 {
+		t.Errorf("filter = %+v", f)
+	}
+	if want := now.Add(-48 * time.Hour); !f.Since.Equal(want) {
+		t.Errorf("Since = %v, want %v", f.Since, want)
+	}
+
+	if _, err := incidentFilter(map[string]any{"since": "yesterday"}, now); err == nil {
+		t.Error("expected an error for an invalid since")
+	}
+}
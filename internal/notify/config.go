@@ -7,30 +7,94 @@ const (
 	ChannelSlack    Channel = "slack"
 	ChannelDiscord  Channel = "discord"
 	ChannelWebhook  Channel = "webhook"
+	ChannelEmail    Channel = "email"
+	ChannelNtfy     Channel = "ntfy"
+	ChannelGotify   Channel = "gotify"
+	ChannelPushover Channel = "pushover"
 )
 
+// Channels lists every provider, in the order SendAll sends to them.
+var Channels = []Channel{
+	ChannelTelegram, ChannelSlack, ChannelDiscord, ChannelWebhook,
+	ChannelEmail, ChannelNtfy, ChannelGotify, ChannelPushover,
+}
+
 type TelegramConfig struct {
-	BotToken string `yaml:"bot_token" json:"bot_token"`
-	ChatID   string `yaml:"chat_id" json:"chat_id"`
+	BotToken  string    `yaml:"bot_token" json:"bot_token"`
+	ChatID    string    `yaml:"chat_id" json:"chat_id"`
+	Templates Templates `yaml:"templates,omitempty" json:"templates,omitempty"`
 }
 
 type SlackConfig struct {
-	WebhookURL string `yaml:"webhook_url" json:"webhook_url"`
+	WebhookURL string    `yaml:"webhook_url" json:"webhook_url"`
+	Templates  Templates `yaml:"templates,omitempty" json:"templates,omitempty"`
 }
 
 type DiscordConfig struct {
-	WebhookURL string `yaml:"webhook_url" json:"webhook_url"`
+	WebhookURL string    `yaml:"webhook_url" json:"webhook_url"`
+	Templates  Templates `yaml:"templates,omitempty" json:"templates,omitempty"`
 }
 
 type WebhookConfig struct {
 	URL string `yaml:"url" json:"url"`
 }
 
+// EmailConfig sends notifications by SMTP. Security is "starttls" (the
+// default), "tls" for implicit TLS, or "none"; Port defaults to 587, 465 or
+// 25 to match.
+type EmailConfig struct {
+	Host      string    `yaml:"host" json:"host"`
+	Port      int       `yaml:"port,omitempty" json:"port,omitempty"`
+	Security  string    `yaml:"security,omitempty" json:"security,omitempty"`
+	Username  string    `yaml:"username,omitempty" json:"username,omitempty"`
+	Password  string    `yaml:"password,omitempty" json:"-"`
+	From      string    `yaml:"from" json:"from"`
+	To        []string  `yaml:"to" json:"to"`
+	Templates Templates `yaml:"templates,omitempty" json:"templates,omitempty"`
+}
+
+// NtfyConfig publishes to an ntfy topic, e.g. https://ntfy.sh/homelab.
+// Priority (1-5) replaces the one derived from the event status; Tags are
+// added to every message.
+type NtfyConfig struct {
+	URL       string    `yaml:"url" json:"url"`
+	Token     string    `yaml:"token,omitempty" json:"-"`
+	Priority  int       `yaml:"priority,omitempty" json:"priority,omitempty"`
+	Tags      []string  `yaml:"tags,omitempty" json:"tags,omitempty"`
+	Templates Templates `yaml:"templates,omitempty" json:"templates,omitempty"`
+}
+
+// GotifyConfig posts to a Gotify server with an application token.
+// Priority (1-10) replaces the one derived from the event status.
+type GotifyConfig struct {
+	URL       string    `yaml:"url" json:"url"`
+	Token     string    `yaml:"token" json:"-"`
+	Priority  int       `yaml:"priority,omitempty" json:"priority,omitempty"`
+	Templates Templates `yaml:"templates,omitempty" json:"templates,omitempty"`
+}
+
+// PushoverConfig sends through Pushover with an application token and a
+// user or group key; Device limits delivery to one device.
+type PushoverConfig struct {
+	Token     string    `yaml:"token" json:"-"`
+	User      string    `yaml:"user" json:"user"`
+	Device    string    `yaml:"device,omitempty" json:"device,omitempty"`
+	Templates Templates `yaml:"templates,omitempty" json:"templates,omitempty"`
+}
+
 type ProviderConfig struct {
 	Telegram *TelegramConfig `yaml:"telegram,omitempty" json:"telegram,omitempty"`
 	Slack    *SlackConfig    `yaml:"slack,omitempty" json:"slack,omitempty"`
 	Discord  *DiscordConfig  `yaml:"discord,omitempty" json:"discord,omitempty"`
 	Webhook  *WebhookConfig  `yaml:"webhook,omitempty" json:"webhook,omitempty"`
+	Email    *EmailConfig    `yaml:"email,omitempty" json:"email,omitempty"`
+	Ntfy     *NtfyConfig     `yaml:"ntfy,omitempty" json:"ntfy,omitempty"`
+	Gotify   *GotifyConfig   `yaml:"gotify,omitempty" json:"gotify,omitempty"`
+	Pushover *PushoverConfig `yaml:"pushover,omitempty" json:"pushover,omitempty"`
+
+	// Templates are the message templates for every provider that has no
+	// template of its own for an event kind.
+	Templates Templates `yaml:"templates,omitempty" json:"templates,omitempty"`
 }
 
 func (pc *ProviderConfig) EnabledChannels() []Channel {
@@ -38,7 +102,7 @@ func (pc *ProviderConfig) EnabledChannels() []Channel {
 		return nil
 	}
 
-	channels := make([]Channel, 0, 4)
+	channels := make([]Channel, 0, 8)
 	if pc.Telegram != nil && pc.Telegram.BotToken != "" && pc.Telegram.ChatID != "" {
 		channels = append(channels, ChannelTelegram)
 	}
@@ -51,9 +115,22 @@ func (pc *ProviderConfig) EnabledChannels() []Channel {
 	if pc.Webhook != nil && pc.Webhook.URL != "" {
 		channels = append(channels, ChannelWebhook)
 	}
+	if pc.Email != nil && pc.Email.Host != "" && pc.Email.From != "" && len(pc.Email.To) > 0 {
+		channels = append(channels, ChannelEmail)
+	}
+	if pc.Ntfy != nil && pc.Ntfy.URL != "" {
+		channels = append(channels, ChannelNtfy)
+	}
+	if pc.Gotify != nil && pc.Gotify.URL != "" && pc.Gotify.Token != "" {
+		channels = append(channels, ChannelGotify)
+	}
+	if pc.Pushover != nil && pc.Pushover.Token != "" && pc.Pushover.User != "" {
+		channels = append(channels, ChannelPushover)
+	}
 	return channels
 }
 
 func (pc *ProviderConfig) IsEmpty() bool {
-	return pc == nil || (pc.Telegram == nil && pc.Slack == nil && pc.Discord == nil && pc.Webhook == nil)
+	return pc == nil || (pc.Telegram == nil && pc.Slack == nil && pc.Discord == nil && pc.Webhook == nil && pc.Email == nil &&
+		pc.Ntfy == nil && pc.Gotify == nil && pc.Pushover == nil)
 }
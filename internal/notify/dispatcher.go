@@ -81,6 +81,14 @@ func (d *Dispatcher) resolveProviders(event Event) *ProviderConfig {
 			filtered.Discord = d.Providers.Discord
 		case ChannelWebhook:
 			filtered.Webhook = d.Providers.Webhook
+		case ChannelEmail:
+			filtered.Email = d.Providers.Email
+		case ChannelNtfy:
+			filtered.Ntfy = d.Providers.Ntfy
+		case ChannelGotify:
+			filtered.Gotify = d.Providers.Gotify
+		case ChannelPushover:
+			filtered.Pushover = d.Providers.Pushover
 		}
 	}
 	if filtered.IsEmpty() {
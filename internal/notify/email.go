@@ -0,0 +1,280 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Email connection security.
+const (
+	EmailSTARTTLS = "starttls" // plain connection upgraded with STARTTLS (port 587)
+	EmailTLS      = "tls"      // implicit TLS from the first byte (port 465)
+	EmailNone     = "none"     // no encryption, for a relay on the local network
+)
+
+// EffectiveSecurity returns the connection security, defaulting to
+// STARTTLS.
+func (c *EmailConfig) EffectiveSecurity() string {
+	if c.Security == "" {
+		return EmailSTARTTLS
+	}
+	return c.Security
+}
+
+// EffectivePort returns the port, defaulting from the security mode.
+func (c *EmailConfig) EffectivePort() int {
+	if c.Port != 0 {
+		return c.Port
+	}
+	switch c.EffectiveSecurity() {
+	case EmailTLS:
+		return 465
+	case EmailNone:
+		return 25
+	default:
+		return 587
+	}
+}
+
+// Validate reports the first problem that would stop mail from being sent.
+func (c *EmailConfig) Validate() error {
+	switch {
+	case c.Host == "":
+		return fmt.Errorf("host is missing")
+	case c.From == "":
+		return fmt.Errorf("from is missing")
+	case len(c.To) == 0:
+		return fmt.Errorf("to has no recipients")
+	}
+	switch c.EffectiveSecurity() {
+	case EmailSTARTTLS, EmailTLS, EmailNone:
+	default:
+		return fmt.Errorf("unknown security %q (expected starttls, tls or none)", c.Security)
+	}
+	if _, err := mail.ParseAddress(c.From); err != nil {
+		return fmt.Errorf("invalid from address %q", c.From)
+	}
+	for _, to := range c.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return fmt.Errorf("invalid recipient %q", to)
+		}
+	}
+	if c.Username != "" && c.Password == "" {
+		return fmt.Errorf("username is set but password is missing")
+	}
+	return nil
+}
+
+// smtpTimeout bounds a whole delivery, from dial to QUIT.
+const smtpTimeout = 30 * time.Second
+
+// smtpTLSConfig returns the TLS settings for host; tests replace it to
+// trust their own certificate.
+var smtpTLSConfig = func(host string) *tls.Config {
+	return &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+}
+
+// sendEmail sends text as a plain-text message, or the built-in text and
+// HTML bodies when text is empty.
+func sendEmail(cfg *EmailConfig, event Event, text string) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	msg, err := buildEmail(cfg, event, text, time.Now())
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.EffectivePort()))
+	security := cfg.EffectiveSecurity()
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	var conn net.Conn
+	if security == EmailTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, smtpTLSConfig(cfg.Host))
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("connect %s: %w", addr, err)
+	}
+	conn.SetDeadline(time.Now().Add(smtpTimeout))
+
+	c, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("connect %s: %w", addr, err)
+	}
+	defer c.Close()
+
+	if security == EmailSTARTTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("%s does not offer STARTTLS; set security: tls or none", addr)
+		}
+		if err := c.StartTLS(smtpTLSConfig(cfg.Host)); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	}
+	if cfg.Username != "" {
+		// PlainAuth refuses to send the password over an unencrypted
+		// connection to anything but localhost.
+		if err := c.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)); err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+	}
+
+	from, _ := mail.ParseAddress(cfg.From)
+	if err := c.Mail(from.Address); err != nil {
+		return fmt.Errorf("MAIL FROM: %w", err)
+	}
+	for _, to := range cfg.To {
+		rcpt, _ := mail.ParseAddress(to)
+		if err := c.Rcpt(rcpt.Address); err != nil {
+			return fmt.Errorf("RCPT TO %s: %w", rcpt.Address, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("DATA: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("DATA: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("DATA: %w", err)
+	}
+	return c.Quit()
+}
+
+// emailSubject is the subject line, e.g. "[homebutler] postgres flapping".
+func emailSubject(event Event) string {
+	return fmt.Sprintf("[homebutler] %s %s", event.Name, event.Status)
+}
+
+var emailText = texttemplate.Must(texttemplate.New("text").Parse(`{{.Name}}: {{.Status}}
+{{with .Details}}
+{{.}}
+{{end}}
+{{range .Fields}}{{printf "%-12s" (print .Name ":")}} {{.Value}}
+{{end}}{{with .Action}}{{printf "%-12s" "Action:"}} {{.}}
+{{end}}{{with .Result}}{{printf "%-12s" "Result:"}} {{.}}
+{{end}}{{printf "%-12s" "Time:"}} {{.Time.Format "2006-01-02 15:04:05"}}
+{{with .Excerpt}}
+--- Log excerpt ---
+{{.}}
+{{end}}`))
+
+var emailHTML = htmltemplate.Must(htmltemplate.New("html").Parse(`<!DOCTYPE html>
+<html><body style="font-family: sans-serif; font-size: 14px;">
+<h2 style="margin: 0 0 8px;">{{.Name}} <span style="color: #c0392b;">{{.Status}}</span></h2>
+{{with .Details}}<p>{{.}}</p>
+{{end}}<table style="border-collapse: collapse;">
+{{range .Fields}}<tr><td style="padding: 2px 12px 2px 0; color: #666;">{{.Name}}</td><td>{{.Value}}</td></tr>
+{{end}}{{with .Action}}<tr><td style="padding: 2px 12px 2px 0; color: #666;">Action</td><td>{{.}}</td></tr>
+{{end}}{{with .Result}}<tr><td style="padding: 2px 12px 2px 0; color: #666;">Result</td><td>{{.}}</td></tr>
+{{end}}<tr><td style="padding: 2px 12px 2px 0; color: #666;">Time</td><td>{{.Time.Format "2006-01-02 15:04:05"}}</td></tr>
+</table>
+{{with .Excerpt}}<h3 style="margin: 16px 0 4px;">Log excerpt</h3>
+<pre style="background: #f4f4f4; padding: 8px; white-space: pre-wrap;">{{.}}</pre>
+{{end}}</body></html>
+`))
+
+// buildEmail renders event as a multipart/alternative message with a
+// plain-text and an HTML body, or as a plain-text message of templated
+// when that is set.
+func buildEmail(cfg *EmailConfig, event Event, templated string, now time.Time) ([]byte, error) {
+	// Addresses are re-encoded so that display names outside ASCII survive.
+	from, _ := mail.ParseAddress(cfg.From)
+	var to []string
+	for _, addr := range cfg.To {
+		rcpt, _ := mail.ParseAddress(addr)
+		to = append(to, rcpt.String())
+	}
+	var head bytes.Buffer
+	for _, h := range [][2]string{
+		{"From", from.String()},
+		{"To", strings.Join(to, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", emailSubject(event))},
+		{"Date", now.Format(time.RFC1123Z)},
+		{"Message-ID", messageID(from.Address)},
+		{"MIME-Version", "1.0"},
+	} {
+		fmt.Fprintf(&head, "%s: %s\r\n", h[0], h[1])
+	}
+
+	if templated != "" {
+		head.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+		head.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		qp := quotedprintable.NewWriter(&head)
+		if _, err := qp.Write([]byte(templated + "\n")); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+		return head.Bytes(), nil
+	}
+
+	var text, html bytes.Buffer
+	if err := emailText.Execute(&text, event); err != nil {
+		return nil, fmt.Errorf("render text body: %w", err)
+	}
+	if err := emailHTML.Execute(&html, event); err != nil {
+		return nil, fmt.Errorf("render html body: %w", err)
+	}
+
+	var msg bytes.Buffer
+	body := multipart.NewWriter(&msg)
+	fmt.Fprintf(&head, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", body.Boundary())
+
+	for _, part := range []struct {
+		contentType string
+		content     []byte
+	}{
+		{"text/plain; charset=utf-8", text.Bytes()},
+		{"text/html; charset=utf-8", html.Bytes()},
+	} {
+		w, err := body.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write(part.content); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+	return append(head.Bytes(), msg.Bytes()...), nil
+}
+
+func messageID(from string) string {
+	domain := "homebutler.local"
+	if _, d, ok := strings.Cut(from, "@"); ok && d != "" {
+		domain = d
+	}
+	b := make([]byte, 12)
+	rand.Read(b)
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(b), domain)
+}
@@ -0,0 +1,383 @@
+package notify
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSMTP is just enough of an SMTP server to receive one message:
+// EHLO, STARTTLS, AUTH PLAIN, MAIL, RCPT, DATA and QUIT.
+type fakeSMTP struct {
+	addr     string
+	starttls bool // advertise STARTTLS on the plain connection
+
+	mu      sync.Mutex
+	tls     bool // the session was encrypted when the message arrived
+	auth    string
+	from    string
+	rcpts   []string
+	message string
+}
+
+// selfSignedCert returns a certificate for 127.0.0.1 and a pool that
+// trusts it.
+func selfSignedCert(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "fake smtp"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, pool
+}
+
+// startFakeSMTP serves one session. With implicitTLS the listener speaks
+// TLS from the first byte; otherwise starttls decides whether the plain
+// connection can be upgraded.
+func startFakeSMTP(t *testing.T, implicitTLS, starttls bool) *fakeSMTP {
+	t.Helper()
+	cert, pool := selfSignedCert(t)
+	serverTLS := &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	orig := smtpTLSConfig
+	smtpTLSConfig = func(host string) *tls.Config {
+		return &tls.Config{ServerName: host, RootCAs: pool}
+	}
+	t.Cleanup(func() { smtpTLSConfig = orig })
+
+	var ln net.Listener
+	var err error
+	if implicitTLS {
+		ln, err = tls.Listen("tcp", "127.0.0.1:0", serverTLS)
+	} else {
+		ln, err = net.Listen("tcp", "127.0.0.1:0")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	s := &fakeSMTP{addr: ln.Addr().String(), starttls: starttls, tls: implicitTLS}
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		s.serve(conn, serverTLS)
+	}()
+	return s
+}
+
+func (s *fakeSMTP) serve(conn net.Conn, serverTLS *tls.Config) {
+	r := bufio.NewReader(conn)
+	reply := func(lines ...string) {
+		io.WriteString(conn, strings.Join(lines, "\r\n")+"\r\n")
+	}
+	reply("220 fake ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb, arg, _ := strings.Cut(line, " ")
+
+		s.mu.Lock()
+		encrypted := s.tls
+		s.mu.Unlock()
+
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			if s.starttls && !encrypted {
+				reply("250-fake", "250-STARTTLS", "250 8BITMIME")
+			} else {
+				reply("250-fake", "250-AUTH PLAIN", "250 8BITMIME")
+			}
+		case "STARTTLS":
+			reply("220 ready")
+			tlsConn := tls.Server(conn, serverTLS)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn, r = tlsConn, bufio.NewReader(tlsConn)
+			s.mu.Lock()
+			s.tls = true
+			s.mu.Unlock()
+		case "AUTH":
+			_, b64, _ := strings.Cut(arg, " ")
+			decoded, _ := base64.StdEncoding.DecodeString(b64)
+			s.mu.Lock()
+			s.auth = string(decoded)
+			s.mu.Unlock()
+			reply("235 ok")
+		case "MAIL":
+			s.mu.Lock()
+			s.from = arg
+			s.mu.Unlock()
+			reply("250 ok")
+		case "RCPT":
+			s.mu.Lock()
+			s.rcpts = append(s.rcpts, arg)
+			s.mu.Unlock()
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			var msg strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				msg.WriteString(strings.TrimPrefix(l, "."))
+			}
+			s.mu.Lock()
+			s.message = msg.String()
+			s.mu.Unlock()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 unknown command")
+		}
+	}
+}
+
+func (s *fakeSMTP) config(security string) *EmailConfig {
+	host, port, _ := net.SplitHostPort(s.addr)
+	p, _ := strconv.Atoi(port)
+	return &EmailConfig{
+		Host:     host,
+		Port:     p,
+		Security: security,
+		Username: "butler",
+		Password: "hunter2",
+		From:     "homebutler <butler@home.lan>",
+		To:       []string{"ops@home.lan", "Ada <ada@example.com>"},
+	}
+}
+
+func incidentEvent() Event {
+	return Event{
+		Kind:    "watch.flapping",
+		Source:  "watch",
+		Name:    "postgres",
+		Status:  "flapping",
+		Details: "category=oom reason=Killed by the kernel",
+		Time:    time.Date(2026, 3, 1, 4, 12, 0, 0, time.UTC),
+		Fields: []Field{
+			{Name: "Category", Value: "oom"},
+			{Name: "Flapping", Value: "acute, 4 restarts in the short window"},
+		},
+		Excerpt:  "LOG:  checkpoint starting\nFATAL:  out of memory <shared buffers>",
+		Crash:    &Crash{Category: "oom", Reason: "Killed by the kernel", Confidence: "high", ExitCode: 137, Signal: "SIGKILL"},
+		Flapping: "acute",
+	}
+}
+
+// emailParts decodes the plain-text and HTML bodies of a message.
+func emailParts(t *testing.T, raw string) (*mail.Message, map[string]string) {
+	t.Helper()
+	msg, err := mail.ReadMessage(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("parse message: %v\n%s", err, raw)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q", msg.Header.Get("Content-Type"))
+	}
+	parts := map[string]string{}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := mr.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(quotedprintable.NewReader(p))
+		ct, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		parts[ct] = string(body)
+	}
+	return msg, parts
+}
+
+func TestSendEmailSTARTTLS(t *testing.T) {
+	srv := startFakeSMTP(t, false, true)
+
+	if err := sendEmail(srv.config(EmailSTARTTLS), incidentEvent(), ""); err != nil {
+		t.Fatalf("sendEmail: %v", err)
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if !srv.tls {
+		t.Error("message was sent before STARTTLS")
+	}
+	if srv.auth != "\x00butler\x00hunter2" {
+		t.Errorf("AUTH PLAIN = %q", srv.auth)
+	}
+	if !strings.HasPrefix(srv.from, "FROM:<butler@home.lan>") {
+		t.Errorf("MAIL %s", srv.from)
+	}
+	if got := strings.Join(srv.rcpts, " "); got != "TO:<ops@home.lan> TO:<ada@example.com>" {
+		t.Errorf("RCPT %s", got)
+	}
+
+	msg, parts := emailParts(t, srv.message)
+	if got := msg.Header.Get("Subject"); got != "[homebutler] postgres flapping" {
+		t.Errorf("Subject = %q", got)
+	}
+	if got := msg.Header.Get("To"); got != `<ops@home.lan>, "Ada" <ada@example.com>` {
+		t.Errorf("To = %q", got)
+	}
+
+	text := parts["text/plain"]
+	for _, want := range []string{"postgres: flapping", "Category:    oom", "Flapping:    acute, 4 restarts", "--- Log excerpt ---", "FATAL:  out of memory <shared buffers>"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text body lacks %q:\n%s", want, text)
+		}
+	}
+	html := parts["text/html"]
+	for _, want := range []string{"<td>oom</td>", "<pre", "out of memory &lt;shared buffers&gt;"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("html body lacks %q:\n%s", want, html)
+		}
+	}
+}
+
+func TestSendEmailImplicitTLS(t *testing.T) {
+	srv := startFakeSMTP(t, true, false)
+
+	if err := sendEmail(srv.config(EmailTLS), incidentEvent(), ""); err != nil {
+		t.Fatalf("sendEmail: %v", err)
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.message == "" || srv.auth == "" {
+		t.Errorf("no authenticated message received: auth=%q", srv.auth)
+	}
+}
+
+func TestSendEmailNoSTARTTLS(t *testing.T) {
+	srv := startFakeSMTP(t, false, false)
+
+	err := sendEmail(srv.config(EmailSTARTTLS), incidentEvent(), "")
+	if err == nil || !strings.Contains(err.Error(), "does not offer STARTTLS") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestSendEmailPlainRelayWithoutAuth(t *testing.T) {
+	srv := startFakeSMTP(t, false, false)
+	cfg := srv.config(EmailNone)
+	cfg.Username, cfg.Password = "", ""
+
+	if err := sendEmail(cfg, Event{Name: "disk", Status: "triggered", Details: "disk / at 93%"}, ""); err != nil {
+		t.Fatalf("sendEmail: %v", err)
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.auth != "" || srv.tls {
+		t.Errorf("auth=%q tls=%v on a plain relay", srv.auth, srv.tls)
+	}
+	_, parts := emailParts(t, srv.message)
+	if strings.Contains(parts["text/plain"], "Log excerpt") {
+		t.Errorf("empty excerpt rendered:\n%s", parts["text/plain"])
+	}
+}
+
+func TestEmailConfigValidate(t *testing.T) {
+	valid := EmailConfig{Host: "smtp.example.com", From: "butler@home.lan", To: []string{"ops@home.lan"}}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("valid config: %v", err)
+	}
+	if valid.EffectivePort() != 587 {
+		t.Errorf("default port = %d", valid.EffectivePort())
+	}
+
+	tests := []struct {
+		mutate func(*EmailConfig)
+		want   string
+	}{
+		{func(c *EmailConfig) { c.Host = "" }, "host is missing"},
+		{func(c *EmailConfig) { c.To = nil }, "no recipients"},
+		{func(c *EmailConfig) { c.Security = "ssl" }, `unknown security "ssl"`},
+		{func(c *EmailConfig) { c.To = []string{"not an address"} }, "invalid recipient"},
+		{func(c *EmailConfig) { c.Username = "butler" }, "password is missing"},
+	}
+	for _, tt := range tests {
+		c := valid
+		tt.mutate(&c)
+		if err := c.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("error = %v, want %q", err, tt.want)
+		}
+	}
+
+	tlsCfg := EmailConfig{Security: EmailTLS}
+	if tlsCfg.EffectivePort() != 465 {
+		t.Errorf("tls port = %d", tlsCfg.EffectivePort())
+	}
+}
+
+func TestSendAllReportsEmailError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().(*net.TCPAddr)
+	ln.Close() // nothing listens there now
+
+	cfg := &ProviderConfig{Email: &EmailConfig{
+		Host: "127.0.0.1", Port: addr.Port, Security: EmailNone,
+		From: "butler@home.lan", To: []string{"ops@home.lan"},
+	}}
+	errs := SendAll(cfg, Event{Name: "x", Status: "triggered"})
+	if len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), "email: connect") {
+		t.Errorf("errs = %v", errs)
+	}
+	if got := cfg.EnabledChannels(); len(got) != 1 || got[0] != ChannelEmail {
+		t.Errorf("EnabledChannels() = %v", got)
+	}
+}
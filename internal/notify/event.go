@@ -13,4 +13,71 @@ type Event struct {
 	Time        time.Time `json:"time"`
 	Channels    []Channel `json:"channels,omitempty"`
 	Fingerprint string    `json:"fingerprint,omitempty"`
+
+	// Server is the host the event happened on; templates fall back to
+	// this host's name.
+	Server string `json:"server,omitempty"`
+
+	// Fields are labelled facts, such as the crash category and flapping
+	// level of an incident, for channels that can lay them out.
+	Fields []Field `json:"fields,omitempty"`
+
+	// Excerpt is preformatted text such as the last log lines before a
+	// crash, for channels with room for it.
+	Excerpt string `json:"excerpt,omitempty"`
+
+	// Crash is an incident's crash analysis and Flapping its flapping
+	// level, for templates; both are unset for other events.
+	Crash    *Crash `json:"crash,omitempty"`
+	Flapping string `json:"flapping,omitempty"`
+}
+
+// Crash is the crash analysis of an incident.
+type Crash struct {
+	Category   string `json:"category"`
+	Reason     string `json:"reason"`
+	Confidence string `json:"confidence"`
+	ExitCode   int    `json:"exit_code"`
+	Signal     string `json:"signal,omitempty"`
+}
+
+// Field is one labelled fact of an Event.
+type Field struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Severity is how urgent an event is, derived from its Status, for
+// channels with priority levels.
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityCritical
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityCritical:
+		return "critical"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// Severity maps the status to a severity: recoveries are info, repeated
+// or unrecovered failures are critical, and anything else that fired —
+// an alert rule, a single restart — is a warning.
+func (e Event) Severity() Severity {
+	switch e.Status {
+	case "critical", "flapping", "failing", "failed", "error", "down":
+		return SeverityCritical
+	case "ok", "resolved", "recovered", "healthy", "up":
+		return SeverityInfo
+	default:
+		return SeverityWarning
+	}
 }
@@ -28,28 +28,53 @@ func SendAll(cfg *ProviderConfig, event Event) []error {
 
 	var errs []error
 
-	if cfg.Telegram != nil && cfg.Telegram.BotToken != "" && cfg.Telegram.ChatID != "" {
-		if err := sendTelegram(cfg.Telegram, event); err != nil {
-			errs = append(errs, fmt.Errorf("telegram: %w", err))
+	// send renders ch's template for the event, if one applies, and hands
+	// the message to fn; an empty message means fn uses its built-in
+	// layout. A template that renders nothing skips the channel, and one
+	// that fails is reported but does not cost the notification.
+	send := func(ch Channel, fn func(msg string) error) {
+		msg, templated, err := cfg.Render(ch, event)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: template: %w", ch, err))
+			msg = ""
+		} else if templated && msg == "" {
+			return
+		}
+		if err := fn(msg); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ch, err))
 		}
 	}
 
+	if cfg.Telegram != nil && cfg.Telegram.BotToken != "" && cfg.Telegram.ChatID != "" {
+		send(ChannelTelegram, func(msg string) error { return sendTelegram(cfg.Telegram, event, msg) })
+	}
+
 	if cfg.Slack != nil && cfg.Slack.WebhookURL != "" {
-		if err := sendSlack(cfg.Slack, event); err != nil {
-			errs = append(errs, fmt.Errorf("slack: %w", err))
-		}
+		send(ChannelSlack, func(msg string) error { return sendSlack(cfg.Slack, event, msg) })
 	}
 
 	if cfg.Discord != nil && cfg.Discord.WebhookURL != "" {
-		if err := sendDiscord(cfg.Discord, event); err != nil {
-			errs = append(errs, fmt.Errorf("discord: %w", err))
-		}
+		send(ChannelDiscord, func(msg string) error { return sendDiscord(cfg.Discord, event, msg) })
 	}
 
 	if cfg.Webhook != nil && cfg.Webhook.URL != "" {
-		if err := sendWebhook(cfg.Webhook, event); err != nil {
-			errs = append(errs, fmt.Errorf("webhook: %w", err))
-		}
+		send(ChannelWebhook, func(string) error { return sendWebhook(cfg.Webhook, event) })
+	}
+
+	if cfg.Email != nil && cfg.Email.Host != "" && cfg.Email.From != "" && len(cfg.Email.To) > 0 {
+		send(ChannelEmail, func(msg string) error { return sendEmail(cfg.Email, event, msg) })
+	}
+
+	if cfg.Ntfy != nil && cfg.Ntfy.URL != "" {
+		send(ChannelNtfy, func(msg string) error { return sendNtfy(cfg.Ntfy, event, msg) })
+	}
+
+	if cfg.Gotify != nil && cfg.Gotify.URL != "" && cfg.Gotify.Token != "" {
+		send(ChannelGotify, func(msg string) error { return sendGotify(cfg.Gotify, event, msg) })
+	}
+
+	if cfg.Pushover != nil && cfg.Pushover.Token != "" && cfg.Pushover.User != "" {
+		send(ChannelPushover, func(msg string) error { return sendPushover(cfg.Pushover, event, msg) })
 	}
 
 	return errs
@@ -69,13 +94,17 @@ func buildTelegramText(event Event) string {
 	)
 }
 
-func sendTelegram(cfg *TelegramConfig, event Event) error {
-	text := buildTelegramText(event)
-
+// sendTelegram sends msg, or the built-in layout when msg is empty.
+// Templated messages go out as plain text, since event values are not
+// HTML-escaped.
+func sendTelegram(cfg *TelegramConfig, event Event, msg string) error {
 	body := map[string]string{
-		"chat_id":    cfg.ChatID,
-		"text":       text,
-		"parse_mode": "HTML",
+		"chat_id": cfg.ChatID,
+		"text":    msg,
+	}
+	if msg == "" {
+		body["text"] = buildTelegramText(event)
+		body["parse_mode"] = "HTML"
 	}
 
 	return postJSON(
@@ -84,7 +113,23 @@ func sendTelegram(cfg *TelegramConfig, event Event) error {
 	)
 }
 
-func sendSlack(cfg *SlackConfig, event Event) error {
+func buildSlackText(event Event) string {
+	return fmt.Sprintf(
+		"*%s* %s\n%s\n> Action: %s | Result: %s\n_%s_",
+		event.Name, event.Status,
+		event.Details,
+		event.Action, event.Result,
+		event.Time.Format("2006-01-02 15:04:05"),
+	)
+}
+
+// sendSlack sends msg as a plain message, or the built-in attachment when
+// msg is empty.
+func sendSlack(cfg *SlackConfig, event Event, msg string) error {
+	if msg != "" {
+		return postJSON(cfg.WebhookURL, map[string]string{"text": msg})
+	}
+
 	color := "#ff0000"
 	if event.Result == "success" {
 		color = "#36a64f"
@@ -99,13 +144,7 @@ func sendSlack(cfg *SlackConfig, event Event) error {
 						"type": "section",
 						"text": map[string]string{
 							"type": "mrkdwn",
-							"text": fmt.Sprintf(
-								"*%s* %s\n%s\n> Action: %s | Result: %s\n_%s_",
-								event.Name, event.Status,
-								event.Details,
-								event.Action, event.Result,
-								event.Time.Format("2006-01-02 15:04:05"),
-							),
+							"text": buildSlackText(event),
 						},
 					},
 				},
@@ -116,7 +155,13 @@ func sendSlack(cfg *SlackConfig, event Event) error {
 	return postJSON(cfg.WebhookURL, payload)
 }
 
-func sendDiscord(cfg *DiscordConfig, event Event) error {
+// sendDiscord sends msg as a plain message, or the built-in embed when msg
+// is empty.
+func sendDiscord(cfg *DiscordConfig, event Event, msg string) error {
+	if msg != "" {
+		return postJSON(cfg.WebhookURL, map[string]string{"content": msg})
+	}
+
 	color := 0xff0000
 	if event.Result == "success" {
 		color = 0x36a64f
@@ -143,7 +188,11 @@ func sendDiscord(cfg *DiscordConfig, event Event) error {
 }
 
 func sendWebhook(cfg *WebhookConfig, event Event) error {
-	payload := WebhookPayload{
+	return postJSON(cfg.URL, buildWebhookPayload(event))
+}
+
+func buildWebhookPayload(event Event) WebhookPayload {
+	return WebhookPayload{
 		Source:       event.Source,
 		Name:         event.Name,
 		Status:       event.Status,
@@ -152,8 +201,6 @@ func sendWebhook(cfg *WebhookConfig, event Event) error {
 		ActionResult: event.Result,
 		Timestamp:    event.Time.Format("2006-01-02 15:04:05"),
 	}
-
-	return postJSON(cfg.URL, payload)
 }
 
 func postJSON(url string, payload interface{}) error {
@@ -162,7 +209,17 @@ func postJSON(url string, payload interface{}) error {
 		return fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	resp, err := httpClient.Post(url, "application/json", bytes.NewReader(body))
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return doRequest(req)
+}
+
+// doRequest sends req and treats any 4xx or 5xx status as an error.
+func doRequest(req *http.Request) error {
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
 	}
@@ -71,6 +71,10 @@ func TestIsEmpty(t *testing.T) {
 		{"slack set", ProviderConfig{Slack: &SlackConfig{}}, false},
 		{"discord set", ProviderConfig{Discord: &DiscordConfig{}}, false},
 		{"webhook set", ProviderConfig{Webhook: &WebhookConfig{}}, false},
+		{"email set", ProviderConfig{Email: &EmailConfig{}}, false},
+		{"ntfy set", ProviderConfig{Ntfy: &NtfyConfig{}}, false},
+		{"gotify set", ProviderConfig{Gotify: &GotifyConfig{}}, false},
+		{"pushover set", ProviderConfig{Pushover: &PushoverConfig{}}, false},
 	}
 
 	for _, tt := range tests {
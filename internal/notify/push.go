@@ -0,0 +1,144 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Message size limits of the push services; longer messages are cut.
+const (
+	ntfyMaxMessage     = 4096
+	pushoverMaxMessage = 1024
+)
+
+// pushoverURL is the Pushover message API; tests point it at a local server.
+var pushoverURL = "https://api.pushover.net/1/messages.json"
+
+// Priorities by severity for each push service.
+var (
+	ntfyPriority     = map[Severity]int{SeverityInfo: 3, SeverityWarning: 4, SeverityCritical: 5}
+	gotifyPriority   = map[Severity]int{SeverityInfo: 4, SeverityWarning: 6, SeverityCritical: 8}
+	pushoverPriority = map[Severity]int{SeverityInfo: -1, SeverityWarning: 0, SeverityCritical: 1}
+)
+
+// ntfyTags are the emoji shown in front of an ntfy message.
+var ntfyTags = map[Severity]string{
+	SeverityInfo:     "white_check_mark",
+	SeverityWarning:  "warning",
+	SeverityCritical: "rotating_light",
+}
+
+func pushTitle(event Event) string {
+	return fmt.Sprintf("%s %s", event.Name, event.Status)
+}
+
+// pushText is the short plain-text body for push services: the details,
+// the event's fields, and the action taken, without the log excerpt.
+func pushText(event Event, limit int) string {
+	var lines []string
+	if event.Details != "" {
+		lines = append(lines, event.Details)
+	}
+	for _, f := range event.Fields {
+		lines = append(lines, f.Name+": "+f.Value)
+	}
+	if event.Action != "" || event.Result != "" {
+		lines = append(lines, fmt.Sprintf("Action: %s → Result: %s", event.Action, event.Result))
+	}
+	text := strings.Join(lines, "\n")
+	if text == "" {
+		text = pushTitle(event)
+	}
+	if r := []rune(text); len(r) > limit {
+		text = string(r[:limit-1]) + "…"
+	}
+	return text
+}
+
+// pushBody is msg, or the built-in body when msg is empty, cut to limit.
+func pushBody(event Event, msg string, limit int) string {
+	if msg == "" {
+		return pushText(event, limit)
+	}
+	if r := []rune(msg); len(r) > limit {
+		msg = string(r[:limit-1]) + "…"
+	}
+	return msg
+}
+
+func sendNtfy(cfg *NtfyConfig, event Event, msg string) error {
+	sev := event.Severity()
+	priority := ntfyPriority[sev]
+	if cfg.Priority != 0 {
+		priority = cfg.Priority
+	}
+	tags := append([]string{ntfyTags[sev]}, cfg.Tags...)
+
+	req, err := http.NewRequest(http.MethodPost, cfg.URL, strings.NewReader(pushBody(event, msg, ntfyMaxMessage)))
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	// Headers must be ASCII; ntfy decodes RFC 2047 encoded words.
+	req.Header.Set("Title", mime.QEncoding.Encode("utf-8", pushTitle(event)))
+	req.Header.Set("Priority", strconv.Itoa(priority))
+	req.Header.Set("Tags", strings.Join(tags, ","))
+	if cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+cfg.Token)
+	}
+	return doRequest(req)
+}
+
+func sendGotify(cfg *GotifyConfig, event Event, msg string) error {
+	priority := gotifyPriority[event.Severity()]
+	if cfg.Priority != 0 {
+		priority = cfg.Priority
+	}
+	payload := map[string]any{
+		"title":    pushTitle(event),
+		"message":  pushBody(event, msg, 1<<16),
+		"priority": priority,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	// The token goes in a header rather than the query string, so it does
+	// not show up in request errors.
+	req, err := http.NewRequest(http.MethodPost, strings.TrimRight(cfg.URL, "/")+"/message", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", cfg.Token)
+	return doRequest(req)
+}
+
+func sendPushover(cfg *PushoverConfig, event Event, msg string) error {
+	form := url.Values{
+		"token":    {cfg.Token},
+		"user":     {cfg.User},
+		"title":    {pushTitle(event)},
+		"message":  {pushBody(event, msg, pushoverMaxMessage)},
+		"priority": {strconv.Itoa(pushoverPriority[event.Severity()])},
+	}
+	if cfg.Device != "" {
+		form.Set("device", cfg.Device)
+	}
+	if !event.Time.IsZero() {
+		form.Set("timestamp", strconv.FormatInt(event.Time.Unix(), 10))
+	}
+
+	req, err := http.NewRequest(http.MethodPost, pushoverURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return doRequest(req)
+}
@@ -0,0 +1,152 @@
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// capture records the last request a test server received.
+type capture struct {
+	req  *http.Request
+	body string
+}
+
+func captureServer(t *testing.T, status int) (*httptest.Server, *capture) {
+	t.Helper()
+	c := &capture{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		c.req, c.body = r, string(b)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, c
+}
+
+func TestEventSeverity(t *testing.T) {
+	tests := map[string]Severity{
+		"flapping":  SeverityCritical,
+		"failing":   SeverityCritical,
+		"critical":  SeverityCritical,
+		"triggered": SeverityWarning,
+		"restart":   SeverityWarning,
+		"warning":   SeverityWarning,
+		"":          SeverityWarning,
+		"ok":        SeverityInfo,
+		"resolved":  SeverityInfo,
+	}
+	for status, want := range tests {
+		if got := (Event{Status: status}).Severity(); got != want {
+			t.Errorf("Severity(%q) = %s, want %s", status, got, want)
+		}
+	}
+}
+
+func TestSendNtfy(t *testing.T) {
+	srv, got := captureServer(t, http.StatusOK)
+	cfg := &NtfyConfig{URL: srv.URL + "/homelab", Token: "tk_secret", Tags: []string{"homelab"}}
+
+	if err := sendNtfy(cfg, incidentEvent(), ""); err != nil {
+		t.Fatalf("sendNtfy: %v", err)
+	}
+	if got.req.URL.Path != "/homelab" {
+		t.Errorf("path = %s", got.req.URL.Path)
+	}
+	h := got.req.Header
+	if h.Get("Title") != "postgres flapping" || h.Get("Priority") != "5" || h.Get("Tags") != "rotating_light,homelab" {
+		t.Errorf("headers = Title %q, Priority %q, Tags %q", h.Get("Title"), h.Get("Priority"), h.Get("Tags"))
+	}
+	if h.Get("Authorization") != "Bearer tk_secret" {
+		t.Errorf("Authorization = %q", h.Get("Authorization"))
+	}
+	if !strings.Contains(got.body, "Flapping: acute, 4 restarts") || strings.Contains(got.body, "checkpoint") {
+		t.Errorf("body should carry fields but not the log excerpt:\n%s", got.body)
+	}
+
+	// A configured priority replaces the mapped one.
+	cfg.Priority = 2
+	if err := sendNtfy(cfg, Event{Name: "cpu", Status: "triggered"}, ""); err != nil {
+		t.Fatal(err)
+	}
+	if p := got.req.Header.Get("Priority"); p != "2" {
+		t.Errorf("Priority = %q, want 2", p)
+	}
+}
+
+func TestSendGotify(t *testing.T) {
+	srv, got := captureServer(t, http.StatusOK)
+	cfg := &GotifyConfig{URL: srv.URL + "/", Token: "app-token"}
+
+	if err := sendGotify(cfg, Event{Name: "container:nginx", Status: "ok", Details: "recovered"}, ""); err != nil {
+		t.Fatalf("sendGotify: %v", err)
+	}
+	if got.req.URL.Path != "/message" || got.req.URL.RawQuery != "" {
+		t.Errorf("url = %s", got.req.URL)
+	}
+	if got.req.Header.Get("X-Gotify-Key") != "app-token" {
+		t.Errorf("X-Gotify-Key = %q", got.req.Header.Get("X-Gotify-Key"))
+	}
+	var msg struct {
+		Title    string `json:"title"`
+		Message  string `json:"message"`
+		Priority int    `json:"priority"`
+	}
+	if err := json.Unmarshal([]byte(got.body), &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Title != "container:nginx ok" || msg.Message != "recovered" || msg.Priority != 4 {
+		t.Errorf("message = %+v", msg)
+	}
+}
+
+func TestSendPushover(t *testing.T) {
+	srv, got := captureServer(t, http.StatusOK)
+	orig := pushoverURL
+	pushoverURL = srv.URL
+	t.Cleanup(func() { pushoverURL = orig })
+
+	ev := incidentEvent()
+	ev.Details = strings.Repeat("x", 2000)
+	cfg := &PushoverConfig{Token: "app", User: "user-key", Device: "phone"}
+	if err := sendPushover(cfg, ev, ""); err != nil {
+		t.Fatalf("sendPushover: %v", err)
+	}
+	form, err := url.ParseQuery(got.body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if form.Get("token") != "app" || form.Get("user") != "user-key" || form.Get("device") != "phone" {
+		t.Errorf("form = %v", form)
+	}
+	if form.Get("priority") != "1" {
+		t.Errorf("priority = %q, want 1 for flapping", form.Get("priority"))
+	}
+	if n := len([]rune(form.Get("message"))); n != pushoverMaxMessage {
+		t.Errorf("message length = %d, want %d", n, pushoverMaxMessage)
+	}
+	if form.Get("timestamp") != "1772338320" {
+		t.Errorf("timestamp = %q", form.Get("timestamp"))
+	}
+}
+
+func TestSendAllPushErrors(t *testing.T) {
+	srv, _ := captureServer(t, http.StatusUnauthorized)
+	cfg := &ProviderConfig{
+		Ntfy:   &NtfyConfig{URL: srv.URL + "/topic"},
+		Gotify: &GotifyConfig{URL: srv.URL, Token: "wrong"},
+	}
+	errs := SendAll(cfg, Event{Name: "x", Status: "triggered", Time: time.Now()})
+	if len(errs) != 2 || errs[0].Error() != "ntfy: returned status 401" || errs[1].Error() != "gotify: returned status 401" {
+		t.Errorf("errs = %v", errs)
+	}
+	want := []Channel{ChannelNtfy, ChannelGotify}
+	if got := cfg.EnabledChannels(); len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("EnabledChannels() = %v", got)
+	}
+}
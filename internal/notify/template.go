@@ -0,0 +1,241 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Event kinds a message template can be written for.
+const (
+	KindIncident = "incident"
+	KindFlapping = "flapping"
+	KindAlert    = "alert"
+	KindDoctor   = "doctor"
+	KindBackup   = "backup"
+)
+
+// TemplateKinds lists the event kinds in the order they are documented.
+var TemplateKinds = []string{KindIncident, KindFlapping, KindAlert, KindDoctor, KindBackup}
+
+// templateDefault is the Templates key used for kinds without their own.
+const templateDefault = "default"
+
+// Templates are text/template message templates keyed by event kind, with
+// "default" for any kind that has none of its own.
+type Templates map[string]string
+
+// Validate checks that every key is a known kind and that every template
+// parses and renders against an empty event.
+func (t Templates) Validate() error {
+	keys := make([]string, 0, len(t))
+	for k := range t {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if k != templateDefault && !validKind(k) {
+			return fmt.Errorf("unknown kind %q (expected %s or default)", k, strings.Join(TemplateKinds, ", "))
+		}
+		tmpl, err := parseTemplate(k, t[k])
+		if err != nil {
+			return err
+		}
+		if err := tmpl.Execute(&bytes.Buffer{}, TemplateData{Event: Event{Time: time.Now()}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validKind(kind string) bool {
+	for _, k := range TemplateKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// TemplateKind is the kind an event's templates are chosen by: the last
+// part of Kind, so "watch.flapping" is "flapping".
+func (e Event) TemplateKind() string {
+	kind := e.Kind
+	if i := strings.LastIndex(kind, "."); i >= 0 {
+		kind = kind[i+1:]
+	}
+	return kind
+}
+
+// TemplateData is what a message template is executed with: the event's
+// own fields (.Name, .Status, .Details, .Fields, .Excerpt for the log tail,
+// .Flapping for the flapping level, ...) and a few derived from them. The
+// crash analysis is flattened so templates need no nil checks; its fields
+// are empty for events without one.
+type TemplateData struct {
+	Event
+	Kind     string // incident, flapping, alert, doctor or backup
+	Severity string // info, warning or critical
+	Provider string // the channel the message is rendered for
+	Server   string // the event's server, or this host's name
+
+	Category   string // crash category, such as oom or panic
+	Reason     string // why the crash was put in that category
+	Confidence string // high, medium or low
+	ExitCode   int
+	Signal     string
+}
+
+var templateFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"join":  func(sep string, s []string) string { return strings.Join(s, sep) },
+	// field returns the value of the named field, or "" when it is absent.
+	"field": func(name string, fields []Field) string {
+		for _, f := range fields {
+			if f.Name == name {
+				return f.Value
+			}
+		}
+		return ""
+	},
+	// truncate cuts s to n runes, ending with "…" when it was longer.
+	"truncate": func(n int, s string) string {
+		if r := []rune(s); n > 0 && len(r) > n {
+			return string(r[:n-1]) + "…"
+		}
+		return s
+	},
+}
+
+func parseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs).Parse(text)
+}
+
+// template returns the template that applies to event on a provider with
+// its own templates: its template for the kind, then its default, then
+// the shared ones under notify.templates.
+func (pc *ProviderConfig) template(own Templates, event Event) (name, text string, ok bool) {
+	kind := event.TemplateKind()
+	for _, set := range []Templates{own, pc.Templates} {
+		for _, key := range []string{kind, templateDefault} {
+			if text, ok := set[key]; ok && key != "" {
+				return key, text, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// Render renders the message template for event on channel ch. ok is false
+// when no template applies and the provider's built-in layout is used.
+func (pc *ProviderConfig) Render(ch Channel, event Event) (msg string, ok bool, err error) {
+	if pc == nil || ch == ChannelWebhook {
+		return "", false, nil
+	}
+	name, text, ok := pc.template(pc.ProviderTemplates(ch), event)
+	if !ok {
+		return "", false, nil
+	}
+	tmpl, err := parseTemplate(name, text)
+	if err != nil {
+		return "", true, err
+	}
+	data := TemplateData{
+		Event:    event,
+		Kind:     event.TemplateKind(),
+		Severity: event.Severity().String(),
+		Provider: string(ch),
+		Server:   event.Server,
+	}
+	if data.Server == "" {
+		data.Server, _ = os.Hostname()
+	}
+	if c := event.Crash; c != nil {
+		data.Category, data.Reason, data.Confidence = c.Category, c.Reason, c.Confidence
+		data.ExitCode, data.Signal = c.ExitCode, c.Signal
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", true, err
+	}
+	return strings.TrimSpace(buf.String()), true, nil
+}
+
+// Preview returns the message ch would send for event, as text: the
+// template's output, or the built-in layout. templated reports which; an
+// empty templated message means the template skips the event.
+func (pc *ProviderConfig) Preview(ch Channel, event Event) (msg string, templated bool, err error) {
+	msg, templated, err = pc.Render(ch, event)
+	if templated || err != nil {
+		return msg, templated, err
+	}
+	switch ch {
+	case ChannelTelegram:
+		msg = buildTelegramText(event)
+	case ChannelSlack:
+		msg = buildSlackText(event)
+	case ChannelDiscord:
+		msg = fmt.Sprintf("%s %s\n%s\nAction: %s | Result: %s\n%s",
+			event.Name, event.Status, event.Details, event.Action, event.Result,
+			event.Time.Format("2006-01-02 15:04:05"))
+	case ChannelWebhook:
+		b, err := json.MarshalIndent(buildWebhookPayload(event), "", "  ")
+		if err != nil {
+			return "", false, err
+		}
+		msg = string(b)
+	case ChannelEmail:
+		var buf bytes.Buffer
+		if err := emailText.Execute(&buf, event); err != nil {
+			return "", false, err
+		}
+		msg = "Subject: " + emailSubject(event) + "\n\n" + strings.TrimSpace(buf.String())
+	case ChannelNtfy, ChannelGotify, ChannelPushover:
+		msg = pushTitle(event) + "\n" + pushText(event, ntfyMaxMessage)
+	default:
+		return "", false, fmt.Errorf("unknown provider %q", ch)
+	}
+	return msg, false, nil
+}
+
+// ProviderTemplates returns the templates configured on the provider for
+// ch; webhook has none, since its payload is fixed JSON.
+func (pc *ProviderConfig) ProviderTemplates(ch Channel) Templates {
+	switch ch {
+	case ChannelTelegram:
+		if pc.Telegram != nil {
+			return pc.Telegram.Templates
+		}
+	case ChannelSlack:
+		if pc.Slack != nil {
+			return pc.Slack.Templates
+		}
+	case ChannelDiscord:
+		if pc.Discord != nil {
+			return pc.Discord.Templates
+		}
+	case ChannelEmail:
+		if pc.Email != nil {
+			return pc.Email.Templates
+		}
+	case ChannelNtfy:
+		if pc.Ntfy != nil {
+			return pc.Ntfy.Templates
+		}
+	case ChannelGotify:
+		if pc.Gotify != nil {
+			return pc.Gotify.Templates
+		}
+	case ChannelPushover:
+		if pc.Pushover != nil {
+			return pc.Pushover.Templates
+		}
+	}
+	return nil
+}
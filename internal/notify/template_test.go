@@ -0,0 +1,169 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderTemplatePrecedence(t *testing.T) {
+	pc := &ProviderConfig{
+		Templates: Templates{
+			"flapping": "shared flapping",
+			"default":  "shared default",
+		},
+		Slack: &SlackConfig{WebhookURL: "https://hooks.example", Templates: Templates{
+			"incident": "slack incident",
+			"default":  "slack default",
+		}},
+		Telegram: &TelegramConfig{BotToken: "t", ChatID: "c"},
+	}
+
+	tests := []struct {
+		ch   Channel
+		kind string
+		want string
+	}{
+		{ChannelSlack, "watch.incident", "slack incident"},
+		{ChannelSlack, "watch.flapping", "slack default"},
+		{ChannelTelegram, "watch.flapping", "shared flapping"},
+		{ChannelTelegram, "alert", "shared default"},
+	}
+	for _, tt := range tests {
+		msg, ok, err := pc.Render(tt.ch, Event{Kind: tt.kind})
+		if err != nil || !ok || msg != tt.want {
+			t.Errorf("Render(%s, %s) = %q, %v, %v; want %q", tt.ch, tt.kind, msg, ok, err, tt.want)
+		}
+	}
+
+	if _, ok, _ := pc.Render(ChannelWebhook, Event{Kind: "alert"}); ok {
+		t.Error("webhook payloads are fixed JSON and take no template")
+	}
+	if _, ok, _ := (&ProviderConfig{}).Render(ChannelSlack, Event{}); ok {
+		t.Error("no templates should leave the built-in layout")
+	}
+}
+
+func TestRenderTemplateData(t *testing.T) {
+	pc := &ProviderConfig{Templates: Templates{"default": `{{.Kind}}/{{.Severity}}/{{.Provider}}/{{.Server}} ` +
+		`{{upper .Name}} {{field "Category" .Fields}} {{truncate 6 .Excerpt}} {{.Time.Format "15:04"}}`}}
+	ev := incidentEvent()
+	ev.Server = "nas"
+
+	msg, _, err := pc.Render(ChannelNtfy, ev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "flapping/critical/ntfy/nas POSTGRES oom LOG: … 04:12"; msg != want {
+		t.Errorf("msg = %q, want %q", msg, want)
+	}
+}
+
+func TestRenderTemplateCrashFields(t *testing.T) {
+	pc := &ProviderConfig{Templates: Templates{"default": `{{.Category}}/{{.Confidence}}/{{.Flapping}}/{{.ExitCode}}/{{.Signal}}: {{.Reason}}` +
+		`{{if and (eq .Category "oom") (eq .Confidence "high")}} (confirmed){{end}}`}}
+
+	msg, _, err := pc.Render(ChannelSlack, incidentEvent())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "oom/high/acute/137/SIGKILL: Killed by the kernel (confirmed)"; msg != want {
+		t.Errorf("msg = %q, want %q", msg, want)
+	}
+
+	// Events without a crash analysis render the fields empty rather than
+	// failing on a nil pointer.
+	msg, _, err = pc.Render(ChannelSlack, Event{Kind: KindAlert, Name: "disk-full"})
+	if err != nil || msg != "///0/:" {
+		t.Errorf("alert msg = %q, %v", msg, err)
+	}
+}
+
+func TestTemplatesValidate(t *testing.T) {
+	tests := []struct {
+		templates Templates
+		want      string
+	}{
+		{Templates{"incident": "{{.Name}}", "default": "{{.Status}}"}, ""},
+		{Templates{"restart": "{{.Name}}"}, `unknown kind "restart"`},
+		{Templates{"alert": "{{.Name"}, "unclosed action"},
+		{Templates{"alert": "{{.Hostname}}"}, "can't evaluate field Hostname"},
+		{Templates{"alert": "{{shout .Name}}"}, `function "shout" not defined`},
+	}
+	for _, tt := range tests {
+		err := tt.templates.Validate()
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("%v: %v", tt.templates, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%v: error = %v, want %q", tt.templates, err, tt.want)
+		}
+	}
+}
+
+func TestSendAllTemplates(t *testing.T) {
+	srv, got := captureServer(t, http.StatusOK)
+	cfg := &ProviderConfig{Slack: &SlackConfig{WebhookURL: srv.URL, Templates: Templates{
+		"default": `{{if ne .Severity "info"}}{{.Name}} {{.Status}}{{end}}`,
+	}}}
+
+	if errs := SendAll(cfg, Event{Name: "cpu", Status: "triggered"}); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(got.body), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload["text"] != "cpu triggered" || payload["attachments"] != nil {
+		t.Errorf("payload = %v, want a plain one-line message", payload)
+	}
+
+	// The template renders nothing for recoveries, so nothing is sent.
+	got.req = nil
+	if errs := SendAll(cfg, Event{Name: "cpu", Status: "ok"}); len(errs) != 0 || got.req != nil {
+		t.Errorf("errs = %v, sent = %v; want the event skipped", errs, got.req != nil)
+	}
+
+	// A broken template is reported, and the built-in layout still goes out.
+	cfg.Slack.Templates = Templates{"default": "{{.Nope}}"}
+	errs := SendAll(cfg, Event{Name: "cpu", Status: "triggered", Time: time.Now()})
+	if len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), "slack: template:") {
+		t.Errorf("errs = %v", errs)
+	}
+	if !strings.Contains(got.body, "attachments") {
+		t.Errorf("body = %s, want the built-in layout", got.body)
+	}
+}
+
+func TestBuildEmailTemplated(t *testing.T) {
+	cfg := &EmailConfig{Host: "smtp.example.com", From: "butler@home.lan", To: []string{"ops@home.lan"}}
+	raw, err := buildEmail(cfg, incidentEvent(), "postgres is flapping", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(raw)
+	if !strings.Contains(msg, "Content-Type: text/plain; charset=utf-8") || strings.Contains(msg, "multipart") {
+		t.Errorf("templated email should be plain text only:\n%s", msg)
+	}
+	if !strings.HasSuffix(msg, "\r\n\r\npostgres is flapping\r\n") {
+		t.Errorf("body:\n%q", msg)
+	}
+}
+
+func TestPreviewBuiltInLayouts(t *testing.T) {
+	ev := incidentEvent()
+	for _, ch := range Channels {
+		msg, templated, err := (*ProviderConfig)(nil).Preview(ch, ev)
+		if err != nil || templated || !strings.Contains(msg, "postgres") {
+			t.Errorf("Preview(%s) = %q, %v, %v", ch, msg, templated, err)
+		}
+	}
+	if _, _, err := (&ProviderConfig{}).Preview("pager", ev); err == nil {
+		t.Error("unknown provider should be an error")
+	}
+}
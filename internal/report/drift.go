@@ -0,0 +1,229 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Higangssh/homebutler/internal/docker"
+	"github.com/Higangssh/homebutler/internal/inventory"
+	"github.com/Higangssh/homebutler/internal/ports"
+)
+
+// applyDrift adds per-container changes between prev and snap: containers
+// that appeared, vanished or changed state, public ports and who owns them,
+// and configuration drift such as an image replaced under the same tag.
+//
+// Configuration is only compared for containers inspected in both
+// snapshots, so snapshots taken before details were recorded, or on a host
+// where inspect failed, report nothing rather than a wall of false changes.
+func applyDrift(r *Report, prev, snap *Snapshot) {
+	before := containersByName(prev.Containers)
+	after := containersByName(snap.Containers)
+
+	for _, name := range sortedKeys(after) {
+		c := after[name]
+		old, ok := before[name]
+		switch {
+		case !ok:
+			r.NotableChanges = append(r.NotableChanges,
+				fmt.Sprintf("New container %s (%s)", name, c.Image))
+		case old.State != c.State && (old.State == "running" || c.State == "running"):
+			r.NotableChanges = append(r.NotableChanges,
+				fmt.Sprintf("%s: %s → %s", name, old.State, c.State))
+		}
+	}
+	for _, name := range sortedKeys(before) {
+		if _, ok := after[name]; !ok {
+			r.NotableChanges = append(r.NotableChanges, fmt.Sprintf("Container %s was removed", name))
+		}
+	}
+
+	applyPortOwnership(r, prev, snap)
+
+	oldDetails := detailsByName(prev.ContainerDetails)
+	for _, d := range snap.ContainerDetails {
+		old, ok := oldDetails[d.Name]
+		if !ok {
+			continue
+		}
+		changes, silentUpdate := configDrift(old, d)
+		r.NotableChanges = append(r.NotableChanges, changes...)
+		if silentUpdate {
+			r.SuggestedActions = append(r.SuggestedActions,
+				fmt.Sprintf("%s was updated in place (same tag, new image) — if it misbehaves, check 'homebutler docker logs %s'.", d.Name, d.Name))
+		}
+	}
+}
+
+// configDrift describes how one container's configuration changed. The
+// second result is true when the image changed but the reference it was
+// created from did not — the signature of Watchtower or a `pull && up -d`.
+func configDrift(old, cur docker.ContainerDetails) ([]string, bool) {
+	var changes []string
+	name := cur.Name
+	silent := false
+
+	switch {
+	case old.Image != cur.Image:
+		changes = append(changes, fmt.Sprintf("Image for %s changed from %s to %s", name, old.Image, cur.Image))
+	case old.ImageID != cur.ImageID && old.ImageID != "" && cur.ImageID != "":
+		changes = append(changes, fmt.Sprintf("Image for %s changed from %s to %s", name, shortDigest(old.ImageID), shortDigest(cur.ImageID)))
+		silent = true
+	}
+
+	if restartPolicyName(old.RestartPolicy) != restartPolicyName(cur.RestartPolicy) {
+		changes = append(changes, fmt.Sprintf("Restart policy for %s changed from %s to %s",
+			name, restartPolicyName(old.RestartPolicy), restartPolicyName(cur.RestartPolicy)))
+	}
+
+	added, removed := diffStrings(old.EnvKeys, cur.EnvKeys)
+	if len(added) > 0 {
+		changes = append(changes, fmt.Sprintf("%s gained env var(s) %s", name, strings.Join(added, ", ")))
+	}
+	if len(removed) > 0 {
+		changes = append(changes, fmt.Sprintf("%s lost env var(s) %s", name, strings.Join(removed, ", ")))
+	}
+
+	oldMounts := mountsByDestination(old.Mounts)
+	curMounts := mountsByDestination(cur.Mounts)
+	for _, m := range old.Mounts {
+		now, ok := curMounts[m.Destination]
+		switch {
+		case !ok:
+			changes = append(changes, fmt.Sprintf("%s lost its %s mount", name, m.Destination))
+		case now.Source != m.Source:
+			changes = append(changes, fmt.Sprintf("%s's %s mount now comes from %s (was %s)", name, m.Destination, now.Source, m.Source))
+		}
+	}
+	for _, m := range cur.Mounts {
+		if _, ok := oldMounts[m.Destination]; !ok {
+			changes = append(changes, fmt.Sprintf("%s gained a %s mount from %s", name, m.Destination, m.Source))
+		}
+	}
+
+	added, removed = diffStrings(old.PublishedPorts, cur.PublishedPorts)
+	for _, p := range added {
+		changes = append(changes, fmt.Sprintf("%s now publishes %s", name, p))
+	}
+	for _, p := range removed {
+		changes = append(changes, fmt.Sprintf("%s no longer publishes %s", name, p))
+	}
+
+	return changes, silent
+}
+
+// applyPortOwnership names the public ports that opened or closed, along
+// with the container or process that owns them.
+func applyPortOwnership(r *Report, prev, snap *Snapshot) {
+	before := publicPorts(prev)
+	after := publicPorts(snap)
+	for _, port := range sortedKeys(after) {
+		if _, ok := before[port]; !ok {
+			r.NotableChanges = append(r.NotableChanges,
+				fmt.Sprintf("Public port %s opened by %s", port, after[port]))
+		}
+	}
+	for _, port := range sortedKeys(before) {
+		if _, ok := after[port]; !ok {
+			r.NotableChanges = append(r.NotableChanges,
+				fmt.Sprintf("Public port %s (%s) closed", port, before[port]))
+		}
+	}
+}
+
+// publicPorts maps each publicly bound port to a description of its owner.
+func publicPorts(snap *Snapshot) map[string]string {
+	owners := inventory.PortOwners(snap.Containers)
+	out := make(map[string]string)
+	for _, p := range snap.Ports {
+		if !ports.IsPublicBind(p.Address) {
+			continue
+		}
+		owner := strings.Join(owners[p.Port], ", ")
+		if owner == "" {
+			owner = p.Process
+		}
+		if owner == "" {
+			owner = "unknown process"
+		}
+		out[p.Port] = owner
+	}
+	return out
+}
+
+func containersByName(cs []docker.Container) map[string]docker.Container {
+	m := make(map[string]docker.Container, len(cs))
+	for _, c := range cs {
+		m[c.Name] = c
+	}
+	return m
+}
+
+func detailsByName(ds []docker.ContainerDetails) map[string]docker.ContainerDetails {
+	m := make(map[string]docker.ContainerDetails, len(ds))
+	for _, d := range ds {
+		m[d.Name] = d
+	}
+	return m
+}
+
+func mountsByDestination(ms []docker.Mount) map[string]docker.Mount {
+	m := make(map[string]docker.Mount, len(ms))
+	for _, mt := range ms {
+		m[mt.Destination] = mt
+	}
+	return m
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// diffStrings returns the entries only in cur and the entries only in old.
+func diffStrings(old, cur []string) (added, removed []string) {
+	seen := make(map[string]bool, len(old))
+	for _, s := range old {
+		seen[s] = true
+	}
+	now := make(map[string]bool, len(cur))
+	for _, s := range cur {
+		now[s] = true
+		if !seen[s] {
+			added = append(added, s)
+		}
+	}
+	for _, s := range old {
+		if !now[s] {
+			removed = append(removed, s)
+		}
+	}
+	return added, removed
+}
+
+// shortDigest shortens "sha256:<64 hex>" to "sha256:<12 hex>…".
+func shortDigest(id string) string {
+	algo, hex, ok := strings.Cut(id, ":")
+	if !ok {
+		algo, hex = "", id
+	}
+	if len(hex) > 12 {
+		hex = hex[:12] + "…"
+	}
+	if algo == "" {
+		return hex
+	}
+	return algo + ":" + hex
+}
+
+func restartPolicyName(p string) string {
+	if p == "" {
+		return "no"
+	}
+	return p
+}
@@ -0,0 +1,131 @@
+package report
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Higangssh/homebutler/internal/docker"
+	"github.com/Higangssh/homebutler/internal/ports"
+)
+
+func driftSnap(containers []docker.Container, pp []ports.PortInfo, details ...docker.ContainerDetails) *Snapshot {
+	return &Snapshot{
+		Timestamp:        "2026-10-12T08:00:00Z",
+		ServerName:       "testhost",
+		Containers:       containers,
+		Ports:            pp,
+		ContainerDetails: details,
+	}
+}
+
+func vaultwarden() docker.ContainerDetails {
+	return docker.ContainerDetails{
+		Name:           "vaultwarden",
+		Image:          "vaultwarden/server:latest",
+		ImageID:        "sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		RestartPolicy:  "unless-stopped",
+		EnvKeys:        []string{"ADMIN_TOKEN", "TZ"},
+		Mounts:         []docker.Mount{{Type: "bind", Source: "/srv/vw", Destination: "/data"}},
+		PublishedPorts: []string{"0.0.0.0:8080->80/tcp"},
+	}
+}
+
+func TestDriftSilentImageUpdate(t *testing.T) {
+	containers := []docker.Container{{Name: "vaultwarden", State: "running"}}
+	prev := driftSnap(containers, nil, vaultwarden())
+	cur := vaultwarden()
+	cur.ImageID = "sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	r := compareSnapshots(driftSnap(containers, nil, cur), []*Snapshot{prev}, 0, "since last report")
+
+	changes := join(r.NotableChanges)
+	if !strings.Contains(changes, "Image for vaultwarden changed from sha256:aaaaaaaaaaaa… to sha256:bbbbbbbbbbbb…") {
+		t.Errorf("changes = %s", changes)
+	}
+	if !strings.Contains(join(r.SuggestedActions), "vaultwarden was updated in place") {
+		t.Errorf("actions = %v", r.SuggestedActions)
+	}
+}
+
+func TestDriftConfigChanges(t *testing.T) {
+	jellyfin := docker.ContainerDetails{
+		Name:    "jellyfin",
+		Image:   "jellyfin/jellyfin:10.9",
+		ImageID: "sha256:1111",
+		Mounts: []docker.Mount{
+			{Type: "bind", Source: "/srv/jellyfin", Destination: "/config"},
+			{Type: "bind", Source: "/mnt/media", Destination: "/media"},
+		},
+	}
+	prev := driftSnap(nil, nil, jellyfin, vaultwarden())
+
+	j := jellyfin
+	j.Image, j.ImageID = "jellyfin/jellyfin:10.10", "sha256:2222"
+	j.Mounts = []docker.Mount{{Type: "bind", Source: "/srv/jellyfin2", Destination: "/config"}}
+	v := vaultwarden()
+	v.RestartPolicy = ""
+	v.EnvKeys = []string{"SIGNUPS_ALLOWED", "TZ"}
+	v.PublishedPorts = []string{"0.0.0.0:8443->80/tcp"}
+	r := compareSnapshots(driftSnap(nil, nil, j, v), []*Snapshot{prev}, 0, "since last report")
+
+	changes := join(r.NotableChanges)
+	for _, want := range []string{
+		"Image for jellyfin changed from jellyfin/jellyfin:10.9 to jellyfin/jellyfin:10.10",
+		"jellyfin lost its /media mount",
+		"jellyfin's /config mount now comes from /srv/jellyfin2 (was /srv/jellyfin)",
+		"Restart policy for vaultwarden changed from unless-stopped to no",
+		"vaultwarden gained env var(s) SIGNUPS_ALLOWED",
+		"vaultwarden lost env var(s) ADMIN_TOKEN",
+		"vaultwarden now publishes 0.0.0.0:8443->80/tcp",
+		"vaultwarden no longer publishes 0.0.0.0:8080->80/tcp",
+	} {
+		if !strings.Contains(changes, want) {
+			t.Errorf("missing %q in %s", want, changes)
+		}
+	}
+	if strings.Contains(join(r.SuggestedActions), "updated in place") {
+		t.Error("a tag change is not a silent update")
+	}
+}
+
+func TestDriftContainersAndPortOwners(t *testing.T) {
+	prev := driftSnap(
+		[]docker.Container{{Name: "web", State: "running"}, {Name: "old", State: "exited"}},
+		[]ports.PortInfo{{Address: "0.0.0.0", Port: "22", Process: "sshd"}},
+	)
+	cur := driftSnap(
+		[]docker.Container{
+			{Name: "web", State: "exited"},
+			{Name: "vaultwarden", Image: "vaultwarden/server", State: "running", Ports: "0.0.0.0:8080->80/tcp"},
+		},
+		[]ports.PortInfo{
+			{Address: "0.0.0.0", Port: "8080", Process: "docker-proxy"},
+			{Address: "127.0.0.1", Port: "5432", Process: "postgres"},
+		},
+	)
+	r := compareSnapshots(cur, []*Snapshot{prev}, 0, "since last report")
+
+	changes := join(r.NotableChanges)
+	for _, want := range []string{
+		"New container vaultwarden (vaultwarden/server)",
+		"web: running → exited",
+		"Container old was removed",
+		"Public port 8080 opened by vaultwarden",
+		"Public port 22 (sshd) closed",
+	} {
+		if !strings.Contains(changes, want) {
+			t.Errorf("missing %q in %s", want, changes)
+		}
+	}
+	if strings.Contains(changes, "5432") {
+		t.Error("loopback ports are not public")
+	}
+}
+
+func TestDriftIgnoresSnapshotsWithoutDetails(t *testing.T) {
+	containers := []docker.Container{{Name: "vaultwarden", State: "running"}}
+	prev := driftSnap(containers, nil)
+	r := compareSnapshots(driftSnap(containers, nil, vaultwarden()), []*Snapshot{prev}, 0, "since last report")
+	if !strings.Contains(join(r.NotableChanges), "No significant changes") {
+		t.Errorf("changes = %v", r.NotableChanges)
+	}
+}
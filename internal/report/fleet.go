@@ -0,0 +1,210 @@
+package report
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Higangssh/homebutler/internal/config"
+	"github.com/Higangssh/homebutler/internal/inventory"
+	"github.com/Higangssh/homebutler/internal/style"
+)
+
+// FleetReport combines the reports of every configured server.
+type FleetReport struct {
+	Timestamp string         `json:"timestamp"`
+	Summary   FleetSummary   `json:"summary"`
+	Servers   []*Report      `json:"servers"`
+	Failed    []FleetFailure `json:"failed,omitempty"`
+}
+
+// FleetSummary totals the per-server reports.
+type FleetSummary struct {
+	Servers        int `json:"servers"`
+	Reporting      int `json:"reporting"`
+	Unreachable    int `json:"unreachable"`
+	Running        int `json:"running_containers"`
+	Stopped        int `json:"stopped_containers"`
+	PublicPorts    int `json:"public_ports"`
+	NeedsAttention int `json:"servers_needing_attention"`
+	Baselines      int `json:"baselines_created"`
+}
+
+// FleetFailure records a server that could not be reported on.
+type FleetFailure struct {
+	Server string `json:"server"`
+	Error  string `json:"error"`
+}
+
+// FleetCollectFunc gathers the inventory of one configured server.
+type FleetCollectFunc func(server config.ServerConfig) (*inventory.Inventory, error)
+
+// DefaultFleetCollect collects local servers in-process with fns and remote
+// ones over SSH.
+func DefaultFleetCollect(cfg *config.Config, fns CollectFuncs) FleetCollectFunc {
+	return func(server config.ServerConfig) (*inventory.Inventory, error) {
+		if server.Local {
+			inv, err := inventory.Collect(cfg, fns)
+			if err != nil {
+				return nil, err
+			}
+			inv.ServerName = server.Name
+			return inv, nil
+		}
+		return inventory.CollectRemote(&server)
+	}
+}
+
+// RunFleet reports on every configured server in parallel.
+//
+// Each server keeps its own snapshot history, so its changes and trends are
+// judged against itself. Local servers use the same directory as a plain
+// `report`, which keeps one history per machine however it was reported on;
+// remote servers get a subdirectory named after them.
+func RunFleet(cfg *config.Config, collect FleetCollectFunc, opts Options) (*FleetReport, error) {
+	if cfg == nil || len(cfg.Servers) == 0 {
+		return nil, fmt.Errorf("no servers configured. Add servers to your config file")
+	}
+	baseDir := opts.SnapshotDir
+	if baseDir == "" {
+		baseDir = defaultSnapshotDir()
+	}
+
+	reports := make([]*Report, len(cfg.Servers))
+	errs := make([]error, len(cfg.Servers))
+	var wg sync.WaitGroup
+	for i, srv := range cfg.Servers {
+		wg.Add(1)
+		go func(idx int, server config.ServerConfig) {
+			defer wg.Done()
+			inv, err := collect(server)
+			if err != nil {
+				errs[idx] = err
+				return
+			}
+			serverOpts := opts
+			serverOpts.SnapshotDir = ServerSnapshotDir(baseDir, server)
+			if !server.Local {
+				serverOpts.History = nil // history records this machine only
+			}
+			reports[idx], errs[idx] = fromInventory(inv, serverOpts)
+		}(i, srv)
+	}
+	wg.Wait()
+
+	fr := &FleetReport{
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Servers:   []*Report{},
+	}
+	for i, srv := range cfg.Servers {
+		if errs[i] != nil {
+			fr.Failed = append(fr.Failed, FleetFailure{Server: srv.Name, Error: errs[i].Error()})
+			continue
+		}
+		fr.Servers = append(fr.Servers, reports[i])
+	}
+	fr.Summary = summarizeFleet(fr, len(cfg.Servers))
+	return fr, nil
+}
+
+// ServerSnapshotDir is where a server's snapshots live under baseDir. The
+// local server deliberately keeps baseDir itself, so a fleet report and a
+// plain `homebutler report` share one history and its trends; each remote
+// server gets servers/<name>.
+func ServerSnapshotDir(baseDir string, server config.ServerConfig) string {
+	if server.Local {
+		return baseDir
+	}
+	return filepath.Join(baseDir, "servers", safeDirName(server.Name))
+}
+
+// safeDirName keeps a server name from escaping the snapshot directory.
+func safeDirName(name string) string {
+	var b strings.Builder
+	for _, c := range name {
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.' {
+			b.WriteRune(c)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	s := strings.Trim(b.String(), ".")
+	if s == "" {
+		return "_"
+	}
+	return s
+}
+
+func summarizeFleet(fr *FleetReport, total int) FleetSummary {
+	s := FleetSummary{
+		Servers:     total,
+		Reporting:   len(fr.Servers),
+		Unreachable: len(fr.Failed),
+	}
+	for _, r := range fr.Servers {
+		s.Running += r.runningCount
+		s.Stopped += r.stoppedCount
+		s.PublicPorts += r.publicPortCount
+		if len(r.NeedsAttention) > 0 {
+			s.NeedsAttention++
+		}
+		if r.IsBaseline {
+			s.Baselines++
+		}
+	}
+	return s
+}
+
+// FormatFleetHuman renders the fleet summary followed by each server's report.
+func FormatFleetHuman(fr *FleetReport) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "🏘  %s\n", style.Title.Render("Homebutler Fleet Report"))
+	fmt.Fprintf(&b, "   %s\n\n", style.Dim.Render(fr.Timestamp))
+
+	s := fr.Summary
+	lines := []string{
+		fmt.Sprintf("Servers: %d reporting, %d unreachable", s.Reporting, s.Unreachable),
+		fmt.Sprintf("Containers: %d running, %d stopped", s.Running, s.Stopped),
+		fmt.Sprintf("Public ports: %d", s.PublicPorts),
+		fmt.Sprintf("Needs attention: %d of %d server(s)", s.NeedsAttention, s.Reporting),
+	}
+	if s.Baselines > 0 {
+		lines = append(lines, fmt.Sprintf("Baselines: created for %d server(s)", s.Baselines))
+	}
+	fmt.Fprintf(&b, "%s\n", style.Section("Fleet Summary"))
+	b.WriteString(style.LabelledBlock(lines, "   "))
+	fmt.Fprintln(&b)
+
+	var attention []string
+	for _, r := range fr.Servers {
+		for _, a := range r.NeedsAttention {
+			attention = append(attention, r.ServerName+": "+a)
+		}
+	}
+	if len(attention) > 0 || len(fr.Failed) > 0 {
+		fmt.Fprintf(&b, "%s\n", style.Section("Across the Fleet"))
+		for _, f := range fr.Failed {
+			fmt.Fprintf(&b, "   ❌ %s\n", style.Fail.Render(f.Server+": unreachable — "+firstLine(f.Error)))
+		}
+		for _, a := range attention {
+			fmt.Fprintf(&b, "   ⚠️  %s\n", style.Warn.Render(a))
+		}
+		fmt.Fprintln(&b)
+	}
+
+	for _, r := range fr.Servers {
+		b.WriteString(FormatHuman(r))
+	}
+	return b.String()
+}
+
+// firstLine trims the multi-line hints that SSH errors carry.
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
@@ -0,0 +1,136 @@
+package report
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Higangssh/homebutler/internal/config"
+	"github.com/Higangssh/homebutler/internal/docker"
+	"github.com/Higangssh/homebutler/internal/inventory"
+	"github.com/Higangssh/homebutler/internal/ports"
+	"github.com/Higangssh/homebutler/internal/system"
+)
+
+func fleetConfig() *config.Config {
+	return &config.Config{Servers: []config.ServerConfig{
+		{Name: "homelab", Host: "192.168.1.10", Local: true},
+		{Name: "nas", Host: "192.168.1.20"},
+		{Name: "pi", Host: "192.168.1.30"},
+	}}
+}
+
+func fakeFleetCollect(stopped map[string]int, unreachable ...string) FleetCollectFunc {
+	return func(server config.ServerConfig) (*inventory.Inventory, error) {
+		for _, u := range unreachable {
+			if server.Name == u {
+				return nil, fmt.Errorf("[%s] ssh: connect refused\n  → Check the host", server.Name)
+			}
+		}
+		containers := []docker.Container{{Name: "web", State: "running"}}
+		for i := 0; i < stopped[server.Name]; i++ {
+			containers = append(containers, docker.Container{Name: fmt.Sprintf("old%d", i), State: "exited"})
+		}
+		return &inventory.Inventory{
+			ServerName: server.Name,
+			System: &system.StatusInfo{
+				Hostname: server.Name,
+				Memory:   system.MemInfo{TotalGB: 8, UsedGB: 2, Percent: 25},
+				Disks:    []system.DiskInfo{{Mount: "/", TotalGB: 100, UsedGB: 40, Percent: 40}},
+			},
+			Containers: containers,
+			Ports:      []ports.PortInfo{{Address: "0.0.0.0", Port: "80"}},
+		}, nil
+	}
+}
+
+func TestRunFleetKeepsPerServerHistory(t *testing.T) {
+	dir := t.TempDir()
+	cfg := fleetConfig()
+
+	fr, err := RunFleet(cfg, fakeFleetCollect(map[string]int{"nas": 2}, "pi"), Options{SnapshotDir: dir, Keep: 30})
+	if err != nil {
+		t.Fatalf("RunFleet: %v", err)
+	}
+
+	if len(fr.Servers) != 2 || len(fr.Failed) != 1 {
+		t.Fatalf("got %d reports and %d failures, want 2 and 1", len(fr.Servers), len(fr.Failed))
+	}
+	if fr.Failed[0].Server != "pi" {
+		t.Errorf("failed server = %q, want pi", fr.Failed[0].Server)
+	}
+
+	s := fr.Summary
+	if s.Servers != 3 || s.Reporting != 2 || s.Unreachable != 1 {
+		t.Errorf("server counts = %+v", s)
+	}
+	if s.Running != 2 || s.Stopped != 2 || s.PublicPorts != 2 {
+		t.Errorf("container/port totals = %+v", s)
+	}
+	if s.NeedsAttention != 1 || s.Baselines != 2 {
+		t.Errorf("attention/baselines = %+v", s)
+	}
+
+	// The local server shares the plain report's directory; remotes get their own.
+	local, _ := listSnapshotFiles(dir)
+	remote, _ := listSnapshotFiles(filepath.Join(dir, "servers", "nas"))
+	if len(local) != 1 || len(remote) != 1 {
+		t.Errorf("snapshots: local %d, nas %d; want 1 each", len(local), len(remote))
+	}
+
+	// A second run compares each server with its own previous snapshot.
+	fr, err = RunFleet(cfg, fakeFleetCollect(map[string]int{"nas": 3}, "pi"), Options{SnapshotDir: dir, Keep: 30})
+	if err != nil {
+		t.Fatalf("RunFleet: %v", err)
+	}
+	for _, r := range fr.Servers {
+		if r.IsBaseline {
+			t.Errorf("%s: expected comparison against its own baseline", r.ServerName)
+		}
+		changes := join(r.NotableChanges)
+		if r.ServerName == "nas" && !strings.Contains(changes, "Stopped containers: 2 → 3") {
+			t.Errorf("nas changes = %s", changes)
+		}
+		if r.ServerName == "homelab" && !strings.Contains(changes, "No significant changes") {
+			t.Errorf("homelab changes = %s", changes)
+		}
+	}
+}
+
+func TestRunFleetNoServers(t *testing.T) {
+	if _, err := RunFleet(&config.Config{}, fakeFleetCollect(nil), Options{SnapshotDir: t.TempDir()}); err == nil {
+		t.Error("expected error with no servers configured")
+	}
+}
+
+func TestFormatFleetHuman(t *testing.T) {
+	fr, err := RunFleet(fleetConfig(), fakeFleetCollect(map[string]int{"nas": 1}, "pi"), Options{SnapshotDir: t.TempDir(), NoSave: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text := FormatFleetHuman(fr)
+	for _, want := range []string{
+		"Fleet Report",
+		"2 reporting, 1 unreachable",
+		"pi: unreachable — [pi] ssh: connect refused",
+		"nas: 1 container(s) stopped",
+		"Homebutler Report — homelab",
+		"Homebutler Report — nas",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("fleet output missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "Check the host") {
+		t.Error("SSH hint lines should be trimmed from the fleet summary")
+	}
+}
+
+func TestServerSnapshotDirSanitizesName(t *testing.T) {
+	got := ServerSnapshotDir("/base", config.ServerConfig{Name: "../etc"})
+	if got != filepath.Join("/base", "servers", "_etc") {
+		t.Errorf("ServerSnapshotDir = %q", got)
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/Higangssh/homebutler/internal/config"
 	"github.com/Higangssh/homebutler/internal/docker"
+	"github.com/Higangssh/homebutler/internal/history"
 	"github.com/Higangssh/homebutler/internal/inventory"
 	"github.com/Higangssh/homebutler/internal/ports"
 	"github.com/Higangssh/homebutler/internal/style"
@@ -28,19 +29,26 @@ type Snapshot struct {
 	RunningCount    int                `json:"running_count"`
 	StoppedCount    int                `json:"stopped_count"`
 	PublicPortCount int                `json:"public_port_count"`
+
+	ContainerDetails []docker.ContainerDetails `json:"container_details,omitempty"`
 }
 
 // Report is the structured output of a report run.
 type Report struct {
-	Timestamp        string   `json:"timestamp"`
-	ServerName       string   `json:"server_name"`
-	IsBaseline       bool     `json:"is_baseline"`
-	SnapshotSaved    bool     `json:"snapshot_saved"`
-	Status           []string `json:"status"`
-	NeedsAttention   []string `json:"needs_attention"`
-	NotableChanges   []string `json:"notable_changes"`
-	SuggestedActions []string `json:"suggested_actions"`
-	Warnings         []string `json:"warnings,omitempty"`
+	Timestamp        string         `json:"timestamp"`
+	ServerName       string         `json:"server_name"`
+	ComparedTo       string         `json:"compared_to,omitempty"`
+	IsBaseline       bool           `json:"is_baseline"`
+	SnapshotSaved    bool           `json:"snapshot_saved"`
+	Status           []string       `json:"status"`
+	NeedsAttention   []string       `json:"needs_attention"`
+	NotableChanges   []string       `json:"notable_changes"`
+	SuggestedActions []string       `json:"suggested_actions"`
+	Forecasts        []DiskForecast `json:"forecasts,omitempty"`
+	Warnings         []string       `json:"warnings,omitempty"`
+
+	// Snapshot counts, kept for fleet totals.
+	runningCount, stoppedCount, publicPortCount int
 }
 
 // Options controls report behavior.
@@ -48,6 +56,15 @@ type Options struct {
 	SnapshotDir string // Override snapshot directory (for testing)
 	Keep        int    // Number of snapshots to retain
 	NoSave      bool   // Skip writing snapshot
+
+	// ForecastHorizon raises a disk-full forecast under Needs Attention when
+	// it lands within this window. Zero uses DefaultForecastHorizon.
+	ForecastHorizon time.Duration
+
+	// History, when set, receives a metrics sample for each saved report.
+	// StatsFn adds per-container usage to that sample; it is best-effort.
+	History *history.Store
+	StatsFn func() ([]docker.ContainerStats, error)
 }
 
 // CollectFuncs allows injecting data sources for testing.
@@ -64,7 +81,12 @@ func Run(cfg *config.Config, fns CollectFuncs, opts Options) (*Report, error) {
 	if err != nil {
 		return nil, fmt.Errorf("collecting inventory: %w", err)
 	}
+	return fromInventory(inv, opts)
+}
 
+// fromInventory turns a collected inventory into a snapshot, compares it
+// against the snapshots already in opts.SnapshotDir, and saves it.
+func fromInventory(inv *inventory.Inventory, opts Options) (*Report, error) {
 	snap := buildSnapshot(inv)
 
 	snapshotDir := opts.SnapshotDir
@@ -72,10 +94,11 @@ func Run(cfg *config.Config, fns CollectFuncs, opts Options) (*Report, error) {
 		snapshotDir = defaultSnapshotDir()
 	}
 
-	// Load the latest previous snapshot.
-	prev, _ := loadLatest(snapshotDir)
+	// Load every retained snapshot; the latest is the comparison baseline
+	// and the rest feed the growth trends.
+	past, _ := loadAll(snapshotDir)
 
-	report := buildReport(snap, prev)
+	report := buildReport(snap, past, opts.ForecastHorizon)
 
 	if opts.Keep < 1 {
 		opts.Keep = 1
@@ -89,12 +112,31 @@ func Run(cfg *config.Config, fns CollectFuncs, opts Options) (*Report, error) {
 		if err := pruneSnapshots(snapshotDir, opts.Keep); err != nil {
 			report.Warnings = append(report.Warnings, "retention cleanup: "+err.Error())
 		}
+		if err := recordHistory(opts, snap); err != nil {
+			report.Warnings = append(report.Warnings, "metrics history: "+err.Error())
+		}
 	}
 	updateBaselineWording(report, opts.NoSave)
 
 	return report, nil
 }
 
+// recordHistory appends the snapshot's usage figures to the metrics store.
+func recordHistory(opts Options, snap *Snapshot) error {
+	if opts.History == nil {
+		return nil
+	}
+	var stats []docker.ContainerStats
+	if opts.StatsFn != nil {
+		stats, _ = opts.StatsFn()
+	}
+	t, err := time.Parse(time.RFC3339, snap.Timestamp)
+	if err != nil {
+		t = time.Now()
+	}
+	return opts.History.Append(history.NewSample(t, snap.System, stats))
+}
+
 func updateBaselineWording(r *Report, noSave bool) {
 	if !r.IsBaseline {
 		return
@@ -114,6 +156,8 @@ func buildSnapshot(inv *inventory.Inventory) *Snapshot {
 		Containers: inv.Containers,
 		Ports:      inv.Ports,
 		Warnings:   inv.Warnings,
+
+		ContainerDetails: inv.ContainerDetails,
 	}
 	for _, c := range inv.Containers {
 		switch c.State {
@@ -137,11 +181,29 @@ func countPublicPorts(pp []ports.PortInfo) int {
 	return count
 }
 
-func buildReport(snap *Snapshot, prev *Snapshot) *Report {
+// buildReport compares snap against the most recent of past, which must be
+// sorted oldest first, and projects disk growth across all of them.
+func buildReport(snap *Snapshot, past []*Snapshot, horizon time.Duration) *Report {
+	return compareSnapshots(snap, past, horizon, "since last report")
+}
+
+// compareSnapshots is buildReport with the wording for the baseline left to
+// the caller, so that a diff between two stored snapshots can say which one
+// it is comparing against instead of "last report".
+func compareSnapshots(snap *Snapshot, past []*Snapshot, horizon time.Duration, since string) *Report {
+	var prev *Snapshot
+	if len(past) > 0 {
+		prev = past[len(past)-1]
+	}
+
 	r := &Report{
 		Timestamp:  snap.Timestamp,
 		ServerName: snap.ServerName,
 		Warnings:   snap.Warnings,
+
+		runningCount:    snap.RunningCount,
+		stoppedCount:    snap.StoppedCount,
+		publicPortCount: snap.PublicPortCount,
 	}
 
 	// Status section
@@ -192,6 +254,8 @@ func buildReport(snap *Snapshot, prev *Snapshot) *Report {
 		return r
 	}
 
+	r.ComparedTo = prev.Timestamp
+
 	// Notable changes (diff against previous)
 	if prev.System != nil && snap.System != nil {
 		for _, d := range snap.System.Disks {
@@ -200,7 +264,7 @@ func buildReport(snap *Snapshot, prev *Snapshot) *Report {
 					delta := d.UsedGB - pd.UsedGB
 					if delta > 0.5 || delta < -0.5 {
 						r.NotableChanges = append(r.NotableChanges,
-							fmt.Sprintf("Disk %s: %+.1f GB since last report", d.Mount, delta))
+							fmt.Sprintf("Disk %s: %+.1f GB %s", d.Mount, delta, since))
 					}
 				}
 			}
@@ -220,8 +284,11 @@ func buildReport(snap *Snapshot, prev *Snapshot) *Report {
 			fmt.Sprintf("Public ports: %d → %d", prev.PublicPortCount, snap.PublicPortCount))
 	}
 
+	applyDrift(r, prev, snap)
+	applyTrends(r, snap, past, horizon)
+
 	if len(r.NotableChanges) == 0 {
-		r.NotableChanges = append(r.NotableChanges, "No significant changes since last report.")
+		r.NotableChanges = append(r.NotableChanges, "No significant changes "+since+".")
 	}
 
 	// Suggested actions
@@ -231,7 +298,7 @@ func buildReport(snap *Snapshot, prev *Snapshot) *Report {
 	}
 	if snap.StoppedCount > prev.StoppedCount {
 		r.SuggestedActions = append(r.SuggestedActions,
-			"Container(s) stopped since last report — check logs with 'homebutler docker logs'.")
+			"Container(s) stopped "+since+" — check logs with 'homebutler docker logs'.")
 	}
 	if len(r.NeedsAttention) > 0 && len(r.SuggestedActions) == 0 {
 		r.SuggestedActions = append(r.SuggestedActions,
@@ -310,12 +377,27 @@ func saveSnapshot(dir string, snap *Snapshot) error {
 	return os.WriteFile(filepath.Join(dir, filename), data, 0o644)
 }
 
-func loadLatest(dir string) (*Snapshot, error) {
+// loadAll reads every snapshot in dir, oldest first. Files that cannot be
+// read or parsed are skipped so that one damaged snapshot does not hide the
+// rest of the history.
+func loadAll(dir string) ([]*Snapshot, error) {
 	files, err := listSnapshotFiles(dir)
 	if err != nil || len(files) == 0 {
 		return nil, fmt.Errorf("no previous snapshots")
 	}
-	data, err := os.ReadFile(filepath.Join(dir, files[len(files)-1]))
+	snaps := make([]*Snapshot, 0, len(files))
+	for _, f := range files {
+		snap, err := loadSnapshotFile(filepath.Join(dir, f))
+		if err != nil {
+			continue
+		}
+		snaps = append(snaps, snap)
+	}
+	return snaps, nil
+}
+
+func loadSnapshotFile(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
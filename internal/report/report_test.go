@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/Higangssh/homebutler/internal/docker"
+	"github.com/Higangssh/homebutler/internal/history"
 	"github.com/Higangssh/homebutler/internal/inventory"
 	"github.com/Higangssh/homebutler/internal/ports"
 	"github.com/Higangssh/homebutler/internal/system"
@@ -224,6 +225,43 @@ func TestKeepMinimumOne(t *testing.T) {
 	}
 }
 
+func TestRunRecordsHistory(t *testing.T) {
+	dir := t.TempDir()
+	store, err := history.Open(filepath.Join(dir, "history"), history.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Run(nil, fakeFuncs(nil, nil), Options{
+		SnapshotDir: filepath.Join(dir, "snapshots"),
+		Keep:        30,
+		History:     store,
+		StatsFn: func() ([]docker.ContainerStats, error) {
+			return []docker.ContainerStats{{Name: "web", CPUPerc: "2.5%", MemPerc: "10%"}}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	since := time.Now().Add(-time.Hour)
+	if points, _ := store.Query(history.DiskMetric("/"), since); len(points) != 1 || points[0].Value != 60 {
+		t.Errorf("disk history = %+v, want one point at 60", points)
+	}
+	if points, _ := store.Query(history.ContainerCPUMetric("web"), since); len(points) != 1 {
+		t.Errorf("container history = %+v, want one point", points)
+	}
+
+	// --no-save previews must not leave history behind either.
+	_, err = Run(nil, fakeFuncs(nil, nil), Options{SnapshotDir: filepath.Join(dir, "snapshots"), NoSave: true, History: store})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if points, _ := store.Query(history.MetricCPU, since); len(points) != 1 {
+		t.Errorf("--no-save recorded history: %d points", len(points))
+	}
+}
+
 // --- helpers ---
 
 func writeTestSnapshot(t *testing.T, dir string, snap *Snapshot) {
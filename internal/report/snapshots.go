@@ -0,0 +1,226 @@
+package report
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Higangssh/homebutler/internal/style"
+)
+
+// SnapshotInfo summarises one stored snapshot for listing.
+type SnapshotInfo struct {
+	ID          string `json:"id"`
+	File        string `json:"file"`
+	Timestamp   string `json:"timestamp"`
+	ServerName  string `json:"server_name"`
+	Containers  int    `json:"containers"`
+	Running     int    `json:"running"`
+	Stopped     int    `json:"stopped"`
+	PublicPorts int    `json:"public_ports"`
+}
+
+// DiffResult is the comparison of two stored snapshots.
+type DiffResult struct {
+	From   SnapshotInfo `json:"from"`
+	To     SnapshotInfo `json:"to"`
+	Report *Report      `json:"report"`
+}
+
+// SnapshotDir returns dir, or the default snapshot directory when it is empty.
+func SnapshotDir(dir string) string {
+	if dir == "" {
+		return defaultSnapshotDir()
+	}
+	return dir
+}
+
+// snapshotID is the timestamp part of a snapshot file name, which is both
+// unique and sortable.
+func snapshotID(file string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(file, "snapshot_"), ".json")
+}
+
+// ListSnapshots returns every stored snapshot in dir, oldest first.
+// Snapshots that cannot be parsed are skipped.
+func ListSnapshots(dir string) ([]SnapshotInfo, error) {
+	files, err := listSnapshotFiles(dir)
+	if err != nil {
+		return nil, fmt.Errorf("no snapshots in %s: %w", dir, err)
+	}
+	infos := make([]SnapshotInfo, 0, len(files))
+	for _, f := range files {
+		snap, err := loadSnapshotFile(filepath.Join(dir, f))
+		if err != nil {
+			continue
+		}
+		infos = append(infos, describeSnapshot(f, snap))
+	}
+	return infos, nil
+}
+
+func describeSnapshot(file string, snap *Snapshot) SnapshotInfo {
+	return SnapshotInfo{
+		ID:          snapshotID(file),
+		File:        file,
+		Timestamp:   snap.Timestamp,
+		ServerName:  snap.ServerName,
+		Containers:  len(snap.Containers),
+		Running:     snap.RunningCount,
+		Stopped:     snap.StoppedCount,
+		PublicPorts: snap.PublicPortCount,
+	}
+}
+
+// ResolveSnapshot finds the snapshot a user referred to. A reference may be
+// "latest", "previous", a file name, an ID as printed by ListSnapshots, or a
+// prefix of one. Dates and times may be written with or without separators,
+// so "2026-10-12" and "20261012" both select a snapshot taken that day.
+//
+// A prefix that matches more than one snapshot is an error rather than a
+// guess; during a post-mortem, silently comparing the wrong two snapshots is
+// worse than being asked to be more specific.
+func ResolveSnapshot(dir, ref string) (*Snapshot, SnapshotInfo, error) {
+	files, err := listSnapshotFiles(dir)
+	if err != nil || len(files) == 0 {
+		return nil, SnapshotInfo{}, fmt.Errorf("no snapshots in %s", dir)
+	}
+
+	var matches []string
+	switch ref {
+	case "latest":
+		matches = files[len(files)-1:]
+	case "previous":
+		if len(files) < 2 {
+			return nil, SnapshotInfo{}, fmt.Errorf("only one snapshot exists; there is no previous one")
+		}
+		matches = files[len(files)-2 : len(files)-1]
+	default:
+		key := normalizeRef(ref)
+		for _, f := range files {
+			id := snapshotID(f)
+			if f == ref || id == key {
+				matches = []string{f}
+				break
+			}
+			if key != "" && strings.HasPrefix(id, key) {
+				matches = append(matches, f)
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, SnapshotInfo{}, fmt.Errorf("no snapshot matches %q (run 'homebutler report snapshots' to list them)", ref)
+	case 1:
+	default:
+		ids := make([]string, len(matches))
+		for i, m := range matches {
+			ids[i] = snapshotID(m)
+		}
+		return nil, SnapshotInfo{}, fmt.Errorf("%q matches %d snapshots: %s", ref, len(matches), strings.Join(ids, ", "))
+	}
+
+	snap, err := loadSnapshotFile(filepath.Join(dir, matches[0]))
+	if err != nil {
+		return nil, SnapshotInfo{}, fmt.Errorf("reading %s: %w", matches[0], err)
+	}
+	return snap, describeSnapshot(matches[0], snap), nil
+}
+
+// normalizeRef turns "2026-10-12T08:00:00Z" style input into the compact
+// form used in snapshot IDs.
+func normalizeRef(ref string) string {
+	ref = strings.TrimSuffix(strings.TrimPrefix(ref, "snapshot_"), ".json")
+	return strings.NewReplacer("-", "", ":", "").Replace(ref)
+}
+
+// Diff compares two stored snapshots with the same logic report uses against
+// the previous snapshot. The older of the two is always the baseline, so the
+// argument order does not change the direction of the changes reported.
+func Diff(dir, refA, refB string) (*DiffResult, error) {
+	a, infoA, err := ResolveSnapshot(dir, refA)
+	if err != nil {
+		return nil, err
+	}
+	b, infoB, err := ResolveSnapshot(dir, refB)
+	if err != nil {
+		return nil, err
+	}
+	if infoA.ID == infoB.ID {
+		return nil, fmt.Errorf("%q and %q are the same snapshot", refA, refB)
+	}
+	if infoA.ID > infoB.ID {
+		a, b = b, a
+		infoA, infoB = infoB, infoA
+	}
+
+	r := compareSnapshots(b, []*Snapshot{a}, 0, "since "+shortTime(a.Timestamp))
+	return &DiffResult{From: infoA, To: infoB, Report: r}, nil
+}
+
+func shortTime(ts string) string {
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return ts
+	}
+	return t.Local().Format("Jan 2 15:04")
+}
+
+// FormatSnapshotsHuman renders the snapshot list as a table.
+func FormatSnapshotsHuman(dir string, infos []SnapshotInfo) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "🗂  %s\n", style.Title.Render("Report snapshots"))
+	fmt.Fprintf(&b, "   %s\n\n", style.Dim.Render(dir))
+	if len(infos) == 0 {
+		fmt.Fprintf(&b, "   %s\n", style.Dim.Render("No snapshots yet. Run 'homebutler report' to create a baseline."))
+		return b.String()
+	}
+	fmt.Fprintf(&b, "   %-18s %-17s %-14s %8s %8s %7s\n", "ID", "TAKEN", "SERVER", "RUNNING", "STOPPED", "PUBLIC")
+	for _, s := range infos {
+		fmt.Fprintf(&b, "   %-18s %-17s %-14s %8d %8d %7d\n",
+			s.ID, shortTimeWithYear(s.Timestamp), s.ServerName, s.Running, s.Stopped, s.PublicPorts)
+	}
+	fmt.Fprintf(&b, "\n   %s\n", style.Dim.Render(fmt.Sprintf("%d snapshot(s). Compare two with 'homebutler report diff <id> <id>'.", len(infos))))
+	return b.String()
+}
+
+func shortTimeWithYear(ts string) string {
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return ts
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// FormatDiffHuman renders a snapshot diff.
+func FormatDiffHuman(d *DiffResult) string {
+	var b strings.Builder
+	r := d.Report
+
+	fmt.Fprintf(&b, "🔍 %s\n", style.Title.Render("Snapshot Diff — "+r.ServerName))
+	fmt.Fprintf(&b, "   %s\n\n", style.Dim.Render(fmt.Sprintf("%s (%s) → %s (%s)",
+		d.From.ID, shortTimeWithYear(d.From.Timestamp), d.To.ID, shortTimeWithYear(d.To.Timestamp))))
+
+	fmt.Fprintf(&b, "%s\n", style.Section("Changes"))
+	b.WriteString(style.LabelledBlock(r.NotableChanges, "   "))
+	fmt.Fprintln(&b)
+
+	if len(r.NeedsAttention) > 0 {
+		fmt.Fprintf(&b, "%s\n", style.Section("Needs Attention at "+d.To.ID))
+		for _, s := range r.NeedsAttention {
+			fmt.Fprintf(&b, "   ⚠️  %s\n", style.Warn.Render(s))
+		}
+		fmt.Fprintln(&b)
+	}
+
+	if len(r.SuggestedActions) > 0 {
+		fmt.Fprintf(&b, "%s\n", style.Section("Suggested Actions"))
+		for _, s := range r.SuggestedActions {
+			fmt.Fprintf(&b, "   %s %s\n", style.Accent.Render("→"), s)
+		}
+		fmt.Fprintln(&b)
+	}
+	return b.String()
+}
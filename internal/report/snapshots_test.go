@@ -0,0 +1,136 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Higangssh/homebutler/internal/docker"
+	"github.com/Higangssh/homebutler/internal/system"
+)
+
+// writeSeries stores three daily snapshots with a growing disk and a
+// container that stops on the last day.
+func writeSeries(t *testing.T, dir string) {
+	t.Helper()
+	base := time.Date(2026, 10, 12, 8, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		stopped := 0
+		if i == 2 {
+			stopped = 1
+		}
+		writeTestSnapshot(t, dir, &Snapshot{
+			Timestamp:  base.Add(time.Duration(i) * 24 * time.Hour).Format(time.RFC3339),
+			ServerName: "testhost",
+			System: &system.StatusInfo{
+				Disks: []system.DiskInfo{{Mount: "/", TotalGB: 100, UsedGB: 50 + float64(i)*5}},
+			},
+			Containers:      []docker.Container{{Name: "web"}, {Name: "db"}},
+			RunningCount:    2 - stopped,
+			StoppedCount:    stopped,
+			PublicPortCount: 1,
+		})
+	}
+}
+
+func TestListSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	writeSeries(t, dir)
+
+	infos, err := ListSnapshots(dir)
+	if err != nil {
+		t.Fatalf("ListSnapshots: %v", err)
+	}
+	if len(infos) != 3 {
+		t.Fatalf("got %d snapshots, want 3", len(infos))
+	}
+	if infos[0].ID != "20261012T080000Z" || infos[2].Stopped != 1 || infos[0].Containers != 2 {
+		t.Errorf("unexpected listing: %+v", infos)
+	}
+
+	text := FormatSnapshotsHuman(dir, infos)
+	if !strings.Contains(text, "20261014T080000Z") || !strings.Contains(text, "3 snapshot(s)") {
+		t.Errorf("human listing missing entries:\n%s", text)
+	}
+}
+
+func TestResolveSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	writeSeries(t, dir)
+
+	tests := []struct {
+		ref    string
+		wantID string
+		errSub string
+	}{
+		{"latest", "20261014T080000Z", ""},
+		{"previous", "20261013T080000Z", ""},
+		{"20261012T080000Z", "20261012T080000Z", ""},
+		{"snapshot_20261013T080000Z.json", "20261013T080000Z", ""},
+		{"2026-10-14", "20261014T080000Z", ""},
+		{"2026-10-12T08:00:00Z", "20261012T080000Z", ""},
+		{"202610", "", "matches 3 snapshots"},
+		{"2025", "", "no snapshot matches"},
+	}
+	for _, tc := range tests {
+		_, info, err := ResolveSnapshot(dir, tc.ref)
+		if tc.errSub != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.errSub) {
+				t.Errorf("%q: err = %v, want %q", tc.ref, err, tc.errSub)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", tc.ref, err)
+			continue
+		}
+		if info.ID != tc.wantID {
+			t.Errorf("%q resolved to %s, want %s", tc.ref, info.ID, tc.wantID)
+		}
+	}
+}
+
+func TestDiffUsesReportComparison(t *testing.T) {
+	dir := t.TempDir()
+	writeSeries(t, dir)
+
+	// Arguments in either order compare older → newer.
+	for _, args := range [][2]string{{"2026-10-12", "latest"}, {"latest", "2026-10-12"}} {
+		d, err := Diff(dir, args[0], args[1])
+		if err != nil {
+			t.Fatalf("Diff(%v): %v", args, err)
+		}
+		if d.From.ID != "20261012T080000Z" || d.To.ID != "20261014T080000Z" {
+			t.Errorf("Diff(%v) direction: %s → %s", args, d.From.ID, d.To.ID)
+		}
+		changes := join(d.Report.NotableChanges)
+		if !strings.Contains(changes, "Disk /: +10.0 GB since") || !strings.Contains(changes, "Stopped containers: 0 → 1") {
+			t.Errorf("changes = %s", changes)
+		}
+		if strings.Contains(changes, "last report") {
+			t.Errorf("diff should name the baseline snapshot, not the last report: %s", changes)
+		}
+	}
+
+	text := FormatDiffHuman(mustDiff(t, dir, "previous", "latest"))
+	if !strings.Contains(text, "Snapshot Diff — testhost") || !strings.Contains(text, "20261013T080000Z") {
+		t.Errorf("human diff output:\n%s", text)
+	}
+}
+
+func TestDiffRejectsSameSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	writeSeries(t, dir)
+	if _, err := Diff(dir, "latest", "2026-10-14"); err == nil {
+		t.Error("expected error comparing a snapshot with itself")
+	}
+}
+
+func mustDiff(t *testing.T, dir, a, b string) *DiffResult {
+	t.Helper()
+	d, err := Diff(dir, a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
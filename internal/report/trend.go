@@ -0,0 +1,207 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// DefaultForecastHorizon is how far ahead a disk-full forecast has to land
+// before it is raised under Needs Attention.
+const DefaultForecastHorizon = 14 * 24 * time.Hour
+
+// Forecasts need enough history for a slope to mean something: a handful of
+// points spread over at least a day.
+const (
+	minForecastPoints = 3
+	minForecastSpan   = 24 * time.Hour
+	growthWindow      = 7 * 24 * time.Hour
+)
+
+// DiskForecast is the linear fill projection for one mount.
+type DiskForecast struct {
+	Mount          string  `json:"mount"`
+	UsedGB         float64 `json:"used_gb"`
+	TotalGB        float64 `json:"total_gb"`
+	GrowthGBPerDay float64 `json:"growth_gb_per_day"`
+	DaysUntilFull  float64 `json:"days_until_full"`
+	FullAt         string  `json:"full_at"`
+}
+
+// diskPoint is one observation of a mount's used space.
+type diskPoint struct {
+	at     time.Time
+	usedGB float64
+}
+
+// diskSeries collects, per mount, the used space recorded in past snapshots
+// followed by the current one, oldest first.
+func diskSeries(snap *Snapshot, past []*Snapshot) map[string][]diskPoint {
+	series := map[string][]diskPoint{}
+	for _, s := range append(append([]*Snapshot{}, past...), snap) {
+		if s == nil || s.System == nil {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, s.Timestamp)
+		if err != nil {
+			continue
+		}
+		for _, d := range s.System.Disks {
+			series[d.Mount] = append(series[d.Mount], diskPoint{at: at, usedGB: d.UsedGB})
+		}
+	}
+	for _, pts := range series {
+		sort.SliceStable(pts, func(i, j int) bool { return pts[i].at.Before(pts[j].at) })
+	}
+	return series
+}
+
+// applyTrends adds week-over-week growth lines and disk-full forecasts to the
+// report, using every retained snapshot rather than only the latest one.
+func applyTrends(r *Report, snap *Snapshot, past []*Snapshot, horizon time.Duration) {
+	if snap.System == nil || len(past) == 0 {
+		return
+	}
+	now, err := time.Parse(time.RFC3339, snap.Timestamp)
+	if err != nil {
+		return
+	}
+	if horizon <= 0 {
+		horizon = DefaultForecastHorizon
+	}
+
+	series := diskSeries(snap, past)
+	for _, d := range snap.System.Disks {
+		pts := series[d.Mount]
+
+		if line, ok := growthLine(d.Mount, pts, now); ok {
+			r.NotableChanges = append(r.NotableChanges, line)
+		}
+
+		f, ok := forecastDisk(d.Mount, d.TotalGB, pts, now)
+		if !ok {
+			continue
+		}
+		r.Forecasts = append(r.Forecasts, f)
+		if time.Duration(f.DaysUntilFull*float64(24*time.Hour)) <= horizon {
+			full, _ := time.Parse(time.RFC3339, f.FullAt)
+			r.NeedsAttention = append(r.NeedsAttention,
+				fmt.Sprintf("Disk %s is on track to fill in ~%s (around %s) at +%.1f GB/day",
+					d.Mount, formatDays(f.DaysUntilFull), full.Format("Jan 2"), f.GrowthGBPerDay))
+			r.SuggestedActions = append(r.SuggestedActions,
+				fmt.Sprintf("Free space on %s or expand it before it fills — find what is growing with 'du -xh --max-depth=1 %s'.", d.Mount, d.Mount))
+		}
+	}
+}
+
+// growthLine summarises how much a mount grew over the last week, or over the
+// span the snapshots cover when that is shorter.
+func growthLine(mount string, pts []diskPoint, now time.Time) (string, bool) {
+	if len(pts) < 3 {
+		return "", false
+	}
+	// The previous snapshot alone is already covered by the "since last
+	// report" line, so growth needs at least one point before it.
+	var from *diskPoint
+	for i := range pts[:len(pts)-2] {
+		if now.Sub(pts[i].at) <= growthWindow {
+			from = &pts[i]
+			break
+		}
+	}
+	if from == nil {
+		return "", false
+	}
+	span := now.Sub(from.at)
+	if span < minForecastSpan {
+		return "", false
+	}
+	delta := pts[len(pts)-1].usedGB - from.usedGB
+	if math.Abs(delta) < 1 {
+		return "", false
+	}
+
+	verb := "grew"
+	if delta < 0 {
+		verb = "shrank"
+	}
+	period := "this week"
+	if span < growthWindow-12*time.Hour {
+		period = "over the last " + formatDays(span.Hours()/24)
+	}
+	return fmt.Sprintf("Disk %s: %s %.1f GB %s", mount, verb, math.Abs(delta), period), true
+}
+
+// forecastDisk fits a least-squares line to used space over time and projects
+// when it reaches the mount's capacity.
+//
+// Only points since the last large drop are used: a cleanup resets the trend,
+// and fitting across it would average the growth before and after into a
+// slope that describes neither.
+func forecastDisk(mount string, totalGB float64, pts []diskPoint, now time.Time) (DiskForecast, bool) {
+	if totalGB <= 0 {
+		return DiskForecast{}, false
+	}
+	start := 0
+	for i := 1; i < len(pts); i++ {
+		if pts[i-1].usedGB-pts[i].usedGB > totalGB*0.05 {
+			start = i
+		}
+	}
+	pts = pts[start:]
+	if len(pts) < minForecastPoints || pts[len(pts)-1].at.Sub(pts[0].at) < minForecastSpan {
+		return DiskForecast{}, false
+	}
+
+	slope := slopePerDay(pts)
+	if slope <= 0.01 { // flat or shrinking: never fills
+		return DiskForecast{}, false
+	}
+
+	used := pts[len(pts)-1].usedGB
+	days := (totalGB - used) / slope
+	if days < 0 {
+		days = 0
+	}
+	full := now.Add(time.Duration(days * float64(24*time.Hour)))
+	return DiskForecast{
+		Mount:          mount,
+		UsedGB:         used,
+		TotalGB:        totalGB,
+		GrowthGBPerDay: math.Round(slope*100) / 100,
+		DaysUntilFull:  math.Round(days*10) / 10,
+		FullAt:         full.UTC().Format(time.RFC3339),
+	}, true
+}
+
+// slopePerDay is the least-squares slope of used space in GB per day.
+func slopePerDay(pts []diskPoint) float64 {
+	t0 := pts[0].at
+	var sumX, sumY, sumXY, sumXX float64
+	n := float64(len(pts))
+	for _, p := range pts {
+		x := p.at.Sub(t0).Hours() / 24
+		sumX += x
+		sumY += p.usedGB
+		sumXY += x * p.usedGB
+		sumXX += x * x
+	}
+	denom := n*sumXX - sumX*sumX
+	if denom == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denom
+}
+
+// formatDays renders a day count the way a person would say it.
+func formatDays(days float64) string {
+	switch {
+	case days < 1:
+		return fmt.Sprintf("%.0fh", days*24)
+	case days < 10:
+		return fmt.Sprintf("%.1f days", days)
+	default:
+		return fmt.Sprintf("%.0f days", days)
+	}
+}
@@ -0,0 +1,120 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Higangssh/homebutler/internal/system"
+)
+
+// diskSnap builds a snapshot with a single /mnt/data mount.
+func diskSnap(at time.Time, usedGB float64) *Snapshot {
+	return &Snapshot{
+		Timestamp:  at.UTC().Format(time.RFC3339),
+		ServerName: "testhost",
+		System: &system.StatusInfo{
+			Disks: []system.DiskInfo{{Mount: "/mnt/data", TotalGB: 1000, UsedGB: usedGB, Percent: usedGB / 10}},
+		},
+	}
+}
+
+func TestTrendWeeklyGrowthAndForecast(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	// 10 GB/day for a week, ending at 900 of 1000 GB: ten days from full.
+	var past []*Snapshot
+	for d := 7; d >= 1; d-- {
+		past = append(past, diskSnap(now.Add(-time.Duration(d)*24*time.Hour), 900-float64(d)*10))
+	}
+	snap := diskSnap(now, 900)
+
+	r := buildReport(snap, past, 14*24*time.Hour)
+
+	changes := join(r.NotableChanges)
+	if !strings.Contains(changes, "Disk /mnt/data: grew 70.0 GB this week") {
+		t.Errorf("expected weekly growth line, got: %s", changes)
+	}
+	if len(r.Forecasts) != 1 {
+		t.Fatalf("expected one forecast, got %+v", r.Forecasts)
+	}
+	f := r.Forecasts[0]
+	if f.GrowthGBPerDay != 10 || f.DaysUntilFull != 10 {
+		t.Errorf("forecast = %+v, want 10 GB/day and 10 days", f)
+	}
+	if f.FullAt != "2026-10-28T12:00:00Z" {
+		t.Errorf("FullAt = %s", f.FullAt)
+	}
+	attention := join(r.NeedsAttention)
+	if !strings.Contains(attention, "Disk /mnt/data is on track to fill in ~10 days (around Oct 28)") {
+		t.Errorf("expected forecast under needs attention, got: %s", attention)
+	}
+}
+
+func TestTrendForecastOutsideHorizonIsNotRaised(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	past := []*Snapshot{
+		diskSnap(now.Add(-72*time.Hour), 500),
+		diskSnap(now.Add(-48*time.Hour), 501),
+		diskSnap(now.Add(-24*time.Hour), 502),
+	}
+
+	r := buildReport(diskSnap(now, 503), past, 14*24*time.Hour)
+
+	if len(r.Forecasts) != 1 {
+		t.Fatalf("expected forecast to be reported, got %+v", r.Forecasts)
+	}
+	if strings.Contains(join(r.NeedsAttention), "on track to fill") {
+		t.Errorf("forecast ~500 days out should not need attention: %v", r.NeedsAttention)
+	}
+}
+
+func TestTrendNeedsEnoughHistory(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	// Two points only, and an hour apart.
+	r := buildReport(diskSnap(now, 950), []*Snapshot{diskSnap(now.Add(-time.Hour), 900)}, 0)
+
+	if len(r.Forecasts) != 0 {
+		t.Errorf("expected no forecast from a single hour of history, got %+v", r.Forecasts)
+	}
+	if strings.Contains(join(r.NotableChanges), "this week") {
+		t.Errorf("expected no growth line under a day of history: %v", r.NotableChanges)
+	}
+}
+
+func TestTrendShrinkingDiskHasNoForecast(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	past := []*Snapshot{
+		diskSnap(now.Add(-72*time.Hour), 600),
+		diskSnap(now.Add(-48*time.Hour), 590),
+		diskSnap(now.Add(-24*time.Hour), 580),
+	}
+
+	r := buildReport(diskSnap(now, 570), past, 0)
+
+	if len(r.Forecasts) != 0 {
+		t.Errorf("shrinking disk should not be forecast to fill: %+v", r.Forecasts)
+	}
+	if !strings.Contains(join(r.NotableChanges), "Disk /mnt/data: shrank 30.0 GB over the last 3.0 days") {
+		t.Errorf("expected shrink line, got %v", r.NotableChanges)
+	}
+}
+
+func TestTrendCleanupResetsForecast(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	// Fast growth, then a 300 GB cleanup, then a slow 1 GB/day creep.
+	past := []*Snapshot{
+		diskSnap(now.Add(-144*time.Hour), 600),
+		diskSnap(now.Add(-120*time.Hour), 700),
+		diskSnap(now.Add(-96*time.Hour), 800),
+		diskSnap(now.Add(-72*time.Hour), 500),
+		diskSnap(now.Add(-48*time.Hour), 501),
+		diskSnap(now.Add(-24*time.Hour), 502),
+	}
+
+	r := buildReport(diskSnap(now, 503), past, 0)
+
+	if len(r.Forecasts) != 1 || r.Forecasts[0].GrowthGBPerDay != 1 {
+		t.Errorf("forecast should follow post-cleanup growth of 1 GB/day: %+v", r.Forecasts)
+	}
+}
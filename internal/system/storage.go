@@ -0,0 +1,318 @@
+package system
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/Higangssh/homebutler/internal/util"
+)
+
+// StorageHealth is what the disks themselves say about their health, as
+// opposed to how full they are.
+type StorageHealth struct {
+	// SmartAvailable is false when smartctl is not installed; Smart is then
+	// empty and says nothing about the drives.
+	SmartAvailable bool          `json:"smart_available"`
+	Smart          []SmartDevice `json:"smart,omitempty"`
+	Mounts         []MountInfo   `json:"mounts,omitempty"`
+	Inodes         []InodeUsage  `json:"inodes,omitempty"`
+	Warnings       []string      `json:"warnings,omitempty"`
+}
+
+// SmartDevice is the SMART summary of one drive.
+type SmartDevice struct {
+	Device       string `json:"device"`
+	Model        string `json:"model,omitempty"`
+	Serial       string `json:"serial,omitempty"`
+	Protocol     string `json:"protocol,omitempty"` // ATA, NVMe, SCSI
+	Readable     bool   `json:"readable"`           // false when smartctl could not read the drive
+	Passed       bool   `json:"passed"`
+	TemperatureC int    `json:"temperature_c,omitempty"`
+
+	ReallocatedSectors int64 `json:"reallocated_sectors,omitempty"`
+	PendingSectors     int64 `json:"pending_sectors,omitempty"`
+	Uncorrectable      int64 `json:"uncorrectable,omitempty"`
+	MediaErrors        int64 `json:"media_errors,omitempty"`     // NVMe
+	CriticalWarning    int   `json:"critical_warning,omitempty"` // NVMe bitmask
+
+	// FailingAttributes names attributes at or below their threshold, now or
+	// at some point in the past, e.g. "Reallocated_Sector_Ct (now)".
+	FailingAttributes []string `json:"failing_attributes,omitempty"`
+	Error             string   `json:"error,omitempty"`
+}
+
+// MountInfo is one line of /proc/mounts.
+type MountInfo struct {
+	Device  string   `json:"device"`
+	Mount   string   `json:"mount"`
+	FSType  string   `json:"fs_type"`
+	Options []string `json:"options"`
+}
+
+// ReadOnly reports whether the filesystem is mounted read-only.
+func (m MountInfo) ReadOnly() bool { return m.HasOption("ro") }
+
+// HasOption reports whether opt (or opt=value) is among the mount options.
+func (m MountInfo) HasOption(opt string) bool {
+	for _, o := range m.Options {
+		if o == opt || strings.HasPrefix(o, opt+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDiskFilesystem reports whether the mount is a writable-by-design
+// filesystem on a block device, the kind that turning read-only signals
+// trouble. Squashfs, ISO images and pseudo filesystems are always read-only
+// or never on disk and are excluded.
+func (m MountInfo) IsDiskFilesystem() bool {
+	switch m.FSType {
+	case "ext2", "ext3", "ext4", "xfs", "btrfs", "f2fs", "jfs", "reiserfs", "vfat", "exfat", "ntfs", "ntfs3":
+		return strings.HasPrefix(m.Device, "/dev/")
+	}
+	return false
+}
+
+// InodeUsage is one line of `df -i`.
+type InodeUsage struct {
+	Filesystem string  `json:"filesystem"`
+	Mount      string  `json:"mount"`
+	Inodes     int64   `json:"inodes"`
+	Used       int64   `json:"used"`
+	Free       int64   `json:"free"`
+	Percent    float64 `json:"usage_percent"`
+}
+
+// Storage collects SMART data, mounts and inode usage. Each part is
+// best-effort: a part that cannot be read is left empty and noted in
+// Warnings. SMART and mounts are Linux-only; SMART needs smartctl and
+// usually root.
+func Storage() (*StorageHealth, error) {
+	h := &StorageHealth{}
+
+	if runtime.GOOS == "linux" {
+		if data, err := os.ReadFile("/proc/mounts"); err != nil {
+			h.Warnings = append(h.Warnings, "mounts: "+err.Error())
+		} else {
+			h.Mounts = ParseProcMounts(string(data))
+		}
+
+		if out, err := util.RunCmd("df", "-iP"); err != nil && out == "" {
+			h.Warnings = append(h.Warnings, "df -i: "+err.Error())
+		} else {
+			h.Inodes = ParseDfInodes(out)
+		}
+	}
+
+	if _, err := exec.LookPath("smartctl"); err == nil {
+		h.SmartAvailable = true
+		devices, err := scanSmartDevices()
+		if err != nil {
+			h.Warnings = append(h.Warnings, "smartctl: "+err.Error())
+		}
+		for _, d := range devices {
+			// smartctl's exit status is a bitmask that is non-zero for a
+			// failing drive too, so the output is parsed regardless.
+			out, _ := smartctlJSON("-a", "-d", d.kind, d.name)
+			dev, err := ParseSmartctl([]byte(out))
+			if err != nil {
+				dev = &SmartDevice{Device: d.name, Error: err.Error()}
+			}
+			if dev.Device == "" {
+				dev.Device = d.name
+			}
+			h.Smart = append(h.Smart, *dev)
+		}
+	}
+	return h, nil
+}
+
+type smartScanEntry struct {
+	name string
+	kind string
+}
+
+func scanSmartDevices() ([]smartScanEntry, error) {
+	out, err := smartctlJSON("--scan")
+	if out == "" && err != nil {
+		return nil, err
+	}
+	var scan struct {
+		Devices []struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+		} `json:"devices"`
+	}
+	if err := json.Unmarshal([]byte(out), &scan); err != nil {
+		return nil, fmt.Errorf("parse smartctl --scan: %w", err)
+	}
+	entries := make([]smartScanEntry, 0, len(scan.Devices))
+	for _, d := range scan.Devices {
+		if !strings.HasPrefix(d.Name, "/dev/") || strings.ContainsAny(d.Type, " ;|&") {
+			continue
+		}
+		entries = append(entries, smartScanEntry{name: d.Name, kind: d.Type})
+	}
+	return entries, nil
+}
+
+func smartctlJSON(args ...string) (string, error) {
+	out, err := exec.Command("smartctl", append([]string{"--json"}, args...)...).Output()
+	return string(out), err
+}
+
+// smartctlOutput is the subset of `smartctl --json -a` that ParseSmartctl
+// reads, covering ATA and NVMe drives.
+type smartctlOutput struct {
+	Smartctl struct {
+		Messages []struct {
+			String   string `json:"string"`
+			Severity string `json:"severity"`
+		} `json:"messages"`
+	} `json:"smartctl"`
+	Device struct {
+		Name     string `json:"name"`
+		Protocol string `json:"protocol"`
+	} `json:"device"`
+	ModelName    string `json:"model_name"`
+	SerialNumber string `json:"serial_number"`
+	SmartStatus  *struct {
+		Passed bool `json:"passed"`
+	} `json:"smart_status"`
+	Temperature struct {
+		Current int `json:"current"`
+	} `json:"temperature"`
+	ATAAttributes struct {
+		Table []struct {
+			ID         int    `json:"id"`
+			Name       string `json:"name"`
+			WhenFailed string `json:"when_failed"`
+			Raw        struct {
+				Value int64 `json:"value"`
+			} `json:"raw"`
+		} `json:"table"`
+	} `json:"ata_smart_attributes"`
+	NVMeLog *struct {
+		CriticalWarning int   `json:"critical_warning"`
+		MediaErrors     int64 `json:"media_errors"`
+	} `json:"nvme_smart_health_information_log"`
+}
+
+// ParseSmartctl reads the output of `smartctl --json -a <device>`. A drive
+// smartctl could not read (typically for lack of root) parses successfully
+// with Readable false and the reason in Error.
+func ParseSmartctl(data []byte) (*SmartDevice, error) {
+	var out smartctlOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("parse smartctl output: %w", err)
+	}
+
+	d := &SmartDevice{
+		Device:       out.Device.Name,
+		Model:        out.ModelName,
+		Serial:       out.SerialNumber,
+		Protocol:     out.Device.Protocol,
+		TemperatureC: out.Temperature.Current,
+	}
+	if out.SmartStatus == nil {
+		d.Error = "no SMART status reported"
+		for _, m := range out.Smartctl.Messages {
+			if m.Severity == "error" {
+				d.Error = m.String
+				break
+			}
+		}
+		return d, nil
+	}
+	d.Readable = true
+	d.Passed = out.SmartStatus.Passed
+
+	for _, a := range out.ATAAttributes.Table {
+		switch a.ID {
+		case 5:
+			d.ReallocatedSectors = a.Raw.Value
+		case 197:
+			d.PendingSectors = a.Raw.Value
+		case 198:
+			d.Uncorrectable = a.Raw.Value
+		}
+		if a.WhenFailed == "now" || a.WhenFailed == "past" {
+			d.FailingAttributes = append(d.FailingAttributes, fmt.Sprintf("%s (%s)", a.Name, a.WhenFailed))
+		}
+	}
+	if out.NVMeLog != nil {
+		d.CriticalWarning = out.NVMeLog.CriticalWarning
+		d.MediaErrors = out.NVMeLog.MediaErrors
+	}
+	return d, nil
+}
+
+// ParseProcMounts parses /proc/mounts. Octal escapes such as \040 for a
+// space in a mount point are decoded.
+func ParseProcMounts(data string) []MountInfo {
+	var mounts []MountInfo
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		mounts = append(mounts, MountInfo{
+			Device:  unescapeMount(fields[0]),
+			Mount:   unescapeMount(fields[1]),
+			FSType:  fields[2],
+			Options: strings.Split(fields[3], ","),
+		})
+	}
+	return mounts
+}
+
+func unescapeMount(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// ParseDfInodes parses `df -iP` output. Filesystems that do not report a
+// fixed inode count (btrfs, many network filesystems) are skipped.
+func ParseDfInodes(out string) []InodeUsage {
+	var usage []InodeUsage
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 6 || fields[0] == "Filesystem" {
+			continue
+		}
+		total, err1 := strconv.ParseInt(fields[1], 10, 64)
+		used, err2 := strconv.ParseInt(fields[2], 10, 64)
+		free, err3 := strconv.ParseInt(fields[3], 10, 64)
+		if err1 != nil || err2 != nil || err3 != nil || total <= 0 {
+			continue
+		}
+		usage = append(usage, InodeUsage{
+			Filesystem: fields[0],
+			Mount:      strings.Join(fields[5:], " "),
+			Inodes:     total,
+			Used:       used,
+			Free:       free,
+			Percent:    round2(float64(used) / float64(total) * 100),
+		})
+	}
+	return usage
+}
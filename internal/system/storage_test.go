@@ -0,0 +1,107 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture %s: %v", name, err)
+	}
+	return data
+}
+
+func TestParseSmartctlFailingATA(t *testing.T) {
+	d, err := ParseSmartctl(readFixture(t, "smartctl-ata-failing.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Device != "/dev/sdb" || d.Model != "WDC WD40EFRX-68N32N0" || d.Protocol != "ATA" {
+		t.Errorf("identity = %+v", d)
+	}
+	if !d.Readable || d.Passed {
+		t.Errorf("Readable=%v Passed=%v, want readable and failed", d.Readable, d.Passed)
+	}
+	if d.ReallocatedSectors != 1848 || d.PendingSectors != 24 || d.Uncorrectable != 3 {
+		t.Errorf("sector counts = %d/%d/%d", d.ReallocatedSectors, d.PendingSectors, d.Uncorrectable)
+	}
+	if strings.Join(d.FailingAttributes, ", ") != "Reallocated_Sector_Ct (now), Reallocated_Event_Count (past)" {
+		t.Errorf("FailingAttributes = %v", d.FailingAttributes)
+	}
+	if d.TemperatureC != 40 {
+		t.Errorf("TemperatureC = %d", d.TemperatureC)
+	}
+}
+
+func TestParseSmartctlHealthyDrives(t *testing.T) {
+	hot, err := ParseSmartctl(readFixture(t, "smartctl-ata-hot.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hot.Passed || hot.TemperatureC != 58 || hot.ReallocatedSectors != 0 || len(hot.FailingAttributes) != 0 {
+		t.Errorf("hot drive = %+v", hot)
+	}
+
+	nvme, err := ParseSmartctl(readFixture(t, "smartctl-nvme.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !nvme.Passed || nvme.Protocol != "NVMe" || nvme.TemperatureC != 41 || nvme.MediaErrors != 0 || nvme.CriticalWarning != 0 {
+		t.Errorf("nvme drive = %+v", nvme)
+	}
+}
+
+func TestParseSmartctlUnreadable(t *testing.T) {
+	d, err := ParseSmartctl(readFixture(t, "smartctl-permission-denied.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Readable || !strings.Contains(d.Error, "Permission denied") {
+		t.Errorf("unreadable drive = %+v", d)
+	}
+	if _, err := ParseSmartctl([]byte("smartctl: command not found")); err == nil {
+		t.Error("expected error for non-JSON output")
+	}
+}
+
+func TestParseProcMounts(t *testing.T) {
+	mounts := ParseProcMounts(string(readFixture(t, "proc-mounts.txt")))
+	if len(mounts) != 10 {
+		t.Fatalf("got %d mounts, want 10", len(mounts))
+	}
+
+	var readOnlyDisks []string
+	for _, m := range mounts {
+		if m.IsDiskFilesystem() && m.ReadOnly() {
+			readOnlyDisks = append(readOnlyDisks, m.Mount)
+		}
+	}
+	if strings.Join(readOnlyDisks, ",") != "/mnt/media,/mnt/archive" {
+		t.Errorf("read-only disk filesystems = %v", readOnlyDisks)
+	}
+	if !mounts[4].HasOption("errors") || mounts[5].HasOption("errors") {
+		t.Error("errors= option not detected correctly")
+	}
+	if mounts[9].Mount != "/mnt/photo library" {
+		t.Errorf("escaped mount point = %q", mounts[9].Mount)
+	}
+}
+
+func TestParseDfInodes(t *testing.T) {
+	usage := ParseDfInodes(string(readFixture(t, "df-inodes.txt")))
+	if len(usage) != 5 {
+		t.Fatalf("got %d entries, want 5 (filesystems without inode counts skipped)", len(usage))
+	}
+	root := usage[2]
+	if root.Mount != "/" || root.Inodes != 6291456 || root.Free != 117535 || root.Percent != 98.13 {
+		t.Errorf("root = %+v", root)
+	}
+	if usage[4].Mount != "/mnt/photo library" {
+		t.Errorf("mount with space = %q", usage[4].Mount)
+	}
+}
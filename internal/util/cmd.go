@@ -13,3 +13,32 @@ func RunCmd(name string, args ...string) (string, error) {
 	out, err := cmd.CombinedOutput()
 	return strings.TrimSpace(string(out)), err
 }
+
+// dangerousPatterns contains shell commands that should never be executed.
+var dangerousPatterns = []string{
+	"rm -rf /",
+	"rm -rf /*",
+	"mkfs",
+	"dd if=",
+	":(){:|:&};:",
+	"shutdown",
+	"reboot",
+	"init 0",
+	"init 6",
+	"halt",
+	"poweroff",
+	"> /dev/sda",
+}
+
+// IsDangerousCommand checks if a command matches known dangerous patterns.
+// NOTE: This is a best-effort blocklist and is NOT a complete security boundary.
+// It serves as a supplementary safety net; do not rely on it as the sole defense.
+func IsDangerousCommand(cmd string) bool {
+	lower := strings.ToLower(strings.TrimSpace(cmd))
+	for _, pattern := range dangerousPatterns {
+		if strings.Contains(lower, pattern) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,78 +2,206 @@ package util
 
 import (
 	"os"
+	"os/exec"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
 )
 
+// Container runtimes homebutler can drive. Podman serves a Docker-compatible
+// API on its socket and accepts the same CLI arguments, so the rest of the
+// code only needs to know which binary to run and which socket to talk to.
+const (
+	RuntimeDocker = "docker"
+	RuntimePodman = "podman"
+)
+
+const defaultDockerSocket = "/var/run/docker.sock"
+
+// Runtime is the container engine in use.
+type Runtime struct {
+	Name   string // RuntimeDocker or RuntimePodman
+	Binary string // CLI to run; usually Name
+	Host   string // API endpoint in DOCKER_HOST form
+	Socket string // socket path when Host is a unix socket
+	Found  bool   // Socket exists, or Host is a TCP address from DOCKER_HOST
+}
+
 var (
-	dockerHostOnce sync.Once
+	runtimeMu     sync.RWMutex
+	runtimeForced string
 )
 
-// EnsureDockerHost detects the docker socket and sets DOCKER_HOST if needed.
-// Call this once before any docker command. Safe to call multiple times.
-func EnsureDockerHost() {
-	dockerHostOnce.Do(func() {
-		// Already set by user — respect it
-		if os.Getenv("DOCKER_HOST") != "" {
-			return
-		}
+// SetContainerRuntime forces the runtime used by every container command,
+// normally from the `runtime:` config key. "", "auto" and unknown names
+// detect it; `config validate` reports the unknown ones.
+func SetContainerRuntime(name string) {
+	runtimeMu.Lock()
+	defer runtimeMu.Unlock()
+	if name != RuntimeDocker && name != RuntimePodman {
+		name = ""
+	}
+	runtimeForced = name
+}
 
-		// Default socket works
-		if _, err := os.Stat("/var/run/docker.sock"); err == nil {
-			return
-		}
+// ContainerRuntime detects the runtime to use. DOCKER_HOST wins when set;
+// otherwise Docker sockets are preferred over Podman ones, and rootless
+// sockets under $XDG_RUNTIME_DIR are checked alongside the system ones.
+func ContainerRuntime() Runtime {
+	runtimeMu.RLock()
+	forced := runtimeForced
+	runtimeMu.RUnlock()
+	return detectRuntime(forced, systemProbe())
+}
 
-		// Try colima (macOS)
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return
-		}
-		colimaSock := home + "/.colima/default/docker.sock"
-		if _, err := os.Stat(colimaSock); err == nil {
-			os.Setenv("DOCKER_HOST", "unix://"+colimaSock)
-			return
-		}
+// runtimeProbe is what runtime detection looks at, injectable for tests.
+type runtimeProbe struct {
+	getenv    func(string) string
+	exists    func(string) bool
+	hasBinary func(string) bool
+	uid       int
+	home      string
+}
+
+func systemProbe() runtimeProbe {
+	home, _ := os.UserHomeDir()
+	return runtimeProbe{
+		getenv: os.Getenv,
+		exists: func(path string) bool {
+			_, err := os.Stat(path)
+			return err == nil
+		},
+		hasBinary: func(name string) bool {
+			_, err := exec.LookPath(name)
+			return err == nil
+		},
+		uid:  os.Getuid(),
+		home: home,
+	}
+}
+
+func (p runtimeProbe) dockerSockets() []string {
+	socks := []string{defaultDockerSocket}
+	if xdg := p.getenv("XDG_RUNTIME_DIR"); xdg != "" {
+		socks = append(socks, filepath.Join(xdg, "docker.sock")) // rootless Docker
+	}
+	if p.home != "" {
+		socks = append(socks,
+			filepath.Join(p.home, ".colima/default/docker.sock"),
+			filepath.Join(p.home, ".docker/run/docker.sock"),
+			filepath.Join(p.home, "Library/Containers/com.docker.docker/Data/docker.sock"))
+	}
+	return socks
+}
+
+func (p runtimeProbe) podmanSockets() []string {
+	var socks []string
+	if xdg := p.getenv("XDG_RUNTIME_DIR"); xdg != "" {
+		socks = append(socks, filepath.Join(xdg, "podman/podman.sock"))
+	}
+	socks = append(socks, "/run/user/"+strconv.Itoa(p.uid)+"/podman/podman.sock")
+	if p.uid == 0 {
+		socks = append([]string{"/run/podman/podman.sock"}, socks...)
+	} else {
+		socks = append(socks, "/run/podman/podman.sock")
+	}
+	return socks
+}
 
-		// Try podman
-		uid := os.Getuid()
-		podmanSock := "/run/user/" + strconv.Itoa(uid) + "/podman/podman.sock"
-		if _, err := os.Stat(podmanSock); err == nil {
-			os.Setenv("DOCKER_HOST", "unix://"+podmanSock)
-			return
+func (p runtimeProbe) firstSocket(socks []string) string {
+	for _, s := range socks {
+		if p.exists(s) {
+			return s
 		}
-	})
+	}
+	return ""
 }
 
-// DockerSocket returns the path to the docker socket.
-// Priority: /var/run/docker.sock → ~/.colima/default/docker.sock →
-// ~/Library/Containers/com.docker.docker/Data/docker.sock
-func DockerSocket() string {
-	// Default Linux socket
-	if _, err := os.Stat("/var/run/docker.sock"); err == nil {
-		return "/var/run/docker.sock"
+func detectRuntime(forced string, p runtimeProbe) Runtime {
+	var rt Runtime
+	if host := p.getenv("DOCKER_HOST"); host != "" {
+		rt = Runtime{Name: forced, Host: host, Found: true}
+		if path, ok := strings.CutPrefix(host, "unix://"); ok {
+			rt.Socket = path
+			rt.Found = p.exists(path)
+		}
+		if rt.Name == "" {
+			rt.Name = RuntimeDocker
+			if strings.Contains(host, "podman") {
+				rt.Name = RuntimePodman
+			}
+		}
+	} else {
+		docker, podman := p.firstSocket(p.dockerSockets()), p.firstSocket(p.podmanSockets())
+		switch {
+		case forced == RuntimePodman || (forced == "" && docker == "" && podman != ""):
+			rt = Runtime{Name: RuntimePodman, Socket: podman}
+			if podman == "" {
+				rt.Socket = p.podmanSockets()[0]
+			}
+		case forced == "" && docker == "" && !p.hasBinary(RuntimeDocker) && p.hasBinary(RuntimePodman):
+			// Podman is installed but its API socket is not enabled.
+			rt = Runtime{Name: RuntimePodman, Socket: p.podmanSockets()[0]}
+		default:
+			rt = Runtime{Name: RuntimeDocker, Socket: docker}
+			if docker == "" {
+				rt.Socket = defaultDockerSocket
+			}
+		}
+		rt.Found = p.exists(rt.Socket)
+		rt.Host = "unix://" + rt.Socket
 	}
 
-	home, _ := os.UserHomeDir()
+	// podman-docker installs a `docker` shim, and some Docker setups have
+	// only the other CLI on PATH; run whichever exists.
+	rt.Binary = rt.Name
+	other := RuntimePodman
+	if rt.Name == RuntimePodman {
+		other = RuntimeDocker
+	}
+	if !p.hasBinary(rt.Binary) && p.hasBinary(other) {
+		rt.Binary = other
+	}
+	return rt
+}
 
-	// macOS colima
-	colimaSock := home + "/.colima/default/docker.sock"
-	if _, err := os.Stat(colimaSock); err == nil {
-		return colimaSock
+// SocketHint explains how to get the runtime's API socket running.
+func (rt Runtime) SocketHint() string {
+	if rt.Name == RuntimePodman {
+		if strings.HasPrefix(rt.Socket, "/run/podman/") {
+			return "sudo systemctl enable --now podman.socket"
+		}
+		return "systemctl --user enable --now podman.socket"
 	}
+	return "sudo systemctl start docker   (Linux)  ·  colima start   (macOS)"
+}
 
-	// macOS Docker Desktop
-	desktopSock := home + "/Library/Containers/com.docker.docker/Data/docker.sock"
-	if _, err := os.Stat(desktopSock); err == nil {
-		return desktopSock
+// EnsureDockerHost points DOCKER_HOST at the detected socket when it is not
+// the default one, so the CLI and the API agree on which engine to use.
+// Safe to call multiple times.
+func EnsureDockerHost() {
+	if os.Getenv("DOCKER_HOST") != "" {
+		return
+	}
+	rt := ContainerRuntime()
+	if rt.Found && rt.Socket != defaultDockerSocket {
+		os.Setenv("DOCKER_HOST", rt.Host)
 	}
+}
 
-	// Fallback to default
-	return "/var/run/docker.sock"
+// DockerSocket returns the path of the container runtime's API socket, for
+// apps that mount it. It falls back to /var/run/docker.sock.
+func DockerSocket() string {
+	if s := ContainerRuntime().Socket; s != "" {
+		return s
+	}
+	return defaultDockerSocket
 }
 
-// DockerCmd runs a docker command with proper socket detection.
+// DockerCmd runs a container CLI command with the detected runtime: docker,
+// or podman, which takes the same arguments.
 func DockerCmd(args ...string) (string, error) {
 	EnsureDockerHost()
-	return RunCmd("docker", args...)
+	return RunCmd(ContainerRuntime().Binary, args...)
 }
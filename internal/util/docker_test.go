@@ -2,6 +2,7 @@ package util
 
 import (
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"testing"
@@ -69,3 +70,110 @@ func TestStrconvItoaBehavior(t *testing.T) {
 		}
 	}
 }
+
+func fakeProbe(env map[string]string, sockets []string, binaries ...string) runtimeProbe {
+	return runtimeProbe{
+		getenv:    func(k string) string { return env[k] },
+		exists:    func(p string) bool { return slices.Contains(sockets, p) },
+		hasBinary: func(b string) bool { return slices.Contains(binaries, b) },
+		uid:       1000,
+		home:      "/home/ana",
+	}
+}
+
+func TestDetectRuntime(t *testing.T) {
+	xdg := map[string]string{"XDG_RUNTIME_DIR": "/run/user/1000"}
+	tests := []struct {
+		name       string
+		forced     string
+		probe      runtimeProbe
+		wantName   string
+		wantBinary string
+		wantHost   string
+		wantFound  bool
+	}{
+		{
+			name:     "docker socket",
+			probe:    fakeProbe(nil, []string{"/var/run/docker.sock", "/run/user/1000/podman/podman.sock"}, "docker", "podman"),
+			wantName: "docker", wantBinary: "docker", wantHost: "unix:///var/run/docker.sock", wantFound: true,
+		},
+		{
+			name:     "rootless podman under XDG_RUNTIME_DIR",
+			probe:    fakeProbe(xdg, []string{"/run/user/1000/podman/podman.sock"}, "podman"),
+			wantName: "podman", wantBinary: "podman", wantHost: "unix:///run/user/1000/podman/podman.sock", wantFound: true,
+		},
+		{
+			name:     "rootless docker",
+			probe:    fakeProbe(xdg, []string{"/run/user/1000/docker.sock"}, "docker"),
+			wantName: "docker", wantBinary: "docker", wantHost: "unix:///run/user/1000/docker.sock", wantFound: true,
+		},
+		{
+			name:     "colima",
+			probe:    fakeProbe(nil, []string{"/home/ana/.colima/default/docker.sock"}, "docker"),
+			wantName: "docker", wantBinary: "docker", wantHost: "unix:///home/ana/.colima/default/docker.sock", wantFound: true,
+		},
+		{
+			name:     "podman installed but socket not enabled",
+			probe:    fakeProbe(xdg, nil, "podman"),
+			wantName: "podman", wantBinary: "podman", wantHost: "unix:///run/user/1000/podman/podman.sock",
+		},
+		{
+			name:     "nothing installed",
+			probe:    fakeProbe(nil, nil),
+			wantName: "docker", wantBinary: "docker", wantHost: "unix:///var/run/docker.sock",
+		},
+		{
+			name:     "forced podman with both present",
+			forced:   "podman",
+			probe:    fakeProbe(nil, []string{"/var/run/docker.sock", "/run/podman/podman.sock"}, "docker", "podman"),
+			wantName: "podman", wantBinary: "podman", wantHost: "unix:///run/podman/podman.sock", wantFound: true,
+		},
+		{
+			name:     "forced docker with only the podman CLI",
+			forced:   "docker",
+			probe:    fakeProbe(nil, []string{"/run/user/1000/podman/podman.sock"}, "podman"),
+			wantName: "docker", wantBinary: "podman", wantHost: "unix:///var/run/docker.sock",
+		},
+		{
+			name:     "DOCKER_HOST wins",
+			probe:    fakeProbe(map[string]string{"DOCKER_HOST": "unix:///run/user/1000/podman/podman.sock"}, []string{"/var/run/docker.sock", "/run/user/1000/podman/podman.sock"}, "docker"),
+			wantName: "podman", wantBinary: "docker", wantHost: "unix:///run/user/1000/podman/podman.sock", wantFound: true,
+		},
+		{
+			name:     "DOCKER_HOST over tcp",
+			probe:    fakeProbe(map[string]string{"DOCKER_HOST": "tcp://nas:2375"}, nil, "docker"),
+			wantName: "docker", wantBinary: "docker", wantHost: "tcp://nas:2375", wantFound: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := detectRuntime(tt.forced, tt.probe)
+			if rt.Name != tt.wantName || rt.Binary != tt.wantBinary || rt.Host != tt.wantHost || rt.Found != tt.wantFound {
+				t.Errorf("got %+v, want name=%s binary=%s host=%s found=%t", rt, tt.wantName, tt.wantBinary, tt.wantHost, tt.wantFound)
+			}
+		})
+	}
+}
+
+func TestSetContainerRuntime(t *testing.T) {
+	t.Cleanup(func() { SetContainerRuntime("") })
+	t.Setenv("DOCKER_HOST", "tcp://nas:2375")
+
+	SetContainerRuntime("podman")
+	if got := ContainerRuntime().Name; got != "podman" {
+		t.Errorf("forced podman, got %s", got)
+	}
+	SetContainerRuntime("containerd")
+	if got := ContainerRuntime().Name; got != "docker" {
+		t.Errorf("unknown runtime should fall back to detection, got %s", got)
+	}
+}
+
+func TestSocketHint(t *testing.T) {
+	if h := (Runtime{Name: "podman", Socket: "/run/user/1000/podman/podman.sock"}).SocketHint(); !strings.Contains(h, "--user") {
+		t.Errorf("rootless hint = %q", h)
+	}
+	if h := (Runtime{Name: "podman", Socket: "/run/podman/podman.sock"}).SocketHint(); !strings.Contains(h, "sudo") {
+		t.Errorf("rootful hint = %q", h)
+	}
+}
@@ -1,9 +1,12 @@
 package watch
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
+	"sync"
 )
 
 type CrashInfo struct {
@@ -12,6 +15,12 @@ type CrashInfo struct {
 	Signal    string
 	ErrorLog  string
 	Backend   string
+
+	// OOMKill is the kernel OOM kill matched to this crash, if any.
+	OOMKill *OOMKill
+	// OOMChecked is set when the kernel log was read, so a missing OOMKill
+	// means there was none rather than that nobody could look.
+	OOMChecked bool
 }
 
 type CrashSummary struct {
@@ -21,30 +30,160 @@ type CrashSummary struct {
 	Signal     string   `json:"signal,omitempty"`
 	Patterns   []string `json:"patterns,omitempty"`
 	Confidence string   `json:"confidence"`
+	OOMKill    *OOMKill `json:"oom_kill,omitempty"`
+}
+
+// AnalysisRule maps a log pattern to a crash category. Built-in rules come
+// in packs per stack; users add their own under watch.analysis.rules.
+type AnalysisRule struct {
+	// Name is what Patterns reports when the rule matches; defaults to Pattern.
+	Name    string `yaml:"name,omitempty" json:"name,omitempty"`
+	Pattern string `yaml:"pattern" json:"pattern"` // Go regexp, matched against the pre-death logs
+	// Category is free-form; the built-in ones are oom, segfault, panic,
+	// exception, config, database, dependency, fatal_error and timeout.
+	Category string `yaml:"category" json:"category"`
+	// Reason and Confidence default from the category when empty.
+	Reason     string `yaml:"reason,omitempty" json:"reason,omitempty"`
+	Confidence string `yaml:"confidence,omitempty" json:"confidence,omitempty"`
 }
 
-type logPattern struct {
+// AnalysisConfig is the watch.analysis config section.
+type AnalysisConfig struct {
+	Rules []AnalysisRule `yaml:"rules,omitempty" json:"rules,omitempty"`
+}
+
+// Validate reports why a rule cannot be used.
+func (r AnalysisRule) Validate() error {
+	if r.Pattern == "" {
+		return fmt.Errorf("pattern is required")
+	}
+	if _, err := regexp.Compile(r.Pattern); err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", r.Pattern, err)
+	}
+	if r.Category == "" {
+		return fmt.Errorf("category is required")
+	}
+	switch r.Confidence {
+	case "", "high", "medium", "low":
+	default:
+		return fmt.Errorf("unknown confidence %q (expected high, medium, or low)", r.Confidence)
+	}
+	return nil
+}
+
+// logRule is a compiled AnalysisRule. When several rules match, the lowest
+// priority decides the category; ties go to the rule listed first.
+type logRule struct {
+	AnalysisRule
 	re       *regexp.Regexp
-	name     string
-	category string
 	priority int
 }
 
-var logPatterns = []logPattern{
-	{regexp.MustCompile(`(?i)out of memory`), "out of memory", "oom", 0},
-	{regexp.MustCompile(`(?i)cannot allocate`), "cannot allocate", "oom", 0},
-	{regexp.MustCompile(`(?i)\boom\b`), "oom", "oom", 0},
-	{regexp.MustCompile(`(?i)segmentation fault`), "segmentation fault", "segfault", 1},
-	{regexp.MustCompile(`(?i)sigsegv`), "sigsegv", "segfault", 1},
-	{regexp.MustCompile(`panic:`), "panic:", "panic", 2},
-	{regexp.MustCompile(`goroutine \d+`), `goroutine \d+`, "panic", 2},
-	{regexp.MustCompile(`(?i)connection refused`), "connection refused", "dependency", 3},
-	{regexp.MustCompile(`(?i)no such host`), "no such host", "dependency", 3},
-	{regexp.MustCompile(`(?i)connection reset`), "connection reset", "dependency", 3},
-	{regexp.MustCompile(`(?i)fatal`), "fatal", "fatal_error", 4},
-	{regexp.MustCompile(`(?i)critical`), "critical", "fatal_error", 4},
-	{regexp.MustCompile(`(?i)timeout`), "timeout", "timeout", 5},
-	{regexp.MustCompile(`(?i)deadline exceeded`), "deadline exceeded", "timeout", 5},
+func (r logRule) name() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return r.Pattern
+}
+
+// userRulePriority puts user rules ahead of every built-in one, since they
+// describe the apps actually being watched.
+const userRulePriority = -1
+
+func rule(priority int, pattern, name, category, reason string) logRule {
+	return logRule{
+		AnalysisRule: AnalysisRule{Name: name, Pattern: pattern, Category: category, Reason: reason},
+		re:           regexp.MustCompile(pattern),
+		priority:     priority,
+	}
+}
+
+// builtinRules are the built-in packs: one per common stack, then the
+// generic patterns, so that on a tie the stack-specific reason wins. Only
+// packs whose patterns appear in a log affect it, so they are always on. A
+// stack trace ranks below a dependency failure because the trace is usually
+// just how that failure surfaced.
+var builtinRules = slices.Concat(
+	// java
+	[]logRule{
+		rule(0, `java\.lang\.OutOfMemoryError`, "java OutOfMemoryError", "oom",
+			"Java heap or metaspace exhausted (OutOfMemoryError)"),
+		rule(35, `Exception in thread "[^"]+"`, "java uncaught exception", "exception",
+			"Uncaught Java exception"),
+	},
+	// python
+	[]logRule{
+		rule(0, `\bMemoryError\b`, "python MemoryError", "oom", "Python ran out of memory (MemoryError)"),
+		rule(35, `Traceback \(most recent call last\)`, "python traceback", "exception",
+			"Unhandled Python exception"),
+	},
+	// node
+	[]logRule{
+		rule(0, `JavaScript heap out of memory`, "node heap out of memory", "oom",
+			"Node.js heap limit reached (raise --max-old-space-size or fix the leak)"),
+		rule(35, `UnhandledPromiseRejection|Unhandled promise rejection|unhandledRejection`, "node unhandled rejection", "exception",
+			"Unhandled promise rejection in Node.js"),
+	},
+	// postgres: FATAL lines that mean the server or a client cannot go on,
+	// not every message at FATAL level.
+	[]logRule{
+		rule(30, `FATAL:\s+(password authentication failed|(database|role) "[^"]+" does not exist|the database system is (starting up|shutting down|in recovery mode)|sorry, too many clients|remaining connection slots are reserved)`,
+			"postgres FATAL", "database", "PostgreSQL refused the connection or is not accepting them"),
+		rule(10, `FATAL:\s+(data directory "[^"]+" has (wrong ownership|invalid permissions)|database files are incompatible with server|lock file "[^"]+" already exists|could not (open|access|create|write|map) )`,
+			"postgres FATAL startup", "database", "PostgreSQL could not start (data directory, version or lock file problem)"),
+	},
+	// nginx
+	[]logRule{
+		rule(10, `\[emerg\]`, "nginx [emerg]", "config",
+			"nginx refused to start: usually a config error or a port already in use"),
+	},
+	// generic
+	[]logRule{
+		rule(0, `(?i)out of memory`, "out of memory", "oom", ""),
+		rule(0, `(?i)cannot allocate`, "cannot allocate", "oom", ""),
+		rule(0, `(?i)\boom\b`, "oom", "oom", ""),
+		rule(10, `(?i)segmentation fault`, "segmentation fault", "segfault", ""),
+		rule(10, `(?i)sigsegv`, "sigsegv", "segfault", ""),
+		rule(20, `panic:`, "panic:", "panic", ""),
+		rule(20, `goroutine \d+`, `goroutine \d+`, "panic", ""),
+		rule(30, `(?i)connection refused`, "connection refused", "dependency", ""),
+		rule(30, `(?i)no such host`, "no such host", "dependency", ""),
+		rule(30, `(?i)connection reset`, "connection reset", "dependency", ""),
+		rule(40, `(?i)fatal`, "fatal", "fatal_error", ""),
+		rule(40, `(?i)critical`, "critical", "fatal_error", ""),
+		rule(50, `(?i)timeout`, "timeout", "timeout", ""),
+		rule(50, `(?i)deadline exceeded`, "deadline exceeded", "timeout", ""),
+	},
+)
+
+var (
+	rulesMu   sync.RWMutex
+	userRules []logRule
+)
+
+// SetAnalysisRules installs user rules, checked ahead of the built-in ones.
+// Invalid rules are skipped and reported in the returned error, so one bad
+// regexp does not take the rest of the rules with it.
+func SetAnalysisRules(rules []AnalysisRule) error {
+	var compiled []logRule
+	var errs []error
+	for i, r := range rules {
+		if err := r.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("rule %d: %w", i+1, err))
+			continue
+		}
+		compiled = append(compiled, logRule{AnalysisRule: r, re: regexp.MustCompile(r.Pattern), priority: userRulePriority})
+	}
+	rulesMu.Lock()
+	userRules = compiled
+	rulesMu.Unlock()
+	return errors.Join(errs...)
+}
+
+func activeRules() []logRule {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+	return slices.Concat(userRules, builtinRules)
 }
 
 func Analyze(info CrashInfo) CrashSummary {
@@ -53,6 +192,18 @@ func Analyze(info CrashInfo) CrashSummary {
 		Signal:   info.Signal,
 	}
 
+	if info.OOMKill != nil {
+		s.Category = "oom"
+		s.Reason = info.OOMKill.describe()
+		s.Confidence = "high"
+		s.OOMKill = info.OOMKill
+		if s.Signal == "" {
+			s.Signal = "SIGKILL"
+		}
+		return s
+	}
+
+	// The runtime's own OOM flag comes from the same kernel event.
 	if info.OOMKilled {
 		s.Category = "oom"
 		s.Reason = "Process killed by OOM killer"
@@ -62,10 +213,15 @@ func Analyze(info CrashInfo) CrashSummary {
 
 	switch info.ExitCode {
 	case 137:
-		s.Category = "oom"
-		s.Reason = "Process received SIGKILL (likely OOM)"
-		s.Confidence = "high"
 		s.Signal = "SIGKILL"
+		s.Confidence = "medium"
+		if info.OOMChecked {
+			s.Category = "sigkill"
+			s.Reason = "Process received SIGKILL, but the kernel logged no OOM kill (docker kill, a stop timeout, or a manual kill)"
+			return s
+		}
+		s.Category = "oom"
+		s.Reason = "Process received SIGKILL (OOM or forced kill; no kernel OOM record to confirm)"
 		return s
 	case 139:
 		s.Category = "segfault"
@@ -82,25 +238,31 @@ func Analyze(info CrashInfo) CrashSummary {
 	}
 
 	if info.ErrorLog != "" {
-		bestPriority := len(logPatterns)
-		bestCategory := ""
+		var best *logRule
 		var matched []string
 
-		for _, p := range logPatterns {
-			if p.re.MatchString(info.ErrorLog) {
-				matched = append(matched, p.name)
-				if p.priority < bestPriority {
-					bestPriority = p.priority
-					bestCategory = p.category
+		rules := activeRules()
+		for i := range rules {
+			r := &rules[i]
+			if r.re.MatchString(info.ErrorLog) {
+				matched = append(matched, r.name())
+				if best == nil || r.priority < best.priority {
+					best = r
 				}
 			}
 		}
 
-		if len(matched) > 0 {
-			s.Category = bestCategory
+		if best != nil {
+			s.Category = best.Category
 			s.Patterns = matched
-			s.Confidence = confidenceForCategory(bestCategory)
-			s.Reason = reasonForCategory(bestCategory, matched)
+			s.Confidence = best.Confidence
+			if s.Confidence == "" {
+				s.Confidence = confidenceForCategory(best.Category)
+			}
+			s.Reason = best.Reason
+			if s.Reason == "" {
+				s.Reason = reasonForCategory(best.Category, matched)
+			}
 			return s
 		}
 	}
@@ -1,6 +1,7 @@
 package watch
 
 import (
+	"slices"
 	"strings"
 	"testing"
 )
@@ -24,7 +25,20 @@ func TestAnalyze(t *testing.T) {
 			name:           "exit code 137 without OOMKilled",
 			info:           CrashInfo{ExitCode: 137, Backend: "systemd"},
 			wantCategory:   "oom",
+			wantConfidence: "medium",
+		},
+		{
+			name:           "exit code 137 with a matching kernel OOM kill",
+			info:           CrashInfo{ExitCode: 137, Backend: "docker", OOMKill: &OOMKill{PID: 42, Process: "java", RSSBytes: 500 << 20, LimitBytes: 512 << 20}},
+			wantCategory:   "oom",
 			wantConfidence: "high",
+			checkReason:    "Killed by the kernel OOM killer: java (pid 42) using 500 MiB of a 512 MiB cgroup limit",
+		},
+		{
+			name:           "exit code 137 and no OOM kill in the kernel log",
+			info:           CrashInfo{ExitCode: 137, Backend: "docker", OOMChecked: true},
+			wantCategory:   "sigkill",
+			wantConfidence: "medium",
 		},
 		{
 			name:           "exit code 139 segfault",
@@ -82,7 +96,7 @@ func TestAnalyze(t *testing.T) {
 			name:           "exit code 137 with panic log - exit code wins",
 			info:           CrashInfo{ExitCode: 137, ErrorLog: "panic: something went wrong", Backend: "docker"},
 			wantCategory:   "oom",
-			wantConfidence: "high",
+			wantConfidence: "medium",
 		},
 		{
 			name:           "multiple patterns matched",
@@ -149,3 +163,76 @@ func TestAnalyze(t *testing.T) {
 		})
 	}
 }
+
+func TestAnalyzeBuiltinPacks(t *testing.T) {
+	tests := []struct {
+		name, log, wantCategory, wantPattern string
+	}{
+		{"java OOM", `Exception in thread "main" java.lang.OutOfMemoryError: Java heap space`, "oom", "java OutOfMemoryError"},
+		{"python traceback", "Traceback (most recent call last):\n  File \"app.py\", line 3\nKeyError: 'id'", "exception", "python traceback"},
+		{"node rejection", "[UnhandledPromiseRejection: This error originated either by throwing inside of an async function]", "exception", "node unhandled rejection"},
+		{"postgres client", `FATAL:  password authentication failed for user "immich"`, "database", "postgres FATAL"},
+		{"postgres startup", `FATAL:  data directory "/var/lib/postgresql/data" has wrong ownership`, "database", "postgres FATAL startup"},
+		{"nginx emerg", `nginx: [emerg] unknown directive "proxy_passs" in /etc/nginx/conf.d/app.conf:12`, "config", "nginx [emerg]"},
+		{"traceback from a refused connection", "Traceback (most recent call last):\nredis.exceptions.ConnectionError: Connection refused", "dependency", "connection refused"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Analyze(CrashInfo{ExitCode: 1, ErrorLog: tt.log, Backend: "docker"})
+			if got.Category != tt.wantCategory {
+				t.Errorf("Category = %q, want %q (reason %q)", got.Category, tt.wantCategory, got.Reason)
+			}
+			if !slices.Contains(got.Patterns, tt.wantPattern) {
+				t.Errorf("Patterns = %v, missing %q", got.Patterns, tt.wantPattern)
+			}
+		})
+	}
+}
+
+func TestSetAnalysisRules(t *testing.T) {
+	t.Cleanup(func() { SetAnalysisRules(nil) })
+
+	err := SetAnalysisRules([]AnalysisRule{
+		{Name: "immich ml model", Pattern: `Failed to load model`, Category: "model", Reason: "Immich ML could not load its model cache"},
+		{Pattern: `(unclosed`, Category: "broken"},
+		{Pattern: `license expired`, Category: "license"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "rule 2") {
+		t.Errorf("err = %v, want the invalid rule reported", err)
+	}
+
+	// A user rule outranks built-ins, even the oom ones.
+	got := Analyze(CrashInfo{ExitCode: 1, ErrorLog: "out of memory\nFailed to load model clip", Backend: "docker"})
+	if got.Category != "model" || got.Reason != "Immich ML could not load its model cache" || got.Confidence != "medium" {
+		t.Errorf("user rule summary = %+v", got)
+	}
+	if !slices.Contains(got.Patterns, "immich ml model") || !slices.Contains(got.Patterns, "out of memory") {
+		t.Errorf("Patterns = %v, want both the user and the built-in match", got.Patterns)
+	}
+
+	// The rules after the invalid one still apply.
+	if got := Analyze(CrashInfo{ExitCode: 1, ErrorLog: "license expired", Backend: "docker"}); got.Category != "license" || got.Reason == "" {
+		t.Errorf("rule after an invalid one = %+v", got)
+	}
+
+	SetAnalysisRules(nil)
+	if got := Analyze(CrashInfo{ExitCode: 1, ErrorLog: "Failed to load model clip", Backend: "docker"}); got.Category != "error" {
+		t.Errorf("after clearing rules, Category = %q, want error", got.Category)
+	}
+}
+
+func TestAnalysisRuleValidate(t *testing.T) {
+	for _, r := range []AnalysisRule{
+		{Category: "x"},
+		{Pattern: "[", Category: "x"},
+		{Pattern: "x"},
+		{Pattern: "x", Category: "x", Confidence: "certain"},
+	} {
+		if r.Validate() == nil {
+			t.Errorf("%+v: expected a validation error", r)
+		}
+	}
+	if err := (AnalysisRule{Pattern: "x", Category: "x", Confidence: "low"}).Validate(); err != nil {
+		t.Errorf("valid rule: %v", err)
+	}
+}
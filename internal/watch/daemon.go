@@ -0,0 +1,523 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SocketPath is where `watch daemon` serves its control API.
+func SocketPath(dir string) string {
+	return filepath.Join(dir, "daemon.sock")
+}
+
+// PIDPath is the daemon's PID file.
+func PIDPath(dir string) string {
+	return filepath.Join(dir, "daemon.pid")
+}
+
+// DaemonConfig is what the daemon runs with. Load builds it at start and on
+// every reload.
+type DaemonConfig struct {
+	Targets  []Target
+	Config   *WatchConfig
+	Notifier *WatchNotifier
+}
+
+// Daemon runs the monitors in the background and serves a control API on
+// a unix socket: status, reload, pause and resume a target, and recent
+// incidents.
+type Daemon struct {
+	// Dir is the watch directory: incidents, the PID file and the socket.
+	Dir string
+
+	// Interval is the polling interval for the polling monitors.
+	Interval time.Duration
+
+	// Load reads targets.json and the watch config.
+	Load func() (*DaemonConfig, error)
+
+	// Monitor returns the monitor for a target kind. Nil uses NewMonitor
+	// without a directory, so that only incidents the daemon keeps are
+	// stored: a paused target's are dropped.
+	Monitor func(kind string) Monitor
+
+	// Logf reports incidents, reloads and errors. Nil discards them.
+	Logf func(format string, args ...any)
+
+	// reloadMu serializes calls to Load: a SIGHUP and a reload request can
+	// arrive together, and Load is not safe to run concurrently.
+	reloadMu sync.Mutex
+
+	mu         sync.Mutex
+	cfg        *DaemonConfig
+	paused     map[string]bool
+	started    time.Time
+	reloads    int
+	lastReload time.Time
+	incidents  int
+	lastInc    *Incident
+	restart    chan struct{}
+	run        *Runner
+}
+
+// kindMonitor is the running monitor of one target kind.
+type kindMonitor struct {
+	targets []Target
+	stop    context.CancelFunc
+	done    chan struct{}
+}
+
+// DaemonStatus is the daemon's answer to GET /v1/status.
+type DaemonStatus struct {
+	PID        int            `json:"pid"`
+	StartedAt  time.Time      `json:"started_at"`
+	Interval   string         `json:"interval"`
+	Targets    []DaemonTarget `json:"targets"`
+	Reloads    int            `json:"reloads"`
+	LastReload *time.Time     `json:"last_reload,omitempty"`
+	Incidents  int            `json:"incidents"` // handled since the daemon started
+	Last       *IncidentBrief `json:"last_incident,omitempty"`
+}
+
+// DaemonTarget is one watched target in DaemonStatus.
+type DaemonTarget struct {
+	Name   string `json:"name"`
+	Kind   string `json:"kind"`
+	Paused bool   `json:"paused"`
+}
+
+// IncidentBrief is an incident without its logs.
+type IncidentBrief struct {
+	ID         string    `json:"id"`
+	Container  string    `json:"container"`
+	DetectedAt time.Time `json:"detected_at"`
+	Category   string    `json:"category,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
+	Flapping   string    `json:"flapping,omitempty"` // flapping level, if any
+	Status     string    `json:"status"`
+	Recovery   string    `json:"recovery,omitempty"` // recovery verdict, once checked
+}
+
+func briefOf(inc Incident) IncidentBrief {
+	b := IncidentBrief{ID: inc.ID, Container: inc.Container, DetectedAt: inc.DetectedAt, Status: inc.EffectiveStatus()}
+	if inc.CrashAnalysis != nil {
+		b.Category, b.Reason = inc.CrashAnalysis.Category, inc.CrashAnalysis.Reason
+	}
+	if inc.Flapping != nil {
+		b.Flapping = inc.Flapping.Level
+	}
+	if inc.Recovery != nil {
+		b.Recovery = inc.Recovery.Verdict
+	}
+	return b
+}
+
+func (d *Daemon) logf(format string, args ...any) {
+	if d.Logf != nil {
+		d.Logf(format, args...)
+	}
+}
+
+// Run starts the daemon and blocks until ctx is cancelled. It refuses to
+// start while another daemon answers on the socket.
+func (d *Daemon) Run(ctx context.Context) error {
+	if err := ensureDir(d.Dir); err != nil {
+		return err
+	}
+	sock := SocketPath(d.Dir)
+	if st, err := NewDaemonClient(d.Dir).Status(ctx); err == nil {
+		return fmt.Errorf("watch daemon is already running (pid %d)", st.PID)
+	}
+	_ = os.Remove(sock) // left behind by a daemon that did not shut down
+
+	d.reloadMu.Lock()
+	cfg, err := d.Load()
+	d.reloadMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		return fmt.Errorf("control socket: %w", err)
+	}
+	defer os.Remove(sock)
+	if err := os.Chmod(sock, 0o600); err != nil {
+		ln.Close()
+		return fmt.Errorf("control socket: %w", err)
+	}
+
+	pidFile := PIDPath(d.Dir)
+	if err := os.WriteFile(pidFile, []byte(strconv.Itoa(os.Getpid())+"\n"), 0o644); err != nil {
+		ln.Close()
+		return fmt.Errorf("write PID file: %w", err)
+	}
+	defer os.Remove(pidFile)
+
+	d.mu.Lock()
+	d.cfg = cfg
+	d.run = d.runner(cfg)
+	d.paused = make(map[string]bool)
+	d.started = time.Now()
+	d.restart = make(chan struct{}, 1)
+	d.mu.Unlock()
+
+	srv := &http.Server{Handler: d.handler(), ReadHeaderTimeout: 5 * time.Second}
+	go func() { _ = srv.Serve(ln) }()
+	defer srv.Close()
+
+	// One goroutine handles the incidents of every monitor, with the
+	// runner of the config loaded last.
+	incCh := make(chan Incident, 64)
+	handled := make(chan struct{})
+	go func() {
+		defer close(handled)
+		var verifying sync.WaitGroup
+		for inc := range incCh {
+			d.mu.Lock()
+			run, targets, paused := d.run, d.cfg.Targets, d.paused[inc.Container]
+			d.mu.Unlock()
+			if paused {
+				continue
+			}
+			run.Handle(ctx, inc, targets, &verifying)
+		}
+		verifying.Wait()
+	}()
+
+	monitors := make(map[string]*kindMonitor)
+	for ctx.Err() == nil {
+		d.syncMonitors(ctx, monitors, incCh)
+		select {
+		case <-ctx.Done():
+		case <-d.restart:
+		}
+	}
+	for _, m := range monitors {
+		<-m.done
+	}
+	close(incCh)
+	<-handled
+	return nil
+}
+
+// syncMonitors starts, restarts or stops the monitor of each kind so that
+// it watches the loaded targets. A monitor whose targets did not change
+// keeps running, and with it what it knows about them: a polling monitor
+// restarted would take a crash since its last poll as its new baseline.
+func (d *Daemon) syncMonitors(ctx context.Context, monitors map[string]*kindMonitor, incCh chan<- Incident) {
+	d.mu.Lock()
+	run, groups := d.run, GroupByKind(d.cfg.Targets)
+	d.mu.Unlock()
+	for _, kind := range Kinds {
+		m, targets := monitors[kind], groups[kind]
+		if m != nil && slices.Equal(m.targets, targets) {
+			continue
+		}
+		if m != nil {
+			m.stop()
+			<-m.done
+			delete(monitors, kind)
+		}
+		if len(targets) == 0 {
+			continue
+		}
+		kindCtx, stop := context.WithCancel(ctx)
+		m = &kindMonitor{targets: targets, stop: stop, done: make(chan struct{})}
+		monitors[kind] = m
+		go func() {
+			defer close(m.done)
+			run.WatchKind(kindCtx, kind, targets, incCh)
+		}()
+	}
+}
+
+// runner builds the runner for cfg. Its monitors are started by
+// syncMonitors; the runner of the config loaded last handles the incidents
+// of every one of them.
+func (d *Daemon) runner(cfg *DaemonConfig) *Runner {
+	monitor := d.Monitor
+	if monitor == nil {
+		monitor = func(kind string) Monitor {
+			m := NewMonitor(kind, "", d.Interval, 0)
+			if km, ok := m.(*K8sMonitor); ok {
+				km.Logf = func(format string, args ...any) { d.logf("[k8s-monitor] "+format, args...) }
+			}
+			return m
+		}
+	}
+	r := &Runner{
+		Dir:      d.Dir,
+		Interval: d.Interval,
+		Config:   cfg.Config,
+		Notifier: cfg.Notifier,
+		Monitor:  monitor,
+		OnIncident: func(inc Incident, flap FlappingResult, summary CrashSummary) {
+			d.mu.Lock()
+			d.incidents++
+			d.lastInc = &inc
+			d.mu.Unlock()
+			msg := fmt.Sprintf("incident %s: %s — %s (%s, confidence: %s)", inc.ID, inc.Container, summary.Reason, summary.Category, summary.Confidence)
+			if flap.IsFlapping {
+				msg += fmt.Sprintf(" [FLAPPING %s: %d restarts in %s]", flap.Level, flap.Count, flap.Window)
+			}
+			d.logf("%s", msg)
+		},
+		OnRecovery: func(inc Incident) {
+			d.logf("incident %s: %s %s", inc.ID, inc.Container, inc.Recovery.Summary())
+		},
+		OnRemediation: func(inc Incident) {
+			for _, act := range inc.Remediation {
+				d.logf("incident %s: %s remediation %s", inc.ID, inc.Container, act.Summary())
+			}
+		},
+		OnError: func(kind string, err error) {
+			d.logf("[%s-monitor] error: %v", kind, err)
+		},
+	}
+	r.prepare()
+	return r
+}
+
+func (d *Daemon) kick() {
+	select {
+	case d.restart <- struct{}{}:
+	default:
+	}
+}
+
+// Reload re-reads targets and config. Only the monitors of kinds whose
+// targets changed are restarted; the new config applies to every incident
+// from then on. On error the daemon keeps running with what it had. Paused
+// targets stay paused.
+func (d *Daemon) Reload() error {
+	// Held until the new config is in place, so that of two overlapping
+	// reloads the later load is the one kept.
+	d.reloadMu.Lock()
+	defer d.reloadMu.Unlock()
+	cfg, err := d.Load()
+	if err != nil {
+		d.logf("reload failed, keeping the current config: %v", err)
+		return err
+	}
+	run := d.runner(cfg)
+	d.mu.Lock()
+	d.cfg = cfg
+	d.run = run
+	d.reloads++
+	d.lastReload = time.Now()
+	d.mu.Unlock()
+	d.logf("reloaded: %d targets", len(cfg.Targets))
+	d.kick()
+	return nil
+}
+
+// SetPaused pauses or resumes a target. A paused target is still watched,
+// so that no monitor loses what it knows, but its incidents are dropped:
+// nothing about it is recorded or sent until it is resumed.
+func (d *Daemon) SetPaused(name string, paused bool) error {
+	d.mu.Lock()
+	known := slices.ContainsFunc(d.cfg.Targets, func(t Target) bool { return t.Container == name })
+	changed := known && d.paused[name] != paused
+	if changed {
+		if paused {
+			d.paused[name] = true
+		} else {
+			delete(d.paused, name)
+		}
+	}
+	d.mu.Unlock()
+	if !known {
+		return fmt.Errorf("%w: %q", errUnknownTarget, name)
+	}
+	if changed {
+		verb := "resumed"
+		if paused {
+			verb = "paused"
+		}
+		d.logf("%s %s", verb, name)
+	}
+	return nil
+}
+
+var errUnknownTarget = errors.New("not a watched target")
+
+// Status reports what the daemon is doing.
+func (d *Daemon) Status() DaemonStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	st := DaemonStatus{
+		PID:       os.Getpid(),
+		StartedAt: d.started,
+		Interval:  d.Interval.String(),
+		Targets:   []DaemonTarget{},
+		Reloads:   d.reloads,
+		Incidents: d.incidents,
+	}
+	for _, t := range d.cfg.Targets {
+		st.Targets = append(st.Targets, DaemonTarget{Name: t.Container, Kind: t.EffectiveKind(), Paused: d.paused[t.Container]})
+	}
+	if !d.lastReload.IsZero() {
+		last := d.lastReload
+		st.LastReload = &last
+	}
+	if d.lastInc != nil {
+		b := briefOf(*d.lastInc)
+		st.Last = &b
+	}
+	return st
+}
+
+func (d *Daemon) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/status", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, d.Status())
+	})
+	mux.HandleFunc("POST /v1/reload", func(w http.ResponseWriter, r *http.Request) {
+		if err := d.Reload(); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, d.Status())
+	})
+	for _, action := range []string{"pause", "resume"} {
+		mux.HandleFunc("POST /v1/targets/{name}/"+action, func(w http.ResponseWriter, r *http.Request) {
+			if err := d.SetPaused(r.PathValue("name"), action == "pause"); err != nil {
+				writeError(w, http.StatusNotFound, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, d.Status())
+		})
+	}
+	mux.HandleFunc("GET /v1/incidents", func(w http.ResponseWriter, r *http.Request) {
+		limit := 20
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit %q", v))
+				return
+			}
+			limit = n
+		}
+		incidents, err := ListIncidents(d.Dir)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		briefs := []IncidentBrief{}
+		for _, inc := range incidents[:min(limit, len(incidents))] {
+			briefs = append(briefs, briefOf(inc))
+		}
+		writeJSON(w, http.StatusOK, briefs)
+	})
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+// DaemonClient talks to a running daemon over its control socket.
+type DaemonClient struct {
+	http *http.Client
+}
+
+// NewDaemonClient returns a client for the daemon serving dir.
+func NewDaemonClient(dir string) *DaemonClient {
+	sock := SocketPath(dir)
+	dialer := &net.Dialer{Timeout: 2 * time.Second}
+	return &DaemonClient{http: &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, "unix", sock)
+			},
+		},
+	}}
+}
+
+// ErrDaemonNotRunning is returned when nothing answers on the socket.
+var ErrDaemonNotRunning = errors.New("watch daemon is not running (start it with 'homebutler watch daemon')")
+
+func (c *DaemonClient) do(ctx context.Context, method, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, "http://daemon"+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return ErrDaemonNotRunning
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&e) == nil && e.Error != "" {
+			return errors.New(e.Error)
+		}
+		return fmt.Errorf("watch daemon returned %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Status returns the daemon's status.
+func (c *DaemonClient) Status(ctx context.Context) (*DaemonStatus, error) {
+	var st DaemonStatus
+	if err := c.do(ctx, http.MethodGet, "/v1/status", &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// Reload asks the daemon to re-read targets and config.
+func (c *DaemonClient) Reload(ctx context.Context) (*DaemonStatus, error) {
+	var st DaemonStatus
+	if err := c.do(ctx, http.MethodPost, "/v1/reload", &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// SetPaused pauses or resumes a target.
+func (c *DaemonClient) SetPaused(ctx context.Context, name string, paused bool) (*DaemonStatus, error) {
+	action := "resume"
+	if paused {
+		action = "pause"
+	}
+	var st DaemonStatus
+	if err := c.do(ctx, http.MethodPost, "/v1/targets/"+url.PathEscape(name)+"/"+action, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// Incidents returns up to limit of the most recent incidents.
+func (c *DaemonClient) Incidents(ctx context.Context, limit int) ([]IncidentBrief, error) {
+	var out []IncidentBrief
+	if err := c.do(ctx, http.MethodGet, "/v1/incidents?limit="+strconv.Itoa(limit), &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,247 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeMonitor reports the targets of every run, sends one incident for
+// the first target of its first run, and then whatever is put on inject.
+type fakeMonitor struct {
+	mu     sync.Mutex
+	runs   [][]string
+	sent   bool
+	inject chan Incident
+}
+
+func (f *fakeMonitor) Watch(ctx context.Context, targets []Target, incidents chan<- Incident) error {
+	var names []string
+	for _, t := range targets {
+		names = append(names, t.Container)
+	}
+	f.mu.Lock()
+	f.runs = append(f.runs, names)
+	send := !f.sent
+	f.sent = true
+	f.mu.Unlock()
+	if send {
+		now := time.Now()
+		incidents <- Incident{ID: GenerateIncidentID(names[0], now), Container: names[0], DetectedAt: now, PreLogs: "panic: boom"}
+	}
+	for {
+		select {
+		case inc := <-f.inject:
+			incidents <- inc
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (f *fakeMonitor) runCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.runs)
+}
+
+func (f *fakeMonitor) lastRun() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.runs) == 0 {
+		return nil
+	}
+	return f.runs[len(f.runs)-1]
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDaemon(t *testing.T) {
+	dir, err := os.MkdirTemp("", "hbd") // unix socket paths are short
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	fake := &fakeMonitor{inject: make(chan Incident)}
+	systemd := &fakeMonitor{sent: true}
+	var loadMu sync.Mutex
+	targets := []Target{{Container: "api", Kind: "process"}, {Container: "db", Kind: "process"}}
+	loadErr := error(nil)
+	d := &Daemon{
+		Dir:      dir,
+		Interval: 30 * time.Second,
+		Monitor: func(kind string) Monitor {
+			if kind == "systemd" {
+				return systemd
+			}
+			return fake
+		},
+		Load: func() (*DaemonConfig, error) {
+			loadMu.Lock()
+			defer loadMu.Unlock()
+			if loadErr != nil {
+				return nil, loadErr
+			}
+			return &DaemonConfig{Targets: slices.Clone(targets)}, nil
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- d.Run(ctx) }()
+
+	c := NewDaemonClient(dir)
+	var st *DaemonStatus
+	waitFor(t, "the control socket", func() bool {
+		st, err = c.Status(ctx)
+		return err == nil
+	})
+	if st.PID != os.Getpid() || len(st.Targets) != 2 || st.Interval != "30s" {
+		t.Errorf("status = %+v", st)
+	}
+	if pid, _ := os.ReadFile(PIDPath(dir)); strings.TrimSpace(string(pid)) != strconv.Itoa(os.Getpid()) {
+		t.Errorf("PID file = %q", pid)
+	}
+
+	// The fake's incident goes through the runner: analyzed, stored, counted.
+	waitFor(t, "the incident", func() bool {
+		st, _ = c.Status(ctx)
+		return st.Incidents == 1
+	})
+	if st.Last == nil || st.Last.Container != "api" || st.Last.Category != "panic" {
+		t.Errorf("last incident = %+v", st.Last)
+	}
+	incs, err := c.Incidents(ctx, 5)
+	if err != nil || len(incs) != 1 || incs[0].ID != st.Last.ID {
+		t.Errorf("incidents = %+v, %v", incs, err)
+	}
+
+	if err := (&Daemon{Dir: dir}).Run(ctx); err == nil || !strings.Contains(err.Error(), "already running") {
+		t.Errorf("second daemon: err = %v", err)
+	}
+
+	// A paused target is still watched, but its incidents are dropped.
+	if st, err = c.SetPaused(ctx, "api", true); err != nil || !st.Targets[0].Paused {
+		t.Fatalf("pause: %+v, %v", st, err)
+	}
+	inject := func(name string) {
+		now := time.Now()
+		fake.inject <- Incident{ID: GenerateIncidentID(name, now), Container: name, DetectedAt: now, PreLogs: "panic: boom"}
+	}
+	inject("api")
+	inject("db")
+	waitFor(t, "the db incident", func() bool {
+		st, _ = c.Status(ctx)
+		return st.Incidents == 2
+	})
+	if st.Last.Container != "db" {
+		t.Errorf("last incident = %+v, want db's with api's dropped", st.Last)
+	}
+	if fake.runCount() != 1 {
+		t.Errorf("pausing restarted the monitor: runs = %v", fake.runs)
+	}
+	if _, err := c.SetPaused(ctx, "nope", true); err == nil || !strings.Contains(err.Error(), "not a watched target") {
+		t.Errorf("pause unknown target: err = %v", err)
+	}
+
+	// A reload restarts the monitor whose targets changed and keeps api
+	// paused.
+	loadMu.Lock()
+	targets = append(targets, Target{Container: "cache", Kind: "process"})
+	loadMu.Unlock()
+	if st, err = c.Reload(ctx); err != nil || len(st.Targets) != 3 || st.Reloads != 1 || !st.Targets[0].Paused {
+		t.Fatalf("reload: %+v, %v", st, err)
+	}
+	waitFor(t, "the reloaded targets", func() bool { return slices.Equal(fake.lastRun(), []string{"api", "db", "cache"}) })
+
+	// A target of another kind starts its monitor and leaves this one be.
+	loadMu.Lock()
+	targets = append(targets, Target{Container: "web", Kind: "systemd"})
+	loadMu.Unlock()
+	if _, err := c.Reload(ctx); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the systemd monitor", func() bool { return slices.Equal(systemd.lastRun(), []string{"web"}) })
+
+	// A failed reload keeps what the daemon had.
+	loadMu.Lock()
+	loadErr = errors.New("config error: bad yaml")
+	loadMu.Unlock()
+	if _, err := c.Reload(ctx); err == nil || !strings.Contains(err.Error(), "bad yaml") {
+		t.Errorf("failed reload: err = %v", err)
+	}
+	if st, _ = c.Status(ctx); len(st.Targets) != 4 {
+		t.Errorf("targets after a failed reload = %+v", st.Targets)
+	}
+
+	if _, err := c.SetPaused(ctx, "api", false); err != nil {
+		t.Fatal(err)
+	}
+	inject("api")
+	waitFor(t, "api's incident after resuming", func() bool {
+		st, _ = c.Status(ctx)
+		return st.Incidents == 3
+	})
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run = %v", err)
+	}
+	if fake.runCount() != 2 || systemd.runCount() != 1 {
+		t.Errorf("monitor runs = %v and %v, want the process monitor restarted once", fake.runs, systemd.runs)
+	}
+	if _, err := os.Stat(SocketPath(dir)); !os.IsNotExist(err) {
+		t.Error("socket left behind")
+	}
+	if _, err := os.Stat(PIDPath(dir)); !os.IsNotExist(err) {
+		t.Error("PID file left behind")
+	}
+	if _, err := c.Status(context.Background()); !errors.Is(err, ErrDaemonNotRunning) {
+		t.Errorf("status after stop: err = %v", err)
+	}
+}
+
+func TestDaemonReloadSerializesLoad(t *testing.T) {
+	var inFlight, overlaps atomic.Int32
+	d := &Daemon{Load: func() (*DaemonConfig, error) {
+		if inFlight.Add(1) > 1 {
+			overlaps.Add(1)
+		}
+		time.Sleep(5 * time.Millisecond)
+		inFlight.Add(-1)
+		return &DaemonConfig{}, nil
+	}}
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = d.Reload()
+		}()
+	}
+	wg.Wait()
+	if overlaps.Load() != 0 {
+		t.Errorf("Load ran concurrently %d time(s)", overlaps.Load())
+	}
+	if d.reloads != 4 {
+		t.Errorf("reloads = %d, want 4", d.reloads)
+	}
+}
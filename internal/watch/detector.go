@@ -1,10 +1,11 @@
 package watch
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"time"
 
+	"github.com/Higangssh/homebutler/internal/docker"
 	"github.com/Higangssh/homebutler/internal/util"
 )
 
@@ -28,17 +29,19 @@ type RestartEvent struct {
 }
 
 func InspectContainer(name string) (*InspectResult, error) {
-	out, err := util.DockerCmd("inspect",
-		"--format", `{"restart_count":{{.RestartCount}},"started_at":"{{.State.StartedAt}}","running":{{.State.Running}}}`,
-		name)
+	client, err := docker.DefaultClient()
 	if err != nil {
 		return nil, fmt.Errorf("docker inspect %s: %w", name, err)
 	}
-	var result InspectResult
-	if err := json.Unmarshal([]byte(out), &result); err != nil {
-		return nil, fmt.Errorf("parse inspect output for %s: %w", name, err)
+	info, err := client.ContainerInspect(context.Background(), name)
+	if err != nil {
+		return nil, fmt.Errorf("docker inspect %s: %w", name, err)
 	}
-	return &result, nil
+	return &InspectResult{
+		RestartCount: info.RestartCount,
+		StartedAt:    info.State.StartedAt,
+		Running:      info.State.Running,
+	}, nil
 }
 
 func DetectRestart(prev *ContainerState, curr *InspectResult) *RestartEvent {
@@ -8,6 +8,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/Higangssh/homebutler/internal/docker/dockertest"
 )
 
 func TestDetectRestart_NilPrev(t *testing.T) {
@@ -143,6 +145,27 @@ func TestDetectRestart_RestartCountIncrease_SameStartedAt(t *testing.T) {
 	}
 }
 
+func TestInspectContainerFromEngine(t *testing.T) {
+	srv := dockertest.NewServer(t).Use(t)
+	srv.JSON("GET /containers/web/json", map[string]any{
+		"Id":           "0123456789abcdef",
+		"Name":         "/web",
+		"RestartCount": 3,
+		"State":        map[string]any{"Running": true, "StartedAt": "2025-01-01T00:00:00Z"},
+	})
+
+	got, err := InspectContainer("web")
+	if err != nil {
+		t.Fatalf("InspectContainer: %v", err)
+	}
+	if got.RestartCount != 3 || got.StartedAt != "2025-01-01T00:00:00Z" || !got.Running {
+		t.Errorf("got %+v", got)
+	}
+	if _, err := InspectContainer("missing"); err == nil || !strings.Contains(err.Error(), "docker inspect missing") {
+		t.Errorf("err = %v", err)
+	}
+}
+
 func TestInspectResultStruct(t *testing.T) {
 	r := InspectResult{
 		RestartCount: 3,
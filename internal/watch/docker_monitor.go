@@ -7,10 +7,11 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/Higangssh/homebutler/internal/docker"
 	"github.com/Higangssh/homebutler/internal/util"
 )
 
@@ -29,7 +30,8 @@ type DockerMonitor struct {
 	// PostLogDelay is how long to wait after a die event before capturing post-restart logs.
 	PostLogDelay time.Duration
 
-	// Events creates the docker events stream. Nil defaults to exec.CommandContext("docker", "events", ...).
+	// Events creates the docker events stream. Nil defaults to the Engine
+	// API's /events endpoint, filtered to die events.
 	Events EventStreamer
 
 	// Keep caps how many incidents are retained; zero or less keeps everything.
@@ -44,6 +46,7 @@ type dockerEvent struct {
 }
 
 type dockerEventActor struct {
+	ID         string            `json:"ID"`
 	Attributes map[string]string `json:"Attributes"`
 }
 
@@ -54,6 +57,10 @@ func (e *dockerEvent) containerName() string {
 			return name
 		}
 	}
+	if e.ID == "" {
+		// Newer API versions drop the legacy top-level id.
+		return e.Actor.ID
+	}
 	return e.ID
 }
 
@@ -66,41 +73,46 @@ func (dm *DockerMonitor) Watch(ctx context.Context, targets []Target, incidents
 
 	run := dm.Run
 	if run == nil {
-		// Default runner: first arg is the binary name (e.g. "docker"),
+		// Default runner: first arg is the binary name (docker or podman),
 		// consistent with all other monitors.
 		run = func(name string, args ...string) (string, error) {
 			return util.RunCmd(name, args...)
 		}
 	}
 
+	binary := util.ContainerRuntime().Binary
+
 	delay := dm.PostLogDelay
 	if delay == 0 {
 		delay = 5 * time.Second
 	}
 
-	// Build a set of watched container names
+	// Build a set of watched container names, and take the baseline of
+	// their cgroups' OOM kill counts.
 	watched := make(map[string]bool, len(targets))
 	for _, t := range targets {
 		watched[t.EffectiveUnit()] = true
+		if id, err := run(binary, "inspect", "--format", "{{.Id}}", t.EffectiveUnit()); err == nil {
+			seedCgroupOOM(dockerCgroupDirs(strings.TrimSpace(id))...)
+		}
 	}
 
 	// Start docker events stream
 	evStream := dm.Events
 	if evStream == nil {
 		evStream = func(ctx context.Context) (io.ReadCloser, func(), error) {
-			util.EnsureDockerHost()
-			cmd := exec.CommandContext(ctx, "docker", "events",
-				"--filter", "event=die",
-				"--format", "{{json .}}")
-			stdout, err := cmd.StdoutPipe()
+			client, err := docker.DefaultClient()
 			if err != nil {
-				return nil, nil, fmt.Errorf("docker events pipe: %w", err)
+				return nil, nil, fmt.Errorf("docker events: %w", err)
 			}
-			if err := cmd.Start(); err != nil {
-				return nil, nil, fmt.Errorf("docker events start: %w", err)
+			body, err := client.Events(ctx, map[string][]string{
+				"type":  {"container"},
+				"event": {"die"},
+			})
+			if err != nil {
+				return nil, nil, fmt.Errorf("docker events: %w", err)
 			}
-			cleanup := func() { _ = cmd.Wait() }
-			return stdout, cleanup, nil
+			return body, func() { _ = body.Close() }, nil
 		}
 	}
 
@@ -163,7 +175,7 @@ func (dm *DockerMonitor) Watch(ctx context.Context, targets []Target, incidents
 			}
 
 			// Capture pre-death logs immediately (the container just died)
-			preLogs := captureLogsWithRunner(run, "docker", name, "100")
+			preLogs := captureLogsWithRunner(run, binary, name, "100")
 
 			now := time.Now()
 
@@ -171,18 +183,19 @@ func (dm *DockerMonitor) Watch(ctx context.Context, targets []Target, incidents
 			postLogs := ""
 			select {
 			case <-time.After(delay):
-				postLogs = captureLogsWithRunner(run, "docker", name, "50")
+				postLogs = captureLogsWithRunner(run, binary, name, "50")
 			case <-ctx.Done():
 			}
 
 			inc := Incident{
-				ID:          GenerateIncidentID(name, now),
-				Container:   name,
-				DetectedAt:  now,
-				PrevStarted: fmt.Sprintf("died at event time %d", ev.Time),
-				CurrStarted: "(post-restart)",
-				PreLogs:     preLogs,
-				PostLogs:    postLogs,
+				ID:            GenerateIncidentID(name, now),
+				Container:     name,
+				DetectedAt:    now,
+				PrevStarted:   fmt.Sprintf("died at event time %d", ev.Time),
+				CurrStarted:   "(post-restart)",
+				PreLogs:       preLogs,
+				PostLogs:      postLogs,
+				CrashAnalysis: dm.analyze(ev, preLogs, now),
 			}
 			if dm.Dir != "" {
 				if err := SaveIncident(dm.Dir, &inc, dm.Keep); err != nil {
@@ -203,6 +216,34 @@ func (dm *DockerMonitor) Watch(ctx context.Context, targets []Target, incidents
 	}
 }
 
+// analyze classifies a die event from its exit code and the pre-death
+// logs. A SIGKILL is checked against the kernel's OOM kills in the
+// container's cgroup, which tells the OOM killer apart from `docker kill`.
+func (dm *DockerMonitor) analyze(ev dockerEvent, preLogs string, now time.Time) *CrashSummary {
+	info := CrashInfo{ErrorLog: preLogs, Backend: "docker"}
+	info.ExitCode, _ = strconv.Atoi(ev.Actor.Attributes["exitCode"])
+	if info.mayBeOOMKill() {
+		died := now
+		if ev.Time > 0 {
+			died = time.Unix(ev.Time, 0)
+		}
+		id := ev.Actor.ID
+		if id == "" {
+			id = ev.ID
+		}
+		// A remote Engine's kernel is not ours to read.
+		local := util.ContainerRuntime().Socket != ""
+		correlateOOM(&info, oomQuery{
+			Since:      died.Add(-time.Minute),
+			Until:      died,
+			CgroupIDs:  []string{id},
+			CgroupDirs: dockerCgroupDirs(id),
+		}, local)
+	}
+	summary := Analyze(info)
+	return &summary
+}
+
 func captureLogsWithRunner(run CommandRunner, binary string, container string, lines string) string {
 	out, err := run(binary, "logs", "--tail", lines, container)
 	if err != nil {
@@ -6,9 +6,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/Higangssh/homebutler/internal/docker/dockertest"
 )
 
 func TestDockerEvent_ContainerName(t *testing.T) {
@@ -62,6 +65,50 @@ func TestDockerEvent_ParseJSON(t *testing.T) {
 	}
 }
 
+func TestDockerEvent_ActorIDFallback(t *testing.T) {
+	// Current API versions no longer send the top-level id.
+	raw := `{"Type":"container","Action":"die","Actor":{"ID":"deadbeef","Attributes":{}},"time":1700000000}`
+	var ev dockerEvent
+	if err := json.Unmarshal([]byte(raw), &ev); err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if ev.containerName() != "deadbeef" {
+		t.Errorf("expected deadbeef, got %s", ev.containerName())
+	}
+}
+
+func TestDockerMonitor_Watch_EngineEvents(t *testing.T) {
+	// With no Events override, the monitor reads the Engine API stream.
+	srv := dockertest.NewServer(t).Use(t)
+	srv.Handle("GET /events", func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.URL.Query().Get("filters"), `"die"`) {
+			t.Errorf("filters = %s", r.URL.Query().Get("filters"))
+		}
+		fmt.Fprintln(w, `{"Type":"container","Action":"die","Actor":{"ID":"abc","Attributes":{"name":"other"}},"time":1700000000}`)
+		fmt.Fprintln(w, `{"Type":"container","Action":"die","Actor":{"ID":"def","Attributes":{"name":"nginx","exitCode":"137"}},"time":1700000001}`)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+
+	dm := &DockerMonitor{
+		Run:          func(name string, args ...string) (string, error) { return "logs", nil },
+		PostLogDelay: time.Millisecond,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	incCh := make(chan Incident, 10)
+	go func() { _ = dm.Watch(ctx, []Target{{Container: "nginx", Kind: "docker"}}, incCh) }()
+
+	select {
+	case inc := <-incCh:
+		if inc.Container != "nginx" || inc.PrevStarted != "died at event time 1700000001" {
+			t.Errorf("incident = %+v", inc)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for incident from the engine stream")
+	}
+}
+
 func TestCaptureLogsWithRunner_Success(t *testing.T) {
 	runner := func(name string, args ...string) (string, error) {
 		return "line1\nline2\nline3", nil
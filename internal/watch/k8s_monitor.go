@@ -0,0 +1,243 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Higangssh/homebutler/internal/kube"
+)
+
+// K8sMonitor watches Kubernetes (including k3s) workloads by polling pod
+// container restart counts through the API server.
+type K8sMonitor struct {
+	// Client talks to the API server. Nil loads the default kubeconfig.
+	Client *kube.Client
+
+	// Dir is the storage directory for incidents.
+	Dir string
+
+	// Interval is the polling interval.
+	Interval time.Duration
+
+	// Keep caps how many incidents are retained; zero or less keeps everything.
+	Keep int
+
+	// Logf reports a namespace that cannot be listed, and its recovery.
+	// Nil writes to stderr.
+	Logf func(format string, args ...any)
+}
+
+func (km *K8sMonitor) logf(format string, args ...any) {
+	if km.Logf != nil {
+		km.Logf(format, args...)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "[k8s-monitor] "+format+"\n", args...)
+}
+
+// k8sUnit splits a k8s target's unit, "namespace/name", defaulting the
+// namespace to "default".
+func k8sUnit(t Target) (namespace, name string) {
+	if ns, name, ok := strings.Cut(t.EffectiveUnit(), "/"); ok {
+		return ns, name
+	}
+	return "default", t.EffectiveUnit()
+}
+
+// podMatches reports whether pod belongs to the workload called name: the
+// pod itself, the Deployment/StatefulSet/DaemonSet that owns it, or the app
+// named by its app.kubernetes.io/name or app label.
+func podMatches(pod kube.Pod, name string) bool {
+	if pod.Metadata.Name == name || pod.WorkloadName() == name {
+		return true
+	}
+	l := pod.Metadata.Labels
+	return l["app.kubernetes.io/name"] == name || l["app"] == name
+}
+
+// Watch polls the pods of each watched workload and sends an incident when a
+// container's restart count goes up.
+func (km *K8sMonitor) Watch(ctx context.Context, targets []Target, incidents chan<- Incident) error {
+	if len(targets) == 0 {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	client := km.Client
+	if client == nil {
+		c, err := kube.DefaultClient()
+		if err != nil {
+			return fmt.Errorf("kubernetes: %w", err)
+		}
+		client = c
+	}
+
+	interval := km.Interval
+	if interval == 0 {
+		interval = 30 * time.Second
+	}
+
+	byNamespace := make(map[string][]Target)
+	for _, t := range targets {
+		ns, _ := k8sUnit(t)
+		byNamespace[ns] = append(byNamespace[ns], t)
+	}
+
+	// Restart counts by namespace/pod/container. A namespace only counts as
+	// seeded once a list succeeded; after that, a container seen for the
+	// first time is new, so any restarts it has happened while watched.
+	prevRestarts := make(map[string]int)
+	seeded := make(map[string]bool)
+	// A namespace that cannot be listed (the API server is down, the token
+	// expired, RBAC forbids it) is reported once, and again when it can be
+	// listed again, rather than on every poll.
+	failing := make(map[string]bool)
+
+	poll := func() error {
+		for ns, nsTargets := range byNamespace {
+			pods, err := client.ListPods(ctx, ns)
+			if err != nil {
+				if !failing[ns] && ctx.Err() == nil {
+					km.logf("warning: cannot list pods in namespace %s, its targets are not watched until it can: %v", ns, err)
+					failing[ns] = true
+				}
+				continue
+			}
+			if failing[ns] {
+				km.logf("pods in namespace %s can be listed again", ns)
+				delete(failing, ns)
+			}
+			seen := make(map[string]bool)
+			for _, pod := range pods {
+				for _, t := range nsTargets {
+					_, name := k8sUnit(t)
+					if !podMatches(pod, name) {
+						continue
+					}
+					for _, cs := range pod.Status.ContainerStatuses {
+						key := ns + "/" + pod.Metadata.Name + "/" + cs.Name
+						seen[key] = true
+						prevCount, hasPrev := prevRestarts[key]
+						prevRestarts[key] = cs.RestartCount
+						if !seeded[ns] || (hasPrev && cs.RestartCount <= prevCount) || cs.RestartCount == 0 {
+							continue
+						}
+						inc := km.incident(ctx, client, t, pod, cs)
+						if km.Dir != "" {
+							if err := SaveIncident(km.Dir, &inc, km.Keep); err != nil {
+								fmt.Fprintf(os.Stderr, "[k8s-monitor] warning: save incident: %v\n", err)
+							}
+						}
+						select {
+						case incidents <- inc:
+						case <-ctx.Done():
+							return ctx.Err()
+						}
+					}
+					break
+				}
+			}
+			// Forget pods that are gone, so churn does not grow the map.
+			for key := range prevRestarts {
+				if strings.HasPrefix(key, ns+"/") && !seen[key] {
+					delete(prevRestarts, key)
+				}
+			}
+			seeded[ns] = true
+		}
+		return nil
+	}
+
+	// Seed initial state
+	if err := poll(); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := poll(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// incident builds the incident for a container restart: the log of the
+// instance that died, the log of the new one, and a crash analysis that
+// uses the exit code and OOMKilled reason Kubernetes recorded.
+func (km *K8sMonitor) incident(ctx context.Context, client *kube.Client, t Target, pod kube.Pod, cs kube.ContainerStatus) Incident {
+	ns, podName := pod.Metadata.Namespace, pod.Metadata.Name
+	if ns == "" {
+		ns, _ = k8sUnit(t)
+	}
+
+	preLogs, err := client.PodLogs(ctx, ns, podName, kube.LogOptions{Container: cs.Name, Previous: true, TailLines: 100})
+	if err != nil {
+		preLogs = fmt.Sprintf("(failed to capture previous logs: %v)", err)
+	}
+
+	var postLogs string
+	if cs.State.Waiting != nil {
+		// A container in back-off has no current log yet.
+		postLogs = fmt.Sprintf("state=waiting reason=%s", cs.State.Waiting.Reason)
+	} else if out, err := client.PodLogs(ctx, ns, podName, kube.LogOptions{Container: cs.Name, TailLines: 50}); err != nil {
+		postLogs = fmt.Sprintf("(failed to capture logs: %v)", err)
+	} else {
+		postLogs = out
+	}
+
+	where := "pod " + podName
+	if len(pod.Status.ContainerStatuses) > 1 {
+		where += ", container " + cs.Name
+	}
+	curr := "(not running)"
+	if cs.State.Running != nil {
+		curr = cs.State.Running.StartedAt
+	} else if cs.State.Waiting != nil {
+		curr = "waiting: " + cs.State.Waiting.Reason
+	}
+
+	info := CrashInfo{ErrorLog: preLogs, Backend: "k8s"}
+	prev := "(unknown)"
+	if last := cs.LastState.Terminated; last != nil {
+		prev = last.StartedAt
+		info.ExitCode = last.ExitCode
+		info.OOMKilled = last.Reason == "OOMKilled"
+		if last.Message != "" {
+			info.ErrorLog += "\n" + last.Message
+		}
+		if info.mayBeOOMKill() {
+			// The pod may run on another node, so only a match counts.
+			q := oomQuery{CgroupIDs: []string{pod.Metadata.UID, strings.ReplaceAll(pod.Metadata.UID, "-", "_")}}
+			if _, id, ok := strings.Cut(last.ContainerID, "://"); ok {
+				q.CgroupIDs = append(q.CgroupIDs, id)
+			}
+			q.Since, _ = time.Parse(time.RFC3339, last.StartedAt)
+			q.Until, _ = time.Parse(time.RFC3339, last.FinishedAt)
+			correlateOOM(&info, q, false)
+		}
+	}
+	summary := Analyze(info)
+
+	now := time.Now()
+	return Incident{
+		ID:            GenerateIncidentID(t.Container, now),
+		Container:     t.Container,
+		DetectedAt:    now,
+		RestartCount:  cs.RestartCount,
+		PrevStarted:   prev,
+		CurrStarted:   fmt.Sprintf("%s (%s)", curr, where),
+		PreLogs:       preLogs,
+		PostLogs:      postLogs,
+		CrashAnalysis: &summary,
+	}
+}
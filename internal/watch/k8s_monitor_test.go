@@ -0,0 +1,170 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Higangssh/homebutler/internal/kube"
+)
+
+// fakeAPIServer serves one deployment pod in namespace apps whose restart
+// count goes up by one on every list after the first.
+func fakeAPIServer(t *testing.T) *kube.Client {
+	t.Helper()
+	var lists atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/namespaces/apps/pods":
+			n := lists.Add(1)
+			fmt.Fprintf(w, `{"items": [
+				{"metadata": {"name": "api-6c4f7b9d8-q2w3e", "namespace": "apps",
+					"ownerReferences": [{"kind": "ReplicaSet", "name": "api-6c4f7b9d8"}]},
+				 "status": {"containerStatuses": [{"name": "api", "restartCount": %d,
+					"state": {"waiting": {"reason": "CrashLoopBackOff"}},
+					"lastState": {"terminated": {"exitCode": 137, "reason": "OOMKilled", "startedAt": "2026-10-18T03:00:00Z"}}}]}},
+				{"metadata": {"name": "other-0", "namespace": "apps",
+					"ownerReferences": [{"kind": "StatefulSet", "name": "other"}]},
+				 "status": {"containerStatuses": [{"name": "other", "restartCount": %d}]}}
+			]}`, n, n)
+		case "/api/v1/namespaces/apps/pods/api-6c4f7b9d8-q2w3e/log":
+			if r.URL.Query().Get("previous") != "true" {
+				t.Errorf("a container in back-off has no current log; query = %s", r.URL.RawQuery)
+			}
+			w.Write([]byte("starting worker pool\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	c, err := kube.NewClient(&kube.Config{Server: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestK8sMonitor_DetectRestart(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	dir := t.TempDir()
+	km := &K8sMonitor{Client: fakeAPIServer(t), Dir: dir, Interval: 50 * time.Millisecond}
+	incCh := make(chan Incident, 10)
+	targets := []Target{{Container: "api", Kind: "k8s", Unit: "apps/api"}}
+	go func() { _ = km.Watch(ctx, targets, incCh) }()
+
+	var inc Incident
+	select {
+	case inc = <-incCh:
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for incident")
+	}
+	if inc.Container != "api" || inc.RestartCount != 2 {
+		t.Errorf("incident = %s restart #%d, want api restart #2 (the seed poll must not report)", inc.Container, inc.RestartCount)
+	}
+	if inc.PreLogs != "starting worker pool\n" {
+		t.Errorf("PreLogs = %q, want the previous container's log", inc.PreLogs)
+	}
+	if inc.PostLogs != "state=waiting reason=CrashLoopBackOff" {
+		t.Errorf("PostLogs = %q", inc.PostLogs)
+	}
+	if inc.PrevStarted != "2026-10-18T03:00:00Z" || inc.CurrStarted != "waiting: CrashLoopBackOff (pod api-6c4f7b9d8-q2w3e)" {
+		t.Errorf("PrevStarted = %q, CurrStarted = %q", inc.PrevStarted, inc.CurrStarted)
+	}
+	ca := inc.CrashAnalysis
+	if ca == nil || ca.Category != "oom" || ca.Confidence != "high" || ca.ExitCode != 137 {
+		t.Errorf("CrashAnalysis = %+v, want oom from the OOMKilled reason", ca)
+	}
+	if saved, err := ListIncidents(dir); err != nil || len(saved) == 0 {
+		t.Errorf("incident not saved: %v", err)
+	}
+}
+
+func TestK8sMonitor_APIUnreachable(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	c, _ := kube.NewClient(&kube.Config{Server: "http://127.0.0.1:1"})
+	var logs []string
+	km := &K8sMonitor{Client: c, Interval: 50 * time.Millisecond, Logf: func(format string, args ...any) {
+		logs = append(logs, fmt.Sprintf(format, args...))
+	}}
+	incCh := make(chan Incident, 1)
+	err := km.Watch(ctx, []Target{{Container: "api", Kind: "k8s", Unit: "apps/api"}}, incCh)
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected the monitor to keep polling until cancelled, got %v", err)
+	}
+	if len(incCh) != 0 {
+		t.Error("no incident expected while the API server is down")
+	}
+	if len(logs) != 1 || !strings.Contains(logs[0], "cannot list pods in namespace apps") {
+		t.Errorf("want the failure reported once, got %q", logs)
+	}
+}
+
+func TestK8sMonitor_ReportsListRecovery(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var lists atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if lists.Add(1) <= 3 {
+			http.Error(w, `{"message": "pods is forbidden"}`, http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"items": []}`))
+	}))
+	defer srv.Close()
+	c, err := kube.NewClient(&kube.Config{Server: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logs := make(chan string, 10)
+	km := &K8sMonitor{Client: c, Interval: 20 * time.Millisecond, Logf: func(format string, args ...any) {
+		logs <- fmt.Sprintf(format, args...)
+	}}
+	go func() { _ = km.Watch(ctx, []Target{{Container: "api", Kind: "k8s", Unit: "apps/api"}}, make(chan Incident)) }()
+
+	var got []string
+	for len(got) < 2 {
+		select {
+		case l := <-logs:
+			got = append(got, l)
+		case <-ctx.Done():
+			t.Fatalf("timed out; logs = %q", got)
+		}
+	}
+	if !strings.Contains(got[0], "cannot list pods in namespace apps") || !strings.Contains(got[1], "can be listed again") {
+		t.Errorf("logs = %q", got)
+	}
+}
+
+func TestPodMatches(t *testing.T) {
+	var pod kube.Pod
+	pod.Metadata.Name = "immich-server-5f7c9d8b4-abcde"
+	pod.Metadata.OwnerReferences = []kube.OwnerReference{{Kind: "ReplicaSet", Name: "immich-server-5f7c9d8b4"}}
+	pod.Metadata.Labels = map[string]string{"app.kubernetes.io/name": "immich"}
+
+	for name, want := range map[string]bool{
+		"immich-server":                 true, // deployment
+		"immich-server-5f7c9d8b4-abcde": true, // pod
+		"immich":                        true, // app label
+		"immich-server-5f7c9d8b4":       false,
+		"server":                        false,
+	} {
+		if got := podMatches(pod, name); got != want {
+			t.Errorf("podMatches(%q) = %v, want %v", name, got, want)
+		}
+	}
+
+	if ns, name := k8sUnit(Target{Container: "web"}); ns != "default" || name != "web" {
+		t.Errorf("k8sUnit without namespace = %q, %q", ns, name)
+	}
+}
@@ -0,0 +1,179 @@
+package watch
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Incident statuses. Incidents saved before statuses existed have none and
+// count as open.
+const (
+	StatusOpen     = "open"
+	StatusAcked    = "acked"
+	StatusResolved = "resolved"
+)
+
+// Statuses lists the incident statuses in lifecycle order.
+var Statuses = []string{StatusOpen, StatusAcked, StatusResolved}
+
+// IncidentNote is a free-text annotation on an incident.
+type IncidentNote struct {
+	Time time.Time `json:"time"`
+	User string    `json:"user,omitempty"`
+	Text string    `json:"text"`
+}
+
+// EffectiveStatus returns the incident status, defaulting to open.
+func (inc Incident) EffectiveStatus() string {
+	if inc.Status == "" {
+		return StatusOpen
+	}
+	return inc.Status
+}
+
+// ValidStatus reports whether s is an incident status.
+func ValidStatus(s string) bool {
+	return slices.Contains(Statuses, s)
+}
+
+// FilterByStatus returns the incidents whose effective status is status.
+// An empty status returns incidents unchanged.
+func FilterByStatus(incidents []Incident, status string) []Incident {
+	if status == "" {
+		return incidents
+	}
+	var out []Incident
+	for _, inc := range incidents {
+		if inc.EffectiveStatus() == status {
+			out = append(out, inc)
+		}
+	}
+	return out
+}
+
+// AckIncident marks an incident as acknowledged by user. Acknowledging an
+// acked incident again is a no-op; a resolved incident stays resolved.
+func AckIncident(dir, id, user string, now time.Time) (*Incident, error) {
+	return updateIncident(dir, id, func(inc *Incident) error {
+		switch inc.EffectiveStatus() {
+		case StatusResolved:
+			return fmt.Errorf("incident %q is already resolved", id)
+		case StatusAcked:
+			return nil
+		}
+		inc.Status = StatusAcked
+		inc.AckedBy = user
+		inc.AckedAt = &now
+		return nil
+	})
+}
+
+// AddIncidentNote appends a note to an incident, whatever its status.
+func AddIncidentNote(dir, id, user, text string, now time.Time) (*Incident, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("note text is empty")
+	}
+	return updateIncident(dir, id, func(inc *Incident) error {
+		inc.Notes = append(inc.Notes, IncidentNote{Time: now, User: user, Text: text})
+		return nil
+	})
+}
+
+// ResolveIncident closes an incident with the cause that was found.
+// rootCause, when set, links the incident to the one that explains it; it
+// must name another stored incident. Resolving an acked incident keeps who
+// acknowledged it.
+func ResolveIncident(dir, id, user, cause, rootCause string, now time.Time) (*Incident, error) {
+	cause = strings.TrimSpace(cause)
+	if cause == "" && rootCause == "" {
+		return nil, fmt.Errorf("a resolution needs a cause or a root-cause incident")
+	}
+	if rootCause != "" {
+		if rootCause == id {
+			return nil, fmt.Errorf("incident %q cannot be its own root cause", id)
+		}
+		if _, err := LoadIncident(dir, rootCause); err != nil {
+			return nil, fmt.Errorf("root cause: %w", err)
+		}
+	}
+	return updateIncident(dir, id, func(inc *Incident) error {
+		inc.Status = StatusResolved
+		inc.ResolvedBy = user
+		inc.ResolvedAt = &now
+		inc.Resolution = cause
+		if rootCause != "" {
+			inc.RootCause = rootCause
+		}
+		return nil
+	})
+}
+
+// InheritAck carries an unresolved acknowledgement of the container's
+// previous incident over to inc. A service that keeps flapping after
+// someone acked it is the problem they already know about, so its new
+// incidents arrive acked and linked to the first one instead of open.
+//
+// previous must be sorted newest first, as ListIncidents returns it.
+func InheritAck(inc *Incident, previous []Incident) {
+	if inc.Status != "" {
+		return
+	}
+	for _, prev := range previous {
+		if prev.Container != inc.Container || prev.ID == inc.ID {
+			continue
+		}
+		if prev.EffectiveStatus() != StatusAcked {
+			return
+		}
+		inc.Status = StatusAcked
+		inc.AckedBy = prev.AckedBy
+		inc.AckedAt = prev.AckedAt
+		inc.RootCause = prev.RootCause
+		if inc.RootCause == "" {
+			inc.RootCause = prev.ID
+		}
+		return
+	}
+}
+
+// CurrentUser returns the name recorded as AckedBy, ResolvedBy or a note's
+// author when no name is given.
+func CurrentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// updateIncident loads an incident, applies fn and writes it back in
+// place. It does not prune: editing an incident does not make it new.
+func updateIncident(dir, id string, fn func(*Incident) error) (*Incident, error) {
+	inc, err := LoadIncident(dir, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(inc); err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(inc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(incidentsDir(dir), inc.ID+".json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+	return inc, nil
+}
@@ -0,0 +1,176 @@
+package watch
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func saveTestIncident(t *testing.T, dir, container string, at time.Time) *Incident {
+	t.Helper()
+	inc := &Incident{ID: GenerateIncidentID(container, at), Container: container, DetectedAt: at}
+	if err := SaveIncident(dir, inc, 0); err != nil {
+		t.Fatalf("SaveIncident: %v", err)
+	}
+	return inc
+}
+
+func TestIncidentLifecycle(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC)
+	inc := saveTestIncident(t, dir, "nginx", now)
+
+	if got := inc.EffectiveStatus(); got != StatusOpen {
+		t.Fatalf("new incident status = %q, want open", got)
+	}
+
+	acked, err := AckIncident(dir, inc.ID, "alice", now.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("AckIncident: %v", err)
+	}
+	if acked.Status != StatusAcked || acked.AckedBy != "alice" || acked.AckedAt == nil {
+		t.Fatalf("after ack: %+v", acked)
+	}
+
+	// Acking again keeps the first acknowledgement.
+	again, err := AckIncident(dir, inc.ID, "bob", now.Add(2*time.Minute))
+	if err != nil {
+		t.Fatalf("second AckIncident: %v", err)
+	}
+	if again.AckedBy != "alice" {
+		t.Errorf("second ack replaced AckedBy with %q", again.AckedBy)
+	}
+
+	if _, err := AddIncidentNote(dir, inc.ID, "bob", "  disk full on /var  ", now.Add(3*time.Minute)); err != nil {
+		t.Fatalf("AddIncidentNote: %v", err)
+	}
+	if _, err := AddIncidentNote(dir, inc.ID, "bob", "   ", now); err == nil {
+		t.Error("expected an error for an empty note")
+	}
+
+	resolved, err := ResolveIncident(dir, inc.ID, "bob", "log rotation was disabled", "", now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("ResolveIncident: %v", err)
+	}
+	if resolved.Status != StatusResolved || resolved.ResolvedBy != "bob" || resolved.Resolution != "log rotation was disabled" {
+		t.Fatalf("after resolve: %+v", resolved)
+	}
+
+	loaded, err := LoadIncident(dir, inc.ID)
+	if err != nil {
+		t.Fatalf("LoadIncident: %v", err)
+	}
+	if loaded.AckedBy != "alice" {
+		t.Errorf("resolve lost AckedBy: %q", loaded.AckedBy)
+	}
+	if len(loaded.Notes) != 1 || loaded.Notes[0].Text != "disk full on /var" || loaded.Notes[0].User != "bob" {
+		t.Errorf("notes = %+v", loaded.Notes)
+	}
+
+	if _, err := AckIncident(dir, inc.ID, "alice", now); err == nil || !strings.Contains(err.Error(), "already resolved") {
+		t.Errorf("acking a resolved incident: err = %v", err)
+	}
+}
+
+func TestResolveIncidentRootCause(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC)
+	first := saveTestIncident(t, dir, "db", now)
+	second := saveTestIncident(t, dir, "app", now.Add(time.Minute))
+
+	if _, err := ResolveIncident(dir, second.ID, "alice", "", "", now); err == nil {
+		t.Error("expected an error without cause or root cause")
+	}
+	if _, err := ResolveIncident(dir, second.ID, "alice", "", second.ID, now); err == nil {
+		t.Error("expected an error for a self-referencing root cause")
+	}
+	if _, err := ResolveIncident(dir, second.ID, "alice", "", "missing-20260301-020000.000-abcdef", now); err == nil {
+		t.Error("expected an error for an unknown root cause")
+	}
+
+	inc, err := ResolveIncident(dir, second.ID, "alice", "", first.ID, now)
+	if err != nil {
+		t.Fatalf("ResolveIncident: %v", err)
+	}
+	if inc.RootCause != first.ID {
+		t.Errorf("RootCause = %q, want %q", inc.RootCause, first.ID)
+	}
+}
+
+func TestUpdateIncidentDoesNotPrune(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC)
+	old := saveTestIncident(t, dir, "nginx", now)
+	saveTestIncident(t, dir, "nginx", now.Add(time.Minute))
+
+	if _, err := AckIncident(dir, old.ID, "alice", now); err != nil {
+		t.Fatalf("AckIncident: %v", err)
+	}
+	incidents, _ := ListIncidents(dir)
+	if len(incidents) != 2 {
+		t.Fatalf("expected 2 incidents after ack, got %d", len(incidents))
+	}
+}
+
+func TestFilterByStatus(t *testing.T) {
+	incidents := []Incident{
+		{ID: "a"},
+		{ID: "b", Status: StatusAcked},
+		{ID: "c", Status: StatusResolved},
+		{ID: "d", Status: StatusOpen},
+	}
+	ids := func(incs []Incident) string {
+		var s []string
+		for _, inc := range incs {
+			s = append(s, inc.ID)
+		}
+		return strings.Join(s, ",")
+	}
+
+	tests := map[string]string{"": "a,b,c,d", StatusOpen: "a,d", StatusAcked: "b", StatusResolved: "c"}
+	for status, want := range tests {
+		if got := ids(FilterByStatus(incidents, status)); got != want {
+			t.Errorf("FilterByStatus(%q) = %s, want %s", status, got, want)
+		}
+	}
+	if ValidStatus("closed") || !ValidStatus(StatusAcked) {
+		t.Error("ValidStatus disagrees with Statuses")
+	}
+}
+
+func TestInheritAck(t *testing.T) {
+	ackedAt := time.Date(2026, 3, 1, 2, 5, 0, 0, time.UTC)
+	previous := []Incident{ // newest first
+		{ID: "web-3", Container: "web"},
+		{ID: "db-2", Container: "db", Status: StatusAcked, AckedBy: "alice", AckedAt: &ackedAt},
+		{ID: "db-1", Container: "db", Status: StatusAcked, AckedBy: "alice", AckedAt: &ackedAt},
+	}
+
+	inc := Incident{ID: "db-4", Container: "db"}
+	InheritAck(&inc, previous)
+	if inc.Status != StatusAcked || inc.AckedBy != "alice" || inc.RootCause != "db-2" {
+		t.Errorf("db incident after InheritAck: %+v", inc)
+	}
+
+	web := Incident{ID: "web-4", Container: "web"}
+	InheritAck(&web, previous)
+	if web.Status != "" {
+		t.Errorf("web's latest incident is open, but status became %q", web.Status)
+	}
+
+	// A resolved incident ends the acknowledgement.
+	previous[1].Status = StatusResolved
+	again := Incident{ID: "db-5", Container: "db"}
+	InheritAck(&again, previous)
+	if again.Status != "" {
+		t.Errorf("status after a resolved incident = %q, want open", again.Status)
+	}
+
+	// A linked root cause is carried over rather than chaining.
+	chained := []Incident{{ID: "db-2", Container: "db", Status: StatusAcked, RootCause: "db-1"}}
+	next := Incident{ID: "db-3", Container: "db"}
+	InheritAck(&next, chained)
+	if next.RootCause != "db-1" {
+		t.Errorf("RootCause = %q, want db-1", next.RootCause)
+	}
+}
@@ -36,6 +36,17 @@ func (wn *WatchNotifier) NotifyIncident(inc Incident, flap FlappingResult, crash
 		return nil
 	}
 
+	event := IncidentEvent(inc, flap, crash)
+	errs := wn.Dispatcher.Send(event.Fingerprint, event, now)
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// IncidentEvent is the notification for an incident: the crash analysis,
+// flapping level, remediation and recovery results, and the log tail.
+func IncidentEvent(inc Incident, flap FlappingResult, crash *CrashSummary) notify.Event {
 	status := "restart"
 	kind := "watch.incident"
 	if flap.IsFlapping {
@@ -47,6 +58,9 @@ func (wn *WatchNotifier) NotifyIncident(inc Incident, flap FlappingResult, crash
 	if crash != nil {
 		details = append(details, fmt.Sprintf("category=%s reason=%s", crash.Category, crash.Reason))
 	}
+	for _, act := range inc.Remediation {
+		details = append(details, "remediation "+act.Summary())
+	}
 
 	event := notify.Event{
 		Kind:        kind,
@@ -56,11 +70,60 @@ func (wn *WatchNotifier) NotifyIncident(inc Incident, flap FlappingResult, crash
 		Details:     strings.Join(details, "; "),
 		Time:        inc.DetectedAt,
 		Fingerprint: kind + ":" + inc.Container,
+		Fields:      incidentFields(inc, flap, crash),
+		Excerpt:     logExcerpt(inc.PreLogs, excerptLines),
+	}
+	if crash != nil {
+		event.Crash = &notify.Crash{
+			Category:   crash.Category,
+			Reason:     crash.Reason,
+			Confidence: crash.Confidence,
+			ExitCode:   crash.ExitCode,
+			Signal:     crash.Signal,
+		}
+	}
+	if flap.IsFlapping {
+		event.Flapping = flap.Level
+	}
+	if rec := inc.Recovery; rec != nil {
+		event.Action = "verified with " + rec.Check
+		event.Result = rec.Summary()
+		if !rec.Healthy() && !flap.IsFlapping {
+			event.Status = "failing"
+		}
 	}
+	return event
+}
 
-	errs := wn.Dispatcher.Send(event.Fingerprint, event, now)
-	if len(errs) > 0 {
-		return errs[0]
+// excerptLines is how many of the last log lines before a crash go into a
+// notification.
+const excerptLines = 15
+
+// incidentFields lists the facts of an incident for channels that lay
+// fields out, such as email.
+func incidentFields(inc Incident, flap FlappingResult, crash *CrashSummary) []notify.Field {
+	fields := []notify.Field{{Name: "Incident", Value: inc.ID}}
+	if crash != nil {
+		fields = append(fields,
+			notify.Field{Name: "Category", Value: crash.Category},
+			notify.Field{Name: "Reason", Value: crash.Reason},
+			notify.Field{Name: "Confidence", Value: crash.Confidence},
+		)
 	}
-	return nil
+	if flap.IsFlapping {
+		fields = append(fields, notify.Field{
+			Name:  "Flapping",
+			Value: fmt.Sprintf("%s, %d restarts in the %s window", flap.Level, flap.Count, flap.Window),
+		})
+	}
+	return fields
+}
+
+// logExcerpt returns the last n lines of logs.
+func logExcerpt(logs string, n int) string {
+	lines := strings.Split(strings.TrimRight(logs, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
 }
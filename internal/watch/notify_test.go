@@ -184,3 +184,23 @@ func TestNotifyIncident_IndependentCooldown(t *testing.T) {
 		t.Errorf("expected 2 calls for different containers, got %d", called)
 	}
 }
+
+func TestIncidentEventCrashAnalysis(t *testing.T) {
+	inc := baseIncident("redis", time.Now())
+	flap := FlappingResult{IsFlapping: true, Level: "acute", Count: 4, Window: "10m"}
+	crash := &CrashSummary{Category: "oom", Reason: "killed", Confidence: "high", ExitCode: 137, Signal: "SIGKILL"}
+
+	event := IncidentEvent(inc, flap, crash)
+	want := notify.Crash{Category: "oom", Reason: "killed", Confidence: "high", ExitCode: 137, Signal: "SIGKILL"}
+	if event.Crash == nil || *event.Crash != want {
+		t.Errorf("Crash = %+v, want %+v", event.Crash, want)
+	}
+	if event.Flapping != "acute" {
+		t.Errorf("Flapping = %q", event.Flapping)
+	}
+
+	plain := IncidentEvent(inc, FlappingResult{}, nil)
+	if plain.Crash != nil || plain.Flapping != "" {
+		t.Errorf("event without analysis = %+v", plain)
+	}
+}
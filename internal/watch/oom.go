@@ -0,0 +1,372 @@
+package watch
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Higangssh/homebutler/internal/util"
+)
+
+// OOMKill is a kernel OOM-killer kill matched to a crash.
+type OOMKill struct {
+	Time       time.Time `json:"time,omitzero"`
+	PID        int       `json:"pid,omitempty"`
+	Process    string    `json:"process,omitempty"`
+	RSSBytes   int64     `json:"rss_bytes,omitempty"`   // anon + file + shmem RSS when it was killed
+	LimitBytes int64     `json:"limit_bytes,omitempty"` // memory limit of the cgroup that ran out; 0 when none
+	Cgroup     string    `json:"cgroup,omitempty"`
+	// Constraint is what ran out: "memcg" for a cgroup limit, "none" for
+	// the whole host.
+	Constraint string `json:"constraint,omitempty"`
+	Source     string `json:"source"` // "kernel log" or "memory.events"
+}
+
+func (k *OOMKill) describe() string {
+	s := "Killed by the kernel OOM killer"
+	if k.Process != "" {
+		s += fmt.Sprintf(": %s (pid %d)", k.Process, k.PID)
+	}
+	if k.RSSBytes > 0 {
+		s += " using " + formatMemory(k.RSSBytes)
+	}
+	switch {
+	case k.LimitBytes > 0:
+		s += " of a " + formatMemory(k.LimitBytes) + " cgroup limit"
+	case k.Constraint == "none":
+		s += " (the host ran out of memory)"
+	}
+	return s
+}
+
+// Summary is a one-line account of the kill for `watch show`.
+func (k *OOMKill) Summary() string {
+	var parts []string
+	if k.Process != "" {
+		parts = append(parts, fmt.Sprintf("%s (pid %d)", k.Process, k.PID))
+	}
+	if k.RSSBytes > 0 {
+		parts = append(parts, "rss "+formatMemory(k.RSSBytes))
+	}
+	if k.LimitBytes > 0 {
+		parts = append(parts, "limit "+formatMemory(k.LimitBytes))
+	}
+	if k.Cgroup != "" {
+		parts = append(parts, "cgroup "+k.Cgroup)
+	}
+	if !k.Time.IsZero() {
+		parts = append(parts, "at "+k.Time.Local().Format("15:04:05"))
+	}
+	return strings.Join(append(parts, "from "+k.Source), ", ")
+}
+
+func formatMemory(bytes int64) string {
+	const mib, gib = 1 << 20, 1 << 30
+	if bytes >= gib {
+		return fmt.Sprintf("%.1f GiB", float64(bytes)/gib)
+	}
+	return fmt.Sprintf("%d MiB", (bytes+mib/2)/mib)
+}
+
+// oomQuery says which kernel OOM kill would explain a crash.
+type oomQuery struct {
+	Since, Until time.Time // when the process could have died
+
+	PIDs      []int    // processes that died, when known
+	CgroupIDs []string // container IDs or pod UIDs, found anywhere in the killed task's cgroup
+	Units     []string // systemd units, matched as an element of the cgroup path
+
+	// CgroupDirs are checked for a new oom_kill in memory.events when the
+	// kernel log has no match; paths are relative to the cgroup root.
+	CgroupDirs []string
+}
+
+func (q oomQuery) matches(k OOMKill) bool {
+	const slack = 30 * time.Second // boot-time clock vs wall clock
+	if !k.Time.IsZero() && (k.Time.Before(q.Since.Add(-slack)) || (!q.Until.IsZero() && k.Time.After(q.Until.Add(slack)))) {
+		return false
+	}
+	if k.PID != 0 && slices.Contains(q.PIDs, k.PID) {
+		return true
+	}
+	if k.Cgroup == "" {
+		return false
+	}
+	for _, id := range q.CgroupIDs {
+		if id != "" && strings.Contains(k.Cgroup, id) {
+			return true
+		}
+	}
+	for _, elem := range strings.Split(k.Cgroup, "/") {
+		if slices.Contains(q.Units, elem) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	// readKernelLog and cgroupRoot are swapped out by tests.
+	readKernelLog = kernelLog
+	cgroupRoot    = "/sys/fs/cgroup"
+
+	oomSeenMu sync.Mutex
+	oomSeen   = make(map[string]int) // memory.events oom_kill count per cgroup, as last read
+)
+
+// mayBeOOMKill reports whether a crash looks like the OOM killer's work:
+// SIGKILL, or the runtime says so.
+func (info CrashInfo) mayBeOOMKill() bool {
+	return info.ExitCode == 137 || info.Signal == "SIGKILL" || info.OOMKilled
+}
+
+// correlateOOM looks for the kernel OOM kill behind a crash and records it
+// in info. localKernel says the process ran on this host, so a readable
+// kernel log with no matching kill means it was killed some other way.
+func correlateOOM(info *CrashInfo, q oomQuery, localKernel bool) {
+	kill, checked := findOOMKill(q)
+	info.OOMKill = kill
+	info.OOMChecked = checked && localKernel
+}
+
+// findOOMKill returns the latest kernel OOM kill matching q. checked
+// reports whether the kernel log could be read at all.
+func findOOMKill(q oomQuery) (kill *OOMKill, checked bool) {
+	if text, boot, err := readKernelLog(); err == nil {
+		checked = true
+		for _, k := range parseOOMKills(text, boot) {
+			if q.matches(k) && (kill == nil || !k.Time.Before(kill.Time)) {
+				k := k
+				kill = &k
+			}
+		}
+	}
+	if kill != nil {
+		if kill.LimitBytes == 0 && kill.Constraint == "memcg" {
+			kill.LimitBytes = cgroupLimit(kill.Cgroup)
+		}
+		return kill, checked
+	}
+	for _, dir := range q.CgroupDirs {
+		if k := cgroupOOMKill(dir); k != nil {
+			return k, checked
+		}
+	}
+	return nil, checked
+}
+
+// kernelLog reads the kernel ring buffer from /dev/kmsg, falling back to
+// dmesg, and returns it with the boot time its timestamps count from. Both
+// need root, or kernel.dmesg_restrict=0.
+func kernelLog() (string, time.Time, error) {
+	data, err := os.ReadFile("/proc/uptime")
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return "", time.Time{}, fmt.Errorf("unexpected /proc/uptime: %q", data)
+	}
+	uptime, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("unexpected /proc/uptime: %q", data)
+	}
+	boot := time.Now().Add(-time.Duration(uptime * float64(time.Second)))
+
+	if text, ok := readKmsg(); ok {
+		return text, boot, nil
+	}
+	out, err := util.RunCmd("dmesg")
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("cannot read the kernel log: %w", err)
+	}
+	return out, boot, nil
+}
+
+// readKmsg drains /dev/kmsg. Each read returns one record; at the end the
+// read would block, so a short deadline ends it.
+func readKmsg() (string, bool) {
+	f, err := os.Open("/dev/kmsg")
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+	if err := f.SetReadDeadline(time.Now().Add(200 * time.Millisecond)); err != nil {
+		return "", false
+	}
+	var b strings.Builder
+	buf := make([]byte, 8192)
+	for {
+		n, err := f.Read(buf)
+		b.Write(buf[:n])
+		if err != nil {
+			if errors.Is(err, syscall.EPIPE) {
+				continue // records were overwritten while reading
+			}
+			break
+		}
+	}
+	return b.String(), b.Len() > 0
+}
+
+var (
+	kmsgLineRe    = regexp.MustCompile(`^\d+,\d+,(\d+),[^;]*;(.*)$`)
+	dmesgLineRe   = regexp.MustCompile(`^\[\s*(\d+)\.(\d+)\]\s?(.*)$`)
+	oomInvokedRe  = regexp.MustCompile(`invoked oom-killer`)
+	oomUsageRe    = regexp.MustCompile(`^memory: usage (\d+)kB, limit (\d+)kB`)
+	oomKillLineRe = regexp.MustCompile(`^oom-kill:(.*)$`)
+	oomKilledRe   = regexp.MustCompile(`Killed process (\d+) \((.*)\).*?anon-rss:(\d+)kB, file-rss:(\d+)kB, shmem-rss:(\d+)kB`)
+)
+
+// parseOOMKills extracts OOM kills from kernel log text in /dev/kmsg or
+// dmesg format. One kill is reported over several lines: an optional
+// "memory: usage …, limit …" line for a cgroup OOM, the "oom-kill:" line
+// naming the task's cgroup, and "Killed process" with its RSS.
+func parseOOMKills(text string, boot time.Time) []OOMKill {
+	var kills []OOMKill
+	var pending OOMKill
+	for _, line := range strings.Split(text, "\n") {
+		var at time.Time
+		if m := kmsgLineRe.FindStringSubmatch(line); m != nil {
+			usec, _ := strconv.ParseInt(m[1], 10, 64)
+			at, line = boot.Add(time.Duration(usec)*time.Microsecond), m[2]
+		} else if m := dmesgLineRe.FindStringSubmatch(line); m != nil {
+			sec, _ := strconv.ParseInt(m[1], 10, 64)
+			frac, _ := strconv.ParseFloat("0."+m[2], 64)
+			at, line = boot.Add(time.Duration(sec)*time.Second+time.Duration(frac*float64(time.Second))), m[3]
+		} else if strings.HasPrefix(line, " ") {
+			continue // /dev/kmsg key=value continuation
+		}
+
+		switch {
+		case oomInvokedRe.MatchString(line):
+			pending = OOMKill{}
+		case oomUsageRe.MatchString(line):
+			m := oomUsageRe.FindStringSubmatch(line)
+			if limit, _ := strconv.ParseInt(m[2], 10, 64); limit < 1<<50 { // larger is "max"
+				pending.LimitBytes = limit * 1024
+			}
+		case oomKillLineRe.MatchString(line):
+			for _, kv := range strings.Split(oomKillLineRe.FindStringSubmatch(line)[1], ",") {
+				k, v, _ := strings.Cut(kv, "=")
+				switch k {
+				case "constraint":
+					pending.Constraint = strings.ToLower(strings.TrimPrefix(v, "CONSTRAINT_"))
+				case "task_memcg":
+					pending.Cgroup = v
+				}
+			}
+		case oomKilledRe.MatchString(line):
+			m := oomKilledRe.FindStringSubmatch(line)
+			k := pending
+			k.Time = at
+			k.PID, _ = strconv.Atoi(m[1])
+			k.Process = m[2]
+			for _, kb := range m[3:6] {
+				n, _ := strconv.ParseInt(kb, 10, 64)
+				k.RSSBytes += n * 1024
+			}
+			if k.Constraint == "none" {
+				k.LimitBytes = 0 // the usage line, if any, was for another cgroup
+			}
+			k.Source = "kernel log"
+			kills = append(kills, k)
+			pending = OOMKill{}
+		}
+	}
+	return kills
+}
+
+// cgroupOOMKill reports an OOM kill in a cgroup's memory.events that has
+// happened since the last look. The victim is not recorded there, so only
+// the cgroup and its limit are known. The count covers the cgroup's whole
+// life, so a cgroup seen for the first time only sets the baseline.
+func cgroupOOMKill(dir string) *OOMKill {
+	kills, ok := readOOMKillCount(dir)
+	if !ok {
+		return nil
+	}
+
+	oomSeenMu.Lock()
+	seen, known := oomSeen[dir]
+	oomSeen[dir] = kills
+	oomSeenMu.Unlock()
+	if !known || kills <= seen {
+		return nil
+	}
+	return &OOMKill{
+		Cgroup:     "/" + strings.TrimPrefix(dir, "/"),
+		LimitBytes: cgroupLimit(dir),
+		Constraint: "memcg",
+		Source:     "memory.events",
+	}
+}
+
+// seedCgroupOOM records the current oom_kill count of each cgroup as the
+// baseline for cgroupOOMKill. Monitors call it when they seed a target, so
+// that a kill between then and the crash is seen as new.
+func seedCgroupOOM(dirs ...string) {
+	for _, dir := range dirs {
+		if kills, ok := readOOMKillCount(dir); ok {
+			oomSeenMu.Lock()
+			oomSeen[dir] = kills
+			oomSeenMu.Unlock()
+		}
+	}
+}
+
+// readOOMKillCount reads the oom_kill count from a cgroup's memory.events.
+func readOOMKillCount(dir string) (int, bool) {
+	data, err := os.ReadFile(filepath.Join(cgroupRoot, dir, "memory.events"))
+	if err != nil {
+		return 0, false
+	}
+	var kills int
+	for _, line := range strings.Split(string(data), "\n") {
+		if v, ok := strings.CutPrefix(line, "oom_kill "); ok {
+			kills, _ = strconv.Atoi(strings.TrimSpace(v))
+		}
+	}
+	return kills, true
+}
+
+// cgroupLimit reads a cgroup's memory limit: memory.max on cgroup v2,
+// memory.limit_in_bytes on v1. Unlimited and unreadable are both 0.
+func cgroupLimit(dir string) int64 {
+	for _, path := range []string{
+		filepath.Join(cgroupRoot, dir, "memory.max"),
+		filepath.Join(cgroupRoot, "memory", dir, "memory.limit_in_bytes"),
+	} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+		if err != nil || n >= 1<<62 { // "max", or v1's page-rounded "unlimited"
+			return 0
+		}
+		return n
+	}
+	return 0
+}
+
+// dockerCgroupDirs are where Docker and Podman put a container's cgroup
+// with the systemd and cgroupfs drivers.
+func dockerCgroupDirs(id string) []string {
+	if id == "" {
+		return nil
+	}
+	return []string{
+		"system.slice/docker-" + id + ".scope",
+		"docker/" + id,
+		"machine.slice/libpod-" + id + ".scope",
+	}
+}
@@ -0,0 +1,183 @@
+package watch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// kmsgOOM is a cgroup v2 OOM kill of a Docker container as /dev/kmsg
+// reports it, 100 seconds after boot, with an unrelated host-wide kill
+// before it.
+const kmsgOOM = `4,800,50000000,-;postgres invoked oom-killer: gfp_mask=0x140cca(GFP_HIGHUSER_MOVABLE|__GFP_COMP), order=0, oom_score_adj=0
+6,801,50000100,-;oom-kill:constraint=CONSTRAINT_NONE,nodemask=(null),cpuset=/,mems_allowed=0,global_oom,task_memcg=/system.slice/postgresql.service,task=postgres,pid=900,uid=70
+3,802,50000200,-;Out of memory: Killed process 900 (postgres) total-vm:900000kB, anon-rss:700000kB, file-rss:0kB, shmem-rss:24kB, UID:70 pgtables:1800kB oom_score_adj:0
+4,803,100000000,-;java invoked oom-killer: gfp_mask=0xcc0(GFP_KERNEL), order=0, oom_score_adj=0
+ SUBSYSTEM=cgroup
+6,804,100000100,-;memory: usage 524288kB, limit 524288kB, failcnt 1234
+6,805,100000200,-;swap: usage 0kB, limit 0kB, failcnt 0
+6,806,100000300,-;oom-kill:constraint=CONSTRAINT_MEMCG,nodemask=(null),cpuset=docker-abc123.scope,mems_allowed=0,oom_memcg=/system.slice/docker-abc123.scope,task_memcg=/system.slice/docker-abc123.scope,task=java,pid=4242,uid=0
+3,807,100000400,-;Memory cgroup out of memory: Killed process 4242 (java) total-vm:3000000kB, anon-rss:500000kB, file-rss:12000kB, shmem-rss:0kB, UID:0 pgtables:1500kB oom_score_adj:0
+6,808,100000500,-;oom_reaper: reaped process 4242 (java), now anon-rss:0kB, file-rss:0kB, shmem-rss:0kB
+`
+
+func TestParseOOMKills(t *testing.T) {
+	boot := time.Date(2026, 10, 18, 3, 0, 0, 0, time.UTC)
+	kills := parseOOMKills(kmsgOOM, boot)
+	if len(kills) != 2 {
+		t.Fatalf("got %d kills, want 2: %+v", len(kills), kills)
+	}
+
+	host, java := kills[0], kills[1]
+	if host.Constraint != "none" || host.LimitBytes != 0 || host.Process != "postgres" || host.Cgroup != "/system.slice/postgresql.service" {
+		t.Errorf("host-wide kill = %+v", host)
+	}
+	want := OOMKill{
+		Time:       boot.Add(100*time.Second + 400*time.Microsecond),
+		PID:        4242,
+		Process:    "java",
+		RSSBytes:   512000 * 1024,
+		LimitBytes: 512 << 20,
+		Cgroup:     "/system.slice/docker-abc123.scope",
+		Constraint: "memcg",
+		Source:     "kernel log",
+	}
+	if java != want {
+		t.Errorf("cgroup kill =\n %+v, want\n %+v", java, want)
+	}
+
+	// dmesg prints the same records as "[seconds.micros] message".
+	dmesg := "[  100.000300] oom-kill:constraint=CONSTRAINT_MEMCG,task_memcg=/docker/abc123,task=java,pid=4242,uid=0\n" +
+		"[  100.000400] Memory cgroup out of memory: Killed process 4242 (java) total-vm:1kB, anon-rss:1024kB, file-rss:0kB, shmem-rss:0kB, UID:0"
+	kills = parseOOMKills(dmesg, boot)
+	if len(kills) != 1 || kills[0].Cgroup != "/docker/abc123" || kills[0].Time != boot.Add(100*time.Second+400*time.Microsecond) || kills[0].RSSBytes != 1<<20 {
+		t.Errorf("dmesg kills = %+v", kills)
+	}
+}
+
+// fakeKernel serves text as the kernel log and returns when the java kill
+// in kmsgOOM happened.
+func fakeKernel(t *testing.T, text string) time.Time {
+	t.Helper()
+	boot := time.Now().Add(-time.Hour)
+	orig := readKernelLog
+	readKernelLog = func() (string, time.Time, error) { return text, boot, nil }
+	t.Cleanup(func() { readKernelLog = orig })
+	return boot.Add(100 * time.Second)
+}
+
+func TestCorrelateOOM(t *testing.T) {
+	died := fakeKernel(t, kmsgOOM)
+
+	info := CrashInfo{ExitCode: 137, Backend: "docker"}
+	correlateOOM(&info, oomQuery{Since: died.Add(-time.Minute), Until: died, CgroupIDs: []string{"abc123"}}, true)
+	if info.OOMKill == nil || info.OOMKill.PID != 4242 {
+		t.Fatalf("OOMKill = %+v, want the java kill", info.OOMKill)
+	}
+	if s := Analyze(info); s.Category != "oom" || s.Confidence != "high" || s.OOMKill == nil || s.Signal != "SIGKILL" {
+		t.Errorf("summary = %+v", s)
+	}
+
+	// Another container, or the right one outside the window, is a plain SIGKILL.
+	for name, q := range map[string]oomQuery{
+		"other container": {Since: died.Add(-time.Minute), Until: died, CgroupIDs: []string{"fff999"}},
+		"too early":       {Since: died.Add(time.Hour), Until: died.Add(2 * time.Hour), CgroupIDs: []string{"abc123"}},
+	} {
+		info := CrashInfo{ExitCode: 137, Backend: "docker"}
+		correlateOOM(&info, q, true)
+		if s := Analyze(info); info.OOMKill != nil || s.Category != "sigkill" {
+			t.Errorf("%s: OOMKill = %+v, category = %q", name, info.OOMKill, s.Category)
+		}
+	}
+
+	// Systemd units match as a path element, not a substring.
+	info = CrashInfo{ExitCode: 137}
+	correlateOOM(&info, oomQuery{Since: died.Add(-time.Hour), Units: []string{"sql.service"}}, true)
+	if info.OOMKill != nil {
+		t.Errorf("sql.service matched %+v", info.OOMKill)
+	}
+	correlateOOM(&info, oomQuery{Since: died.Add(-time.Hour), Units: []string{"postgresql.service"}}, true)
+	if info.OOMKill == nil || info.OOMKill.Constraint != "none" {
+		t.Errorf("postgresql.service: OOMKill = %+v", info.OOMKill)
+	}
+
+	// A remote kernel proves nothing by having no match.
+	info = CrashInfo{ExitCode: 137}
+	correlateOOM(&info, oomQuery{Since: died, CgroupIDs: []string{"fff999"}}, false)
+	if s := Analyze(info); s.Category != "oom" || s.Confidence != "medium" {
+		t.Errorf("remote summary = %+v", s)
+	}
+}
+
+func TestCorrelateOOM_CgroupEvents(t *testing.T) {
+	orig := readKernelLog
+	readKernelLog = func() (string, time.Time, error) { return "", time.Time{}, os.ErrPermission }
+	t.Cleanup(func() { readKernelLog = orig })
+	root := t.TempDir()
+	origRoot := cgroupRoot
+	cgroupRoot = root
+	t.Cleanup(func() { cgroupRoot = origRoot })
+
+	dir := filepath.Join(root, "system.slice", "worker.service")
+	os.MkdirAll(dir, 0o755)
+	os.WriteFile(filepath.Join(dir, "memory.max"), []byte("268435456\n"), 0o644)
+	events := func(kills int) {
+		os.WriteFile(filepath.Join(dir, "memory.events"), fmt.Appendf(nil, "low 0\nhigh 0\nmax 40\noom 2\noom_kill %d\n", kills), 0o644)
+	}
+	q := oomQuery{Since: time.Now(), Units: []string{"worker.service"}, CgroupDirs: []string{"system.slice/worker.service"}}
+
+	// Kills from before the cgroup was first seen, say a worker child
+	// killed hours ago, only set the baseline.
+	events(3)
+	info := CrashInfo{ExitCode: 137}
+	correlateOOM(&info, q, true)
+	if info.OOMKill != nil {
+		t.Fatalf("lifetime count reported as a new kill: %+v", info.OOMKill)
+	}
+	if s := Analyze(info); s.Confidence == "high" {
+		t.Errorf("summary without a matching kill = %+v", s)
+	}
+
+	// A monitor seeds the baseline; a kill after that is found.
+	delete(oomSeen, "system.slice/worker.service")
+	seedCgroupOOM("system.slice/worker.service")
+	events(4)
+	info = CrashInfo{ExitCode: 137}
+	correlateOOM(&info, q, true)
+	if info.OOMKill == nil || info.OOMKill.LimitBytes != 256<<20 || info.OOMKill.Source != "memory.events" {
+		t.Fatalf("OOMKill = %+v", info.OOMKill)
+	}
+	if info.OOMChecked {
+		t.Error("an unreadable kernel log must not count as checked")
+	}
+
+	// The same kill is not reported twice.
+	info = CrashInfo{ExitCode: 137}
+	correlateOOM(&info, q, true)
+	if info.OOMKill != nil {
+		t.Errorf("old kill reported again: %+v", info.OOMKill)
+	}
+	events(5)
+	correlateOOM(&info, q, true)
+	if info.OOMKill == nil {
+		t.Error("new kill not reported")
+	}
+}
+
+func TestDockerMonitor_AnalyzeOOMKill(t *testing.T) {
+	died := fakeKernel(t, kmsgOOM)
+	dm := &DockerMonitor{}
+
+	ev := dockerEvent{Actor: dockerEventActor{ID: "abc123", Attributes: map[string]string{"name": "api", "exitCode": "137"}}, Time: died.Unix()}
+	s := dm.analyze(ev, "", died)
+	if s.Category != "oom" || s.Confidence != "high" || s.OOMKill == nil || s.OOMKill.Process != "java" {
+		t.Errorf("OOM-killed container = %+v", s)
+	}
+
+	ev.Actor.Attributes["exitCode"] = "1"
+	if s := dm.analyze(ev, "Error: connection refused", died); s.Category != "dependency" || s.OOMKill != nil || s.ExitCode != 1 {
+		t.Errorf("exit 1 = %+v", s)
+	}
+}
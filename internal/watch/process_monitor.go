@@ -0,0 +1,226 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProcessMonitor watches plain processes, found by PID file or command-line
+// pattern, by polling /proc. It is for services that run under neither
+// systemd nor a container runtime.
+type ProcessMonitor struct {
+	// Dir is the storage directory for incidents.
+	Dir string
+
+	// Interval is the polling interval.
+	Interval time.Duration
+
+	// ProcRoot is the proc filesystem to read. Empty defaults to /proc.
+	ProcRoot string
+
+	// Keep caps how many incidents are retained; zero or less keeps everything.
+	Keep int
+}
+
+// procIdentity identifies one run of a process. The start time guards
+// against a restarted process being handed the same PID.
+type procIdentity struct {
+	PID   int
+	Start uint64 // clock ticks after boot, field 22 of /proc/<pid>/stat
+}
+
+func (p procIdentity) running() bool { return p.PID != 0 }
+
+func (p procIdentity) String() string {
+	if !p.running() {
+		return "(not running)"
+	}
+	return fmt.Sprintf("pid %d", p.PID)
+}
+
+// findProcess locates a target's process: the PID in its PID file, or the
+// longest-running process whose command line matches its pattern.
+func (pm *ProcessMonitor) findProcess(t Target, re *regexp.Regexp) procIdentity {
+	root := pm.ProcRoot
+	if root == "" {
+		root = "/proc"
+	}
+
+	if t.PIDFile != "" {
+		data, err := os.ReadFile(t.PIDFile)
+		if err != nil {
+			return procIdentity{}
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil || pid <= 0 {
+			return procIdentity{}
+		}
+		start, ok := procStartTime(root, pid)
+		if !ok {
+			return procIdentity{} // stale PID file
+		}
+		return procIdentity{PID: pid, Start: start}
+	}
+
+	if re == nil {
+		return procIdentity{}
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return procIdentity{}
+	}
+	self := os.Getpid()
+	var best procIdentity
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil || pid == self {
+			continue
+		}
+		raw, err := os.ReadFile(filepath.Join(root, e.Name(), "cmdline"))
+		if err != nil || len(raw) == 0 {
+			continue // kernel thread or gone
+		}
+		cmdline := strings.TrimRight(strings.ReplaceAll(string(raw), "\x00", " "), " ")
+		if !re.MatchString(cmdline) {
+			continue
+		}
+		start, ok := procStartTime(root, pid)
+		if !ok {
+			continue
+		}
+		if !best.running() || start < best.Start {
+			best = procIdentity{PID: pid, Start: start}
+		}
+	}
+	return best
+}
+
+// procStartTime reads a process's start time from /proc/<pid>/stat.
+func procStartTime(root string, pid int) (uint64, bool) {
+	data, err := os.ReadFile(filepath.Join(root, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return 0, false
+	}
+	// The command name in parentheses may contain spaces, so fields are
+	// counted from the last ')'. starttime is field 22; state is field 3.
+	s := string(data)
+	i := strings.LastIndexByte(s, ')')
+	if i < 0 {
+		return 0, false
+	}
+	fields := strings.Fields(s[i+1:])
+	if len(fields) < 20 {
+		return 0, false
+	}
+	start, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return start, true
+}
+
+// Watch polls each target's process and sends an incident when it exits or
+// is replaced by a new process.
+func (pm *ProcessMonitor) Watch(ctx context.Context, targets []Target, incidents chan<- Incident) error {
+	if len(targets) == 0 {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	interval := pm.Interval
+	if interval == 0 {
+		interval = 30 * time.Second
+	}
+
+	patterns := make(map[string]*regexp.Regexp)
+	for _, t := range targets {
+		if t.PIDFile == "" && t.Match == "" {
+			return fmt.Errorf("process target %q needs a PID file or a match pattern", t.Container)
+		}
+		if t.Match != "" {
+			re, err := regexp.Compile(t.Match)
+			if err != nil {
+				return fmt.Errorf("process target %q: invalid match pattern: %w", t.Container, err)
+			}
+			patterns[t.Container] = re
+		}
+	}
+
+	// Seed initial state
+	prev := make(map[string]procIdentity, len(targets))
+	for _, t := range targets {
+		prev[t.Container] = pm.findProcess(t, patterns[t.Container])
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			for _, t := range targets {
+				curr := pm.findProcess(t, patterns[t.Container])
+				old := prev[t.Container]
+				prev[t.Container] = curr
+
+				// An exit is reported once; the process coming back after
+				// that is recovery, not another incident.
+				if !old.running() || curr == old {
+					continue
+				}
+
+				now := time.Now()
+				inc := Incident{
+					ID:          GenerateIncidentID(t.Container, now),
+					Container:   t.Container,
+					DetectedAt:  now,
+					PrevStarted: old.String(),
+					CurrStarted: curr.String(),
+					PreLogs:     tailLogFile(t.LogFile),
+				}
+				if curr.running() {
+					inc.PostLogs = fmt.Sprintf("restarted: pid %d replaced pid %d", curr.PID, old.PID)
+				} else {
+					inc.PostLogs = fmt.Sprintf("exited: pid %d is gone and nothing matching has started", old.PID)
+				}
+				// The exit status of a process that is not our child is
+				// lost, but an OOM kill is on record in the kernel log.
+				if kill, _ := findOOMKill(oomQuery{Since: now.Add(-interval - time.Minute), Until: now, PIDs: []int{old.PID}}); kill != nil {
+					summary := Analyze(CrashInfo{ErrorLog: inc.PreLogs, Backend: "process", OOMKill: kill})
+					inc.CrashAnalysis = &summary
+				}
+				if pm.Dir != "" {
+					if err := SaveIncident(pm.Dir, &inc, pm.Keep); err != nil {
+						fmt.Fprintf(os.Stderr, "[process-monitor] warning: save incident: %v\n", err)
+					}
+				}
+				select {
+				case incidents <- inc:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+	}
+}
+
+// tailLogFile returns the last 100 lines of a target's configured log file,
+// or "" when none is configured.
+func tailLogFile(path string) string {
+	if path == "" {
+		return ""
+	}
+	data, err := tailFile(path, 100)
+	if err != nil {
+		return fmt.Sprintf("(cannot read log file: %v)", err)
+	}
+	return data
+}
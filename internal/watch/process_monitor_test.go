@@ -0,0 +1,109 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeProc writes a /proc entry for pid with the given command line and
+// start time.
+func fakeProc(t *testing.T, root string, pid int, cmdline string, start int) {
+	t.Helper()
+	dir := filepath.Join(root, fmt.Sprint(pid))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "cmdline"), []byte(strings.ReplaceAll(cmdline, " ", "\x00")+"\x00"), 0o644)
+	// The comm field has a space and a ')' to check fields are counted from the last ')'.
+	stat := fmt.Sprintf("%d (odd) name) S 1 %d %d 0 -1 4194560 0 0 0 0 0 0 0 0 20 0 1 0 %d 1000 100", pid, pid, pid, start)
+	os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0o644)
+}
+
+func TestProcessMonitor_FindProcess(t *testing.T) {
+	root := t.TempDir()
+	fakeProc(t, root, 200, "java -jar billing.jar --port 8080", 5000)
+	fakeProc(t, root, 150, "java -jar billing.jar --port 8081", 4000)
+	fakeProc(t, root, 300, "vim billing.conf", 100)
+	os.MkdirAll(filepath.Join(root, "self"), 0o755)
+
+	pm := &ProcessMonitor{ProcRoot: root}
+	got := pm.findProcess(Target{Match: `billing\.jar`}, regexp.MustCompile(`billing\.jar`))
+	if got != (procIdentity{PID: 150, Start: 4000}) {
+		t.Errorf("match = %+v, want the longest-running match (pid 150)", got)
+	}
+
+	pidFile := filepath.Join(t.TempDir(), "billing.pid")
+	os.WriteFile(pidFile, []byte("200\n"), 0o644)
+	if got := pm.findProcess(Target{PIDFile: pidFile}, nil); got != (procIdentity{PID: 200, Start: 5000}) {
+		t.Errorf("pid file = %+v", got)
+	}
+	os.WriteFile(pidFile, []byte("999\n"), 0o644)
+	if got := pm.findProcess(Target{PIDFile: pidFile}, nil); got.running() {
+		t.Errorf("stale pid file = %+v, want not running", got)
+	}
+}
+
+func TestProcessMonitor_RestartAndExit(t *testing.T) {
+	root := t.TempDir()
+	fakeProc(t, root, 100, "/opt/legacy/bin/server", 1000)
+	logFile := filepath.Join(t.TempDir(), "legacy.log")
+	os.WriteFile(logFile, []byte("listening\nFATAL: config reload failed\n"), 0o644)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	pm := &ProcessMonitor{ProcRoot: root, Interval: 30 * time.Millisecond}
+	incCh := make(chan Incident, 10)
+	targets := []Target{{Container: "legacy", Kind: "process", Match: "legacy/bin/server", LogFile: logFile}}
+	go func() { _ = pm.Watch(ctx, targets, incCh) }()
+	time.Sleep(60 * time.Millisecond)
+
+	// Same PID, new start time: the process was replaced.
+	fakeProc(t, root, 100, "/opt/legacy/bin/server", 2000)
+	inc := waitIncident(t, ctx, incCh)
+	if !strings.HasPrefix(inc.PostLogs, "restarted") || !strings.Contains(inc.PreLogs, "FATAL: config reload failed") {
+		t.Errorf("restart incident = %+v", inc)
+	}
+
+	os.RemoveAll(filepath.Join(root, "100"))
+	inc = waitIncident(t, ctx, incCh)
+	if !strings.HasPrefix(inc.PostLogs, "exited") || inc.CurrStarted != "(not running)" {
+		t.Errorf("exit incident = %+v", inc)
+	}
+
+	// Coming back after a reported exit is recovery, not a new incident.
+	fakeProc(t, root, 101, "/opt/legacy/bin/server", 3000)
+	select {
+	case inc := <-incCh:
+		t.Errorf("unexpected incident on recovery: %+v", inc)
+	case <-time.After(150 * time.Millisecond):
+	}
+}
+
+func TestProcessMonitor_InvalidTarget(t *testing.T) {
+	pm := &ProcessMonitor{}
+	err := pm.Watch(context.Background(), []Target{{Container: "x", Kind: "process"}}, make(chan Incident))
+	if err == nil || !strings.Contains(err.Error(), "needs a PID file or a match pattern") {
+		t.Errorf("err = %v", err)
+	}
+	err = pm.Watch(context.Background(), []Target{{Container: "x", Kind: "process", Match: "("}}, make(chan Incident))
+	if err == nil || !strings.Contains(err.Error(), "invalid match pattern") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func waitIncident(t *testing.T, ctx context.Context, ch <-chan Incident) Incident {
+	t.Helper()
+	select {
+	case inc := <-ch:
+		return inc
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for incident")
+		return Incident{}
+	}
+}
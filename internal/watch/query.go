@@ -0,0 +1,191 @@
+package watch
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IncidentFilter selects incidents for `watch history` and `watch export`.
+// Zero fields match everything.
+type IncidentFilter struct {
+	// Target is the watched name (Incident.Container), matched exactly.
+	Target string
+
+	// Category is the crash analysis category, e.g. "oom".
+	Category string
+
+	// Since drops incidents detected before it.
+	Since time.Time
+
+	// Flapping keeps only incidents recorded while the target was flapping.
+	Flapping bool
+
+	// Status is an incident status; see Statuses.
+	Status string
+
+	// Search is matched case-insensitively against the pre-death and
+	// post-restart logs.
+	Search string
+
+	// Limit keeps at most this many of the newest matches; zero keeps all.
+	Limit int
+}
+
+// Match reports whether inc passes every set field of f except Limit.
+func (f IncidentFilter) Match(inc Incident) bool {
+	if f.Target != "" && inc.Container != f.Target {
+		return false
+	}
+	if f.Category != "" && (inc.CrashAnalysis == nil || !strings.EqualFold(inc.CrashAnalysis.Category, f.Category)) {
+		return false
+	}
+	if !f.Since.IsZero() && inc.DetectedAt.Before(f.Since) {
+		return false
+	}
+	if f.Flapping && inc.Flapping == nil {
+		return false
+	}
+	if f.Status != "" && inc.EffectiveStatus() != f.Status {
+		return false
+	}
+	if f.Search != "" {
+		needle := strings.ToLower(f.Search)
+		if !strings.Contains(strings.ToLower(inc.PreLogs), needle) &&
+			!strings.Contains(strings.ToLower(inc.PostLogs), needle) {
+			return false
+		}
+	}
+	return true
+}
+
+// FilterIncidents returns the incidents that match f, in their original
+// order, cut to f.Limit.
+func FilterIncidents(incidents []Incident, f IncidentFilter) []Incident {
+	var out []Incident
+	for _, inc := range incidents {
+		if f.Limit > 0 && len(out) == f.Limit {
+			break
+		}
+		if f.Match(inc) {
+			out = append(out, inc)
+		}
+	}
+	return out
+}
+
+// SearchMatch returns the first log line of inc that contains search,
+// case-insensitively, or "" when none does.
+func SearchMatch(inc Incident, search string) string {
+	if search == "" {
+		return ""
+	}
+	needle := strings.ToLower(search)
+	for _, logs := range []string{inc.PreLogs, inc.PostLogs} {
+		for _, line := range strings.Split(logs, "\n") {
+			if strings.Contains(strings.ToLower(line), needle) {
+				return strings.TrimSpace(line)
+			}
+		}
+	}
+	return ""
+}
+
+// Export formats for WriteIncidents.
+const (
+	ExportJSONL = "jsonl"
+	ExportCSV   = "csv"
+)
+
+// csvHeader is the column order of the CSV export. Logs come last so the
+// short columns stay readable in a spreadsheet.
+var csvHeader = []string{
+	"id", "container", "detected_at", "restart_count", "status",
+	"category", "confidence", "reason", "flapping", "recovery", "time_to_recover_seconds", "remediation",
+	"acked_by", "acked_at", "resolved_by", "resolved_at", "resolution", "root_cause", "notes",
+	"prev_started_at", "curr_started_at", "pre_logs", "post_logs",
+}
+
+// WriteIncidents writes incidents in format: JSONL has one complete
+// incident per line, as stored; CSV flattens each incident to one row.
+func WriteIncidents(w io.Writer, incidents []Incident, format string) error {
+	switch format {
+	case ExportJSONL:
+		enc := json.NewEncoder(w)
+		for i := range incidents {
+			if err := enc.Encode(&incidents[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case ExportCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return err
+		}
+		for _, inc := range incidents {
+			if err := cw.Write(csvRow(inc)); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return fmt.Errorf("unsupported export format %q (use %s or %s)", format, ExportJSONL, ExportCSV)
+	}
+}
+
+func csvRow(inc Incident) []string {
+	var category, confidence, reason, flapping, recovery, ttr string
+	if a := inc.CrashAnalysis; a != nil {
+		category, confidence, reason = a.Category, a.Confidence, a.Reason
+	}
+	if inc.Flapping != nil {
+		flapping = inc.Flapping.Level
+	}
+	if r := inc.Recovery; r != nil {
+		recovery = r.Verdict
+		if r.TimeToRecover > 0 {
+			ttr = strconv.FormatFloat(r.TimeToRecover, 'f', -1, 64)
+		}
+	}
+	var remediation []string
+	for _, a := range inc.Remediation {
+		remediation = append(remediation, a.Summary())
+	}
+	var notes []string
+	for _, n := range inc.Notes {
+		notes = append(notes, fmt.Sprintf("[%s] %s: %s", n.Time.UTC().Format(time.RFC3339), n.User, n.Text))
+	}
+	row := []string{
+		inc.ID, inc.Container, inc.DetectedAt.UTC().Format(time.RFC3339), strconv.Itoa(inc.RestartCount), inc.EffectiveStatus(),
+		category, confidence, reason, flapping, recovery, ttr, strings.Join(remediation, "\n"),
+		inc.AckedBy, csvTime(inc.AckedAt), inc.ResolvedBy, csvTime(inc.ResolvedAt), inc.Resolution, inc.RootCause, strings.Join(notes, "\n"),
+		inc.PrevStarted, inc.CurrStarted, inc.PreLogs, inc.PostLogs,
+	}
+	for i, cell := range row {
+		row[i] = csvSafe(cell)
+	}
+	return row
+}
+
+// csvSafe keeps a spreadsheet from running a cell as a formula: a log line
+// or note starting with =, +, - or @ (or a tab or carriage return, which
+// some spreadsheets skip before looking) is prefixed with a quote.
+func csvSafe(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+func csvTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
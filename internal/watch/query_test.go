@@ -0,0 +1,177 @@
+package watch
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func queryTestIncidents(now time.Time) []Incident {
+	return []Incident{ // newest first, as ListIncidents returns them
+		{
+			ID: "nginx-3", Container: "nginx", DetectedAt: now.Add(-time.Hour),
+			PreLogs:       "worker process exited\nOut of memory: Killed process 42",
+			CrashAnalysis: &CrashSummary{Category: "oom", Confidence: "high", Reason: "OOM killed"},
+			Flapping:      &FlappingResult{IsFlapping: true, Level: "acute"},
+		},
+		{
+			ID: "db-2", Container: "db", DetectedAt: now.Add(-2 * time.Hour), Status: StatusAcked,
+			PostLogs:      "FATAL: could not connect to Connection Refused",
+			CrashAnalysis: &CrashSummary{Category: "dependency"},
+		},
+		{
+			ID: "nginx-1", Container: "nginx", DetectedAt: now.Add(-48 * time.Hour), Status: StatusResolved,
+			CrashAnalysis: &CrashSummary{Category: "oom"},
+		},
+	}
+}
+
+func TestFilterIncidents(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	incidents := queryTestIncidents(now)
+
+	tests := []struct {
+		name   string
+		filter IncidentFilter
+		want   string
+	}{
+		{"empty matches all", IncidentFilter{}, "nginx-3,db-2,nginx-1"},
+		{"target", IncidentFilter{Target: "nginx"}, "nginx-3,nginx-1"},
+		{"category is case-insensitive", IncidentFilter{Category: "OOM"}, "nginx-3,nginx-1"},
+		{"since", IncidentFilter{Since: now.Add(-24 * time.Hour)}, "nginx-3,db-2"},
+		{"flapping", IncidentFilter{Flapping: true}, "nginx-3"},
+		{"status", IncidentFilter{Status: StatusOpen}, "nginx-3"},
+		{"search pre logs", IncidentFilter{Search: "out of memory"}, "nginx-3"},
+		{"search post logs", IncidentFilter{Search: "connection refused"}, "db-2"},
+		{"combined", IncidentFilter{Target: "nginx", Category: "oom", Since: now.Add(-24 * time.Hour)}, "nginx-3"},
+		{"limit keeps the newest", IncidentFilter{Limit: 2}, "nginx-3,db-2"},
+		{"limit applies after filtering", IncidentFilter{Target: "nginx", Limit: 1}, "nginx-3"},
+		{"no match", IncidentFilter{Target: "redis"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ids []string
+			for _, inc := range FilterIncidents(incidents, tt.filter) {
+				ids = append(ids, inc.ID)
+			}
+			if got := strings.Join(ids, ","); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchMatch(t *testing.T) {
+	inc := Incident{PreLogs: "starting\n  Out of memory: Killed process 42  \nbye", PostLogs: "memory ok"}
+	if got := SearchMatch(inc, "MEMORY"); got != "Out of memory: Killed process 42" {
+		t.Errorf("SearchMatch = %q", got)
+	}
+	if got := SearchMatch(inc, "absent"); got != "" {
+		t.Errorf("SearchMatch for a missing term = %q", got)
+	}
+	if got := SearchMatch(inc, ""); got != "" {
+		t.Errorf("SearchMatch with no term = %q", got)
+	}
+}
+
+func TestWriteIncidentsJSONL(t *testing.T) {
+	incidents := queryTestIncidents(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	var buf bytes.Buffer
+	if err := WriteIncidents(&buf, incidents, ExportJSONL); err != nil {
+		t.Fatalf("WriteIncidents: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(incidents) {
+		t.Fatalf("got %d lines, want %d", len(lines), len(incidents))
+	}
+	var first Incident
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("line 1 is not an incident: %v", err)
+	}
+	if first.ID != "nginx-3" || first.CrashAnalysis == nil || first.PreLogs == "" {
+		t.Errorf("line 1 lost data: %+v", first)
+	}
+}
+
+func TestWriteIncidentsCSV(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	incidents := queryTestIncidents(now)
+	incidents[1].Notes = []IncidentNote{{Time: now, User: "alice", Text: "db disk, again"}}
+
+	var buf bytes.Buffer
+	if err := WriteIncidents(&buf, incidents, ExportCSV); err != nil {
+		t.Fatalf("WriteIncidents: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	if len(records) != len(incidents)+1 {
+		t.Fatalf("got %d records, want header + %d", len(records), len(incidents))
+	}
+
+	col := make(map[string]int)
+	for i, name := range records[0] {
+		col[name] = i
+	}
+	row := records[1]
+	if row[col["id"]] != "nginx-3" || row[col["category"]] != "oom" || row[col["flapping"]] != "acute" || row[col["status"]] != "open" {
+		t.Errorf("row 1 = %v", row)
+	}
+	if row[col["detected_at"]] != "2026-03-01T11:00:00Z" {
+		t.Errorf("detected_at = %q", row[col["detected_at"]])
+	}
+	if !strings.Contains(row[col["pre_logs"]], "\n") {
+		t.Error("multi-line logs should survive in one quoted cell")
+	}
+	if got := records[2][col["notes"]]; !strings.Contains(got, "alice: db disk, again") {
+		t.Errorf("notes = %q", got)
+	}
+}
+
+func TestWriteIncidentsCSVEscapesFormulas(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	inc := Incident{
+		ID: "app-1", Container: "app", DetectedAt: now,
+		PreLogs:    "=HYPERLINK(\"http://evil.example\",\"click\")",
+		PostLogs:   "-2+3",
+		Notes:      []IncidentNote{{Time: now, User: "bob", Text: "fine"}},
+		RootCause:  "@SUM(A1:A2)",
+		Resolution: "+cmd",
+	}
+
+	var buf bytes.Buffer
+	if err := WriteIncidents(&buf, []Incident{inc}, ExportCSV); err != nil {
+		t.Fatalf("WriteIncidents: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	col := make(map[string]int)
+	for i, name := range records[0] {
+		col[name] = i
+	}
+	row := records[1]
+	for name, want := range map[string]string{
+		"pre_logs":   "'=HYPERLINK(",
+		"post_logs":  "'-2+3",
+		"root_cause": "'@SUM",
+		"resolution": "'+cmd",
+		"notes":      "[2026-03-01",
+		"id":         "app-1",
+	} {
+		if got := row[col[name]]; !strings.HasPrefix(got, want) {
+			t.Errorf("%s = %q, want prefix %q", name, got, want)
+		}
+	}
+}
+
+func TestWriteIncidentsUnknownFormat(t *testing.T) {
+	if err := WriteIncidents(&bytes.Buffer{}, nil, "xml"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
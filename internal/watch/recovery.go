@@ -0,0 +1,360 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Higangssh/homebutler/internal/docker"
+	"github.com/Higangssh/homebutler/internal/util"
+)
+
+// Recovery verdicts.
+const (
+	RecoveryHealthy = "healthy"
+	RecoveryFailing = "failing"
+)
+
+const (
+	defaultRecoverySettle  = 10 * time.Second
+	defaultRecoveryTimeout = 2 * time.Minute
+	recoveryPollInterval   = 2 * time.Second
+)
+
+// RecoveryConfig controls the health check that follows each restart.
+type RecoveryConfig struct {
+	// Settle is how long a restarted target is left alone before the first
+	// check, so a service that comes up and dies again right away does not
+	// pass. Zero takes the default.
+	Settle time.Duration `yaml:"settle,omitempty" json:"settle"`
+
+	// Timeout is how long after the settle period a target may take to
+	// become healthy before it is reported as still failing. Zero takes
+	// the default; a negative value turns verification off.
+	Timeout time.Duration `yaml:"timeout,omitempty" json:"timeout"`
+}
+
+func DefaultRecoveryConfig() RecoveryConfig {
+	return RecoveryConfig{Settle: defaultRecoverySettle, Timeout: defaultRecoveryTimeout}
+}
+
+// Normalize fills in the defaults for unset values.
+func (r *RecoveryConfig) Normalize() {
+	if r.Settle <= 0 {
+		r.Settle = defaultRecoverySettle
+	}
+	if r.Timeout == 0 {
+		r.Timeout = defaultRecoveryTimeout
+	}
+}
+
+// Enabled reports whether restarts are verified at all.
+func (r RecoveryConfig) Enabled() bool {
+	return r.Timeout >= 0
+}
+
+// Recovery is the verdict on whether a target came back after a restart.
+type Recovery struct {
+	Verdict   string    `json:"verdict"` // "healthy" | "failing"
+	Check     string    `json:"check"`   // "docker", "systemd", "http probe" or "tcp probe"
+	Detail    string    `json:"detail,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+
+	// TimeToRecover is the time from detection until the target was
+	// healthy, in seconds. Zero when it was already healthy by then.
+	TimeToRecover float64 `json:"time_to_recover_seconds,omitempty"`
+}
+
+// Healthy reports whether the target came back healthy.
+func (r *Recovery) Healthy() bool {
+	return r != nil && r.Verdict == RecoveryHealthy
+}
+
+// Summary is the one-line verdict used in notifications and `watch show`.
+func (r *Recovery) Summary() string {
+	if r.Healthy() {
+		if r.TimeToRecover <= 0 {
+			return "restarted and healthy"
+		}
+		return fmt.Sprintf("restarted and healthy in %s", formatRecoveryTime(r.TimeToRecover))
+	}
+	out := "restarted but still failing"
+	if r.Detail != "" {
+		out += " (" + r.Detail + ")"
+	}
+	return out
+}
+
+func formatRecoveryTime(seconds float64) string {
+	d := time.Duration(seconds * float64(time.Second))
+	if d < time.Second {
+		return "<1s"
+	}
+	return d.Round(time.Second).String()
+}
+
+// SetIncidentRecovery records the recovery verdict of an incident.
+func SetIncidentRecovery(dir, id string, rec *Recovery) (*Incident, error) {
+	return updateIncident(dir, id, func(inc *Incident) error {
+		inc.Recovery = rec
+		return nil
+	})
+}
+
+// healthObservation is one look at a restarted target.
+type healthObservation struct {
+	healthy bool
+
+	// final means the target will not recover without someone acting,
+	// e.g. an exited container with no restart policy.
+	final bool
+
+	detail string
+
+	// since is when the target became healthy, when the backend knows.
+	since time.Time
+}
+
+// ParseProbe checks a probe declared with `watch add --probe`: an http://
+// or https:// URL, or tcp://host:port.
+func ParseProbe(probe string) (*url.URL, error) {
+	u, err := url.Parse(probe)
+	if err != nil {
+		return nil, fmt.Errorf("invalid probe %q: %w", probe, err)
+	}
+	switch u.Scheme {
+	case "http", "https":
+		if u.Host == "" {
+			return nil, fmt.Errorf("invalid probe %q: missing host", probe)
+		}
+	case "tcp":
+		if _, port, err := net.SplitHostPort(u.Host); err != nil || port == "" {
+			return nil, fmt.Errorf("invalid probe %q: want tcp://host:port", probe)
+		}
+	default:
+		return nil, fmt.Errorf("invalid probe %q: scheme must be http, https or tcp", probe)
+	}
+	return u, nil
+}
+
+// Verifier decides whether restarted targets came back healthy.
+type Verifier struct {
+	Config RecoveryConfig
+
+	// Run executes an external command (systemctl). Nil uses util.RunCmd.
+	Run CommandRunner
+
+	// Poll is how often the target is checked after the settle period.
+	// Zero uses two seconds.
+	Poll time.Duration
+}
+
+// inspectHealthFunc reads a container's state for the Docker check; tests
+// replace it.
+var inspectHealthFunc = inspectHealth
+
+// probeFunc runs an HTTP or TCP probe; tests replace it.
+var probeFunc = runProbe
+
+// CanVerify reports whether t has a check: a probe, or a Docker or
+// systemd backend.
+func (v *Verifier) CanVerify(t Target) bool {
+	if !v.Config.Enabled() {
+		return false
+	}
+	if t.Probe != "" {
+		return true
+	}
+	kind := t.EffectiveKind()
+	return kind == "docker" || kind == "systemd"
+}
+
+// Verify waits out the settle period after detected, then checks t until
+// it is healthy, it cannot recover, or the timeout passes. It returns nil
+// when t has no check or ctx ends first.
+func (v *Verifier) Verify(ctx context.Context, t Target, detected time.Time) *Recovery {
+	if !v.CanVerify(t) {
+		return nil
+	}
+	cfg := v.Config
+	cfg.Normalize()
+	poll := v.Poll
+	if poll <= 0 {
+		poll = recoveryPollInterval
+	}
+
+	check, observe := v.checkFor(t)
+	deadline := detected.Add(cfg.Settle + cfg.Timeout)
+	wait := time.Until(detected.Add(cfg.Settle))
+	for {
+		if wait > 0 {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(wait):
+			}
+		}
+
+		obs := observe(ctx)
+		now := time.Now()
+		rec := &Recovery{Check: check, Detail: obs.detail, CheckedAt: now}
+		switch {
+		case obs.healthy:
+			rec.Verdict = RecoveryHealthy
+			at := now
+			if !obs.since.IsZero() {
+				at = obs.since
+			}
+			if at.After(detected) {
+				rec.TimeToRecover = at.Sub(detected).Round(time.Second).Seconds()
+			}
+			return rec
+		case obs.final, !now.Before(deadline):
+			rec.Verdict = RecoveryFailing
+			return rec
+		}
+		wait = min(poll, time.Until(deadline))
+	}
+}
+
+// checkFor returns the name of the check for t and the function that runs
+// it. A declared probe wins over the backend's own state.
+func (v *Verifier) checkFor(t Target) (string, func(context.Context) healthObservation) {
+	if t.Probe != "" {
+		name := "tcp probe"
+		if !strings.HasPrefix(t.Probe, "tcp://") {
+			name = "http probe"
+		}
+		return name, func(ctx context.Context) healthObservation {
+			if err := probeFunc(ctx, t.Probe); err != nil {
+				return healthObservation{detail: err.Error()}
+			}
+			return healthObservation{healthy: true}
+		}
+	}
+
+	unit := t.EffectiveUnit()
+	if t.EffectiveKind() == "systemd" {
+		run := v.Run
+		if run == nil {
+			run = func(name string, args ...string) (string, error) {
+				return util.RunCmd(name, args...)
+			}
+		}
+		return "systemd", func(context.Context) healthObservation {
+			return observeSystemd(run, unit)
+		}
+	}
+	return "docker", func(ctx context.Context) healthObservation {
+		return inspectHealthFunc(ctx, unit)
+	}
+}
+
+// inspectHealth reads Docker's view of a container: its health check
+// status when it has one, otherwise whether it is running.
+func inspectHealth(ctx context.Context, name string) healthObservation {
+	client, err := docker.DefaultClient()
+	if err != nil {
+		return healthObservation{detail: err.Error()}
+	}
+	info, err := client.ContainerInspect(ctx, name)
+	if err != nil {
+		return healthObservation{detail: err.Error()}
+	}
+	return dockerObservation(info)
+}
+
+func dockerObservation(info *docker.ContainerInfo) healthObservation {
+	st := info.State
+	if !st.Running || st.Status == "restarting" {
+		policy := info.HostConfig.RestartPolicy.Name
+		return healthObservation{
+			detail: "state=" + st.Status,
+			final:  (st.Status == "exited" || st.Status == "dead") && (policy == "" || policy == "no"),
+		}
+	}
+	if st.Health != nil {
+		return healthObservation{healthy: st.Health.Status == "healthy", detail: "health=" + st.Health.Status}
+	}
+	obs := healthObservation{healthy: true, detail: "state=running"}
+	obs.since, _ = time.Parse(time.RFC3339Nano, st.StartedAt)
+	return obs
+}
+
+// observeSystemd reads a unit's ActiveState. A unit between automatic
+// restarts is activating or inactive; only failed is final.
+func observeSystemd(run CommandRunner, unit string) healthObservation {
+	out, err := run("systemctl", "show", unit, "--property=ActiveState,SubState,ActiveEnterTimestamp")
+	if err != nil {
+		return healthObservation{detail: err.Error()}
+	}
+	var active, sub, enter string
+	for _, line := range strings.Split(out, "\n") {
+		key, value, _ := strings.Cut(strings.TrimSpace(line), "=")
+		switch key {
+		case "ActiveState":
+			active = value
+		case "SubState":
+			sub = value
+		case "ActiveEnterTimestamp":
+			enter = value
+		}
+	}
+	obs := healthObservation{
+		healthy: active == "active",
+		final:   active == "failed",
+		detail:  fmt.Sprintf("ActiveState=%s SubState=%s", active, sub),
+	}
+	if obs.healthy {
+		obs.since, _ = time.Parse(systemdTimeLayout, enter)
+	}
+	return obs
+}
+
+var probeClient = &http.Client{Timeout: 5 * time.Second}
+
+// runProbe checks an HTTP endpoint for a 2xx or 3xx answer, or that a TCP
+// port accepts connections.
+func runProbe(ctx context.Context, probe string) error {
+	u, err := ParseProbe(probe)
+	if err != nil {
+		return err
+	}
+	if u.Scheme == "tcp" {
+		d := net.Dialer{Timeout: 5 * time.Second}
+		conn, err := d.DialContext(ctx, "tcp", u.Host)
+		if err != nil {
+			return fmt.Errorf("tcp %s: %w", u.Host, unwrapNetError(err))
+		}
+		return conn.Close()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, probe, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := probeClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("GET %s: %w", u.Redacted(), unwrapNetError(err))
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// unwrapNetError drops the operation prefix of a net error, keeping
+// messages like "connection refused" short enough for a notification.
+func unwrapNetError(err error) error {
+	if opErr, ok := err.(*net.OpError); ok && opErr.Err != nil {
+		return opErr.Err
+	}
+	if urlErr, ok := err.(*url.Error); ok && urlErr.Err != nil {
+		return unwrapNetError(urlErr.Err)
+	}
+	return err
+}
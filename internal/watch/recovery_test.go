@@ -0,0 +1,321 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Higangssh/homebutler/internal/docker"
+	"github.com/Higangssh/homebutler/internal/notify"
+)
+
+// stubInspectHealth replaces the Docker check with a sequence of
+// observations; the last one repeats.
+func stubInspectHealth(t *testing.T, seq ...healthObservation) *int {
+	t.Helper()
+	orig := inspectHealthFunc
+	t.Cleanup(func() { inspectHealthFunc = orig })
+	var mu sync.Mutex
+	calls := 0
+	inspectHealthFunc = func(ctx context.Context, name string) healthObservation {
+		mu.Lock()
+		defer mu.Unlock()
+		obs := seq[min(calls, len(seq)-1)]
+		calls++
+		return obs
+	}
+	return &calls
+}
+
+func fastVerifier() *Verifier {
+	return &Verifier{Config: RecoveryConfig{Settle: 10 * time.Millisecond, Timeout: 200 * time.Millisecond}, Poll: 5 * time.Millisecond}
+}
+
+func TestRecoveryConfigNormalize(t *testing.T) {
+	var r RecoveryConfig
+	r.Normalize()
+	if r != DefaultRecoveryConfig() {
+		t.Errorf("zero config normalized to %+v, want the defaults", r)
+	}
+
+	off := RecoveryConfig{Timeout: -1}
+	off.Normalize()
+	if off.Enabled() {
+		t.Error("a negative timeout should turn verification off")
+	}
+}
+
+func TestParseProbe(t *testing.T) {
+	valid := []string{"http://localhost:8080/health", "https://example.com", "tcp://127.0.0.1:5432", "tcp://db:6379"}
+	for _, p := range valid {
+		if _, err := ParseProbe(p); err != nil {
+			t.Errorf("ParseProbe(%q): %v", p, err)
+		}
+	}
+	invalid := []string{"localhost:8080", "ftp://host", "http://", "tcp://host", "tcp://:"}
+	for _, p := range invalid {
+		if _, err := ParseProbe(p); err == nil {
+			t.Errorf("ParseProbe(%q) should fail", p)
+		}
+	}
+}
+
+func TestVerifyDockerBecomesHealthy(t *testing.T) {
+	calls := stubInspectHealth(t,
+		healthObservation{detail: "health=starting"},
+		healthObservation{detail: "health=starting"},
+		healthObservation{healthy: true, detail: "health=healthy"},
+	)
+	detected := time.Now()
+	rec := fastVerifier().Verify(context.Background(), Target{Container: "web"}, detected)
+	if rec == nil || rec.Verdict != RecoveryHealthy {
+		t.Fatalf("rec = %+v, want healthy", rec)
+	}
+	if rec.Check != "docker" || rec.Detail != "health=healthy" {
+		t.Errorf("rec = %+v", rec)
+	}
+	if *calls != 3 {
+		t.Errorf("inspected %d times, want 3", *calls)
+	}
+	if rec.CheckedAt.Before(detected.Add(10 * time.Millisecond)) {
+		t.Error("the first check ran before the settle period was over")
+	}
+}
+
+func TestVerifyTimeToRecoverFromStartTime(t *testing.T) {
+	detected := time.Now()
+	stubInspectHealth(t, healthObservation{healthy: true, since: detected.Add(14 * time.Second)})
+	rec := fastVerifier().Verify(context.Background(), Target{Container: "web"}, detected)
+	if rec.TimeToRecover != 14 {
+		t.Errorf("TimeToRecover = %v, want 14", rec.TimeToRecover)
+	}
+	if got := rec.Summary(); got != "restarted and healthy in 14s" {
+		t.Errorf("Summary = %q", got)
+	}
+}
+
+func TestVerifyStillFailing(t *testing.T) {
+	stubInspectHealth(t, healthObservation{detail: "health=unhealthy"})
+	rec := fastVerifier().Verify(context.Background(), Target{Container: "web"}, time.Now())
+	if rec == nil || rec.Verdict != RecoveryFailing {
+		t.Fatalf("rec = %+v, want failing", rec)
+	}
+	if got := rec.Summary(); got != "restarted but still failing (health=unhealthy)" {
+		t.Errorf("Summary = %q", got)
+	}
+}
+
+func TestVerifyStopsAtFinalState(t *testing.T) {
+	calls := stubInspectHealth(t, healthObservation{detail: "state=exited", final: true})
+	v := fastVerifier()
+	v.Config.Timeout = time.Hour
+	done := make(chan *Recovery)
+	go func() { done <- v.Verify(context.Background(), Target{Container: "web"}, time.Now()) }()
+	select {
+	case rec := <-done:
+		if rec.Verdict != RecoveryFailing || *calls != 1 {
+			t.Errorf("rec = %+v after %d checks", rec, *calls)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Verify kept polling a container that cannot come back")
+	}
+}
+
+func TestVerifyCancelled(t *testing.T) {
+	stubInspectHealth(t, healthObservation{healthy: true})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	v := &Verifier{Config: RecoveryConfig{Settle: time.Hour}}
+	if rec := v.Verify(ctx, Target{Container: "web"}, time.Now()); rec != nil {
+		t.Errorf("a cancelled verification returned %+v", rec)
+	}
+}
+
+func TestCanVerify(t *testing.T) {
+	v := &Verifier{Config: DefaultRecoveryConfig()}
+	tests := []struct {
+		target Target
+		want   bool
+	}{
+		{Target{Container: "web"}, true},
+		{Target{Container: "nginx", Kind: "systemd"}, true},
+		{Target{Container: "api", Kind: "pm2"}, false},
+		{Target{Container: "api", Kind: "pm2", Probe: "tcp://127.0.0.1:3000"}, true},
+	}
+	for _, tt := range tests {
+		if got := v.CanVerify(tt.target); got != tt.want {
+			t.Errorf("CanVerify(%+v) = %v, want %v", tt.target, got, tt.want)
+		}
+	}
+	off := &Verifier{Config: RecoveryConfig{Timeout: -1}}
+	if off.CanVerify(Target{Container: "web"}) {
+		t.Error("verification is off, but CanVerify is true")
+	}
+}
+
+func TestDockerObservation(t *testing.T) {
+	info := func(status string, running bool, health, policy string) *docker.ContainerInfo {
+		c := &docker.ContainerInfo{}
+		c.State.Status, c.State.Running, c.State.StartedAt = status, running, "2026-03-01T02:00:14.5Z"
+		if health != "" {
+			c.State.Health = &struct {
+				Status string `json:"Status"`
+			}{Status: health}
+		}
+		c.HostConfig.RestartPolicy.Name = policy
+		return c
+	}
+
+	obs := dockerObservation(info("running", true, "", "always"))
+	if !obs.healthy || obs.since.IsZero() {
+		t.Errorf("running without a health check: %+v", obs)
+	}
+	if obs := dockerObservation(info("running", true, "starting", "always")); obs.healthy || obs.final {
+		t.Errorf("health=starting: %+v", obs)
+	}
+	if obs := dockerObservation(info("running", true, "healthy", "always")); !obs.healthy {
+		t.Errorf("health=healthy: %+v", obs)
+	}
+	if obs := dockerObservation(info("restarting", true, "", "always")); obs.healthy || obs.final {
+		t.Errorf("restarting: %+v", obs)
+	}
+	if obs := dockerObservation(info("exited", false, "", "on-failure")); obs.final {
+		t.Errorf("exited with a restart policy should not be final: %+v", obs)
+	}
+	if obs := dockerObservation(info("exited", false, "", "no")); !obs.final {
+		t.Errorf("exited without a restart policy should be final: %+v", obs)
+	}
+}
+
+func TestObserveSystemd(t *testing.T) {
+	out := "ActiveState=active\nSubState=running\nActiveEnterTimestamp=Sun 2026-03-01 02:00:14 UTC\n"
+	run := func(name string, args ...string) (string, error) { return out, nil }
+
+	obs := observeSystemd(run, "nginx.service")
+	if !obs.healthy || obs.since.IsZero() {
+		t.Errorf("active unit: %+v", obs)
+	}
+
+	out = "ActiveState=activating\nSubState=auto-restart\n"
+	if obs := observeSystemd(run, "nginx.service"); obs.healthy || obs.final {
+		t.Errorf("auto-restart: %+v", obs)
+	}
+	out = "ActiveState=failed\nSubState=failed\n"
+	if obs := observeSystemd(run, "nginx.service"); !obs.final || obs.detail != "ActiveState=failed SubState=failed" {
+		t.Errorf("failed unit: %+v", obs)
+	}
+
+	failing := func(name string, args ...string) (string, error) { return "", errors.New("no systemd") }
+	if obs := observeSystemd(failing, "nginx.service"); obs.healthy {
+		t.Errorf("systemctl error: %+v", obs)
+	}
+}
+
+func TestRunProbe(t *testing.T) {
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ok.Close()
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer bad.Close()
+
+	ctx := context.Background()
+	if err := runProbe(ctx, ok.URL+"/health"); err != nil {
+		t.Errorf("healthy endpoint: %v", err)
+	}
+	if err := runProbe(ctx, bad.URL); err == nil || err.Error() != "HTTP 502" {
+		t.Errorf("502 endpoint: %v", err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	if err := runProbe(ctx, "tcp://"+addr); err != nil {
+		t.Errorf("open port: %v", err)
+	}
+	ln.Close()
+	if err := runProbe(ctx, "tcp://"+addr); err == nil || !strings.Contains(err.Error(), "refused") {
+		t.Errorf("closed port: %v", err)
+	}
+}
+
+func TestVerifyUsesProbe(t *testing.T) {
+	stubInspectHealth(t, healthObservation{detail: "docker should not be asked"})
+	orig := probeFunc
+	t.Cleanup(func() { probeFunc = orig })
+	probeFunc = func(ctx context.Context, probe string) error { return nil }
+
+	rec := fastVerifier().Verify(context.Background(), Target{Container: "web", Probe: "http://localhost/health"}, time.Now())
+	if !rec.Healthy() || rec.Check != "http probe" {
+		t.Errorf("rec = %+v", rec)
+	}
+}
+
+func TestRunnerNotifiesWithRecoveryVerdict(t *testing.T) {
+	stubInspectHealth(t, healthObservation{healthy: true, detail: "state=running"})
+	dir := t.TempDir()
+
+	var mu sync.Mutex
+	var events []notify.Event
+	d := notify.NewDispatcher(&notify.ProviderConfig{Telegram: &notify.TelegramConfig{BotToken: "t", ChatID: "c"}}, time.Minute)
+	d.SetSendFunc(func(cfg *notify.ProviderConfig, ev notify.Event) []error {
+		mu.Lock()
+		events = append(events, ev)
+		mu.Unlock()
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := &Runner{
+		Dir:      dir,
+		Notifier: &WatchNotifier{Settings: NotifySettings{Enabled: true, OnIncident: true}, Dispatcher: d},
+		Verifier: fastVerifier(),
+		Monitor:  func(kind string) Monitor { return &fakeMonitor{} },
+	}
+	go r.Run(ctx, []Target{{Container: "web"}})
+
+	waitFor(t, "the notification", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(events) == 1
+	})
+	mu.Lock()
+	ev := events[0]
+	mu.Unlock()
+	if ev.Result != "restarted and healthy" || ev.Action != "verified with docker" {
+		t.Errorf("event = %+v", ev)
+	}
+
+	incidents, _ := ListIncidents(dir)
+	if len(incidents) != 1 || !incidents[0].Recovery.Healthy() {
+		t.Fatalf("stored incidents = %+v", incidents)
+	}
+}
+
+func TestNotifyIncidentStillFailing(t *testing.T) {
+	var got notify.Event
+	d := notify.NewDispatcher(&notify.ProviderConfig{Telegram: &notify.TelegramConfig{BotToken: "t", ChatID: "c"}}, time.Minute)
+	d.SetSendFunc(func(cfg *notify.ProviderConfig, ev notify.Event) []error {
+		got = ev
+		return nil
+	})
+	wn := &WatchNotifier{Settings: NotifySettings{Enabled: true, OnIncident: true}, Dispatcher: d}
+
+	inc := baseIncident("web", time.Now())
+	inc.Recovery = &Recovery{Verdict: RecoveryFailing, Check: "http probe", Detail: "HTTP 502"}
+	if err := wn.NotifyIncident(inc, FlappingResult{}, nil, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != "failing" || got.Result != "restarted but still failing (HTTP 502)" {
+		t.Errorf("event = %+v", got)
+	}
+}
@@ -0,0 +1,737 @@
+package watch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Higangssh/homebutler/internal/util"
+)
+
+// Remediation actions.
+const (
+	RemediateStop        = "stop"
+	RemediateRollback    = "rollback"
+	RemediateScaleMemory = "scale_memory"
+	RemediatePlaybook    = "playbook"
+)
+
+// RemediationActions lists the actions a policy can take.
+var RemediationActions = []string{RemediateStop, RemediateRollback, RemediateScaleMemory, RemediatePlaybook}
+
+// Remediation outcomes.
+const (
+	RemediationDone    = "done"
+	RemediationFailed  = "failed"
+	RemediationDryRun  = "dry-run"
+	RemediationSkipped = "skipped"
+)
+
+const (
+	defaultRemediationBudget   = 3
+	defaultRemediationCooldown = time.Hour
+
+	// remediationLogKeep is how long the action log keeps entries. The
+	// budget only looks back one day; the rest is for `watch remediation`.
+	remediationLogKeep = 7 * 24 * time.Hour
+)
+
+// RemediationPolicy is one automatic action for a flapping target.
+type RemediationPolicy struct {
+	Target string `yaml:"target" json:"target"`
+	Action string `yaml:"action" json:"action"` // stop, rollback, scale_memory or playbook
+
+	// Level is the lowest flapping level that triggers the policy:
+	// "chronic" (the default) fires at either level, "acute" only on an
+	// acute restart loop.
+	Level string `yaml:"level,omitempty" json:"level,omitempty"`
+
+	// Restarts additionally requires this many restarts in the flapping
+	// window, e.g. stop only after the eighth crash.
+	Restarts int `yaml:"restarts,omitempty" json:"restarts,omitempty"`
+
+	// Memory is the new limit for scale_memory: a size such as "2g", or a
+	// factor of the current limit such as "x1.5".
+	Memory string `yaml:"memory,omitempty" json:"memory,omitempty"`
+
+	// Playbook names an entry of RemediationConfig.Playbooks.
+	Playbook string `yaml:"playbook,omitempty" json:"playbook,omitempty"`
+}
+
+// RemediationConfig is the watch.remediation config section.
+type RemediationConfig struct {
+	// DryRun records what each policy would do without doing it.
+	DryRun bool `yaml:"dry_run,omitempty" json:"dry_run"`
+
+	// MaxActionsPerDay caps the actions taken across all targets in any 24
+	// hours. Zero takes the default of 3.
+	MaxActionsPerDay int `yaml:"max_actions_per_day,omitempty" json:"max_actions_per_day"`
+
+	// Cooldown is how long a policy waits before acting on the same target
+	// again. Zero takes the default of one hour.
+	Cooldown time.Duration `yaml:"cooldown,omitempty" json:"cooldown"`
+
+	Policies []RemediationPolicy `yaml:"policies,omitempty" json:"policies,omitempty"`
+
+	// Playbooks maps a playbook name to the shell commands it runs, in order.
+	Playbooks map[string][]string `yaml:"playbooks,omitempty" json:"playbooks,omitempty"`
+}
+
+// Normalize fills in the defaults for unset values.
+func (c *RemediationConfig) Normalize() {
+	if c.MaxActionsPerDay <= 0 {
+		c.MaxActionsPerDay = defaultRemediationBudget
+	}
+	if c.Cooldown <= 0 {
+		c.Cooldown = defaultRemediationCooldown
+	}
+}
+
+// Validate reports why a policy cannot be used. playbooks are the
+// configured playbooks a playbook policy may name.
+func (p RemediationPolicy) Validate(playbooks map[string][]string) error {
+	if p.Target == "" {
+		return fmt.Errorf("target is required")
+	}
+	switch p.Level {
+	case "", "acute", "chronic":
+	default:
+		return fmt.Errorf("unknown level %q (expected acute or chronic)", p.Level)
+	}
+	if p.Restarts < 0 {
+		return fmt.Errorf("restarts cannot be negative")
+	}
+	switch p.Action {
+	case RemediateStop, RemediateRollback:
+	case RemediateScaleMemory:
+		if p.Memory == "" {
+			return fmt.Errorf("scale_memory needs memory, e.g. \"2g\" or \"x1.5\"")
+		}
+		if _, _, err := parseMemorySpec(p.Memory); err != nil {
+			return err
+		}
+	case RemediatePlaybook:
+		if p.Playbook == "" {
+			return fmt.Errorf("playbook action needs a playbook name")
+		}
+		if _, ok := playbooks[p.Playbook]; !ok {
+			return fmt.Errorf("playbook %q is not defined under watch.remediation.playbooks", p.Playbook)
+		}
+	case "":
+		return fmt.Errorf("action is required (%s)", strings.Join(RemediationActions, ", "))
+	default:
+		return fmt.Errorf("unknown action %q (expected %s)", p.Action, strings.Join(RemediationActions, ", "))
+	}
+	return nil
+}
+
+// Triggers reports whether flap crosses the policy's level and restart count.
+func (p RemediationPolicy) Triggers(flap FlappingResult) bool {
+	if !flap.IsFlapping {
+		return false
+	}
+	if p.Level == "acute" && flap.Level != "acute" {
+		return false
+	}
+	return flap.Count >= p.Restarts
+}
+
+// PoliciesFor returns the valid policies for target that flap triggers, in
+// config order.
+func (c RemediationConfig) PoliciesFor(target string, flap FlappingResult) []RemediationPolicy {
+	var out []RemediationPolicy
+	for _, p := range c.Policies {
+		if p.Target == target && p.Triggers(flap) && p.Validate(c.Playbooks) == nil {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// RemediationAction is the audit record of one policy firing. It is kept on
+// the incident that triggered it and in the action log.
+type RemediationAction struct {
+	Time     time.Time `json:"time"`
+	Target   string    `json:"target"`
+	Incident string    `json:"incident,omitempty"`
+	Action   string    `json:"action"`
+	Level    string    `json:"level"`   // flapping level that triggered it
+	Outcome  string    `json:"outcome"` // done, failed, dry-run or skipped
+	Detail   string    `json:"detail,omitempty"`
+
+	// Commands are the commands that ran, or would have in a dry run.
+	Commands []string `json:"commands,omitempty"`
+	Output   string   `json:"output,omitempty"`
+}
+
+// Summary is the one-line form used in notifications and logs.
+func (a RemediationAction) Summary() string {
+	return fmt.Sprintf("%s %s: %s", a.Action, a.Outcome, a.Detail)
+}
+
+// counts reports whether the action uses up budget. Skips and actions that
+// failed before running anything do not.
+func (a RemediationAction) counts() bool {
+	return a.Outcome != RemediationSkipped && len(a.Commands) > 0
+}
+
+// SetIncidentRemediation records the remediation actions of an incident.
+func SetIncidentRemediation(dir, id string, actions []RemediationAction) (*Incident, error) {
+	return updateIncident(dir, id, func(inc *Incident) error {
+		inc.Remediation = actions
+		return nil
+	})
+}
+
+func remediationLogPath(dir string) string {
+	return filepath.Join(dir, "remediation.json")
+}
+
+// LoadRemediationLog returns the actions of the last week, oldest first,
+// that counted against the budget.
+func LoadRemediationLog(dir string) ([]RemediationAction, error) {
+	data, err := os.ReadFile(remediationLogPath(dir))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var log []RemediationAction
+	if err := json.Unmarshal(data, &log); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", remediationLogPath(dir), err)
+	}
+	return log, nil
+}
+
+func saveRemediationLog(dir string, log []RemediationAction, now time.Time) error {
+	log = slices.DeleteFunc(log, func(a RemediationAction) bool {
+		return now.Sub(a.Time) > remediationLogKeep
+	})
+	if err := ensureDir(dir); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(remediationLogPath(dir), data, 0o644)
+}
+
+// BudgetUsed counts the actions in log that count against the budget in
+// the 24 hours before now. Dry runs and live actions have separate budgets,
+// so a dry run shows what the live budget would have allowed.
+func BudgetUsed(log []RemediationAction, dryRun bool, now time.Time) int {
+	n := 0
+	for _, a := range log {
+		if a.counts() && (a.Outcome == RemediationDryRun) == dryRun && now.Sub(a.Time) < 24*time.Hour {
+			n++
+		}
+	}
+	return n
+}
+
+// remediationMu serializes reading and writing the action log, so that two
+// incidents of one target cannot both pass the cooldown and budget checks.
+// It is package-wide because the daemon replaces its Runner on reload while
+// the old one may still be finishing.
+var remediationMu sync.Mutex
+
+// Remediator runs the remediation policies of flapping targets.
+type Remediator struct {
+	Config RemediationConfig
+
+	// Dir is the watch directory: the action log lives there, and rollback
+	// writes its Compose overrides under it.
+	Dir string
+
+	// Run executes external commands (docker, systemctl, pm2, supervisorctl
+	// and playbook shells). Nil runs them, with docker going to the
+	// detected container runtime.
+	Run CommandRunner
+}
+
+func (rm *Remediator) run(name string, args ...string) (string, error) {
+	if rm.Run != nil {
+		return rm.Run(name, args...)
+	}
+	if name == "docker" {
+		return util.DockerCmd(args...)
+	}
+	return util.RunCmd(name, args...)
+}
+
+// Remediate fires the policies that flap triggers for the target of inc and
+// returns what each did. Policies in their cooldown or over the daily budget
+// are recorded as skipped.
+func (rm *Remediator) Remediate(inc Incident, t Target, flap FlappingResult, now time.Time) []RemediationAction {
+	cfg := rm.Config
+	cfg.Normalize()
+	policies := cfg.PoliciesFor(t.Container, flap)
+	if len(policies) == 0 {
+		return nil
+	}
+
+	remediationMu.Lock()
+	defer remediationMu.Unlock()
+	log, err := LoadRemediationLog(rm.Dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v; starting a new remediation log\n", err)
+	}
+
+	var out []RemediationAction
+	for _, p := range policies {
+		act := RemediationAction{
+			Time:     now,
+			Target:   t.Container,
+			Incident: inc.ID,
+			Action:   p.Action,
+			Level:    flap.Level,
+		}
+		if last := lastAction(log, t.Container, p.Action, cfg.DryRun); last != nil && now.Sub(last.Time) < cfg.Cooldown {
+			act.Outcome = RemediationSkipped
+			act.Detail = fmt.Sprintf("already ran at %s for incident %s (cooldown %s)",
+				last.Time.Format("15:04:05"), last.Incident, cfg.Cooldown)
+		} else if used := BudgetUsed(log, cfg.DryRun, now); used >= cfg.MaxActionsPerDay {
+			act.Outcome = RemediationSkipped
+			act.Detail = fmt.Sprintf("daily budget of %d actions used", cfg.MaxActionsPerDay)
+		} else {
+			rm.execute(&act, p, t, flap)
+		}
+		if act.counts() {
+			log = append(log, act)
+		}
+		out = append(out, act)
+	}
+	if err := saveRemediationLog(rm.Dir, log, now); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: save remediation log: %v\n", err)
+	}
+	return out
+}
+
+// lastAction returns the newest counted action in log for target and action
+// in the same mode, or nil.
+func lastAction(log []RemediationAction, target, action string, dryRun bool) *RemediationAction {
+	for i := len(log) - 1; i >= 0; i-- {
+		a := &log[i]
+		if a.Target == target && a.Action == action && a.counts() && (a.Outcome == RemediationDryRun) == dryRun {
+			return a
+		}
+	}
+	return nil
+}
+
+// remediationStep is one command of a plan, or a file written before the
+// next command when file is set.
+type remediationStep struct {
+	name    string
+	args    []string
+	display string // how the audit shows it; defaults to the command line
+
+	file    string
+	content []byte
+}
+
+func (s remediationStep) String() string {
+	if s.display != "" {
+		return s.display
+	}
+	if s.file != "" {
+		return "write " + s.file
+	}
+	return shellJoin(append([]string{s.name}, s.args...))
+}
+
+// execute plans p for t and, unless this is a dry run, carries it out. The
+// plan may read the target's state first, so a dry run reports the actual
+// image or limit it would have chosen.
+func (rm *Remediator) execute(act *RemediationAction, p RemediationPolicy, t Target, flap FlappingResult) {
+	detail, steps, err := rm.plan(p, t, act.Incident, flap)
+	if err != nil {
+		act.Outcome = RemediationFailed
+		act.Detail = err.Error()
+		return
+	}
+	act.Detail = detail
+	for _, s := range steps {
+		act.Commands = append(act.Commands, s.String())
+	}
+	if rm.Config.DryRun {
+		act.Outcome = RemediationDryRun
+		return
+	}
+
+	act.Outcome = RemediationDone
+	for _, s := range steps {
+		if s.file != "" {
+			if err = os.MkdirAll(filepath.Dir(s.file), 0o755); err == nil {
+				err = os.WriteFile(s.file, s.content, 0o644)
+			}
+		} else {
+			var out string
+			out, err = rm.run(s.name, s.args...)
+			act.Output = lastLine(out)
+		}
+		if err != nil {
+			act.Outcome = RemediationFailed
+			act.Detail = fmt.Sprintf("%s: %s: %v", detail, s, err)
+			return
+		}
+	}
+}
+
+// plan returns a description of what p does to t and the steps that do it.
+func (rm *Remediator) plan(p RemediationPolicy, t Target, incident string, flap FlappingResult) (string, []remediationStep, error) {
+	switch p.Action {
+	case RemediateStop:
+		return planStop(t)
+	case RemediateRollback:
+		return rm.planRollback(t)
+	case RemediateScaleMemory:
+		return rm.planScaleMemory(t, p.Memory)
+	case RemediatePlaybook:
+		return rm.planPlaybook(p.Playbook, t, incident, flap)
+	}
+	return "", nil, fmt.Errorf("unknown action %q", p.Action)
+}
+
+// planStop stops the target for good. A Docker container also loses its
+// restart policy, so the daemon does not start it again on its next restart.
+func planStop(t Target) (string, []remediationStep, error) {
+	unit := t.EffectiveUnit()
+	switch kind := t.EffectiveKind(); kind {
+	case "docker":
+		return fmt.Sprintf("stop %s and set its restart policy to no", unit), []remediationStep{
+			{name: "docker", args: []string{"update", "--restart=no", unit}},
+			{name: "docker", args: []string{"stop", unit}},
+		}, nil
+	case "systemd":
+		return "stop " + unit, []remediationStep{{name: "systemctl", args: []string{"stop", unit}}}, nil
+	case "pm2":
+		return "stop " + unit, []remediationStep{{name: "pm2", args: []string{"stop", unit}}}, nil
+	case "supervisord":
+		return "stop " + unit, []remediationStep{{name: "supervisorctl", args: []string{"stop", unit}}}, nil
+	default:
+		return "", nil, fmt.Errorf("stop is not supported for %s targets", kind)
+	}
+}
+
+// composeLabels are the labels Docker Compose puts on the containers it
+// creates, in the order planRollback reads them.
+var composeLabels = []string{
+	"com.docker.compose.project",
+	"com.docker.compose.service",
+	"com.docker.compose.project.working_dir",
+	"com.docker.compose.project.config_files",
+}
+
+// planRollback moves a Compose service back to the newest local tag of its
+// image that predates the running one. The compose file is left alone: an
+// override file under the watch directory pins the old tag, and removing it
+// returns the service to the compose file's image on the next `up`.
+func (rm *Remediator) planRollback(t Target) (string, []remediationStep, error) {
+	if kind := t.EffectiveKind(); kind != "docker" {
+		return "", nil, fmt.Errorf("rollback is only supported for Docker targets, not %s", kind)
+	}
+	name := t.EffectiveUnit()
+	format := "{{.Config.Image}}|{{.Image}}"
+	for _, l := range composeLabels {
+		format += fmt.Sprintf("|{{index .Config.Labels %q}}", l)
+	}
+	out, err := rm.run("docker", "inspect", "--format", format, name)
+	if err != nil {
+		return "", nil, fmt.Errorf("inspect %s: %v", name, firstLine(out, err))
+	}
+	fields := strings.Split(strings.TrimSpace(out), "|")
+	if len(fields) != 2+len(composeLabels) {
+		return "", nil, fmt.Errorf("inspect %s: unexpected output %q", name, out)
+	}
+	image, imageID := fields[0], fields[1]
+	project, service, workDir, configFiles := fields[2], fields[3], fields[4], fields[5]
+	if project == "" || service == "" {
+		return "", nil, fmt.Errorf("rollback needs a Compose-managed container; %s has no compose labels", name)
+	}
+
+	repo, tag := splitImageRef(image)
+	out, err = rm.run("docker", "image", "ls", repo, "--format", "{{.Tag}}|{{.ID}}|{{.CreatedAt}}")
+	if err != nil {
+		return "", nil, fmt.Errorf("list images of %s: %v", repo, firstLine(out, err))
+	}
+	prev, ok := previousTag(out, tag, imageID)
+	if !ok {
+		return "", nil, fmt.Errorf("no earlier tag of %s than %s found locally", repo, tag)
+	}
+	target := repo + ":" + prev
+
+	override := filepath.Join(rm.Dir, "rollback", project+"-"+service+".yml")
+	content := fmt.Sprintf(`# Written by homebutler watch: rolls %s back from %s to %s.
+# Delete this file and run "docker compose up -d" to return to the compose file's image.
+services:
+  %s:
+    image: %s
+`, service, image, target, service, target)
+
+	args := []string{"compose", "-p", project}
+	if workDir != "" {
+		args = append(args, "--project-directory", workDir)
+	}
+	for _, f := range strings.Split(configFiles, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			args = append(args, "-f", f)
+		}
+	}
+	args = append(args, "-f", override, "up", "-d", "--no-deps", service)
+
+	return fmt.Sprintf("roll %s back from %s to %s", name, image, target), []remediationStep{
+		{file: override, content: []byte(content)},
+		{name: "docker", args: args},
+	}, nil
+}
+
+// splitImageRef splits an image reference into repository and tag. A
+// reference without a tag is "latest"; a digest is dropped.
+func splitImageRef(ref string) (repo, tag string) {
+	ref, _, _ = strings.Cut(ref, "@")
+	slash := strings.LastIndex(ref, "/")
+	if colon := strings.LastIndex(ref, ":"); colon > slash {
+		return ref[:colon], ref[colon+1:]
+	}
+	return ref, "latest"
+}
+
+// dockerCreatedLayout is how `docker image ls` prints CreatedAt.
+const dockerCreatedLayout = "2006-01-02 15:04:05 -0700 MST"
+
+// previousTag picks, from `docker image ls` lines of "tag|id|created", the
+// newest tagged image that differs from the running one and was created
+// before it. When the running image is not in the list, any other tag
+// qualifies.
+func previousTag(list, currentTag, currentID string) (string, bool) {
+	type image struct {
+		tag     string
+		id      string
+		created time.Time
+	}
+	currentID = strings.TrimPrefix(currentID, "sha256:")
+	var images []image
+	var current time.Time
+	for _, line := range strings.Split(strings.TrimSpace(list), "\n") {
+		parts := strings.SplitN(line, "|", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		img := image{tag: parts[0], id: strings.TrimPrefix(parts[1], "sha256:")}
+		img.created, _ = time.Parse(dockerCreatedLayout, parts[2])
+		if img.id != "" && strings.HasPrefix(currentID, img.id) {
+			current = img.created
+			continue
+		}
+		images = append(images, img)
+	}
+
+	best := image{}
+	for _, img := range images {
+		if img.tag == "" || img.tag == "<none>" || img.tag == currentTag {
+			continue
+		}
+		if !current.IsZero() && !img.created.Before(current) {
+			continue
+		}
+		if best.tag == "" || img.created.After(best.created) {
+			best = img
+		}
+	}
+	return best.tag, best.tag != ""
+}
+
+// planScaleMemory raises the memory limit of a Docker container or a
+// systemd unit to spec.
+func (rm *Remediator) planScaleMemory(t Target, spec string) (string, []remediationStep, error) {
+	factor, size, err := parseMemorySpec(spec)
+	if err != nil {
+		return "", nil, err
+	}
+	unit := t.EffectiveUnit()
+
+	switch kind := t.EffectiveKind(); kind {
+	case "docker":
+		out, err := rm.run("docker", "inspect", "--format", "{{.HostConfig.Memory}} {{.HostConfig.MemorySwap}}", unit)
+		if err != nil {
+			return "", nil, fmt.Errorf("inspect %s: %v", unit, firstLine(out, err))
+		}
+		var current, swap int64
+		if _, err := fmt.Sscan(out, &current, &swap); err != nil {
+			return "", nil, fmt.Errorf("inspect %s: unexpected output %q", unit, out)
+		}
+		limit, err := scaledLimit(current, factor, size)
+		if err != nil {
+			return "", nil, err
+		}
+		// Docker refuses a memory limit above the memory+swap limit, so
+		// that one moves too, keeping its ratio; no swap limit stays none.
+		newSwap := int64(-1)
+		if swap > 0 && current > 0 {
+			newSwap = int64(float64(swap) * float64(limit) / float64(current))
+		}
+		return memoryDetail(unit, current, limit), []remediationStep{{name: "docker", args: []string{
+			"update", "--memory", strconv.FormatInt(limit, 10), "--memory-swap", strconv.FormatInt(newSwap, 10), unit,
+		}}}, nil
+
+	case "systemd":
+		out, err := rm.run("systemctl", "show", unit, "--property=MemoryMax", "--value")
+		if err != nil {
+			return "", nil, fmt.Errorf("read MemoryMax of %s: %v", unit, firstLine(out, err))
+		}
+		var current int64
+		if v := strings.TrimSpace(out); v != "infinity" {
+			if current, err = strconv.ParseInt(v, 10, 64); err != nil {
+				return "", nil, fmt.Errorf("read MemoryMax of %s: unexpected value %q", unit, v)
+			}
+		}
+		limit, err := scaledLimit(current, factor, size)
+		if err != nil {
+			return "", nil, err
+		}
+		return memoryDetail(unit, current, limit), []remediationStep{{name: "systemctl", args: []string{
+			"set-property", unit, "MemoryMax=" + strconv.FormatInt(limit, 10),
+		}}}, nil
+
+	default:
+		return "", nil, fmt.Errorf("scale_memory is not supported for %s targets", kind)
+	}
+}
+
+// scaledLimit returns the new limit: current times factor, or size. It
+// only ever raises a limit.
+func scaledLimit(current int64, factor float64, size int64) (int64, error) {
+	if factor > 0 {
+		if current <= 0 {
+			return 0, fmt.Errorf("no memory limit is set, so there is nothing to scale by x%g", factor)
+		}
+		return int64(float64(current) * factor), nil
+	}
+	if current > 0 && size <= current {
+		return 0, fmt.Errorf("memory limit is already %s, at or above %s", formatMemory(current), formatMemory(size))
+	}
+	return size, nil
+}
+
+func memoryDetail(unit string, from, to int64) string {
+	if from <= 0 {
+		return fmt.Sprintf("set the memory limit of %s to %s", unit, formatMemory(to))
+	}
+	return fmt.Sprintf("raise the memory limit of %s from %s to %s", unit, formatMemory(from), formatMemory(to))
+}
+
+// parseMemorySpec parses a scale_memory value: a factor such as "x1.5" or
+// "1.5x", or a size such as "512m", "2g" or "2GiB". Sizes use binary
+// units, as docker run --memory does.
+func parseMemorySpec(spec string) (factor float64, size int64, err error) {
+	s := strings.ToLower(strings.TrimSpace(spec))
+	if f, ok := strings.CutPrefix(s, "x"); ok || strings.HasSuffix(s, "x") {
+		if !ok {
+			f = strings.TrimSuffix(s, "x")
+		}
+		factor, err = strconv.ParseFloat(f, 64)
+		if err != nil || factor <= 1 {
+			return 0, 0, fmt.Errorf("invalid memory factor %q: want a number above 1, e.g. \"x1.5\"", spec)
+		}
+		return factor, 0, nil
+	}
+
+	units := []struct {
+		suffix string
+		mult   float64
+	}{
+		{"gib", 1 << 30}, {"mib", 1 << 20}, {"kib", 1 << 10},
+		{"gb", 1 << 30}, {"mb", 1 << 20}, {"kb", 1 << 10},
+		{"g", 1 << 30}, {"m", 1 << 20}, {"k", 1 << 10}, {"b", 1},
+	}
+	mult := 1.0
+	for _, u := range units {
+		if strings.HasSuffix(s, u.suffix) {
+			s, mult = strings.TrimSuffix(s, u.suffix), u.mult
+			break
+		}
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v <= 0 {
+		return 0, 0, fmt.Errorf("invalid memory %q: want a size such as \"2g\" or a factor such as \"x1.5\"", spec)
+	}
+	size = int64(v * mult)
+	if size < 6<<20 {
+		return 0, 0, fmt.Errorf("memory %q is below Docker's 6MiB minimum", spec)
+	}
+	return 0, size, nil
+}
+
+// planPlaybook runs the commands of a named playbook through sh, with the
+// target, incident and flapping level in the environment. Commands on the
+// dangerous-command blocklist are refused before anything runs.
+func (rm *Remediator) planPlaybook(name string, t Target, incident string, flap FlappingResult) (string, []remediationStep, error) {
+	commands := rm.Config.Playbooks[name]
+	if len(commands) == 0 {
+		return "", nil, fmt.Errorf("playbook %q has no commands", name)
+	}
+	env := []string{
+		"HOMEBUTLER_TARGET=" + t.Container,
+		"HOMEBUTLER_UNIT=" + t.EffectiveUnit(),
+		"HOMEBUTLER_INCIDENT=" + incident,
+		"HOMEBUTLER_FLAPPING_LEVEL=" + flap.Level,
+	}
+	var steps []remediationStep
+	for _, c := range commands {
+		if util.IsDangerousCommand(c) {
+			return "", nil, fmt.Errorf("playbook %q: refused %q: it matches the dangerous command blocklist", name, c)
+		}
+		steps = append(steps, remediationStep{
+			name:    "env",
+			args:    append(slices.Clone(env), "sh", "-c", c),
+			display: c,
+		})
+	}
+	return fmt.Sprintf("run playbook %s (%d commands)", name, len(commands)), steps, nil
+}
+
+// shellJoin joins a command line for display, quoting arguments that
+// contain spaces or quotes.
+func shellJoin(args []string) string {
+	out := make([]string, len(args))
+	for i, a := range args {
+		if a == "" || strings.ContainsAny(a, " \t\"'") {
+			a = strconv.Quote(a)
+		}
+		out[i] = a
+	}
+	return strings.Join(out, " ")
+}
+
+// lastLine returns the last non-empty line of command output, for the
+// audit record.
+func lastLine(out string) string {
+	out = strings.TrimSpace(out)
+	if i := strings.LastIndex(out, "\n"); i >= 0 {
+		out = out[i+1:]
+	}
+	out = strings.TrimSpace(out)
+	if r := []rune(out); len(r) > 200 {
+		out = string(r[:200]) + "…"
+	}
+	return out
+}
+
+// firstLine prefers the first line of a failed command's output over its
+// exit status, which says less.
+func firstLine(out string, err error) string {
+	if line, _, _ := strings.Cut(strings.TrimSpace(out), "\n"); line != "" {
+		return line
+	}
+	return err.Error()
+}
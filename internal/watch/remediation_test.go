@@ -0,0 +1,462 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Higangssh/homebutler/internal/notify"
+)
+
+// fakeRemediationRunner answers commands by their first words and records
+// every command line it was asked to run.
+type fakeRemediationRunner struct {
+	mu      sync.Mutex
+	answers map[string]string
+	fail    map[string]bool
+	calls   []string
+}
+
+func (f *fakeRemediationRunner) run(name string, args ...string) (string, error) {
+	line := strings.Join(append([]string{name}, args...), " ")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, line)
+	for prefix, out := range f.answers {
+		if strings.HasPrefix(line, prefix) {
+			if f.fail[prefix] {
+				return out, errors.New("exit status 1")
+			}
+			return out, nil
+		}
+	}
+	return "", nil
+}
+
+func (f *fakeRemediationRunner) called() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+var acuteFlap = FlappingResult{IsFlapping: true, Level: "acute", Count: 4, Window: "short"}
+
+func TestRemediationPolicyValidate(t *testing.T) {
+	playbooks := map[string][]string{"clear-cache": {"docker exec app redis-cli FLUSHALL"}}
+	tests := []struct {
+		policy RemediationPolicy
+		want   string
+	}{
+		{RemediationPolicy{Target: "db", Action: "stop"}, ""},
+		{RemediationPolicy{Target: "db", Action: "stop", Level: "acute", Restarts: 8}, ""},
+		{RemediationPolicy{Target: "web", Action: "scale_memory", Memory: "x1.5"}, ""},
+		{RemediationPolicy{Target: "web", Action: "playbook", Playbook: "clear-cache"}, ""},
+		{RemediationPolicy{Action: "stop"}, "target is required"},
+		{RemediationPolicy{Target: "db"}, "action is required"},
+		{RemediationPolicy{Target: "db", Action: "reboot"}, `unknown action "reboot"`},
+		{RemediationPolicy{Target: "db", Action: "stop", Level: "severe"}, `unknown level "severe"`},
+		{RemediationPolicy{Target: "db", Action: "stop", Restarts: -1}, "restarts cannot be negative"},
+		{RemediationPolicy{Target: "web", Action: "scale_memory"}, "scale_memory needs memory"},
+		{RemediationPolicy{Target: "web", Action: "scale_memory", Memory: "x0.5"}, "invalid memory factor"},
+		{RemediationPolicy{Target: "web", Action: "playbook", Playbook: "nope"}, `playbook "nope" is not defined`},
+	}
+	for _, tt := range tests {
+		err := tt.policy.Validate(playbooks)
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("%+v: unexpected error %v", tt.policy, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%+v: error = %v, want %q", tt.policy, err, tt.want)
+		}
+	}
+}
+
+func TestPoliciesFor(t *testing.T) {
+	cfg := RemediationConfig{Policies: []RemediationPolicy{
+		{Target: "db", Action: "playbook", Playbook: "dump"},
+		{Target: "db", Action: "stop", Restarts: 6},
+		{Target: "db", Action: "scale_memory", Memory: "2g", Level: "acute"},
+		{Target: "db", Action: "rollback", Level: "bogus"},
+		{Target: "web", Action: "stop"},
+	}, Playbooks: map[string][]string{"dump": {"pg_dumpall > /srv/dump.sql"}}}
+
+	actions := func(ps []RemediationPolicy) string {
+		var out []string
+		for _, p := range ps {
+			out = append(out, p.Action)
+		}
+		return strings.Join(out, ",")
+	}
+
+	if got := actions(cfg.PoliciesFor("db", acuteFlap)); got != "playbook,scale_memory" {
+		t.Errorf("acute, 4 restarts: %s", got)
+	}
+	chronic := FlappingResult{IsFlapping: true, Level: "chronic", Count: 7}
+	if got := actions(cfg.PoliciesFor("db", chronic)); got != "playbook,stop" {
+		t.Errorf("chronic, 7 restarts: %s", got)
+	}
+	if got := cfg.PoliciesFor("db", FlappingResult{Level: "none"}); got != nil {
+		t.Errorf("not flapping: %+v", got)
+	}
+}
+
+func TestParseMemorySpec(t *testing.T) {
+	tests := []struct {
+		spec   string
+		factor float64
+		size   int64
+	}{
+		{"x1.5", 1.5, 0},
+		{"2x", 2, 0},
+		{"512m", 0, 512 << 20},
+		{"2g", 0, 2 << 30},
+		{"2GiB", 0, 2 << 30},
+		{"1.5GB", 0, 3 << 29},
+	}
+	for _, tt := range tests {
+		factor, size, err := parseMemorySpec(tt.spec)
+		if err != nil || factor != tt.factor || size != tt.size {
+			t.Errorf("parseMemorySpec(%q) = %g, %d, %v", tt.spec, factor, size, err)
+		}
+	}
+	for _, bad := range []string{"", "x1", "lots", "1k", "-2g"} {
+		if _, _, err := parseMemorySpec(bad); err == nil {
+			t.Errorf("parseMemorySpec(%q): expected an error", bad)
+		}
+	}
+}
+
+func TestSplitImageRef(t *testing.T) {
+	tests := []struct{ ref, repo, tag string }{
+		{"postgres:16.2", "postgres", "16.2"},
+		{"nginx", "nginx", "latest"},
+		{"registry.lan:5000/team/app:1.4", "registry.lan:5000/team/app", "1.4"},
+		{"registry.lan:5000/team/app", "registry.lan:5000/team/app", "latest"},
+		{"app:1.4@sha256:abc", "app", "1.4"},
+	}
+	for _, tt := range tests {
+		if repo, tag := splitImageRef(tt.ref); repo != tt.repo || tag != tt.tag {
+			t.Errorf("splitImageRef(%q) = %q, %q", tt.ref, repo, tag)
+		}
+	}
+}
+
+func TestPreviousTag(t *testing.T) {
+	list := strings.Join([]string{
+		"1.5|eeeeeeeeeeee|2024-06-05 10:00:00 +0000 UTC",
+		"1.4|dddddddddddd|2024-06-04 10:00:00 +0000 UTC",
+		"<none>|cccccccccccc|2024-06-03 10:00:00 +0000 UTC",
+		"1.3|bbbbbbbbbbbb|2024-06-02 10:00:00 +0000 UTC",
+		"1.2|aaaaaaaaaaaa|2024-06-01 10:00:00 +0000 UTC",
+	}, "\n")
+
+	if got, ok := previousTag(list, "1.4", "sha256:dddddddddddd0123"); !ok || got != "1.3" {
+		t.Errorf("previous of 1.4 = %q, %v; want 1.3 (newer 1.5 and untagged images skipped)", got, ok)
+	}
+	if got, ok := previousTag(list, "1.2", "sha256:aaaaaaaaaaaa0123"); ok {
+		t.Errorf("previous of the oldest = %q, want none", got)
+	}
+	// A retagged :latest is not in the list under its ID any more.
+	if got, ok := previousTag(list, "latest", "sha256:ffffffffffff"); !ok || got != "1.5" {
+		t.Errorf("previous of unknown image = %q, %v; want the newest tag", got, ok)
+	}
+}
+
+func newTestRemediator(t *testing.T, cfg RemediationConfig, run *fakeRemediationRunner) *Remediator {
+	t.Helper()
+	return &Remediator{Config: cfg, Dir: t.TempDir(), Run: run.run}
+}
+
+func TestRemediateStopDocker(t *testing.T) {
+	run := &fakeRemediationRunner{}
+	rm := newTestRemediator(t, RemediationConfig{Policies: []RemediationPolicy{{Target: "db", Action: "stop"}}}, run)
+
+	acts := rm.Remediate(Incident{ID: "inc-1", Container: "db"}, Target{Container: "db"}, acuteFlap, time.Now())
+	if len(acts) != 1 || acts[0].Outcome != RemediationDone {
+		t.Fatalf("actions = %+v", acts)
+	}
+	want := []string{"docker update --restart=no db", "docker stop db"}
+	if got := run.called(); strings.Join(got, ";") != strings.Join(want, ";") {
+		t.Errorf("commands = %q, want %q", got, want)
+	}
+	if got := strings.Join(acts[0].Commands, ";"); got != strings.Join(want, ";") {
+		t.Errorf("audited commands = %q", got)
+	}
+	if acts[0].Incident != "inc-1" || acts[0].Level != "acute" {
+		t.Errorf("audit = %+v", acts[0])
+	}
+
+	log, err := LoadRemediationLog(rm.Dir)
+	if err != nil || len(log) != 1 {
+		t.Fatalf("log = %+v, %v", log, err)
+	}
+}
+
+func TestRemediateStopSystemd(t *testing.T) {
+	run := &fakeRemediationRunner{}
+	rm := newTestRemediator(t, RemediationConfig{Policies: []RemediationPolicy{{Target: "db", Action: "stop"}}}, run)
+
+	rm.Remediate(Incident{ID: "inc-1"}, Target{Container: "db", Kind: "systemd", Unit: "postgresql.service"}, acuteFlap, time.Now())
+	if got := run.called(); len(got) != 1 || got[0] != "systemctl stop postgresql.service" {
+		t.Errorf("commands = %q", got)
+	}
+}
+
+func TestRemediateDryRun(t *testing.T) {
+	run := &fakeRemediationRunner{}
+	rm := newTestRemediator(t, RemediationConfig{DryRun: true, Policies: []RemediationPolicy{{Target: "db", Action: "stop"}}}, run)
+
+	acts := rm.Remediate(Incident{ID: "inc-1"}, Target{Container: "db"}, acuteFlap, time.Now())
+	if len(acts) != 1 || acts[0].Outcome != RemediationDryRun || len(acts[0].Commands) != 2 {
+		t.Fatalf("actions = %+v", acts)
+	}
+	if got := run.called(); len(got) != 0 {
+		t.Errorf("dry run ran %q", got)
+	}
+	if !strings.Contains(acts[0].Summary(), "stop dry-run: stop db") {
+		t.Errorf("summary = %q", acts[0].Summary())
+	}
+}
+
+func TestRemediateCooldownAndBudget(t *testing.T) {
+	run := &fakeRemediationRunner{}
+	cfg := RemediationConfig{
+		MaxActionsPerDay: 2,
+		Cooldown:         30 * time.Minute,
+		Policies: []RemediationPolicy{
+			{Target: "a", Action: "stop"},
+			{Target: "b", Action: "stop"},
+			{Target: "c", Action: "stop"},
+		},
+	}
+	rm := newTestRemediator(t, cfg, run)
+	now := time.Now()
+
+	outcome := func(target string, at time.Time) RemediationAction {
+		t.Helper()
+		acts := rm.Remediate(Incident{ID: target + "-inc"}, Target{Container: target}, acuteFlap, at)
+		if len(acts) != 1 {
+			t.Fatalf("%s: actions = %+v", target, acts)
+		}
+		return acts[0]
+	}
+
+	if a := outcome("a", now); a.Outcome != RemediationDone {
+		t.Fatalf("first action = %+v", a)
+	}
+	if a := outcome("a", now.Add(10*time.Minute)); a.Outcome != RemediationSkipped || !strings.Contains(a.Detail, "cooldown") {
+		t.Errorf("within cooldown = %+v", a)
+	}
+	if a := outcome("b", now.Add(11*time.Minute)); a.Outcome != RemediationDone {
+		t.Errorf("second target = %+v", a)
+	}
+	if a := outcome("c", now.Add(12*time.Minute)); a.Outcome != RemediationSkipped || !strings.Contains(a.Detail, "daily budget of 2") {
+		t.Errorf("over budget = %+v", a)
+	}
+	if a := outcome("c", now.Add(25*time.Hour)); a.Outcome != RemediationDone {
+		t.Errorf("next day = %+v", a)
+	}
+	if n := len(run.called()); n != 6 {
+		t.Errorf("ran %d commands, want 6 (two per stop)", n)
+	}
+}
+
+func TestRemediateRollbackCompose(t *testing.T) {
+	run := &fakeRemediationRunner{answers: map[string]string{
+		"docker inspect": "ghcr.io/acme/app:1.4|sha256:dddddddddddd99|app|web|/srv/app|/srv/app/compose.yml",
+		"docker image ls ghcr.io/acme/app": "1.4|dddddddddddd|2024-06-04 10:00:00 +0000 UTC\n" +
+			"1.3|bbbbbbbbbbbb|2024-06-02 10:00:00 +0000 UTC",
+	}}
+	rm := newTestRemediator(t, RemediationConfig{Policies: []RemediationPolicy{{Target: "app-web-1", Action: "rollback"}}}, run)
+
+	acts := rm.Remediate(Incident{ID: "inc-1"}, Target{Container: "app-web-1"}, acuteFlap, time.Now())
+	if len(acts) != 1 || acts[0].Outcome != RemediationDone {
+		t.Fatalf("actions = %+v", acts)
+	}
+	if want := "roll app-web-1 back from ghcr.io/acme/app:1.4 to ghcr.io/acme/app:1.3"; acts[0].Detail != want {
+		t.Errorf("detail = %q", acts[0].Detail)
+	}
+
+	override := rm.Dir + "/rollback/app-web.yml"
+	data, err := os.ReadFile(override)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "  web:\n    image: ghcr.io/acme/app:1.3\n") {
+		t.Errorf("override =\n%s", data)
+	}
+	calls := run.called()
+	want := "docker compose -p app --project-directory /srv/app -f /srv/app/compose.yml -f " + override + " up -d --no-deps web"
+	if calls[len(calls)-1] != want {
+		t.Errorf("compose command = %q\nwant %q", calls[len(calls)-1], want)
+	}
+}
+
+func TestRemediateRollbackNeedsCompose(t *testing.T) {
+	run := &fakeRemediationRunner{answers: map[string]string{"docker inspect": "app:1.4|sha256:dd||||"}}
+	rm := newTestRemediator(t, RemediationConfig{Policies: []RemediationPolicy{{Target: "app", Action: "rollback"}}}, run)
+
+	acts := rm.Remediate(Incident{ID: "inc-1"}, Target{Container: "app"}, acuteFlap, time.Now())
+	if len(acts) != 1 || acts[0].Outcome != RemediationFailed || !strings.Contains(acts[0].Detail, "Compose-managed") {
+		t.Fatalf("actions = %+v", acts)
+	}
+	// Nothing ran, so nothing was spent.
+	if log, _ := LoadRemediationLog(rm.Dir); len(log) != 0 {
+		t.Errorf("log = %+v", log)
+	}
+}
+
+func TestRemediateScaleMemory(t *testing.T) {
+	run := &fakeRemediationRunner{answers: map[string]string{"docker inspect": "536870912 1073741824"}}
+	rm := newTestRemediator(t, RemediationConfig{Policies: []RemediationPolicy{{Target: "worker", Action: "scale_memory", Memory: "x1.5"}}}, run)
+
+	acts := rm.Remediate(Incident{ID: "inc-1"}, Target{Container: "worker"}, acuteFlap, time.Now())
+	if len(acts) != 1 || acts[0].Outcome != RemediationDone {
+		t.Fatalf("actions = %+v", acts)
+	}
+	calls := run.called()
+	if want := "docker update --memory 805306368 --memory-swap 1610612736 worker"; calls[len(calls)-1] != want {
+		t.Errorf("update = %q", calls[len(calls)-1])
+	}
+	if want := "raise the memory limit of worker from 512 MiB to 768 MiB"; acts[0].Detail != want {
+		t.Errorf("detail = %q", acts[0].Detail)
+	}
+}
+
+func TestRemediateScaleMemorySystemd(t *testing.T) {
+	run := &fakeRemediationRunner{answers: map[string]string{"systemctl show": "infinity"}}
+	rm := newTestRemediator(t, RemediationConfig{Policies: []RemediationPolicy{{Target: "api", Action: "scale_memory", Memory: "1g"}}}, run)
+
+	rm.Remediate(Incident{ID: "inc-1"}, Target{Container: "api", Kind: "systemd", Unit: "api.service"}, acuteFlap, time.Now())
+	calls := run.called()
+	if want := "systemctl set-property api.service MemoryMax=1073741824"; calls[len(calls)-1] != want {
+		t.Errorf("set-property = %q", calls[len(calls)-1])
+	}
+
+	// A factor needs a limit to multiply.
+	rm.Config.Policies[0].Memory = "x2"
+	rm.Config.Cooldown = time.Nanosecond
+	acts := rm.Remediate(Incident{ID: "inc-2"}, Target{Container: "api", Kind: "systemd", Unit: "api.service"}, acuteFlap, time.Now().Add(time.Second))
+	if len(acts) != 1 || acts[0].Outcome != RemediationFailed || !strings.Contains(acts[0].Detail, "no memory limit") {
+		t.Errorf("actions = %+v", acts)
+	}
+}
+
+func TestRemediatePlaybook(t *testing.T) {
+	run := &fakeRemediationRunner{answers: map[string]string{"env": "cache cleared"}}
+	rm := newTestRemediator(t, RemediationConfig{
+		Policies:  []RemediationPolicy{{Target: "app", Action: "playbook", Playbook: "clear-cache"}},
+		Playbooks: map[string][]string{"clear-cache": {"docker exec app redis-cli FLUSHALL"}},
+	}, run)
+
+	acts := rm.Remediate(Incident{ID: "inc-1"}, Target{Container: "app"}, acuteFlap, time.Now())
+	if len(acts) != 1 || acts[0].Outcome != RemediationDone || acts[0].Output != "cache cleared" {
+		t.Fatalf("actions = %+v", acts)
+	}
+	if got := acts[0].Commands; len(got) != 1 || got[0] != "docker exec app redis-cli FLUSHALL" {
+		t.Errorf("audited commands = %q", got)
+	}
+	call := run.called()[0]
+	for _, want := range []string{"HOMEBUTLER_TARGET=app", "HOMEBUTLER_INCIDENT=inc-1", "HOMEBUTLER_FLAPPING_LEVEL=acute", "sh -c docker exec app redis-cli"} {
+		if !strings.Contains(call, want) {
+			t.Errorf("command %q lacks %q", call, want)
+		}
+	}
+}
+
+func TestRemediatePlaybookRefusesDangerousCommand(t *testing.T) {
+	run := &fakeRemediationRunner{}
+	rm := newTestRemediator(t, RemediationConfig{
+		Policies:  []RemediationPolicy{{Target: "app", Action: "playbook", Playbook: "nuke"}},
+		Playbooks: map[string][]string{"nuke": {"echo ok", "rm -rf / --no-preserve-root"}},
+	}, run)
+
+	acts := rm.Remediate(Incident{ID: "inc-1"}, Target{Container: "app"}, acuteFlap, time.Now())
+	if len(acts) != 1 || acts[0].Outcome != RemediationFailed || !strings.Contains(acts[0].Detail, "blocklist") {
+		t.Fatalf("actions = %+v", acts)
+	}
+	if got := run.called(); len(got) != 0 {
+		t.Errorf("ran %q before refusing", got)
+	}
+}
+
+func TestRemediateCommandFailure(t *testing.T) {
+	run := &fakeRemediationRunner{
+		answers: map[string]string{"docker update": "Error: No such container: db"},
+		fail:    map[string]bool{"docker update": true},
+	}
+	rm := newTestRemediator(t, RemediationConfig{Policies: []RemediationPolicy{{Target: "db", Action: "stop"}}}, run)
+
+	acts := rm.Remediate(Incident{ID: "inc-1"}, Target{Container: "db"}, acuteFlap, time.Now())
+	if len(acts) != 1 || acts[0].Outcome != RemediationFailed || acts[0].Output != "Error: No such container: db" {
+		t.Fatalf("actions = %+v", acts)
+	}
+	if got := run.called(); len(got) != 1 {
+		t.Errorf("kept going after a failure: %q", got)
+	}
+}
+
+func TestRunnerRemediatesFlappingTarget(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	for i := range 2 {
+		at := now.Add(-time.Duration(i+1) * time.Minute)
+		inc := Incident{ID: GenerateIncidentID("web", at), Container: "web", DetectedAt: at}
+		if err := SaveIncident(dir, &inc, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	verified := stubInspectHealth(t, healthObservation{healthy: true})
+
+	var mu sync.Mutex
+	var events []notify.Event
+	d := notify.NewDispatcher(&notify.ProviderConfig{Telegram: &notify.TelegramConfig{BotToken: "t", ChatID: "c"}}, time.Minute)
+	d.SetSendFunc(func(cfg *notify.ProviderConfig, ev notify.Event) []error {
+		mu.Lock()
+		events = append(events, ev)
+		mu.Unlock()
+		return nil
+	})
+
+	cfg := DefaultWatchConfig()
+	cfg.Flapping.ShortThreshold = 2
+	run := &fakeRemediationRunner{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := &Runner{
+		Dir:        dir,
+		Config:     &cfg,
+		Notifier:   &WatchNotifier{Settings: NotifySettings{Enabled: true, OnFlapping: true}, Dispatcher: d},
+		Verifier:   fastVerifier(),
+		Remediator: &Remediator{Config: RemediationConfig{Policies: []RemediationPolicy{{Target: "web", Action: "stop"}}}, Dir: dir, Run: run.run},
+		Monitor:    func(kind string) Monitor { return &fakeMonitor{} },
+	}
+	go r.Run(ctx, []Target{{Container: "web"}})
+
+	waitFor(t, "the notification", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(events) == 1
+	})
+	mu.Lock()
+	ev := events[0]
+	mu.Unlock()
+	if !strings.Contains(ev.Details, "remediation stop done: stop web") {
+		t.Errorf("details = %q", ev.Details)
+	}
+	if *verified != 0 {
+		t.Errorf("verified a target that remediation stopped")
+	}
+
+	incidents, _ := ListIncidents(dir)
+	if len(incidents) != 3 || len(incidents[0].Remediation) != 1 || incidents[0].Remediation[0].Outcome != RemediationDone {
+		t.Fatalf("newest incident = %+v", incidents[0])
+	}
+}
@@ -0,0 +1,265 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Higangssh/homebutler/internal/util"
+)
+
+// Kinds are the target kinds in the order monitors are started and listed.
+var Kinds = []string{"docker", "systemd", "pm2", "k8s", "process", "supervisord"}
+
+// GroupByKind splits targets by the monitor that watches them. Unknown
+// kinds go to the Docker monitor, as LoadTargets warns.
+func GroupByKind(targets []Target) map[string][]Target {
+	groups := make(map[string][]Target)
+	for _, t := range targets {
+		kind := t.EffectiveKind()
+		if !slices.Contains(Kinds, kind) {
+			kind = "docker"
+		}
+		groups[kind] = append(groups[kind], t)
+	}
+	return groups
+}
+
+// NewMonitor returns the monitor for a target kind, storing incidents in
+// dir and keeping at most keep of them.
+func NewMonitor(kind, dir string, interval time.Duration, keep int) Monitor {
+	run := func(name string, args ...string) (string, error) {
+		return util.RunCmd(name, args...)
+	}
+	switch kind {
+	case "systemd":
+		return &SystemdMonitor{Run: run, Dir: dir, Interval: interval, Keep: keep}
+	case "pm2":
+		return &PM2Monitor{Run: run, Dir: dir, Interval: interval}
+	case "k8s":
+		return &K8sMonitor{Dir: dir, Interval: interval, Keep: keep}
+	case "process":
+		return &ProcessMonitor{Dir: dir, Interval: interval, Keep: keep}
+	case "supervisord":
+		return &SupervisordMonitor{Dir: dir, Interval: interval, Keep: keep}
+	default:
+		return &DockerMonitor{Dir: dir, PostLogDelay: 5 * time.Second, Keep: keep}
+	}
+}
+
+// Runner runs one monitor per target kind and handles the incidents they
+// report: crash analysis, flapping detection, storage and notification.
+// `watch start` and `watch daemon` both run on it.
+type Runner struct {
+	// Dir is the storage directory for incidents.
+	Dir string
+
+	// Interval is the polling interval for the polling monitors.
+	Interval time.Duration
+
+	// Config holds the flapping and retention settings. Nil uses defaults.
+	Config *WatchConfig
+
+	// Notifier sends incident notifications; nil sends none.
+	Notifier *WatchNotifier
+
+	// Monitor returns the monitor for a target kind. Nil uses NewMonitor.
+	Monitor func(kind string) Monitor
+
+	// Verifier checks that restarted targets come back healthy. Nil uses
+	// one built from Config.Recovery.
+	Verifier *Verifier
+
+	// Remediator runs the remediation policies of flapping targets. Nil
+	// uses one built from Config.Remediation.
+	Remediator *Remediator
+
+	// OnIncident is called with each incident once it is stored.
+	OnIncident func(inc Incident, flap FlappingResult, summary CrashSummary)
+
+	// OnRecovery is called with each incident once its recovery verdict is
+	// stored.
+	OnRecovery func(inc Incident)
+
+	// OnRemediation is called with each incident once the actions its
+	// flapping triggered are stored.
+	OnRemediation func(inc Incident)
+
+	// OnError is called when a monitor stops with an error.
+	OnError func(kind string, err error)
+}
+
+// Run starts a monitor for each kind in targets and handles incidents
+// until ctx is cancelled or every monitor has stopped.
+func (r *Runner) Run(ctx context.Context, targets []Target) error {
+	r.prepare()
+
+	incCh := make(chan Incident, 64)
+	var wg sync.WaitGroup
+	groups := GroupByKind(targets)
+	for _, kind := range Kinds {
+		if len(groups[kind]) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.WatchKind(ctx, kind, groups[kind], incCh)
+		}()
+	}
+
+	// Close incCh when all monitors are done
+	go func() {
+		wg.Wait()
+		close(incCh)
+	}()
+
+	var verifying sync.WaitGroup
+	for inc := range incCh {
+		r.Handle(ctx, inc, targets, &verifying)
+	}
+	verifying.Wait()
+	return ctx.Err()
+}
+
+// prepare fills in the defaults of the fields left nil.
+func (r *Runner) prepare() {
+	if r.Config == nil {
+		defaults := DefaultWatchConfig()
+		r.Config = &defaults
+	}
+	if r.Monitor == nil {
+		r.Monitor = func(kind string) Monitor {
+			return NewMonitor(kind, r.Dir, r.Interval, r.Config.Retention.MaxIncidents)
+		}
+	}
+	if r.Verifier == nil {
+		r.Verifier = &Verifier{Config: r.Config.Recovery}
+	}
+	if r.Remediator == nil {
+		r.Remediator = &Remediator{Config: r.Config.Remediation, Dir: r.Dir}
+	}
+}
+
+// WatchKind runs the monitor of kind on targets, sending its incidents to
+// incidents, until ctx is cancelled or the monitor stops. An error it stops
+// with goes to OnError.
+func (r *Runner) WatchKind(ctx context.Context, kind string, targets []Target, incidents chan<- Incident) {
+	r.prepare()
+	err := r.Monitor(kind).Watch(ctx, targets, incidents)
+	if err == nil || ctx.Err() != nil {
+		return
+	}
+	if r.OnError != nil {
+		r.OnError(kind, err)
+	} else {
+		fmt.Fprintf(os.Stderr, "[%s-monitor] error: %v\n", kind, err)
+	}
+}
+
+// Handle analyzes, stores and notifies one incident. When the incident
+// triggers remediation policies or the target can be verified, that runs
+// first, so that the notification can say what was done and whether the
+// restart worked.
+//
+// Handle may be called from one goroutine at a time; verifying tracks the
+// remediation and verification it leaves running.
+func (r *Runner) Handle(ctx context.Context, inc Incident, targets []Target, verifying *sync.WaitGroup) {
+	r.prepare()
+	cfg, verifier, remediator := r.Config, r.Verifier, r.Remediator
+
+	// Monitors that know the exit code analyze the crash
+	// themselves; the rest only have logs to go on.
+	if inc.CrashAnalysis == nil {
+		summary := Analyze(CrashInfo{
+			ErrorLog: inc.PreLogs,
+			Backend:  backendKind(inc.Container, targets),
+		})
+		inc.CrashAnalysis = &summary
+	}
+	summary := *inc.CrashAnalysis
+
+	allIncs, _ := ListIncidents(r.Dir)
+	// A monitor without a Dir has not stored the incident, but it still
+	// counts towards flapping.
+	if !slices.ContainsFunc(allIncs, func(prev Incident) bool { return prev.ID == inc.ID }) {
+		allIncs = append(allIncs, inc)
+	}
+	flapResult := cfg.Flapping.Check(inc.Container, allIncs, time.Now())
+	if flapResult.IsFlapping {
+		inc.Flapping = &flapResult
+	}
+	InheritAck(&inc, allIncs)
+
+	_ = SaveIncident(r.Dir, &inc, cfg.Retention.MaxIncidents)
+	if r.OnIncident != nil {
+		r.OnIncident(inc, flapResult, summary)
+	}
+
+	target := findTarget(inc.Container, targets)
+	remediate := len(remediator.Config.PoliciesFor(inc.Container, flapResult)) > 0
+	if !remediate && !verifier.CanVerify(target) {
+		r.notify(inc, flapResult, &summary)
+		return
+	}
+	verifying.Add(1)
+	go func() {
+		defer verifying.Done()
+		if remediate && ctx.Err() == nil {
+			inc.Remediation = remediator.Remediate(inc, target, flapResult, time.Now())
+			if _, err := SetIncidentRemediation(r.Dir, inc.ID, inc.Remediation); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: save remediation of %s: %v\n", inc.ID, err)
+			}
+			if r.OnRemediation != nil {
+				r.OnRemediation(inc)
+			}
+		}
+		// A target that remediation stopped is not expected back.
+		if !stoppedBy(inc.Remediation) {
+			if rec := verifier.Verify(ctx, target, inc.DetectedAt); rec != nil {
+				inc.Recovery = rec
+				if _, err := SetIncidentRecovery(r.Dir, inc.ID, rec); err != nil {
+					fmt.Fprintf(os.Stderr, "warning: save recovery of %s: %v\n", inc.ID, err)
+				}
+				if r.OnRecovery != nil {
+					r.OnRecovery(inc)
+				}
+			}
+		}
+		r.notify(inc, flapResult, &summary)
+	}()
+}
+
+// stoppedBy reports whether actions stopped the target.
+func stoppedBy(actions []RemediationAction) bool {
+	for _, a := range actions {
+		if a.Action == RemediateStop && a.Outcome == RemediationDone {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Runner) notify(inc Incident, flap FlappingResult, summary *CrashSummary) {
+	if r.Notifier != nil {
+		_ = r.Notifier.NotifyIncident(inc, flap, summary, time.Now())
+	}
+}
+
+// findTarget returns the target an incident was recorded for. An unknown
+// name is treated as a Docker container, as GroupByKind does.
+func findTarget(container string, targets []Target) Target {
+	for _, t := range targets {
+		if t.Container == container {
+			return t
+		}
+	}
+	return Target{Container: container}
+}
+
+func backendKind(container string, targets []Target) string {
+	return findTarget(container, targets).EffectiveKind()
+}
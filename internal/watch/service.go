@@ -0,0 +1,80 @@
+package watch
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ServiceName is the systemd unit `watch install-service` writes.
+const ServiceName = "homebutler-watch.service"
+
+// ServiceOptions describe the systemd unit for `watch daemon`.
+type ServiceOptions struct {
+	// Exe is the absolute path of the homebutler binary.
+	Exe string
+
+	// Args follow "watch daemon" on the ExecStart line.
+	Args []string
+
+	// User runs the daemon in a system unit; empty runs it as root. It is
+	// ignored for a user unit.
+	User string
+
+	// Home is set as $HOME so the daemon finds ~/.homebutler/watch and the
+	// config of the user who installed it.
+	Home string
+
+	// UserUnit writes a systemd --user unit instead of a system one.
+	UserUnit bool
+}
+
+// SystemdUnit renders the unit file. systemctl reload sends SIGHUP, which
+// makes the daemon re-read targets.json and config.yaml.
+func SystemdUnit(o ServiceOptions) string {
+	argv := append([]string{o.Exe, "watch", "daemon"}, o.Args...)
+	for i, a := range argv {
+		argv[i] = systemdQuote(a)
+	}
+
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	b.WriteString("Description=homebutler watch daemon (container and service restart tracker)\n")
+	b.WriteString("Documentation=https://github.com/Higangssh/homebutler\n")
+	if !o.UserUnit {
+		b.WriteString("Wants=network-online.target\n")
+		b.WriteString("After=network-online.target docker.service podman.socket\n")
+	}
+	b.WriteString("\n[Service]\n")
+	b.WriteString("Type=simple\n")
+	if !o.UserUnit && o.User != "" && o.User != "root" {
+		fmt.Fprintf(&b, "User=%s\n", o.User)
+	}
+	if o.Home != "" {
+		fmt.Fprintf(&b, "Environment=%s\n", systemdQuote("HOME="+o.Home))
+	}
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(argv, " "))
+	b.WriteString("ExecReload=/bin/kill -HUP $MAINPID\n")
+	b.WriteString("Restart=on-failure\n")
+	b.WriteString("RestartSec=5s\n")
+	b.WriteString("\n[Install]\n")
+	if o.UserUnit {
+		b.WriteString("WantedBy=default.target\n")
+	} else {
+		b.WriteString("WantedBy=multi-user.target\n")
+	}
+	return b.String()
+}
+
+// systemdQuote quotes a word for an ExecStart= or Environment= line when it
+// needs it. systemd also expands % specifiers and $VARS there, so those are
+// escaped too.
+func systemdQuote(s string) string {
+	s = strings.ReplaceAll(s, "%", "%%")
+	s = strings.ReplaceAll(s, "$", "$$")
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\") {
+		return s
+	}
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,35 @@
+package watch
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSystemdUnit(t *testing.T) {
+	unit := SystemdUnit(ServiceOptions{
+		Exe:  "/usr/local/bin/homebutler",
+		Args: []string{"--config", "/srv/home lab/homebutler.yaml"},
+		User: "pi",
+		Home: "/home/pi",
+	})
+	for _, want := range []string{
+		`ExecStart=/usr/local/bin/homebutler watch daemon --config "/srv/home lab/homebutler.yaml"`,
+		"ExecReload=/bin/kill -HUP $MAINPID",
+		"User=pi\n",
+		"Environment=HOME=/home/pi\n",
+		"After=network-online.target",
+		"WantedBy=multi-user.target",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("unit is missing %q:\n%s", want, unit)
+		}
+	}
+
+	unit = SystemdUnit(ServiceOptions{Exe: "/opt/hb%1/homebutler", User: "pi", UserUnit: true})
+	if strings.Contains(unit, "User=") || strings.Contains(unit, "network-online") || !strings.Contains(unit, "WantedBy=default.target") {
+		t.Errorf("user unit:\n%s", unit)
+	}
+	if !strings.Contains(unit, "ExecStart=/opt/hb%%1/homebutler watch daemon\n") {
+		t.Errorf("%% specifiers must be escaped:\n%s", unit)
+	}
+}
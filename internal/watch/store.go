@@ -14,8 +14,12 @@ import (
 
 type Target struct {
 	Container string    `json:"container"`
-	Kind      string    `json:"kind,omitempty"` // "docker" | "systemd" | "pm2"
-	Unit      string    `json:"unit,omitempty"` // actual container/service/app name (defaults to Container)
+	Kind      string    `json:"kind,omitempty"`     // "docker" | "systemd" | "pm2" | "k8s" | "process" | "supervisord"
+	Unit      string    `json:"unit,omitempty"`     // actual container/service/app name (defaults to Container); "namespace/name" for k8s
+	PIDFile   string    `json:"pid_file,omitempty"` // process: PID file to follow
+	Match     string    `json:"match,omitempty"`    // process: regexp matched against /proc/<pid>/cmdline
+	LogFile   string    `json:"log_file,omitempty"` // process, supervisord: log tailed into PreLogs
+	Probe     string    `json:"probe,omitempty"`    // http(s):// URL or tcp://host:port checked after a restart
 	AddedAt   time.Time `json:"added_at"`
 }
 
@@ -62,6 +66,21 @@ type Incident struct {
 	PostLogs      string          `json:"post_logs"`
 	Flapping      *FlappingResult `json:"flapping,omitempty"`
 	CrashAnalysis *CrashSummary   `json:"crash_analysis,omitempty"`
+	Recovery      *Recovery       `json:"recovery,omitempty"`
+
+	// Remediation is what the remediation policies did about this
+	// incident, including the policies they skipped.
+	Remediation []RemediationAction `json:"remediation,omitempty"`
+
+	// Lifecycle, set by `watch ack`, `watch note` and `watch resolve`.
+	Status     string         `json:"status,omitempty"` // "open" | "acked" | "resolved"; empty is open
+	AckedBy    string         `json:"acked_by,omitempty"`
+	AckedAt    *time.Time     `json:"acked_at,omitempty"`
+	ResolvedBy string         `json:"resolved_by,omitempty"`
+	ResolvedAt *time.Time     `json:"resolved_at,omitempty"`
+	Resolution string         `json:"resolution,omitempty"` // the cause that was found
+	RootCause  string         `json:"root_cause,omitempty"` // ID of the incident that explains this one
+	Notes      []IncidentNote `json:"notes,omitempty"`
 }
 
 func WatchDir() (string, error) {
@@ -101,7 +120,7 @@ func LoadTargets(dir string) ([]Target, error) {
 		return nil, fmt.Errorf("corrupt targets.json: %w", err)
 	}
 	// Validate kind values
-	validKinds := map[string]bool{"": true, "docker": true, "systemd": true, "pm2": true}
+	validKinds := map[string]bool{"": true, "docker": true, "systemd": true, "pm2": true, "k8s": true, "process": true, "supervisord": true}
 	for i, t := range targets {
 		if !validKinds[t.Kind] {
 			fmt.Fprintf(os.Stderr, "warning: target %q has unknown kind %q, defaulting to docker\n", t.Container, t.Kind)
@@ -0,0 +1,361 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultSupervisorSockets are where supervisord's unix_http_server socket
+// usually lives, in the order Debian/Ubuntu and the sample config put it.
+var defaultSupervisorSockets = []string{"/var/run/supervisor.sock", "/run/supervisor.sock", "/tmp/supervisor.sock"}
+
+// SupervisordMonitor watches programs managed by supervisord by polling its
+// XML-RPC interface.
+type SupervisordMonitor struct {
+	// ServerURL is supervisord's XML-RPC endpoint: unix:///path/to.sock or
+	// http://[user:pass@]host:9001. Empty uses $SUPERVISOR_SERVER_URL, then
+	// the first default socket that exists.
+	ServerURL string
+
+	// Dir is the storage directory for incidents.
+	Dir string
+
+	// Interval is the polling interval.
+	Interval time.Duration
+
+	// Keep caps how many incidents are retained; zero or less keeps everything.
+	Keep int
+}
+
+// supervisorProcess is one entry of supervisor.getAllProcessInfo.
+type supervisorProcess struct {
+	Name       string
+	Group      string
+	Start      int64 // unix time the process last started
+	State      string
+	PID        int
+	ExitStatus int
+	SpawnErr   string
+}
+
+// fullName is the name supervisorctl uses: "group:name", or just "name"
+// for a program that is its own group.
+func (p supervisorProcess) fullName() string {
+	if p.Group == "" || p.Group == p.Name {
+		return p.Name
+	}
+	return p.Group + ":" + p.Name
+}
+
+func (p supervisorProcess) started() string {
+	if p.Start == 0 {
+		return "(never started)"
+	}
+	return time.Unix(p.Start, 0).Format(time.RFC3339)
+}
+
+// supervisorStopped are the states a program is in after an unexpected exit.
+var supervisorStopped = map[string]bool{"EXITED": true, "BACKOFF": true, "FATAL": true}
+
+// Watch polls supervisord and sends an incident when a watched program is
+// restarted or exits.
+func (sm *SupervisordMonitor) Watch(ctx context.Context, targets []Target, incidents chan<- Incident) error {
+	if len(targets) == 0 {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	rpc, err := newSupervisorClient(sm.ServerURL)
+	if err != nil {
+		return err
+	}
+
+	interval := sm.Interval
+	if interval == 0 {
+		interval = 30 * time.Second
+	}
+
+	watched := make(map[string]Target, len(targets))
+	for _, t := range targets {
+		watched[t.EffectiveUnit()] = t
+	}
+
+	// Seed initial state
+	prev := make(map[string]supervisorProcess)
+	if procs, err := rpc.allProcessInfo(ctx); err == nil {
+		for _, p := range procs {
+			if _, ok := watched[p.fullName()]; ok {
+				prev[p.fullName()] = p
+			}
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			procs, err := rpc.allProcessInfo(ctx)
+			if err != nil {
+				continue
+			}
+			for _, p := range procs {
+				unit := p.fullName()
+				t, ok := watched[unit]
+				if !ok {
+					continue
+				}
+				old, hasPrev := prev[unit]
+				prev[unit] = p
+				// An exit is reported once; the program coming back after
+				// that (or giving up, FATAL after BACKOFF) is the same crash.
+				if !hasPrev || supervisorStopped[old.State] {
+					continue
+				}
+
+				restarted := p.Start != old.Start && old.Start != 0
+				exited := supervisorStopped[p.State]
+				if !restarted && !exited {
+					continue
+				}
+
+				preLogs := tailLogFile(t.LogFile)
+				if t.LogFile == "" {
+					preLogs = rpc.tailLog(ctx, unit)
+				}
+				info := CrashInfo{ExitCode: p.ExitStatus, ErrorLog: preLogs, Backend: "supervisord"}
+				// supervisord reports -1 for a program killed by a signal.
+				if p.ExitStatus == -1 || info.mayBeOOMKill() {
+					correlateOOM(&info, oomQuery{Since: time.Unix(old.Start, 0), Until: time.Now(), PIDs: []int{old.PID}}, rpc.local)
+				}
+				summary := Analyze(info)
+
+				postLogs := fmt.Sprintf("state=%s pid=%d exitstatus=%d", p.State, p.PID, p.ExitStatus)
+				if p.SpawnErr != "" {
+					postLogs += " spawnerr=" + p.SpawnErr
+				}
+
+				now := time.Now()
+				inc := Incident{
+					ID:            GenerateIncidentID(t.Container, now),
+					Container:     t.Container,
+					DetectedAt:    now,
+					PrevStarted:   old.started(),
+					CurrStarted:   p.started(),
+					PreLogs:       preLogs,
+					PostLogs:      postLogs,
+					CrashAnalysis: &summary,
+				}
+				if sm.Dir != "" {
+					if err := SaveIncident(sm.Dir, &inc, sm.Keep); err != nil {
+						fmt.Fprintf(os.Stderr, "[supervisord-monitor] warning: save incident: %v\n", err)
+					}
+				}
+				select {
+				case incidents <- inc:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+	}
+}
+
+// supervisorClient calls supervisord's XML-RPC methods.
+type supervisorClient struct {
+	endpoint string
+	http     *http.Client
+	local    bool // reached over a unix socket, so the programs run on this host
+}
+
+func newSupervisorClient(serverURL string) (*supervisorClient, error) {
+	if serverURL == "" {
+		serverURL = os.Getenv("SUPERVISOR_SERVER_URL")
+	}
+	if serverURL == "" {
+		for _, sock := range defaultSupervisorSockets {
+			if _, err := os.Stat(sock); err == nil {
+				serverURL = "unix://" + sock
+				break
+			}
+		}
+	}
+	if serverURL == "" {
+		return nil, fmt.Errorf("supervisord socket not found (tried %s); set SUPERVISOR_SERVER_URL", strings.Join(defaultSupervisorSockets, ", "))
+	}
+
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid supervisord URL %q: %w", serverURL, err)
+	}
+	switch u.Scheme {
+	case "unix":
+		path := u.Path
+		dialer := &net.Dialer{Timeout: 5 * time.Second}
+		return &supervisorClient{
+			endpoint: "http://supervisor/RPC2",
+			local:    true,
+			http: &http.Client{Timeout: 30 * time.Second, Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return dialer.DialContext(ctx, "unix", path)
+				},
+			}},
+		}, nil
+	case "http", "https":
+		if u.Path == "" || u.Path == "/" {
+			u.Path = "/RPC2"
+		}
+		return &supervisorClient{endpoint: u.String(), http: &http.Client{Timeout: 30 * time.Second}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported supervisord URL %q (expected unix:// or http://)", serverURL)
+	}
+}
+
+// xmlrpcValue is an XML-RPC <value>. Untyped values are strings.
+type xmlrpcValue struct {
+	Text    string        `xml:",chardata"`
+	String  *string       `xml:"string"`
+	Int     *string       `xml:"int"`
+	I4      *string       `xml:"i4"`
+	Boolean *string       `xml:"boolean"`
+	Double  *string       `xml:"double"`
+	Array   []xmlrpcValue `xml:"array>data>value"`
+	Members []struct {
+		Name  string      `xml:"name"`
+		Value xmlrpcValue `xml:"value"`
+	} `xml:"struct>member"`
+}
+
+func (v xmlrpcValue) str() string {
+	for _, s := range []*string{v.String, v.Int, v.I4, v.Boolean, v.Double} {
+		if s != nil {
+			return *s
+		}
+	}
+	return v.Text
+}
+
+func (v xmlrpcValue) int() int64 {
+	n, _ := strconv.ParseInt(strings.TrimSpace(v.str()), 10, 64)
+	return n
+}
+
+func (v xmlrpcValue) member(name string) xmlrpcValue {
+	for _, m := range v.Members {
+		if m.Name == name {
+			return m.Value
+		}
+	}
+	return xmlrpcValue{}
+}
+
+// call invokes method with string and int params and returns its result.
+func (c *supervisorClient) call(ctx context.Context, method string, params ...any) (xmlrpcValue, error) {
+	var body bytes.Buffer
+	body.WriteString(`<?xml version="1.0"?><methodCall><methodName>` + method + `</methodName><params>`)
+	for _, p := range params {
+		body.WriteString("<param><value>")
+		switch p := p.(type) {
+		case int:
+			fmt.Fprintf(&body, "<int>%d</int>", p)
+		default:
+			body.WriteString("<string>")
+			_ = xml.EscapeText(&body, []byte(fmt.Sprint(p)))
+			body.WriteString("</string>")
+		}
+		body.WriteString("</value></param>")
+	}
+	body.WriteString("</params></methodCall>")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, &body)
+	if err != nil {
+		return xmlrpcValue{}, err
+	}
+	req.Header.Set("Content-Type", "text/xml")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return xmlrpcValue{}, fmt.Errorf("supervisord is not reachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return xmlrpcValue{}, fmt.Errorf("supervisord returned %s", resp.Status)
+	}
+
+	var out struct {
+		Params []xmlrpcValue `xml:"params>param>value"`
+		Fault  *xmlrpcValue  `xml:"fault>value"`
+	}
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, 16<<20)).Decode(&out); err != nil {
+		return xmlrpcValue{}, fmt.Errorf("decode %s response: %w", method, err)
+	}
+	if out.Fault != nil {
+		return xmlrpcValue{}, fmt.Errorf("%s: %s", method, out.Fault.member("faultString").str())
+	}
+	if len(out.Params) == 0 {
+		return xmlrpcValue{}, fmt.Errorf("%s: empty response", method)
+	}
+	return out.Params[0], nil
+}
+
+func (c *supervisorClient) allProcessInfo(ctx context.Context) ([]supervisorProcess, error) {
+	v, err := c.call(ctx, "supervisor.getAllProcessInfo")
+	if err != nil {
+		return nil, err
+	}
+	procs := make([]supervisorProcess, 0, len(v.Array))
+	for _, p := range v.Array {
+		procs = append(procs, supervisorProcess{
+			Name:       p.member("name").str(),
+			Group:      p.member("group").str(),
+			Start:      p.member("start").int(),
+			State:      p.member("statename").str(),
+			PID:        int(p.member("pid").int()),
+			ExitStatus: int(p.member("exitstatus").int()),
+			SpawnErr:   p.member("spawnerr").str(),
+		})
+	}
+	return procs, nil
+}
+
+// tailLog returns the last 100 lines of a program's stderr log as
+// supervisord keeps it, falling back to stdout for programs that redirect
+// stderr into it.
+func (c *supervisorClient) tailLog(ctx context.Context, name string) string {
+	var lastErr error
+	for _, method := range []string{"supervisor.tailProcessStderrLog", "supervisor.tailProcessStdoutLog"} {
+		v, err := c.call(ctx, method, name, 0, 16384)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		var text string
+		if len(v.Array) > 0 {
+			text = v.Array[0].str()
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		lines := strings.Split(text, "\n")
+		if len(lines) > 100 {
+			lines = lines[len(lines)-100:]
+		}
+		return strings.Join(lines, "\n")
+	}
+	if lastErr != nil {
+		return fmt.Sprintf("(cannot read supervisord log: %v)", lastErr)
+	}
+	return ""
+}
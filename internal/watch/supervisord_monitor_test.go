@@ -0,0 +1,159 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSupervisord serves supervisor.getAllProcessInfo with whatever state
+// the test sets, and a stderr log for every program.
+type fakeSupervisord struct {
+	t     *testing.T
+	mu    sync.Mutex
+	start int64
+	state string
+	pid   int
+	exit  int
+}
+
+func (f *fakeSupervisord) set(start int64, state string, pid, exit int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.start, f.state, f.pid, f.exit = start, state, pid, exit
+}
+
+func (f *fakeSupervisord) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if r.URL.Path != "/RPC2" {
+		http.NotFound(w, r)
+		return
+	}
+	switch {
+	case strings.Contains(string(body), "supervisor.getAllProcessInfo"):
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		fmt.Fprintf(w, `<?xml version='1.0'?>
+<methodResponse><params><param><value><array><data>
+<value><struct>
+<member><name>name</name><value><string>worker_00</string></value></member>
+<member><name>group</name><value><string>queue</string></value></member>
+<member><name>start</name><value><int>%d</int></value></member>
+<member><name>statename</name><value><string>%s</string></value></member>
+<member><name>pid</name><value><int>%d</int></value></member>
+<member><name>exitstatus</name><value><int>%d</int></value></member>
+<member><name>spawnerr</name><value><string></string></value></member>
+</struct></value>
+<value><struct>
+<member><name>name</name><value><string>cron</string></value></member>
+<member><name>group</name><value><string>cron</string></value></member>
+<member><name>start</name><value><int>1</int></value></member>
+<member><name>statename</name><value><string>RUNNING</string></value></member>
+</struct></value>
+</data></array></value></param></params></methodResponse>`, f.start, f.state, f.pid, f.exit)
+	case strings.Contains(string(body), "supervisor.tailProcessStderrLog"):
+		if !strings.Contains(string(body), "<string>queue:worker_00</string>") {
+			f.t.Errorf("tail request = %s", body)
+		}
+		fmt.Fprint(w, `<?xml version='1.0'?>
+<methodResponse><params><param><value><array><data>
+<value><string>Traceback (most recent call last):
+redis.exceptions.ConnectionError: Connection refused</string></value>
+<value><int>4096</int></value><value><boolean>0</boolean></value>
+</data></array></value></param></params></methodResponse>`)
+	default:
+		fmt.Fprint(w, `<?xml version='1.0'?>
+<methodResponse><fault><value><struct>
+<member><name>faultCode</name><value><int>1</int></value></member>
+<member><name>faultString</name><value><string>UNKNOWN_METHOD</string></value></member>
+</struct></value></fault></methodResponse>`)
+	}
+}
+
+func TestSupervisordMonitor_RestartAndExit(t *testing.T) {
+	fake := &fakeSupervisord{t: t}
+	fake.set(1760000000, "RUNNING", 4242, 0)
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	sm := &SupervisordMonitor{ServerURL: srv.URL, Interval: 30 * time.Millisecond}
+	incCh := make(chan Incident, 10)
+	targets := []Target{{Container: "worker", Kind: "supervisord", Unit: "queue:worker_00"}}
+	go func() { _ = sm.Watch(ctx, targets, incCh) }()
+	time.Sleep(60 * time.Millisecond)
+
+	fake.set(1760000100, "RUNNING", 4300, 1)
+	inc := waitIncident(t, ctx, incCh)
+	if inc.Container != "worker" || inc.PrevStarted == inc.CurrStarted {
+		t.Errorf("restart incident = %+v", inc)
+	}
+	if !strings.Contains(inc.PreLogs, "Connection refused") {
+		t.Errorf("PreLogs = %q, want supervisord's stderr log", inc.PreLogs)
+	}
+	if inc.CrashAnalysis == nil || inc.CrashAnalysis.Category != "dependency" || inc.CrashAnalysis.ExitCode != 1 {
+		t.Errorf("CrashAnalysis = %+v", inc.CrashAnalysis)
+	}
+
+	fake.set(1760000100, "FATAL", 0, 2)
+	inc = waitIncident(t, ctx, incCh)
+	if inc.PostLogs != "state=FATAL pid=0 exitstatus=2" {
+		t.Errorf("exit PostLogs = %q", inc.PostLogs)
+	}
+}
+
+func TestSupervisordMonitor_ExitThenRestartIsOneIncident(t *testing.T) {
+	fake := &fakeSupervisord{t: t}
+	fake.set(1760000000, "RUNNING", 4242, 0)
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	sm := &SupervisordMonitor{ServerURL: srv.URL, Interval: 30 * time.Millisecond}
+	incCh := make(chan Incident, 10)
+	targets := []Target{{Container: "worker", Kind: "supervisord", Unit: "queue:worker_00"}}
+	go func() { _ = sm.Watch(ctx, targets, incCh) }()
+	time.Sleep(60 * time.Millisecond)
+
+	fake.set(1760000000, "EXITED", 0, 1)
+	inc := waitIncident(t, ctx, incCh)
+	if inc.PostLogs != "state=EXITED pid=0 exitstatus=1" {
+		t.Errorf("exit PostLogs = %q", inc.PostLogs)
+	}
+
+	// supervisord brings it back: recovery, not a second incident.
+	fake.set(1760000100, "RUNNING", 4300, 0)
+	time.Sleep(150 * time.Millisecond)
+	if len(incCh) != 0 {
+		t.Errorf("the restart after a reported exit made another incident: %+v", <-incCh)
+	}
+
+	// A crash after that is a new incident again.
+	fake.set(1760000200, "RUNNING", 4400, 1)
+	if inc := waitIncident(t, ctx, incCh); inc.PrevStarted == inc.CurrStarted {
+		t.Errorf("restart incident = %+v", inc)
+	}
+}
+
+func TestSupervisorClientFault(t *testing.T) {
+	srv := httptest.NewServer(&fakeSupervisord{t: t})
+	defer srv.Close()
+	c, err := newSupervisorClient(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.call(context.Background(), "supervisor.nope"); err == nil || !strings.Contains(err.Error(), "UNKNOWN_METHOD") {
+		t.Errorf("err = %v, want the fault string", err)
+	}
+	if _, err := newSupervisorClient("ftp://x"); err == nil {
+		t.Error("expected an unsupported URL error")
+	}
+}
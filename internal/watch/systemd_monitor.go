@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -23,10 +25,106 @@ type SystemdMonitor struct {
 	Keep int
 }
 
+// systemdProperties are the unit properties each poll reads.
+const systemdProperties = "--property=ActiveState,SubState,ExecMainStartTimestamp,ExecMainExitTimestamp,Result,ExecMainStatus,ExecMainCode"
+
 type systemdState struct {
 	ActiveState string
 	SubState    string
 	StartTS     string
+	ExitTS      string // when the main process last exited
+	Result      string // e.g. "success", "exit-code", "signal", "core-dump", "oom-kill"
+	MainStatus  int    // exit status, or signal number when MainCode says it was killed
+	MainCode    int    // CLD_* code: 1 exited, 2 killed, 3 dumped core
+}
+
+// CLD_* codes systemd reports in ExecMainCode.
+const (
+	cldExited = 1
+	cldKilled = 2
+	cldDumped = 3
+)
+
+// signalNames covers the signals that commonly end a service.
+var signalNames = map[int]string{
+	1: "SIGHUP", 2: "SIGINT", 3: "SIGQUIT", 4: "SIGILL", 6: "SIGABRT", 7: "SIGBUS",
+	8: "SIGFPE", 9: "SIGKILL", 11: "SIGSEGV", 13: "SIGPIPE", 15: "SIGTERM",
+}
+
+// crashInfo converts the unit's exit properties into the form Analyze takes.
+// A process killed by a signal gets the shell-style exit code 128+signal,
+// which is what Docker reports, so SIGKILL reads as 137 and SIGSEGV as 139.
+func (s systemdState) crashInfo(errorLog string) CrashInfo {
+	info := CrashInfo{
+		ExitCode:  s.MainStatus,
+		OOMKilled: s.Result == "oom-kill",
+		ErrorLog:  errorLog,
+		Backend:   "systemd",
+	}
+	if s.MainCode == cldKilled || s.MainCode == cldDumped {
+		info.ExitCode = 128 + s.MainStatus
+		info.Signal = signalNames[s.MainStatus]
+		if info.Signal == "" {
+			info.Signal = fmt.Sprintf("signal %d", s.MainStatus)
+		}
+	}
+	return info
+}
+
+var (
+	journalMainExitRe = regexp.MustCompile(`Main process exited, code=(exited|killed|dumped), status=(\d+)`)
+	journalResultRe   = regexp.MustCompile(`Failed with result '([a-z-]+)'`)
+)
+
+// withJournalExit fills in the exit properties from systemd's own journal
+// messages when the unit has already been restarted: starting the new main
+// process resets ExecMainStatus, ExecMainCode and Result, so by the next poll
+// they describe the new run rather than the one that died.
+func (s systemdState) withJournalExit(journal string) systemdState {
+	if s.MainCode != 0 && s.Result != "" && s.Result != "success" {
+		return s
+	}
+	if m := journalMainExitRe.FindAllStringSubmatch(journal, -1); len(m) > 0 {
+		last := m[len(m)-1]
+		s.MainCode = map[string]int{"exited": cldExited, "killed": cldKilled, "dumped": cldDumped}[last[1]]
+		s.MainStatus, _ = strconv.Atoi(last[2])
+	}
+	if m := journalResultRe.FindAllStringSubmatch(journal, -1); len(m) > 0 {
+		s.Result = m[len(m)-1][1]
+	}
+	return s
+}
+
+// systemdTimeLayout is how systemctl show formats timestamps.
+const systemdTimeLayout = "Mon 2006-01-02 15:04:05 MST"
+
+// oomQuery looks for an OOM kill in the unit's cgroup since the run that
+// died started.
+func (sm *SystemdMonitor) oomQuery(unit string, old systemdState) oomQuery {
+	if !strings.Contains(unit, ".") {
+		unit += ".service"
+	}
+	since, err := time.Parse(systemdTimeLayout, old.StartTS)
+	if err != nil {
+		since = time.Now().Add(-time.Hour)
+	}
+	return oomQuery{Since: since, Until: time.Now(), Units: []string{unit}, CgroupDirs: []string{systemdCgroupDir(unit)}}
+}
+
+// systemdCgroupDir is where a system unit's cgroup lives.
+func systemdCgroupDir(unit string) string {
+	if !strings.Contains(unit, ".") {
+		unit += ".service"
+	}
+	return "system.slice/" + unit
+}
+
+func (s systemdState) describe() string {
+	out := fmt.Sprintf("ActiveState=%s SubState=%s", s.ActiveState, s.SubState)
+	if s.Result != "" {
+		out += fmt.Sprintf(" Result=%s ExecMainCode=%d ExecMainStatus=%d", s.Result, s.MainCode, s.MainStatus)
+	}
+	return out
 }
 
 func (sm *SystemdMonitor) parseState(output string) systemdState {
@@ -39,6 +137,14 @@ func (sm *SystemdMonitor) parseState(output string) systemdState {
 			s.SubState = strings.TrimPrefix(line, "SubState=")
 		} else if strings.HasPrefix(line, "ExecMainStartTimestamp=") {
 			s.StartTS = strings.TrimPrefix(line, "ExecMainStartTimestamp=")
+		} else if strings.HasPrefix(line, "ExecMainExitTimestamp=") {
+			s.ExitTS = strings.TrimPrefix(line, "ExecMainExitTimestamp=")
+		} else if strings.HasPrefix(line, "Result=") {
+			s.Result = strings.TrimPrefix(line, "Result=")
+		} else if strings.HasPrefix(line, "ExecMainStatus=") {
+			s.MainStatus, _ = strconv.Atoi(strings.TrimPrefix(line, "ExecMainStatus="))
+		} else if strings.HasPrefix(line, "ExecMainCode=") {
+			s.MainCode, _ = strconv.Atoi(strings.TrimPrefix(line, "ExecMainCode="))
 		}
 	}
 	return s
@@ -67,12 +173,12 @@ func (sm *SystemdMonitor) Watch(ctx context.Context, targets []Target, incidents
 	// Seed initial states
 	for _, t := range targets {
 		unit := t.EffectiveUnit()
-		out, err := run("systemctl", "show", unit,
-			"--property=ActiveState,SubState,ExecMainStartTimestamp")
+		out, err := run("systemctl", "show", unit, systemdProperties)
 		if err != nil {
 			continue
 		}
 		prev[unit] = sm.parseState(out)
+		seedCgroupOOM(systemdCgroupDir(unit))
 	}
 
 	ticker := time.NewTicker(interval)
@@ -85,8 +191,7 @@ func (sm *SystemdMonitor) Watch(ctx context.Context, targets []Target, incidents
 		case <-ticker.C:
 			for _, t := range targets {
 				unit := t.EffectiveUnit()
-				out, err := run("systemctl", "show", unit,
-					"--property=ActiveState,SubState,ExecMainStartTimestamp")
+				out, err := run("systemctl", "show", unit, systemdProperties)
 				if err != nil {
 					continue
 				}
@@ -102,18 +207,23 @@ func (sm *SystemdMonitor) Watch(ctx context.Context, targets []Target, incidents
 				startChanged := hasPrev && curr.StartTS != old.StartTS && old.StartTS != ""
 
 				if (isFailed && !wasFailed) || (inactiveStopped && startChanged) || startChanged {
-					// Capture journal logs
-					preLogs, _ := run("journalctl", "-u", unit, "-n", "100", "--no-pager")
+					preLogs, postLogs := sm.captureJournal(run, unit, old, curr)
+					info := curr.withJournalExit(preLogs).crashInfo(preLogs)
+					if info.mayBeOOMKill() {
+						correlateOOM(&info, sm.oomQuery(unit, old), true)
+					}
+					summary := Analyze(info)
 
 					now := time.Now()
 					inc := Incident{
-						ID:          GenerateIncidentID(t.Container, now),
-						Container:   t.Container,
-						DetectedAt:  now,
-						PrevStarted: old.StartTS,
-						CurrStarted: curr.StartTS,
-						PreLogs:     preLogs,
-						PostLogs:    fmt.Sprintf("ActiveState=%s SubState=%s", curr.ActiveState, curr.SubState),
+						ID:            GenerateIncidentID(t.Container, now),
+						Container:     t.Container,
+						DetectedAt:    now,
+						PrevStarted:   old.StartTS,
+						CurrStarted:   curr.StartTS,
+						PreLogs:       preLogs,
+						PostLogs:      postLogs,
+						CrashAnalysis: &summary,
 					}
 					if sm.Dir != "" {
 						if err := SaveIncident(sm.Dir, &inc, sm.Keep); err != nil {
@@ -128,7 +238,39 @@ func (sm *SystemdMonitor) Watch(ctx context.Context, targets []Target, incidents
 				}
 
 				prev[unit] = curr
+				// A restart gives the unit a new cgroup with its count back at
+				// zero, so the baseline follows every poll.
+				seedCgroupOOM(systemdCgroupDir(unit))
 			}
 		}
 	}
 }
+
+// captureJournal reads the unit's journal around a failure. Pre-death logs
+// cover the run that ended, from its start to its exit; post-restart logs
+// cover the new run, when there is one. Without timestamps it falls back to
+// the last 100 lines.
+func (sm *SystemdMonitor) captureJournal(run CommandRunner, unit string, old, curr systemdState) (preLogs, postLogs string) {
+	args := []string{"-u", unit, "--no-pager", "-n", "100"}
+	if old.StartTS != "" {
+		args = append(args, "--since", old.StartTS)
+		// A unit that Restart= already brought back has no exit timestamp
+		// any more, so the new run's start bounds the old one instead.
+		switch {
+		case curr.ExitTS != "":
+			args = append(args, "--until", curr.ExitTS)
+		case curr.StartTS != "" && curr.StartTS != old.StartTS:
+			args = append(args, "--until", curr.StartTS)
+		}
+	}
+	preLogs, _ = run("journalctl", args...)
+
+	postLogs = curr.describe()
+	restarted := curr.StartTS != "" && curr.StartTS != old.StartTS && curr.ActiveState != "failed"
+	if restarted {
+		if out, err := run("journalctl", "-u", unit, "--no-pager", "-n", "50", "--since", curr.StartTS); err == nil && strings.TrimSpace(out) != "" {
+			postLogs += "\n" + out
+		}
+	}
+	return preLogs, postLogs
+}
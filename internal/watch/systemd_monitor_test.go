@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -394,3 +395,113 @@ func TestSystemdMonitor_SaveIncident(t *testing.T) {
 		t.Fatal("timed out")
 	}
 }
+
+func TestSystemdState_CrashInfo(t *testing.T) {
+	sm := &SystemdMonitor{}
+	tests := []struct {
+		name     string
+		props    string
+		category string
+		signal   string
+	}{
+		{"exit code", "Result=exit-code\nExecMainCode=1\nExecMainStatus=1", "error", ""},
+		{"killed", "Result=signal\nExecMainCode=2\nExecMainStatus=9", "oom", "SIGKILL"},
+		{"core dump", "Result=core-dump\nExecMainCode=3\nExecMainStatus=11", "segfault", "SIGSEGV"},
+		{"oom-kill", "Result=oom-kill\nExecMainCode=2\nExecMainStatus=15", "oom", "SIGTERM"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Analyze(sm.parseState(tt.props).crashInfo(""))
+			if s.Category != tt.category || s.Signal != tt.signal {
+				t.Errorf("got %s/%q, want %s/%q", s.Category, s.Signal, tt.category, tt.signal)
+			}
+		})
+	}
+}
+
+func TestSystemdState_WithJournalExit(t *testing.T) {
+	// After an automatic restart the exit properties describe the new run.
+	s := systemdState{Result: "success", MainCode: 0}
+	journal := `Oct 18 03:14:20 nas app[812]: starting
+Oct 18 03:14:22 nas systemd[1]: app.service: Main process exited, code=killed, status=9/KILL
+Oct 18 03:14:22 nas systemd[1]: app.service: Failed with result 'oom-kill'.
+Oct 18 03:14:23 nas systemd[1]: app.service: Scheduled restart job, restart counter is at 3.`
+	got := s.withJournalExit(journal)
+	if got.MainCode != cldKilled || got.MainStatus != 9 || got.Result != "oom-kill" {
+		t.Errorf("got %+v", got)
+	}
+
+	failed := systemdState{Result: "exit-code", MainCode: cldExited, MainStatus: 2}
+	if got := failed.withJournalExit(journal); got != failed {
+		t.Errorf("properties of a unit left failed should win, got %+v", got)
+	}
+}
+
+func TestSystemdMonitor_JournalWindow(t *testing.T) {
+	var mu sync.Mutex
+	var journalCalls [][]string
+	polls := 0
+	runner := func(name string, args ...string) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch name {
+		case "systemctl":
+			polls++
+			if polls == 1 {
+				return "ActiveState=active\nSubState=running\nExecMainStartTimestamp=Sun 2026-10-18 03:00:00 UTC\nResult=success", nil
+			}
+			return "ActiveState=failed\nSubState=failed\nExecMainStartTimestamp=Sun 2026-10-18 03:00:00 UTC\n" +
+				"ExecMainExitTimestamp=Sun 2026-10-18 03:14:22 UTC\nResult=core-dump\nExecMainCode=3\nExecMainStatus=11", nil
+		case "journalctl":
+			journalCalls = append(journalCalls, args)
+			return "app[812]: worker crashed", nil
+		}
+		return "", nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	incCh := make(chan Incident, 1)
+	sm := &SystemdMonitor{Run: runner, Interval: 50 * time.Millisecond}
+	go func() { _ = sm.Watch(ctx, []Target{{Container: "app", Kind: "systemd", Unit: "app.service"}}, incCh) }()
+
+	inc := waitIncident(t, ctx, incCh)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(journalCalls) != 1 {
+		t.Fatalf("journalctl calls = %v, want only the pre-death window for a unit left failed", journalCalls)
+	}
+	want := "-u app.service --no-pager -n 100 --since Sun 2026-10-18 03:00:00 UTC --until Sun 2026-10-18 03:14:22 UTC"
+	if got := strings.Join(journalCalls[0], " "); got != want {
+		t.Errorf("journalctl args = %q\nwant %q", got, want)
+	}
+	if inc.CrashAnalysis == nil || inc.CrashAnalysis.Category != "segfault" || inc.CrashAnalysis.ExitCode != 139 {
+		t.Errorf("CrashAnalysis = %+v", inc.CrashAnalysis)
+	}
+	if !strings.Contains(inc.PostLogs, "Result=core-dump") {
+		t.Errorf("PostLogs = %q", inc.PostLogs)
+	}
+}
+
+func TestSystemdMonitor_PostRestartJournal(t *testing.T) {
+	sm := &SystemdMonitor{}
+	var calls []string
+	run := func(name string, args ...string) (string, error) {
+		calls = append(calls, strings.Join(args, " "))
+		return "log line", nil
+	}
+	old := systemdState{ActiveState: "active", StartTS: "ts1"}
+	curr := systemdState{ActiveState: "active", SubState: "running", StartTS: "ts2"}
+	_, post := sm.captureJournal(run, "app.service", old, curr)
+	if len(calls) != 2 || calls[1] != "-u app.service --no-pager -n 50 --since ts2" {
+		t.Fatalf("calls = %q", calls)
+	}
+	// The restart cleared the exit timestamp, so the pre-death window ends
+	// where the new run starts rather than taking the new run's tail.
+	if calls[0] != "-u app.service --no-pager -n 100 --since ts1 --until ts2" {
+		t.Errorf("pre-death call = %q", calls[0])
+	}
+	if post != "ActiveState=active SubState=running\nlog line" {
+		t.Errorf("post = %q", post)
+	}
+}
@@ -14,9 +14,12 @@ import (
 const defaultMaxIncidents = 200
 
 type WatchConfig struct {
-	Notify    NotifySettings  `json:"notify"`
-	Flapping  FlappingConfig  `json:"flapping"`
-	Retention RetentionConfig `json:"retention"`
+	Notify      NotifySettings    `json:"notify"`
+	Flapping    FlappingConfig    `json:"flapping"`
+	Retention   RetentionConfig   `json:"retention"`
+	Analysis    AnalysisConfig    `json:"analysis"`
+	Recovery    RecoveryConfig    `json:"recovery"`
+	Remediation RemediationConfig `json:"remediation"`
 }
 
 // RetentionConfig bounds how much incident history is kept on disk.
@@ -63,6 +66,7 @@ func DefaultWatchConfig() WatchConfig {
 		},
 		Flapping:  DefaultFlappingConfig(),
 		Retention: DefaultRetentionConfig(),
+		Recovery:  DefaultRecoveryConfig(),
 	}
 }
 
@@ -86,6 +90,8 @@ func LoadWatchConfig(dir string) (*WatchConfig, error) {
 	}
 	cfg.Notify.Normalize()
 	cfg.Retention.Normalize()
+	cfg.Recovery.Normalize()
+	cfg.Remediation.Normalize()
 	return &cfg, nil
 }
 